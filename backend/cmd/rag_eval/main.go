@@ -39,6 +39,8 @@ func run(args []string) error {
 		return runPostRetrieval(rest)
 	case "answer":
 		return runAnswer(rest)
+	case "routing":
+		return runRouting(rest)
 	case "all":
 		return runAll(rest)
 	case "-h", "--help", "help":
@@ -57,6 +59,7 @@ func printRootUsage() {
 	fmt.Println("  rag_eval retrieval --queries <path> --qrels <path> [--run <path>] [--online] --out-dir <path>")
 	fmt.Println("  rag_eval post-retrieval --queries <path> --qrels <path> [--run <path>] --out-dir <path>")
 	fmt.Println("  rag_eval answer --queries <path> --qrels <path> --predictions <path> --out-dir <path>")
+	fmt.Println("  rag_eval routing --dataset <path> --predictions <path> --out-dir <path>")
 	fmt.Println("  rag_eval all --chunks <path> --queries <path> --qrels <path> --predictions <path> --out-dir <path>")
 }
 
@@ -332,6 +335,24 @@ func runAnswer(args []string) error {
 	return eval.WriteReport(outDir, run, res.Metrics, res.PerQuery, gate)
 }
 
+func runRouting(args []string) error {
+	fs := flag.NewFlagSet("routing", flag.ContinueOnError)
+	c := bindCommon(fs)
+	dataset := fs.String("dataset", "", "path to labeled routing.jsonl")
+	predictions := fs.String("predictions", "", "path to routing predictions.jsonl")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	outDir, runID := normalizeOutDir("routing", c)
+	res, err := eval.EvaluateRouting(eval.RoutingOptions{DatasetPath: *dataset, PredictionsPath: *predictions})
+	if err != nil {
+		return err
+	}
+	gate := eval.EvaluateGate(c.gateMode, res.Warnings)
+	run := buildRun("routing", runID, map[string]string{"dataset": *dataset, "predictions": *predictions}, nil)
+	return eval.WriteReport(outDir, run, res.Metrics, res.PerQuery, gate)
+}
+
 func runAll(args []string) error {
 	fs := flag.NewFlagSet("all", flag.ContinueOnError)
 	c := bindCommon(fs)
@@ -116,6 +116,53 @@ func ReadPredictionsJSONL(path string) ([]PredictionRecord, error) {
 	return out, nil
 }
 
+// ReadRoutingJSONL loads a labeled query routing dataset.
+func ReadRoutingJSONL(path string) ([]RoutingRecord, error) {
+	rows, err := readJSONLMaps(path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RoutingRecord, 0, len(rows))
+	for i, row := range rows {
+		qid := firstString(row, "qid", "query_id", "id")
+		if strings.TrimSpace(qid) == "" {
+			qid = fmt.Sprintf("r_%d", i+1)
+		}
+		out = append(out, RoutingRecord{
+			QID:                  qid,
+			Question:             firstString(row, "question", "query", "text"),
+			History:              firstStringSlice(row, "history"),
+			ExpectedRoute:        firstString(row, "expected_route", "route"),
+			ExpectedQuestionType: firstString(row, "expected_question_type", "question_type"),
+		})
+	}
+	return out, nil
+}
+
+// ReadRoutingPredictionsJSONL loads router decisions.
+func ReadRoutingPredictionsJSONL(path string) ([]RoutingPrediction, error) {
+	rows, err := readJSONLMaps(path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RoutingPrediction, 0, len(rows))
+	for _, row := range rows {
+		qid := firstString(row, "qid", "query_id")
+		if strings.TrimSpace(qid) == "" {
+			continue
+		}
+		confidence, _ := firstFloat(row, "confidence")
+		out = append(out, RoutingPrediction{
+			QID:          qid,
+			Route:        firstString(row, "route"),
+			QuestionType: firstString(row, "question_type", "questionType"),
+			Confidence:   confidence,
+			Source:       firstString(row, "source"),
+		})
+	}
+	return out, nil
+}
+
 // ReadEmbeddingsJSONL loads embedding vectors from JSONL.
 func ReadEmbeddingsJSONL(path string) ([]EmbeddingRecord, error) {
 	rows, err := readJSONLMaps(path)
@@ -177,7 +177,7 @@ func loadOrBuildRetrievalStages(opts RetrievalOptions, queries []QueryRecord) (m
 				switch lexicalMode {
 				case "online_real":
 					terms := strings.Join(retrieval.Tokenize(query, language), " ")
-					points, err := lexicalClient.QueryBM25(ctx, strings.TrimSpace(q.BookID), terms, topK)
+					points, err := lexicalClient.QueryBM25(ctx, strings.TrimSpace(q.BookID), retrieval.ChunkFilter{}, terms, topK)
 					if err != nil {
 						return nil, err
 					}
@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EvaluateRouting scores query routing decisions against a labeled dataset:
+// route and question type accuracy, per-route recall, how often the model
+// decided rather than the heuristic fallback, and a route confusion matrix.
+func EvaluateRouting(opts RoutingOptions) (EvalResult, error) {
+	if strings.TrimSpace(opts.DatasetPath) == "" {
+		return EvalResult{}, fmt.Errorf("routing dataset path required")
+	}
+	if opts.Router == nil && strings.TrimSpace(opts.PredictionsPath) == "" {
+		return EvalResult{}, fmt.Errorf("routing predictions path or router required")
+	}
+	records, err := ReadRoutingJSONL(opts.DatasetPath)
+	if err != nil {
+		return EvalResult{}, err
+	}
+	predByQ := map[string]RoutingPrediction{}
+	if opts.Router != nil {
+		for _, record := range records {
+			pred, err := opts.Router(record)
+			if err != nil {
+				return EvalResult{}, fmt.Errorf("route %s: %w", record.QID, err)
+			}
+			pred.QID = record.QID
+			predByQ[record.QID] = pred
+		}
+	} else {
+		preds, err := ReadRoutingPredictionsJSONL(opts.PredictionsPath)
+		if err != nil {
+			return EvalResult{}, err
+		}
+		for _, pred := range preds {
+			predByQ[pred.QID] = pred
+		}
+	}
+
+	routeCorrect := 0
+	typeCorrect := 0
+	typeTotal := 0
+	modelDecisions := 0
+	confidences := make([]float64, 0, len(records))
+	routeTotals := map[string]int{}
+	routeHits := map[string]int{}
+	confusion := map[string]map[string]int{}
+	per := make([]map[string]any, 0, len(records))
+
+	for _, record := range records {
+		pred := predByQ[record.QID]
+		expected := strings.TrimSpace(record.ExpectedRoute)
+		got := strings.TrimSpace(pred.Route)
+		routeOK := expected != "" && expected == got
+		routeTotals[expected]++
+		if routeOK {
+			routeCorrect++
+			routeHits[expected]++
+		}
+		if confusion[expected] == nil {
+			confusion[expected] = map[string]int{}
+		}
+		confusion[expected][got]++
+
+		typeOK := false
+		if wantType := strings.TrimSpace(record.ExpectedQuestionType); wantType != "" {
+			typeTotal++
+			typeOK = wantType == strings.TrimSpace(pred.QuestionType)
+			if typeOK {
+				typeCorrect++
+			}
+		}
+		if pred.Source == "model" {
+			modelDecisions++
+		}
+		if pred.Confidence > 0 {
+			confidences = append(confidences, pred.Confidence)
+		}
+		per = append(per, map[string]any{
+			"qid":                    record.QID,
+			"expected_route":         expected,
+			"route":                  got,
+			"route_correct":          routeOK,
+			"expected_question_type": record.ExpectedQuestionType,
+			"question_type":          pred.QuestionType,
+			"question_type_correct":  typeOK,
+			"confidence":             pred.Confidence,
+			"source":                 pred.Source,
+		})
+	}
+
+	routes := make([]string, 0, len(routeTotals))
+	for route := range routeTotals {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	recall := make(map[string]float64, len(routes))
+	for _, route := range routes {
+		recall[route] = safeDiv(float64(routeHits[route]), float64(routeTotals[route]))
+	}
+	metrics := map[string]any{
+		"queries":                len(records),
+		"predictions":            len(predByQ),
+		"route_accuracy":         safeDiv(float64(routeCorrect), float64(len(records))),
+		"question_type_accuracy": safeDiv(float64(typeCorrect), float64(typeTotal)),
+		"model_decision_rate":    safeDiv(float64(modelDecisions), float64(len(records))),
+		"mean_confidence":        mean(confidences),
+		"route_recall":           recall,
+		"route_confusion":        confusion,
+	}
+	return EvalResult{Metrics: metrics, PerQuery: per, Warnings: evaluateRoutingWarnings(metrics, routes, recall)}, nil
+}
+
+func evaluateRoutingWarnings(metrics map[string]any, routes []string, recall map[string]float64) []string {
+	warnings := make([]string, 0)
+	if accuracy := metricFloat(metrics, "route_accuracy"); accuracy < 0.85 {
+		warnings = append(warnings, fmt.Sprintf("route_accuracy %.4f below threshold 0.85", accuracy))
+	}
+	for _, route := range routes {
+		if recall[route] < 0.7 {
+			warnings = append(warnings, fmt.Sprintf("route_recall[%s] %.4f below threshold 0.70", route, recall[route]))
+		}
+	}
+	return warnings
+}
@@ -47,3 +47,45 @@ func TestEvaluateIngestionRequiresInput(t *testing.T) {
 		t.Fatalf("expected error for missing chunks path")
 	}
 }
+
+func TestEvaluateRoutingMetrics(t *testing.T) {
+	base := filepath.Join("testdata")
+	res, err := EvaluateRouting(RoutingOptions{
+		DatasetPath:     filepath.Join(base, "routing.jsonl"),
+		PredictionsPath: filepath.Join(base, "routing_predictions.jsonl"),
+	})
+	if err != nil {
+		t.Fatalf("EvaluateRouting failed: %v", err)
+	}
+	if got := metricFloat(res.Metrics, "route_accuracy"); got < 0.9 || got >= 1 {
+		t.Fatalf("expected route_accuracy 11/12, got %v", got)
+	}
+	recall := res.Metrics["route_recall"].(map[string]float64)
+	if recall["document_overview"] != 0.5 || recall["rag"] != 1 {
+		t.Fatalf("unexpected route recall %v", recall)
+	}
+	if len(res.Warnings) != 1 {
+		t.Fatalf("expected one recall warning, got %v", res.Warnings)
+	}
+}
+
+func TestEvaluateRoutingRunsRouter(t *testing.T) {
+	res, err := EvaluateRouting(RoutingOptions{
+		DatasetPath: filepath.Join("testdata", "routing.jsonl"),
+		Router: func(record RoutingRecord) (RoutingPrediction, error) {
+			if len(record.History) > 0 {
+				return RoutingPrediction{Route: "history_only", Source: "heuristic"}, nil
+			}
+			return RoutingPrediction{Route: "rag", Source: "heuristic"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateRouting failed: %v", err)
+	}
+	if got := metricFloat(res.Metrics, "route_accuracy"); got != 8.0/12 {
+		t.Fatalf("expected route_accuracy 8/12, got %v", got)
+	}
+	if metricFloat(res.Metrics, "model_decision_rate") != 0 {
+		t.Fatalf("expected no model decisions, got %v", res.Metrics["model_decision_rate"])
+	}
+}
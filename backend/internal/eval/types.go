@@ -164,3 +164,34 @@ type AnswerOptions struct {
 	QrelsPath       string
 	PredictionsPath string
 }
+
+// RoutingRecord is one labeled question of a query routing dataset. History
+// holds the prior turns, alternating user and assistant, oldest first.
+type RoutingRecord struct {
+	QID                  string   `json:"qid"`
+	Question             string   `json:"question"`
+	History              []string `json:"history,omitempty"`
+	ExpectedRoute        string   `json:"expected_route"`
+	ExpectedQuestionType string   `json:"expected_question_type,omitempty"`
+}
+
+// RoutingPrediction is a router's decision for one routing question.
+type RoutingPrediction struct {
+	QID          string  `json:"qid"`
+	Route        string  `json:"route"`
+	QuestionType string  `json:"question_type,omitempty"`
+	Confidence   float64 `json:"confidence,omitempty"`
+	// Source is model or heuristic.
+	Source string `json:"source,omitempty"`
+}
+
+// RoutingFunc routes one dataset question in process.
+type RoutingFunc func(record RoutingRecord) (RoutingPrediction, error)
+
+// RoutingOptions configures the routing evaluator. Router, when set, is run
+// over the dataset instead of reading PredictionsPath.
+type RoutingOptions struct {
+	DatasetPath     string
+	PredictionsPath string
+	Router          RoutingFunc
+}
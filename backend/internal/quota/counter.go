@@ -0,0 +1,150 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// reserveScript increments a counter, setting its expiry on first use, and
+// undoes the increment when it would exceed a positive limit.
+var reserveScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+  redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local limit = tonumber(ARGV[2])
+if limit > 0 and count > limit then
+  redis.call("DECR", KEYS[1])
+  return -1
+end
+return count
+`)
+
+var addScript = redis.NewScript(`
+local total = redis.call("INCRBY", KEYS[1], ARGV[2])
+if total == tonumber(ARGV[2]) then
+  redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return total
+`)
+
+// Counter tracks per-user daily questions and monthly tokens in Redis. Days
+// and months are UTC calendar periods; keys expire shortly after they end.
+type Counter struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisCounter creates a Redis-backed quota counter.
+func NewRedisCounter(addr, password, prefix string) (*Counter, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil, errors.New("quota counter redis addr is required")
+	}
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		prefix = "onebook:quota"
+	}
+	return &Counter{
+		client: redis.NewClient(&redis.Options{Addr: addr, Password: password}),
+		prefix: prefix,
+		now:    time.Now,
+	}, nil
+}
+
+// ReserveQuestion counts one question against today's limit. It returns false
+// without counting when the limit is already reached; limit <= 0 is unlimited.
+func (c *Counter) ReserveQuestion(ctx context.Context, userID string, limit int) (bool, error) {
+	now := c.now().UTC()
+	res, err := reserveScript.Run(ctx, c.client, []string{c.questionsKey(userID, now)}, c.ttl(now, QuestionsResetAt(now)), limit).Int64()
+	if err != nil {
+		return false, fmt.Errorf("reserve question: %w", err)
+	}
+	return res >= 0, nil
+}
+
+// ReleaseQuestion gives back a reservation for a question that was not
+// answered, e.g. because the chat service failed or the answer was replayed.
+func (c *Counter) ReleaseQuestion(ctx context.Context, userID string) error {
+	key := c.questionsKey(userID, c.now().UTC())
+	count, err := c.client.Decr(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("release question: %w", err)
+	}
+	if count < 0 {
+		return c.client.Set(ctx, key, 0, redis.KeepTTL).Err()
+	}
+	return nil
+}
+
+// AddTokens adds answered tokens to this month's total.
+func (c *Counter) AddTokens(ctx context.Context, userID string, tokens int64) error {
+	if tokens <= 0 {
+		return nil
+	}
+	now := c.now().UTC()
+	if err := addScript.Run(ctx, c.client, []string{c.tokensKey(userID, now)}, c.ttl(now, TokensResetAt(now)), tokens).Err(); err != nil {
+		return fmt.Errorf("add tokens: %w", err)
+	}
+	return nil
+}
+
+// Usage returns today's question count and this month's token total.
+func (c *Counter) Usage(ctx context.Context, userID string) (int, int64, error) {
+	now := c.now().UTC()
+	values, err := c.client.MGet(ctx, c.questionsKey(userID, now), c.tokensKey(userID, now)).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read quota usage: %w", err)
+	}
+	questions, _ := parseCount(values[0])
+	tokens, _ := parseCount(values[1])
+	return int(questions), tokens, nil
+}
+
+// Now returns the counter clock in UTC.
+func (c *Counter) Now() time.Time {
+	return c.now().UTC()
+}
+
+// QuestionsResetAt is the start of the next UTC day.
+func QuestionsResetAt(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// TokensResetAt is the start of the next UTC month.
+func TokensResetAt(now time.Time) time.Time {
+	y, m, _ := now.UTC().Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (c *Counter) questionsKey(userID string, now time.Time) string {
+	return fmt.Sprintf("%s:questions:%s:%s", c.prefix, userID, now.Format("20060102"))
+}
+
+func (c *Counter) tokensKey(userID string, now time.Time) string {
+	return fmt.Sprintf("%s:tokens:%s:%s", c.prefix, userID, now.Format("200601"))
+}
+
+// ttl keeps keys for a day past their period so late reads still work.
+func (c *Counter) ttl(now, reset time.Time) int64 {
+	return reset.Add(24 * time.Hour).Sub(now).Milliseconds()
+}
+
+func parseCount(value any) (int64, bool) {
+	raw, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	var n int64
+	if _, err := fmt.Sscan(raw, &n); err != nil {
+		return 0, false
+	}
+	return n, true
+}
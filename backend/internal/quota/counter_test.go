@@ -0,0 +1,77 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCounterReserveQuestion(t *testing.T) {
+	redis := miniredis.RunT(t)
+	counter, err := NewRedisCounter(redis.Addr(), "", "test:quota")
+	if err != nil {
+		t.Fatalf("new counter: %v", err)
+	}
+	counter.now = func() time.Time { return time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	for i := range 2 {
+		ok, err := counter.ReserveQuestion(ctx, "u1", 2)
+		if err != nil || !ok {
+			t.Fatalf("reservation %d should pass: ok=%v err=%v", i, ok, err)
+		}
+	}
+	if ok, err := counter.ReserveQuestion(ctx, "u1", 2); err != nil || ok {
+		t.Fatalf("third reservation should be rejected: ok=%v err=%v", ok, err)
+	}
+	if err := counter.ReleaseQuestion(ctx, "u1"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if ok, _ := counter.ReserveQuestion(ctx, "u1", 2); !ok {
+		t.Fatalf("released reservation should be reusable")
+	}
+	if ok, _ := counter.ReserveQuestion(ctx, "u2", 2); !ok {
+		t.Fatalf("other users should have their own counter")
+	}
+
+	if err := counter.AddTokens(ctx, "u1", 1500); err != nil {
+		t.Fatalf("add tokens: %v", err)
+	}
+	if err := counter.AddTokens(ctx, "u1", 500); err != nil {
+		t.Fatalf("add tokens: %v", err)
+	}
+	questions, tokens, err := counter.Usage(ctx, "u1")
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if questions != 2 || tokens != 2000 {
+		t.Fatalf("usage = %d questions, %d tokens; want 2, 2000", questions, tokens)
+	}
+	if ttl := redis.TTL("test:quota:questions:u1:20260331"); ttl <= 0 || ttl > 26*time.Hour {
+		t.Fatalf("unexpected questions ttl %v", ttl)
+	}
+}
+
+func TestCounterFailsOnRedisErrors(t *testing.T) {
+	redis := miniredis.RunT(t)
+	counter, err := NewRedisCounter(redis.Addr(), "", "test:quota")
+	if err != nil {
+		t.Fatalf("new counter: %v", err)
+	}
+	redis.Close()
+	if ok, err := counter.ReserveQuestion(context.Background(), "u1", 5); err == nil || ok {
+		t.Fatalf("expected reservation error when redis is down")
+	}
+}
+
+func TestResetTimes(t *testing.T) {
+	now := time.Date(2026, 12, 31, 15, 4, 5, 0, time.UTC)
+	if got := QuestionsResetAt(now); !got.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("questions reset = %v", got)
+	}
+	if got := TokensResetAt(now); !got.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("tokens reset = %v", got)
+	}
+}
@@ -0,0 +1,121 @@
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"onebookai/pkg/domain"
+)
+
+// UnlimitedPlan is reported when no plans are configured.
+const UnlimitedPlan = "unlimited"
+
+// Plan caps one subscription tier. A zero limit means unlimited.
+type Plan struct {
+	Name            string
+	DailyQuestions  int
+	MonthlyTokens   int64
+	MaxBooks        int
+	MaxStorageBytes int64
+}
+
+// Plans is the configured set of plans plus the plan users get by default.
+type Plans struct {
+	Plans       map[string]Plan
+	DefaultPlan string
+}
+
+// ParsePlans parses QUOTA_PLANS entries of the form
+// "name=dailyQuestions/monthlyTokens/maxBooks/maxStorageBytes" separated by
+// commas, e.g. "free=50/200000/20/524288000,pro=0/5000000/200/0". Zero means
+// unlimited. An empty string disables quotas.
+func ParsePlans(raw, defaultPlan string) (Plans, error) {
+	plans := Plans{Plans: map[string]Plan{}, DefaultPlan: strings.ToLower(strings.TrimSpace(defaultPlan))}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, values, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		parts := strings.Split(values, "/")
+		if !ok || name == "" || len(parts) != 4 {
+			return Plans{}, fmt.Errorf("invalid quota plan %q (want name=dailyQuestions/monthlyTokens/maxBooks/maxStorageBytes)", entry)
+		}
+		numbers := make([]int64, len(parts))
+		for i, part := range parts {
+			n, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil || n < 0 {
+				return Plans{}, fmt.Errorf("invalid quota plan %q", entry)
+			}
+			numbers[i] = n
+		}
+		plans.Plans[name] = Plan{
+			Name:            name,
+			DailyQuestions:  int(numbers[0]),
+			MonthlyTokens:   numbers[1],
+			MaxBooks:        int(numbers[2]),
+			MaxStorageBytes: numbers[3],
+		}
+	}
+	if len(plans.Plans) == 0 {
+		return plans, nil
+	}
+	if plans.DefaultPlan == "" {
+		plans.DefaultPlan = "free"
+	}
+	if _, ok := plans.Plans[plans.DefaultPlan]; !ok {
+		return Plans{}, fmt.Errorf("default quota plan %q is not defined in QUOTA_PLANS", plans.DefaultPlan)
+	}
+	return plans, nil
+}
+
+// Enabled reports whether any plan is configured.
+func (p Plans) Enabled() bool {
+	return len(p.Plans) > 0
+}
+
+// Has reports whether name is a configured plan.
+func (p Plans) Has(name string) bool {
+	_, ok := p.Plans[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// Limits resolves the effective caps for a user: the override's plan (or the
+// default plan) with any per-field override applied on top. Admins and
+// deployments without plans are unlimited.
+func (p Plans) Limits(user domain.User, override *domain.UserQuotaOverride) domain.QuotaLimits {
+	if !p.Enabled() || user.Role == domain.RoleAdmin {
+		return domain.QuotaLimits{Plan: UnlimitedPlan}
+	}
+	plan := p.Plans[p.DefaultPlan]
+	if override != nil {
+		if named, ok := p.Plans[strings.ToLower(strings.TrimSpace(override.Plan))]; ok {
+			plan = named
+		}
+	}
+	limits := domain.QuotaLimits{
+		Plan:            plan.Name,
+		DailyQuestions:  plan.DailyQuestions,
+		MonthlyTokens:   plan.MonthlyTokens,
+		MaxBooks:        plan.MaxBooks,
+		MaxStorageBytes: plan.MaxStorageBytes,
+	}
+	if override == nil {
+		return limits
+	}
+	if override.DailyQuestions != nil {
+		limits.DailyQuestions = *override.DailyQuestions
+	}
+	if override.MonthlyTokens != nil {
+		limits.MonthlyTokens = *override.MonthlyTokens
+	}
+	if override.MaxBooks != nil {
+		limits.MaxBooks = *override.MaxBooks
+	}
+	if override.MaxStorageBytes != nil {
+		limits.MaxStorageBytes = *override.MaxStorageBytes
+	}
+	return limits
+}
@@ -0,0 +1,54 @@
+package quota
+
+import (
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+func TestParsePlans(t *testing.T) {
+	plans, err := ParsePlans("free=50/200000/20/524288000, Pro=0/5000000/200/0", "")
+	if err != nil {
+		t.Fatalf("ParsePlans: %v", err)
+	}
+	if plans.DefaultPlan != "free" || !plans.Has("pro") {
+		t.Fatalf("unexpected plans: %+v", plans)
+	}
+	if got := plans.Plans["free"]; got.DailyQuestions != 50 || got.MonthlyTokens != 200000 || got.MaxBooks != 20 || got.MaxStorageBytes != 524288000 {
+		t.Fatalf("unexpected free plan: %+v", got)
+	}
+
+	disabled, err := ParsePlans("", "free")
+	if err != nil || disabled.Enabled() {
+		t.Fatalf("empty QUOTA_PLANS should disable quotas: %+v %v", disabled, err)
+	}
+	for _, raw := range []string{"free=1/2/3", "free=a/2/3/4", "=1/2/3/4", "free=-1/2/3/4"} {
+		if _, err := ParsePlans(raw, ""); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+	if _, err := ParsePlans("free=1/2/3/4", "pro"); err == nil {
+		t.Fatalf("expected error for undefined default plan")
+	}
+}
+
+func TestPlansLimits(t *testing.T) {
+	plans, err := ParsePlans("free=50/200000/20/1000,pro=500/0/200/0", "free")
+	if err != nil {
+		t.Fatalf("ParsePlans: %v", err)
+	}
+	user := domain.User{ID: "u1", Role: domain.RoleUser}
+
+	if got := plans.Limits(user, nil); got.Plan != "free" || got.DailyQuestions != 50 {
+		t.Fatalf("default limits = %+v", got)
+	}
+	books := 5
+	got := plans.Limits(user, &domain.UserQuotaOverride{Plan: "pro", MaxBooks: &books})
+	want := domain.QuotaLimits{Plan: "pro", DailyQuestions: 500, MaxBooks: 5}
+	if got != want {
+		t.Fatalf("override limits = %+v, want %+v", got, want)
+	}
+	if got := plans.Limits(domain.User{Role: domain.RoleAdmin}, nil); got.Plan != UnlimitedPlan || got.DailyQuestions != 0 {
+		t.Fatalf("admins should be unlimited: %+v", got)
+	}
+}
@@ -0,0 +1,283 @@
+// Package textpdf writes simple flowing-text PDF documents.
+//
+// Text is set in the Adobe STSong-Light CID font with the UniGB-UCS2-H
+// encoding, which PDF viewers provide without embedding, so Chinese and Latin
+// text render without shipping font files. Pages are written to the
+// underlying writer as soon as they are full, keeping memory flat for long
+// documents.
+package textpdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	pageWidth  = 595.0 // A4 in points
+	pageHeight = 842.0
+	margin     = 56.0
+
+	// Object numbers fixed up front; pages follow from firstPageObject.
+	catalogObject   = 1
+	pagesObject     = 2
+	fontObject      = 3
+	cidFontObject   = 4
+	descriptorObj   = 5
+	firstPageObject = 6
+)
+
+// Style selects the size and color of a block of text.
+type Style int
+
+const (
+	Body Style = iota
+	Title
+	Heading
+	Muted
+)
+
+func (s Style) size() float64 {
+	switch s {
+	case Title:
+		return 18
+	case Heading:
+		return 13
+	case Muted:
+		return 9
+	default:
+		return 11
+	}
+}
+
+// Writer lays out text blocks onto A4 pages.
+type Writer struct {
+	out     *countingWriter
+	offsets map[int]int64
+	nextObj int
+	pages   []int
+	page    bytes.Buffer
+	y       float64
+	title   string
+	err     error
+}
+
+// NewWriter starts a PDF document with the given document title.
+func NewWriter(w io.Writer, title string) *Writer {
+	d := &Writer{
+		out:     &countingWriter{w: w},
+		offsets: map[int]int64{},
+		nextObj: firstPageObject,
+		title:   title,
+	}
+	d.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	d.object(fontObject, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [%d 0 R] >>", cidFontObject))
+	d.object(cidFontObject, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor %d 0 R /DW 1000 /W [1 95 500] >>", descriptorObj))
+	d.object(descriptorObj, "<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	return d
+}
+
+// Text writes a paragraph, wrapping it to the page width. Line breaks in
+// text start new lines.
+func (d *Writer) Text(style Style, text string) {
+	d.TextIndent(style, 0, text)
+}
+
+// TextIndent writes a paragraph indented by indent points.
+func (d *Writer) TextIndent(style Style, indent float64, text string) {
+	size := style.size()
+	leading := size * 1.5
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrap(paragraph, (pageWidth-2*margin-indent)/size) {
+			d.ensureSpace(leading)
+			d.y -= leading
+			gray := "0 g"
+			if style == Muted {
+				gray = "0.4 g"
+			}
+			fmt.Fprintf(&d.page, "BT %s /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", gray, size, margin+indent, d.y, encode(line))
+		}
+	}
+}
+
+// Space adds vertical space.
+func (d *Writer) Space(points float64) {
+	if d.page.Len() == 0 {
+		return
+	}
+	d.y -= points
+}
+
+// Rule draws a horizontal line across the text area.
+func (d *Writer) Rule() {
+	d.ensureSpace(12)
+	d.y -= 6
+	fmt.Fprintf(&d.page, "0.8 G 0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, d.y, pageWidth-margin, d.y)
+	d.y -= 6
+}
+
+// Close finishes the last page and writes the page tree, catalog and
+// cross-reference table.
+func (d *Writer) Close() error {
+	d.flushPage()
+	if len(d.pages) == 0 {
+		d.startPage()
+		d.flushPage()
+	}
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	d.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	d.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	info := d.nextObj
+	d.nextObj++
+	d.object(info, fmt.Sprintf("<< /Title <feff%s> /Producer (onebook) >>", utf16Hex(d.title)))
+
+	xref := d.out.n
+	d.printf("xref\n0 %d\n0000000000 65535 f \n", d.nextObj)
+	for obj := 1; obj < d.nextObj; obj++ {
+		d.printf("%010d 00000 n \n", d.offsets[obj])
+	}
+	d.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", d.nextObj, catalogObject, info, xref)
+	return d.err
+}
+
+func (d *Writer) ensureSpace(height float64) {
+	if d.page.Len() == 0 {
+		d.startPage()
+	}
+	if d.y-height < margin {
+		d.flushPage()
+		d.startPage()
+	}
+}
+
+func (d *Writer) startPage() {
+	d.page.Reset()
+	d.page.WriteString(" ")
+	d.y = pageHeight - margin
+}
+
+// flushPage writes the current page's content stream and page object.
+func (d *Writer) flushPage() {
+	if d.page.Len() == 0 {
+		return
+	}
+	content := d.nextObj
+	page := d.nextObj + 1
+	d.nextObj += 2
+	body := d.page.Bytes()
+	d.offsets[content] = d.out.n
+	d.printf("%d 0 obj\n<< /Length %d >>\nstream\n", content, len(body))
+	d.write(body)
+	d.printf("\nendstream\nendobj\n")
+	d.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", pagesObject, pageWidth, pageHeight, fontObject, content))
+	d.pages = append(d.pages, page)
+	d.page.Reset()
+}
+
+func (d *Writer) object(num int, dict string) {
+	d.offsets[num] = d.out.n
+	d.printf("%d 0 obj\n%s\nendobj\n", num, dict)
+}
+
+func (d *Writer) printf(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.out, format, args...)
+}
+
+func (d *Writer) write(p []byte) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = d.out.Write(p)
+}
+
+// wrap splits text into lines of at most width ems. Latin text breaks at the
+// last space when there is one; CJK text breaks anywhere.
+func wrap(text string, width float64) []string {
+	text = strings.TrimRightFunc(strings.ReplaceAll(text, "\t", "    "), unicode.IsSpace)
+	if text == "" {
+		return []string{""}
+	}
+	var lines []string
+	runes := []rune(text)
+	start, lastSpace := 0, -1
+	used := 0.0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == ' ' {
+			lastSpace = i
+		}
+		used += runeWidth(r)
+		if used <= width || i == start {
+			continue
+		}
+		end := i
+		if lastSpace > start {
+			end = lastSpace
+		}
+		lines = append(lines, string(runes[start:end]))
+		start = end
+		for start < len(runes) && runes[start] == ' ' {
+			start++
+		}
+		lastSpace = -1
+		used = 0
+		i = start - 1
+	}
+	if start < len(runes) {
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+// encode renders text as UCS-2 hex for the UniGB-UCS2-H encoding; characters
+// outside the basic multilingual plane become '?'.
+func encode(text string) string {
+	buf := make([]byte, 0, len(text)*2)
+	for _, r := range text {
+		switch {
+		case r > 0xFFFF:
+			r = '?'
+		case unicode.IsControl(r):
+			continue
+		}
+		buf = append(buf, byte(r>>8), byte(r))
+	}
+	return hex.EncodeToString(buf)
+}
+
+func utf16Hex(text string) string {
+	units := utf16.Encode([]rune(text))
+	buf := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		buf = append(buf, byte(u>>8), byte(u))
+	}
+	return hex.EncodeToString(buf)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,63 @@
+package textpdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriterProducesValidXref(t *testing.T) {
+	var buf bytes.Buffer
+	doc := NewWriter(&buf, "测试 export")
+	doc.Text(Title, "Conversation")
+	for i := 0; i < 120; i++ {
+		doc.Text(Body, "Go 语言通过 goroutine 实现轻量级并发, and channels connect them.")
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	if pages := bytes.Count(out, []byte("/Type /Page ")); pages < 2 {
+		t.Fatalf("expected text to flow onto several pages, got %d", pages)
+	}
+
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if start == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+	lines := strings.Split(string(out[xref:]), "\n")
+	for i, entry := range lines[3:] {
+		if !strings.HasSuffix(entry, " n ") {
+			break
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		want := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
+
+func TestWrapBreaksLatinAtSpacesAndCJKAnywhere(t *testing.T) {
+	if got := wrap("alpha beta gamma", 3); len(got) != 3 || got[0] != "alpha" || got[2] != "gamma" {
+		t.Fatalf("latin wrap = %q", got)
+	}
+	if got := wrap("一二三四五六七", 3); len(got) != 3 || got[0] != "一二三" || got[2] != "七" {
+		t.Fatalf("cjk wrap = %q", got)
+	}
+}
+
+func TestEncodeUsesUCS2(t *testing.T) {
+	if got := encode("A中"); got != "00414e2d" {
+		t.Fatalf("encode = %q", got)
+	}
+}
@@ -33,3 +33,8 @@ func (e *OllamaEmbedder) EmbedText(ctx context.Context, text, taskType string) (
 func (e *OllamaEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
 	return e.client.EmbedTexts(ctx, e.model, texts, e.dimensions)
 }
+
+// Ping checks that the underlying Ollama server is reachable.
+func (e *OllamaEmbedder) Ping(ctx context.Context) error {
+	return e.client.Ping(ctx)
+}
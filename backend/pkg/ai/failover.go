@@ -0,0 +1,311 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureThreshold = 3
+	defaultBreakerOpenDuration     = 30 * time.Second
+)
+
+// FailoverProvider is one entry in a failover chain.
+type FailoverProvider struct {
+	// Name identifies the provider in traces and logs, e.g. "gemini/gemini-2.5-flash".
+	Name      string
+	Generator TextGenerator
+}
+
+// BreakerConfig tunes the per-provider circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures (errors or slow
+	// calls) that opens the breaker. Defaults to 3.
+	FailureThreshold int
+	// OpenDuration is how long an open breaker skips the provider before a
+	// single trial call is let through. Defaults to 30s.
+	OpenDuration time.Duration
+	// SlowCallThreshold counts a call as a failure when it takes longer than
+	// this (time to first chunk when streaming). Zero disables latency tripping.
+	SlowCallThreshold time.Duration
+}
+
+// ErrAllProvidersFailed is returned when every provider in the chain failed or
+// was skipped by an open breaker.
+var ErrAllProvidersFailed = errors.New("all generation providers failed")
+
+// FailoverGenerator tries an ordered list of generators, skipping providers
+// whose circuit breaker is open. Streaming falls back to the next provider
+// only while no chunk has been emitted to the caller.
+type FailoverGenerator struct {
+	providers []FailoverProvider
+	breakers  []*circuitBreaker
+	slowCall  time.Duration
+}
+
+// NewFailoverGenerator builds a failover chain; the first provider is preferred.
+func NewFailoverGenerator(providers []FailoverProvider, cfg BreakerConfig) (*FailoverGenerator, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("failover: at least one provider required")
+	}
+	threshold := cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultBreakerFailureThreshold
+	}
+	openFor := cfg.OpenDuration
+	if openFor <= 0 {
+		openFor = defaultBreakerOpenDuration
+	}
+	breakers := make([]*circuitBreaker, 0, len(providers))
+	for _, provider := range providers {
+		if provider.Generator == nil {
+			return nil, fmt.Errorf("failover: provider %q has no generator", provider.Name)
+		}
+		breakers = append(breakers, &circuitBreaker{threshold: threshold, openFor: openFor, now: time.Now})
+	}
+	return &FailoverGenerator{providers: providers, breakers: breakers, slowCall: cfg.SlowCallThreshold}, nil
+}
+
+// GenerateText returns the first successful response in provider order.
+func (g *FailoverGenerator) GenerateText(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return g.run(ctx, func(ctx context.Context, provider TextGenerator, started time.Time) (string, time.Duration, bool, error) {
+		text, err := provider.GenerateText(ctx, systemPrompt, userPrompt)
+		return text, time.Since(started), false, err
+	})
+}
+
+// GenerateTextStream streams from the first provider that starts answering.
+// Once a chunk has reached onChunk the answer is committed to that provider and
+// later errors are returned as-is rather than restarting on another provider.
+func (g *FailoverGenerator) GenerateTextStream(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	return g.run(ctx, func(ctx context.Context, provider TextGenerator, started time.Time) (string, time.Duration, bool, error) {
+		var firstChunk time.Duration
+		emitted := false
+		forward := func(chunk string) error {
+			if !emitted {
+				emitted = true
+				firstChunk = time.Since(started)
+			}
+			if onChunk == nil {
+				return nil
+			}
+			return onChunk(chunk)
+		}
+		streamer, ok := provider.(StreamingTextGenerator)
+		if !ok {
+			text, err := provider.GenerateText(ctx, systemPrompt, userPrompt)
+			if err != nil {
+				return "", time.Since(started), false, err
+			}
+			latency := time.Since(started)
+			text = strings.TrimSpace(text)
+			if text == "" {
+				return "", latency, false, nil
+			}
+			return text, latency, true, forward(text)
+		}
+		text, err := streamer.GenerateTextStream(ctx, systemPrompt, userPrompt, forward)
+		if !emitted {
+			firstChunk = time.Since(started)
+		}
+		return text, firstChunk, emitted, err
+	})
+}
+
+type failoverAttempt func(ctx context.Context, provider TextGenerator, started time.Time) (text string, latency time.Duration, committed bool, err error)
+
+func (g *FailoverGenerator) run(ctx context.Context, attempt failoverAttempt) (string, error) {
+	report := generationReportFrom(ctx)
+	var errs []error
+	for i, provider := range g.providers {
+		breaker := g.breakers[i]
+		if !breaker.allow() {
+			report.addFailure(provider.Name)
+			errs = append(errs, fmt.Errorf("%s: circuit open", provider.Name))
+			continue
+		}
+		text, latency, committed, err := attempt(ctx, provider.Generator, time.Now())
+		if ctx.Err() != nil {
+			// The caller went away; that says nothing about the provider.
+			breaker.release()
+			if committed {
+				report.setProvider(provider.Name)
+			}
+			return text, ctx.Err()
+		}
+		slow := g.slowCall > 0 && latency > g.slowCall
+		if err != nil || slow {
+			breaker.recordFailure()
+		} else {
+			breaker.recordSuccess()
+		}
+		if err == nil || committed {
+			report.setProvider(provider.Name)
+			return text, err
+		}
+		report.addFailure(provider.Name)
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+	}
+	return "", fmt.Errorf("%w: %w", ErrAllProvidersFailed, errors.Join(errs...))
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker opens after a run of consecutive failures, then lets a single
+// trial call through once the open window has elapsed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = breakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release hands back a half-open trial slot without judging the provider.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// GenerationReport records which provider produced a generation and which
+// providers were tried and skipped or failed before it.
+type GenerationReport struct {
+	mu       sync.Mutex
+	provider string
+	failed   []string
+}
+
+type generationReportKey struct{}
+
+// WithGenerationReport attaches a fresh report to ctx. Generators that know
+// which provider answered (such as FailoverGenerator) fill it in.
+func WithGenerationReport(ctx context.Context) (context.Context, *GenerationReport) {
+	report := &GenerationReport{}
+	return context.WithValue(ctx, generationReportKey{}, report), report
+}
+
+// WithoutGenerationReport hides any report attached to ctx, so auxiliary
+// generations (planning, follow-up retrieval) are not attributed to it.
+func WithoutGenerationReport(ctx context.Context) context.Context {
+	return context.WithValue(ctx, generationReportKey{}, (*GenerationReport)(nil))
+}
+
+// RecordGenerationProvider notes the answering provider on the report in ctx
+// unless a provider has already been recorded.
+func RecordGenerationProvider(ctx context.Context, name string) {
+	report := generationReportFrom(ctx)
+	if report == nil {
+		return
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	if report.provider == "" {
+		report.provider = name
+	}
+}
+
+// Provider returns the provider that answered, or "" when none did.
+func (r *GenerationReport) Provider() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.provider
+}
+
+// FailedOver lists the providers that were skipped or failed, in order.
+func (r *GenerationReport) FailedOver() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.failed...)
+}
+
+func (r *GenerationReport) setProvider(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.provider = name
+}
+
+func (r *GenerationReport) addFailure(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = append(r.failed, name)
+}
+
+func generationReportFrom(ctx context.Context) *GenerationReport {
+	if ctx == nil {
+		return nil
+	}
+	report, _ := ctx.Value(generationReportKey{}).(*GenerationReport)
+	return report
+}
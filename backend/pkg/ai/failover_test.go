@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubGenerator struct {
+	calls  int
+	text   string
+	err    error
+	chunks []string
+	delay  time.Duration
+}
+
+func (s *stubGenerator) GenerateText(context.Context, string, string) (string, error) {
+	s.calls++
+	time.Sleep(s.delay)
+	return s.text, s.err
+}
+
+type stubStreamer struct{ stubGenerator }
+
+func (s *stubStreamer) GenerateTextStream(_ context.Context, _, _ string, onChunk func(string) error) (string, error) {
+	s.calls++
+	text := ""
+	for _, chunk := range s.chunks {
+		text += chunk
+		if err := onChunk(chunk); err != nil {
+			return text, err
+		}
+	}
+	return text, s.err
+}
+
+func TestFailoverGeneratorFallsBackAndReportsProvider(t *testing.T) {
+	primary := &stubGenerator{err: errors.New("429 rate limited")}
+	secondary := &stubGenerator{text: "answer"}
+	generator, err := NewFailoverGenerator([]FailoverProvider{{Name: "gemini/flash", Generator: primary}, {Name: "ollama/qwen", Generator: secondary}}, BreakerConfig{})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	ctx, report := WithGenerationReport(context.Background())
+	text, err := generator.GenerateText(ctx, "", "question")
+	if err != nil || text != "answer" {
+		t.Fatalf("expected fallback answer, got %q, %v", text, err)
+	}
+	if report.Provider() != "ollama/qwen" || len(report.FailedOver()) != 1 || report.FailedOver()[0] != "gemini/flash" {
+		t.Fatalf("unexpected report provider=%q failed=%v", report.Provider(), report.FailedOver())
+	}
+}
+
+func TestFailoverGeneratorBreakerOpensAndRecovers(t *testing.T) {
+	primary := &stubGenerator{err: errors.New("503")}
+	secondary := &stubGenerator{text: "answer"}
+	generator, err := NewFailoverGenerator([]FailoverProvider{{Name: "primary", Generator: primary}, {Name: "secondary", Generator: secondary}}, BreakerConfig{FailureThreshold: 2, OpenDuration: time.Minute})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	now := time.Now()
+	generator.breakers[0].now = func() time.Time { return now }
+	for range 4 {
+		if _, err := generator.GenerateText(context.Background(), "", "q"); err != nil {
+			t.Fatalf("GenerateText: %v", err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Fatalf("expected breaker to stop calling primary after 2 failures, got %d calls", primary.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	primary.text = "recovered"
+	text, err := generator.GenerateText(context.Background(), "", "q")
+	if err != nil || text != "recovered" || primary.calls != 3 {
+		t.Fatalf("expected half-open trial to reach primary, got %q, %v, calls=%d", text, err, primary.calls)
+	}
+}
+
+func TestFailoverGeneratorCountsSlowCallsAsFailures(t *testing.T) {
+	primary := &stubGenerator{text: "slow", delay: 20 * time.Millisecond}
+	generator, err := NewFailoverGenerator([]FailoverProvider{{Name: "primary", Generator: primary}, {Name: "secondary", Generator: &stubGenerator{text: "fast"}}}, BreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute, SlowCallThreshold: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	if text, _ := generator.GenerateText(context.Background(), "", "q"); text != "slow" {
+		t.Fatalf("slow calls still return their answer, got %q", text)
+	}
+	if text, _ := generator.GenerateText(context.Background(), "", "q"); text != "fast" {
+		t.Fatalf("expected breaker opened by latency to route to secondary, got %q", text)
+	}
+}
+
+func TestFailoverGeneratorStreamFallsBackOnlyBeforeFirstChunk(t *testing.T) {
+	failsEarly := &stubStreamer{stubGenerator{err: errors.New("connect refused")}}
+	failsLate := &stubStreamer{stubGenerator{chunks: []string{"部分"}, err: errors.New("stream reset")}}
+	backup := &stubStreamer{stubGenerator{chunks: []string{"完整回答"}}}
+
+	generator, err := NewFailoverGenerator([]FailoverProvider{{Name: "early", Generator: failsEarly}, {Name: "backup", Generator: backup}}, BreakerConfig{})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	var chunks []string
+	text, err := generator.GenerateTextStream(context.Background(), "", "q", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil || text != "完整回答" || len(chunks) != 1 {
+		t.Fatalf("expected fallback before first chunk, got %q, %v, %v", text, err, chunks)
+	}
+
+	generator, err = NewFailoverGenerator([]FailoverProvider{{Name: "late", Generator: failsLate}, {Name: "backup", Generator: backup}}, BreakerConfig{})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	backup.calls = 0
+	ctx, report := WithGenerationReport(context.Background())
+	text, err = generator.GenerateTextStream(ctx, "", "q", func(string) error { return nil })
+	if err == nil || text != "部分" || backup.calls != 0 {
+		t.Fatalf("expected mid-stream error without fallback, got %q, %v, backup calls=%d", text, err, backup.calls)
+	}
+	if report.Provider() != "late" {
+		t.Fatalf("expected committed provider recorded, got %q", report.Provider())
+	}
+}
+
+func TestFailoverGeneratorReportsAllFailures(t *testing.T) {
+	generator, err := NewFailoverGenerator([]FailoverProvider{{Name: "a", Generator: &stubGenerator{err: errors.New("down")}}, {Name: "b", Generator: &stubGenerator{err: errors.New("down")}}}, BreakerConfig{})
+	if err != nil {
+		t.Fatalf("NewFailoverGenerator: %v", err)
+	}
+	if _, err := generator.GenerateText(context.Background(), "", "q"); !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("expected ErrAllProvidersFailed, got %v", err)
+	}
+}
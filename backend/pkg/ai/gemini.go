@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -14,9 +15,10 @@ const defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
 
 // GeminiClient calls the Google AI Studio (Gemini) API.
 type GeminiClient struct {
-	apiKey     string
-	baseURL    string
-	httpClient *http.Client
+	apiKey           string
+	baseURL          string
+	httpClient       *http.Client
+	streamHTTPClient *http.Client
 }
 
 // NewGeminiClient constructs a client with the provided API key.
@@ -29,11 +31,126 @@ func NewGeminiClient(apiKey string) (*GeminiClient, error) {
 		apiKey:     apiKey,
 		baseURL:    defaultGeminiBaseURL,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		// Streams stay open for the whole generation and are bounded by the
+		// caller's context instead of a client timeout.
+		streamHTTPClient: &http.Client{},
 	}, nil
 }
 
 // GenerateText returns the generated response for a prompt.
 func (c *GeminiClient) GenerateText(ctx context.Context, model, systemPrompt, userPrompt string) (string, error) {
+	reqBody := newGenerateRequest(systemPrompt, userPrompt)
+	started := time.Now()
+	var resp generateResponse
+	if err := c.doJSON(ctx, fmt.Sprintf("%s/models/%s:generateContent?key=%s", c.baseURL, normalizeModel(model), c.apiKey), reqBody, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("empty response from gemini")
+	}
+	recordGeminiUsage(ctx, model, resp.UsageMetadata, time.Since(started))
+	return resp.Candidates[0].Content.Parts[0].Text, nil
+}
+
+// GenerateTextStream calls streamGenerateContent with SSE framing and emits
+// each text part as it arrives. Cancelling ctx aborts the upstream request.
+func (c *GeminiClient) GenerateTextStream(ctx context.Context, model, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	body, err := json.Marshal(newGenerateRequest(systemPrompt, userPrompt))
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s", c.baseURL, normalizeModel(model), c.apiKey)
+	started := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.streamHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gemini stream request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var errResp errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		if errResp.Error.Message != "" {
+			return "", fmt.Errorf("gemini api error: %s", errResp.Error.Message)
+		}
+		return "", fmt.Errorf("gemini api error: %s", resp.Status)
+	}
+
+	var (
+		full  strings.Builder
+		usage *geminiUsageMetadata
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if payload == "" {
+			continue
+		}
+		var chunk struct {
+			generateResponse
+			errorResponse
+		}
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			return "", fmt.Errorf("gemini stream decode: %w", err)
+		}
+		if chunk.Error.Message != "" {
+			return "", fmt.Errorf("gemini api error: %s", chunk.Error.Message)
+		}
+		// Each event carries the running totals; the last one is final.
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata
+		}
+		for _, candidate := range chunk.Candidates {
+			for _, item := range candidate.Content.Parts {
+				if item.Text == "" {
+					continue
+				}
+				full.WriteString(item.Text)
+				if onChunk != nil {
+					if err := onChunk(item.Text); err != nil {
+						return full.String(), err
+					}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return full.String(), ctxErr
+		}
+		return "", fmt.Errorf("gemini stream read: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return full.String(), err
+	}
+	text := strings.TrimSpace(full.String())
+	if text == "" {
+		return "", fmt.Errorf("empty response from gemini")
+	}
+	recordGeminiUsage(ctx, model, usage, time.Since(started))
+	return text, nil
+}
+
+func recordGeminiUsage(ctx context.Context, model string, usage *geminiUsageMetadata, latency time.Duration) {
+	record := Usage{Provider: "gemini", Model: normalizeModel(model), Latency: latency}
+	if usage != nil {
+		record.PromptTokens = usage.PromptTokenCount
+		record.CompletionTokens = usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+	}
+	recordUsage(ctx, record)
+}
+
+func newGenerateRequest(systemPrompt, userPrompt string) generateRequest {
 	reqBody := generateRequest{
 		Contents: []content{
 			{
@@ -47,14 +164,7 @@ func (c *GeminiClient) GenerateText(ctx context.Context, model, systemPrompt, us
 			Parts: []part{{Text: systemPrompt}},
 		}
 	}
-	var resp generateResponse
-	if err := c.doJSON(ctx, fmt.Sprintf("%s/models/%s:generateContent?key=%s", c.baseURL, normalizeModel(model), c.apiKey), reqBody, &resp); err != nil {
-		return "", err
-	}
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from gemini")
-	}
-	return resp.Candidates[0].Content.Parts[0].Text, nil
+	return reqBody
 }
 
 func normalizeModel(model string) string {
@@ -113,6 +223,13 @@ type generateResponse struct {
 	Candidates []struct {
 		Content content `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
 }
 
 type errorResponse struct {
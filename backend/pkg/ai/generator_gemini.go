@@ -17,3 +17,9 @@ func NewGeminiGenerator(client *GeminiClient, model string) *GeminiGenerator {
 func (g *GeminiGenerator) GenerateText(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	return g.client.GenerateText(ctx, g.model, systemPrompt, userPrompt)
 }
+
+// GenerateTextStream implements StreamingTextGenerator using Gemini
+// streamGenerateContent.
+func (g *GeminiGenerator) GenerateTextStream(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	return g.client.GenerateTextStream(ctx, g.model, systemPrompt, userPrompt, onChunk)
+}
@@ -1,9 +1,14 @@
 package ai
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
 // OllamaGenerator wraps OllamaClient with a fixed model for text generation
@@ -25,18 +30,13 @@ func (g *OllamaGenerator) GenerateText(ctx context.Context, systemPrompt, userPr
 		return "", fmt.Errorf("ollama generation model required")
 	}
 
-	messages := make([]ollamaChatMessage, 0, 2)
-	if strings.TrimSpace(systemPrompt) != "" {
-		messages = append(messages, ollamaChatMessage{Role: "system", Content: systemPrompt})
-	}
-	messages = append(messages, ollamaChatMessage{Role: "user", Content: userPrompt})
-
 	reqBody := ollamaChatRequest{
 		Model:    model,
-		Messages: messages,
+		Messages: ollamaChatMessages(systemPrompt, userPrompt),
 		Stream:   false,
 	}
 
+	started := time.Now()
 	var resp ollamaChatResponse
 	if _, err := g.client.doJSON(ctx, "/api/chat", reqBody, &resp); err != nil {
 		return "", fmt.Errorf("ollama generate: %w", err)
@@ -44,9 +44,102 @@ func (g *OllamaGenerator) GenerateText(ctx context.Context, systemPrompt, userPr
 	if strings.TrimSpace(resp.Message.Content) == "" {
 		return "", fmt.Errorf("empty response from ollama")
 	}
+	recordUsage(ctx, Usage{Provider: "ollama", Model: model, PromptTokens: resp.PromptEvalCount, CompletionTokens: resp.EvalCount, Latency: time.Since(started)})
 	return resp.Message.Content, nil
 }
 
+// GenerateTextStream implements StreamingTextGenerator using Ollama /api/chat
+// with stream enabled; Ollama answers with one JSON object per line.
+// Cancelling ctx aborts the upstream request.
+func (g *OllamaGenerator) GenerateTextStream(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
+	model := strings.TrimSpace(g.model)
+	if model == "" {
+		return "", fmt.Errorf("ollama generation model required")
+	}
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    model,
+		Messages: ollamaChatMessages(systemPrompt, userPrompt),
+		Stream:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+	started := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.client.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.streamHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ollama stream request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var errResp ollamaErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		if errResp.Error != "" {
+			return "", fmt.Errorf("ollama api error: %s", errResp.Error)
+		}
+		return "", fmt.Errorf("ollama api error: %s", resp.Status)
+	}
+
+	var (
+		full  strings.Builder
+		final ollamaChatStreamResponse
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatStreamResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("ollama stream decode: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama api error: %s", chunk.Error)
+		}
+		if delta := chunk.Message.Content; delta != "" {
+			full.WriteString(delta)
+			if onChunk != nil {
+				if err := onChunk(delta); err != nil {
+					return full.String(), err
+				}
+			}
+		}
+		if chunk.Done {
+			final = chunk
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return full.String(), ctxErr
+		}
+		return "", fmt.Errorf("ollama stream read: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return full.String(), err
+	}
+	text := strings.TrimSpace(full.String())
+	if text == "" {
+		return "", fmt.Errorf("empty response from ollama")
+	}
+	recordUsage(ctx, Usage{Provider: "ollama", Model: model, PromptTokens: final.PromptEvalCount, CompletionTokens: final.EvalCount, Latency: time.Since(started)})
+	return text, nil
+}
+
+func ollamaChatMessages(systemPrompt, userPrompt string) []ollamaChatMessage {
+	messages := make([]ollamaChatMessage, 0, 2)
+	if strings.TrimSpace(systemPrompt) != "" {
+		messages = append(messages, ollamaChatMessage{Role: "system", Content: systemPrompt})
+	}
+	return append(messages, ollamaChatMessage{Role: "user", Content: userPrompt})
+}
+
 // Ollama /api/chat request/response types.
 
 type ollamaChatMessage struct {
@@ -61,5 +154,17 @@ type ollamaChatRequest struct {
 }
 
 type ollamaChatResponse struct {
-	Message ollamaChatMessage `json:"message"`
+	Message         ollamaChatMessage `json:"message"`
+	PromptEvalCount int               `json:"prompt_eval_count"`
+	EvalCount       int               `json:"eval_count"`
+}
+
+// ollamaChatStreamResponse is one NDJSON line; token counts arrive on the
+// final line with done=true.
+type ollamaChatStreamResponse struct {
+	Message         ollamaChatMessage `json:"message"`
+	Done            bool              `json:"done"`
+	Error           string            `json:"error"`
+	PromptEvalCount int               `json:"prompt_eval_count"`
+	EvalCount       int               `json:"eval_count"`
 }
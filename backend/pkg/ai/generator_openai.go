@@ -79,6 +79,7 @@ func (g *OpenAICompatGenerator) GenerateText(ctx context.Context, systemPrompt,
 		req.Header.Set("Authorization", "Bearer "+g.apiKey)
 	}
 
+	started := time.Now()
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("openai-compat request: %w", err)
@@ -109,9 +110,19 @@ func (g *OpenAICompatGenerator) GenerateText(ctx context.Context, systemPrompt,
 		}
 		return "", fmt.Errorf("empty response from openai-compat api")
 	}
+	g.recordUsage(ctx, chatResp.Usage, time.Since(started))
 	return text, nil
 }
 
+func (g *OpenAICompatGenerator) recordUsage(ctx context.Context, usage *oaiUsage, latency time.Duration) {
+	record := Usage{Provider: "openai-compat", Model: g.model, Latency: latency}
+	if usage != nil {
+		record.PromptTokens = usage.PromptTokens
+		record.CompletionTokens = usage.CompletionTokens
+	}
+	recordUsage(ctx, record)
+}
+
 // GenerateTextStream implements StreamingTextGenerator using streaming chat
 // completions when the upstream provider supports OpenAI-compatible SSE.
 func (g *OpenAICompatGenerator) GenerateTextStream(ctx context.Context, systemPrompt, userPrompt string, onChunk func(string) error) (string, error) {
@@ -129,6 +140,7 @@ func (g *OpenAICompatGenerator) GenerateTextStream(ctx context.Context, systemPr
 		Model:          g.model,
 		Messages:       messages,
 		Stream:         true,
+		StreamOptions:  &oaiStreamOptions{IncludeUsage: true},
 		EnableThinking: g.enableThinking,
 	}
 
@@ -148,6 +160,7 @@ func (g *OpenAICompatGenerator) GenerateTextStream(ctx context.Context, systemPr
 		req.Header.Set("Authorization", "Bearer "+g.apiKey)
 	}
 
+	started := time.Now()
 	resp, err := g.streamHTTPClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("openai-compat stream request: %w", err)
@@ -163,7 +176,7 @@ func (g *OpenAICompatGenerator) GenerateTextStream(ctx context.Context, systemPr
 		return "", fmt.Errorf("openai-compat api error: %s", resp.Status)
 	}
 
-	text, err := consumeOAIStream(ctx, g.model, resp.Body, onChunk)
+	text, usage, err := consumeOAIStream(ctx, g.model, resp.Body, onChunk)
 	if err != nil {
 		return "", err
 	}
@@ -172,17 +185,23 @@ func (g *OpenAICompatGenerator) GenerateTextStream(ctx context.Context, systemPr
 		log.Info("openai_compat_stream_fallback_to_nonstream", "model", g.model)
 		return g.GenerateText(ctx, systemPrompt, userPrompt)
 	}
+	g.recordUsage(ctx, usage, time.Since(started))
 	return text, nil
 }
 
-func consumeOAIStream(ctx context.Context, model string, body io.Reader, onChunk func(string) error) (string, error) {
+// consumeOAIStream reads SSE chat completion chunks, forwarding content deltas
+// and returning the usage block sent when stream_options.include_usage is honoured.
+func consumeOAIStream(ctx context.Context, model string, body io.Reader, onChunk func(string) error) (string, *oaiUsage, error) {
 	log := util.LoggerFromContext(ctx)
-	var full strings.Builder
+	var (
+		full  strings.Builder
+		usage *oaiUsage
+	)
 	reader := bufio.NewReader(body)
 	for {
 		line, readErr := reader.ReadString('\n')
 		if readErr != nil && !errorsIsEOF(readErr) {
-			return "", fmt.Errorf("openai-compat stream read: %w", readErr)
+			return "", nil, fmt.Errorf("openai-compat stream read: %w", readErr)
 		}
 		line = strings.TrimRight(line, "\r\n")
 		if strings.HasPrefix(line, "data:") {
@@ -195,10 +214,13 @@ func consumeOAIStream(ctx context.Context, model string, body io.Reader, onChunk
 				log.Info("openai_compat_stream_payload", "model", model, "payload", payload)
 				var chunkResp oaiChatStreamResponse
 				if err := json.Unmarshal([]byte(payload), &chunkResp); err != nil {
-					return "", fmt.Errorf("openai-compat stream decode: %w", err)
+					return "", nil, fmt.Errorf("openai-compat stream decode: %w", err)
 				}
 				if chunkResp.Error.Message != "" {
-					return "", fmt.Errorf("openai-compat api error: %s", chunkResp.Error.Message)
+					return "", nil, fmt.Errorf("openai-compat api error: %s", chunkResp.Error.Message)
+				}
+				if chunkResp.Usage != nil {
+					usage = chunkResp.Usage
 				}
 				for _, choice := range chunkResp.Choices {
 					delta := decodeOAIContent(choice.Delta.Content)
@@ -208,7 +230,7 @@ func consumeOAIStream(ctx context.Context, model string, body io.Reader, onChunk
 					full.WriteString(delta)
 					if onChunk != nil {
 						if err := onChunk(delta); err != nil {
-							return full.String(), err
+							return full.String(), nil, err
 						}
 					}
 				}
@@ -218,7 +240,7 @@ func consumeOAIStream(ctx context.Context, model string, body io.Reader, onChunk
 			break
 		}
 	}
-	return strings.TrimSpace(full.String()), nil
+	return strings.TrimSpace(full.String()), usage, nil
 }
 
 // OpenAI-compatible request/response types.
@@ -229,10 +251,20 @@ type oaiMessage struct {
 }
 
 type oaiChatRequest struct {
-	Model          string       `json:"model"`
-	Messages       []oaiMessage `json:"messages"`
-	Stream         bool         `json:"stream,omitempty"`
-	EnableThinking *bool        `json:"enable_thinking,omitempty"`
+	Model          string            `json:"model"`
+	Messages       []oaiMessage      `json:"messages"`
+	Stream         bool              `json:"stream,omitempty"`
+	StreamOptions  *oaiStreamOptions `json:"stream_options,omitempty"`
+	EnableThinking *bool             `json:"enable_thinking,omitempty"`
+}
+
+type oaiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type oaiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 type oaiChatResponse struct {
@@ -243,6 +275,7 @@ type oaiChatResponse struct {
 			ReasoningContent json.RawMessage `json:"reasoning_content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *oaiUsage `json:"usage,omitempty"`
 }
 
 type oaiErrorResponse struct {
@@ -259,6 +292,7 @@ type oaiChatStreamResponse struct {
 			ReasoningContent json.RawMessage `json:"reasoning_content"`
 		} `json:"delta"`
 	} `json:"choices"`
+	Usage *oaiUsage `json:"usage,omitempty"`
 	Error struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
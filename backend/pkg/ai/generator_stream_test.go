@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestGeminiGenerator(t *testing.T, handler http.HandlerFunc) *GeminiGenerator {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := NewGeminiClient("test-key")
+	if err != nil {
+		t.Fatalf("NewGeminiClient: %v", err)
+	}
+	client.baseURL = server.URL
+	return NewGeminiGenerator(client, "models/gemini-test")
+}
+
+func TestGeminiGenerateTextStreamEmitsParts(t *testing.T) {
+	var gotPath, gotQuery string
+	var gotBody generateRequest
+	generator := newTestGeminiGenerator(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"Go ", "并发"} {
+			fmt.Fprintf(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":%q}]}}]}\r\n\r\n", text)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	var chunks []string
+	text, err := generator.GenerateTextStream(context.Background(), "system", "question", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GenerateTextStream: %v", err)
+	}
+	if text != "Go 并发" || strings.Join(chunks, "|") != "Go |并发" {
+		t.Fatalf("unexpected stream result %q chunks=%v", text, chunks)
+	}
+	if gotPath != "/models/gemini-test:streamGenerateContent" || !strings.Contains(gotQuery, "alt=sse") {
+		t.Fatalf("unexpected request %s?%s", gotPath, gotQuery)
+	}
+	if gotBody.SystemInstruction == nil || gotBody.SystemInstruction.Parts[0].Text != "system" {
+		t.Fatalf("expected system instruction in request, got %+v", gotBody)
+	}
+}
+
+func TestGeminiGenerateTextStreamSurfacesErrors(t *testing.T) {
+	generator := newTestGeminiGenerator(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"quota exhausted"}}`))
+	})
+	if _, err := generator.GenerateTextStream(context.Background(), "", "question", nil); err == nil || !strings.Contains(err.Error(), "quota exhausted") {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+}
+
+func TestOllamaGenerateTextStreamEmitsLines(t *testing.T) {
+	var gotBody ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, text := range []string{"分布式", "系统"} {
+			fmt.Fprintf(w, "{\"message\":{\"role\":\"assistant\",\"content\":%q},\"done\":false}\n", text)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true}\n")
+	}))
+	defer server.Close()
+	generator := NewOllamaGenerator(NewOllamaClient(server.URL), "qwen-test")
+
+	var chunks []string
+	text, err := generator.GenerateTextStream(context.Background(), "system", "question", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GenerateTextStream: %v", err)
+	}
+	if text != "分布式系统" || len(chunks) != 2 {
+		t.Fatalf("unexpected stream result %q chunks=%v", text, chunks)
+	}
+	if !gotBody.Stream || len(gotBody.Messages) != 2 {
+		t.Fatalf("expected streaming chat request with system message, got %+v", gotBody)
+	}
+}
+
+func TestOllamaGenerateTextStreamSurfacesInStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "{\"error\":\"model not found\"}\n")
+	}))
+	defer server.Close()
+	generator := NewOllamaGenerator(NewOllamaClient(server.URL), "missing")
+	if _, err := generator.GenerateTextStream(context.Background(), "", "question", nil); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("expected in-stream error, got %v", err)
+	}
+}
+
+// blockingStreamServer sends one chunk and then holds the stream open until
+// the client goes away, reporting whether the request context was cancelled.
+func blockingStreamServer(t *testing.T, firstChunk string) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, firstChunk)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, cancelled
+}
+
+func TestStreamingGeneratorsStopWhenClientDisconnects(t *testing.T) {
+	geminiServer, geminiCancelled := blockingStreamServer(t, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"partial\"}]}}]}\n\n")
+	geminiClient, err := NewGeminiClient("test-key")
+	if err != nil {
+		t.Fatalf("NewGeminiClient: %v", err)
+	}
+	geminiClient.baseURL = geminiServer.URL
+	ollamaServer, ollamaCancelled := blockingStreamServer(t, "{\"message\":{\"content\":\"partial\"},\"done\":false}\n")
+
+	cases := []struct {
+		name      string
+		generator StreamingTextGenerator
+		cancelled <-chan struct{}
+	}{
+		{"gemini", NewGeminiGenerator(geminiClient, "gemini-test"), geminiCancelled},
+		{"ollama", NewOllamaGenerator(NewOllamaClient(ollamaServer.URL), "qwen-test"), ollamaCancelled},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			text, err := tc.generator.GenerateTextStream(ctx, "", "question", func(string) error {
+				cancel()
+				return nil
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+			if text != "partial" {
+				t.Fatalf("expected partial text before cancellation, got %q", text)
+			}
+			select {
+			case <-tc.cancelled:
+			case <-time.After(2 * time.Second):
+				t.Fatal("upstream request was not cancelled")
+			}
+		})
+	}
+}
@@ -14,8 +14,9 @@ const defaultOllamaBaseURL = "http://127.0.0.1:11434"
 
 // OllamaClient calls the Ollama HTTP API.
 type OllamaClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL          string
+	httpClient       *http.Client
+	streamHTTPClient *http.Client
 }
 
 // NewOllamaClient constructs a client with the provided base URL.
@@ -26,8 +27,9 @@ func NewOllamaClient(baseURL string) *OllamaClient {
 	}
 	baseURL = strings.TrimRight(baseURL, "/")
 	return &OllamaClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:          baseURL,
+		httpClient:       &http.Client{Timeout: 60 * time.Second},
+		streamHTTPClient: &http.Client{},
 	}
 }
 
@@ -111,6 +113,23 @@ func (c *OllamaClient) EmbedTexts(ctx context.Context, model string, texts []str
 	return nil, fmt.Errorf("ollama embed response missing embeddings")
 }
 
+// Ping checks that the Ollama server is reachable.
+func (c *OllamaClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/version", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("ollama unhealthy: %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *OllamaClient) embedLegacy(ctx context.Context, model, text string) ([]float32, error) {
 	reqBody := ollamaLegacyEmbedRequest{
 		Model:  model,
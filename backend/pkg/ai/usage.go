@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Usage is the token accounting for one model call as reported by the provider.
+type Usage struct {
+	Stage            string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+}
+
+// UsageRecorder collects Usage for every generation made with a context
+// derived from WithUsageRecorder. It is safe for concurrent use.
+type UsageRecorder struct {
+	mu    sync.Mutex
+	calls []Usage
+}
+
+type usageRecorderKey struct{}
+
+type usageStageKey struct{}
+
+// WithUsageRecorder attaches a fresh recorder to ctx. Generators report every
+// completed call to it, so one recorder covers a whole request pipeline.
+func WithUsageRecorder(ctx context.Context) (context.Context, *UsageRecorder) {
+	recorder := &UsageRecorder{}
+	return context.WithValue(ctx, usageRecorderKey{}, recorder), recorder
+}
+
+// WithUsageStage labels the calls made with ctx, e.g. "query_rewrite" or "answer".
+func WithUsageStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, usageStageKey{}, stage)
+}
+
+// Calls returns the recorded calls in completion order.
+func (r *UsageRecorder) Calls() []Usage {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Usage(nil), r.calls...)
+}
+
+// recordUsage stores one call on the recorder in ctx, if any.
+func recordUsage(ctx context.Context, usage Usage) {
+	if ctx == nil {
+		return
+	}
+	recorder, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	if recorder == nil {
+		return
+	}
+	if stage, ok := ctx.Value(usageStageKey{}).(string); ok {
+		usage.Stage = stage
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.calls = append(recorder.calls, usage)
+}
@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeneratorsRecordUsage(t *testing.T) {
+	gemini := newTestGeminiGenerator(t, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Go\"}]}}],\"usageMetadata\":{\"promptTokenCount\":12,\"candidatesTokenCount\":1}}\n\n")
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\" 并发\"}]}}],\"usageMetadata\":{\"promptTokenCount\":12,\"candidatesTokenCount\":3}}\n\n")
+	})
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"[\"go\"]"},"done":true,"prompt_eval_count":30,"eval_count":5}`)
+	}))
+	defer ollamaServer.Close()
+	ollama := NewOllamaGenerator(NewOllamaClient(ollamaServer.URL), "qwen-test")
+	openaiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":40,\"completion_tokens\":2}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer openaiServer.Close()
+	openai := NewOpenAICompatGenerator(openaiServer.URL, "", "deepseek-test", OpenAICompatConfig{})
+
+	ctx, recorder := WithUsageRecorder(context.Background())
+	if _, err := ollama.GenerateText(WithUsageStage(ctx, "query_rewrite"), "", "q"); err != nil {
+		t.Fatalf("ollama GenerateText: %v", err)
+	}
+	answerCtx := WithUsageStage(ctx, "answer")
+	if _, err := gemini.GenerateTextStream(answerCtx, "", "q", nil); err != nil {
+		t.Fatalf("gemini GenerateTextStream: %v", err)
+	}
+	if _, err := openai.GenerateTextStream(answerCtx, "", "q", nil); err != nil {
+		t.Fatalf("openai GenerateTextStream: %v", err)
+	}
+
+	calls := recorder.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 recorded calls, got %+v", calls)
+	}
+	want := []Usage{
+		{Stage: "query_rewrite", Provider: "ollama", Model: "qwen-test", PromptTokens: 30, CompletionTokens: 5},
+		{Stage: "answer", Provider: "gemini", Model: "gemini-test", PromptTokens: 12, CompletionTokens: 3},
+		{Stage: "answer", Provider: "openai-compat", Model: "deepseek-test", PromptTokens: 40, CompletionTokens: 2},
+	}
+	for i, call := range calls {
+		call.Latency = 0
+		if call != want[i] {
+			t.Fatalf("call %d = %+v, want %+v", i, call, want[i])
+		}
+	}
+}
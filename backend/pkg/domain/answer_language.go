@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Answer languages a user may prefer or a request may ask for. Auto answers
+// in the language of the question.
+const (
+	AnswerLanguageAuto = "auto"
+	AnswerLanguageZH   = "zh"
+	AnswerLanguageEN   = "en"
+)
+
+// NormalizeAnswerLanguage validates an answer language. Empty means auto.
+func NormalizeAnswerLanguage(language string) (string, error) {
+	switch normalized := strings.ToLower(strings.TrimSpace(language)); normalized {
+	case "", AnswerLanguageAuto:
+		return AnswerLanguageAuto, nil
+	case AnswerLanguageZH, AnswerLanguageEN:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported answer language %q", language)
+	}
+}
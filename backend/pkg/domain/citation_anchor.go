@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ChunkCitationAnchor builds the reader anchor recorded at ingest in chunk
+// metadata. Chunks ingested before anchors existed return nil.
+func ChunkCitationAnchor(chunk Chunk) *CitationAnchor {
+	start, startErr := strconv.Atoi(strings.TrimSpace(chunk.Metadata["char_start"]))
+	end, endErr := strconv.Atoi(strings.TrimSpace(chunk.Metadata["char_end"]))
+	if startErr != nil || endErr != nil || start < 0 || end <= start {
+		return nil
+	}
+	return &CitationAnchor{
+		CharStart: start,
+		CharEnd:   end,
+		Quote: TextQuoteSelector{
+			Prefix: chunk.Metadata["quote_prefix"],
+			Exact:  chunk.Content,
+			Suffix: chunk.Metadata["quote_suffix"],
+		},
+	}
+}
+
+// ChunkReaderLocation resolves a chunk into reader coordinates. Chunks without
+// an ingest anchor still get their page or spine item and quote; Anchored
+// reports whether the character range is set.
+func ChunkReaderLocation(chunk Chunk) ReaderLocation {
+	location := ReaderLocation{
+		BookID:  chunk.BookID,
+		ChunkID: chunk.ID,
+		Format:  strings.TrimSpace(chunk.Metadata["source_type"]),
+		Quote:   TextQuoteSelector{Exact: chunk.Content},
+	}
+	if anchor := ChunkCitationAnchor(chunk); anchor != nil {
+		location.Anchored = true
+		location.CharStart = anchor.CharStart
+		location.CharEnd = anchor.CharEnd
+		location.Quote = anchor.Quote
+	}
+	switch location.Format {
+	case string(BookFormatPDF):
+		location.Page, _ = strconv.Atoi(strings.TrimSpace(chunk.Metadata["page"]))
+	case string(BookFormatEPUB):
+		if index, err := strconv.Atoi(strings.TrimSpace(chunk.Metadata["spine_index"])); err == nil {
+			location.SpineIndex = &index
+		}
+		location.SpineIDRef = strings.TrimSpace(chunk.Metadata["spine_idref"])
+		location.SpineHref = strings.TrimSpace(chunk.Metadata["section_href"])
+		location.CFI = strings.TrimSpace(chunk.Metadata["epub_cfi"])
+	}
+	return location
+}
@@ -107,8 +107,10 @@ type User struct {
 	Status       UserStatus `json:"status"`
 	LastLoginAt  *time.Time `json:"lastLoginAt,omitempty"`
 	LoginCount   int        `json:"loginCount,omitempty"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	UpdatedAt    time.Time  `json:"updatedAt"`
+	// AnswerLanguage is the preferred answer language: auto, zh or en.
+	AnswerLanguage string    `json:"answerLanguage,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
 type AdminUser struct {
@@ -142,6 +144,7 @@ type UserProfile struct {
 	LoginCount         int        `json:"loginCount"`
 	LastLoginIP        string     `json:"-"`
 	LastLoginUserAgent string     `json:"-"`
+	AnswerLanguage     string     `json:"answerLanguage,omitempty"`
 	CreatedAt          time.Time  `json:"createdAt"`
 	UpdatedAt          time.Time  `json:"updatedAt"`
 }
@@ -158,16 +161,22 @@ type UserIdentity struct {
 	UpdatedAt  time.Time    `json:"updatedAt"`
 }
 
+// Message is one turn of a conversation. ParentID is the message it follows in
+// its branch and is empty for the first question. SiblingIDs lists the
+// alternatives to a regenerated or edited message, itself included, and is
+// only set when listing.
 type Message struct {
 	ID             string          `json:"id"`
 	ConversationID string          `json:"conversationId,omitempty"`
 	UserID         string          `json:"userId,omitempty"`
 	BookID         string          `json:"bookId"`
+	ParentID       string          `json:"parentId,omitempty"`
 	Role           string          `json:"role"`
 	Content        string          `json:"content"`
 	Sources        []Source        `json:"sources,omitempty"`
 	Metadata       MessageMetadata `json:"metadata,omitempty"`
 	Abstained      bool            `json:"abstained,omitempty"`
+	SiblingIDs     []string        `json:"siblingIds,omitempty"`
 	CreatedAt      time.Time       `json:"createdAt"`
 }
 
@@ -176,38 +185,214 @@ type MessageMetadata struct {
 	AnswerTrace      *AnswerTrace     `json:"answerTrace,omitempty"`
 	KeyEntities      []DocumentEntity `json:"keyEntities,omitempty"`
 	SelectedChunkIDs []string         `json:"selectedChunkIds,omitempty"`
+	Claims           []ClaimCitation  `json:"claims,omitempty"`
+	Usage            *LLMUsage        `json:"usage,omitempty"`
+}
+
+// LLMCallUsage is the token accounting for one model call.
+type LLMCallUsage struct {
+	Stage            string  `json:"stage,omitempty"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	LatencyMs        int64   `json:"latencyMs"`
+	CostUSD          float64 `json:"costUsd"`
+}
+
+// LLMUsage aggregates every model call made while answering one question.
+type LLMUsage struct {
+	PromptTokens     int            `json:"promptTokens"`
+	CompletionTokens int            `json:"completionTokens"`
+	TotalTokens      int            `json:"totalTokens"`
+	CostUSD          float64        `json:"costUsd"`
+	LatencyMs        int64          `json:"latencyMs"`
+	Calls            []LLMCallUsage `json:"calls"`
 }
 
 type Conversation struct {
 	ID            string     `json:"id"`
 	UserID        string     `json:"userId"`
 	BookID        string     `json:"bookId,omitempty"`
+	BookIDs       []string   `json:"bookIds,omitempty"`
 	Title         string     `json:"title"`
 	LastMessageAt *time.Time `json:"lastMessageAt,omitempty"`
-	CreatedAt     time.Time  `json:"createdAt"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
-}
-
+	// ActiveLeafID is the last message of the branch shown and continued by
+	// new questions; empty means the latest message.
+	ActiveLeafID string `json:"activeLeafId,omitempty"`
+	// Summary condenses the turns up to SummarizedThrough that have scrolled
+	// out of the recent history window.
+	Summary           string     `json:"summary,omitempty"`
+	SummarizedThrough *time.Time `json:"summarizedThrough,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// ConversationBranch is one root-to-leaf path of a conversation whose
+// questions were edited or answers regenerated.
+type ConversationBranch struct {
+	LeafID       string    `json:"leafId"`
+	MessageCount int       `json:"messageCount"`
+	Preview      string    `json:"preview"`
+	Active       bool      `json:"active"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ChatScope selects several books for one question: an explicit list, or the
+// user's ready books carrying a tag and/or primary category. Its passage
+// fields instead narrow a single-book question to part of the book.
+type ChatScope struct {
+	BookIDs  []string `json:"bookIds,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Category string   `json:"category,omitempty"`
+	PassageScope
+}
+
+// PassageScope narrows a question to a page range and/or section of one
+// book, or anchors it on a passage the reader selected. Retrieval is limited
+// to Pages and Section; a Selection is always used as evidence.
+type PassageScope struct {
+	Pages     *PageRange        `json:"pages,omitempty"`
+	Section   string            `json:"section,omitempty"`
+	Selection *PassageSelection `json:"selection,omitempty"`
+}
+
+// PageRange is an inclusive range of PDF pages; To defaults to From.
+type PageRange struct {
+	From int `json:"from"`
+	To   int `json:"to,omitempty"`
+}
+
+// PassageSelection is text selected in the reader with where it was
+// selected. ChunkID, when known, ties the passage to its indexed chunk.
+type PassageSelection struct {
+	Text    string `json:"text"`
+	ChunkID string `json:"chunkId,omitempty"`
+	Page    int    `json:"page,omitempty"`
+	Section string `json:"section,omitempty"`
+}
+
+// Answer is the result of one question. QuestionMessageID and MessageID
+// identify the stored question and answer messages.
 type Answer struct {
-	Conversation   Conversation    `json:"conversation"`
-	Question       string          `json:"question"`
-	Answer         string          `json:"answer"`
-	Citations      []Source        `json:"citations"`
-	Abstained      bool            `json:"abstained"`
+	Conversation      Conversation    `json:"conversation"`
+	QuestionMessageID string          `json:"questionMessageId,omitempty"`
+	MessageID         string          `json:"messageId,omitempty"`
+	Question          string          `json:"question"`
+	Answer            string          `json:"answer"`
+	Citations         []Source        `json:"citations"`
+	Claims            []ClaimCitation `json:"claims,omitempty"`
+	Abstained         bool            `json:"abstained"`
+	// Cached is set when the answer was reused from an earlier answer to a
+	// similar question on the same book; AnswerTrace.AnswerCache says which.
+	Cached         bool            `json:"cached,omitempty"`
 	RetrievalDebug *RetrievalDebug `json:"retrievalDebug,omitempty"`
+	Usage          *LLMUsage       `json:"usage,omitempty"`
 	CreatedAt      time.Time       `json:"createdAt"`
 }
 
+// Staged events streamed by POST /chats over SSE before and alongside the
+// answer's chunk events. Each is sent at most once per answer except
+// heartbeat.
+
+// PlanEvent reports how the question was routed.
+type PlanEvent struct {
+	Route              string   `json:"route"`
+	QuestionType       string   `json:"questionType"`
+	StandaloneQuestion string   `json:"standaloneQuestion"`
+	RetrievalQueries   []string `json:"retrievalQueries,omitempty"`
+}
+
+// RetrievalEvent reports how many candidates each retrieval stage produced
+// and how many were selected as evidence.
+type RetrievalEvent struct {
+	Counts   RetrievalCounts `json:"counts"`
+	Selected int             `json:"selected"`
+}
+
+// CitationsEvent carries the selected evidence before generation starts.
+type CitationsEvent struct {
+	Citations []Source `json:"citations"`
+}
+
+// AbstainEvent reports that the answer was withheld for lack of evidence.
+type AbstainEvent struct {
+	Reason string `json:"reason"`
+}
+
+// HeartbeatEvent keeps idle streams open while retrieval or generation runs.
+type HeartbeatEvent struct {
+	ElapsedMs int64 `json:"elapsedMs"`
+}
+
 type Source struct {
 	Label        string  `json:"label"`
 	Location     string  `json:"location"`
 	Snippet      string  `json:"snippet"`
 	ChunkID      string  `json:"chunkId,omitempty"`
+	BookID       string  `json:"bookId,omitempty"`
+	BookTitle    string  `json:"bookTitle,omitempty"`
 	SourceRef    string  `json:"sourceRef,omitempty"`
 	Score        float64 `json:"score,omitempty"`
 	Language     string  `json:"language,omitempty"`
 	SourceReason string  `json:"sourceReason,omitempty"`
 	EvidenceType string  `json:"evidenceType,omitempty"`
+	// Unused marks a citation that no sentence of the answer references or
+	// is supported by.
+	Unused bool            `json:"unused,omitempty"`
+	Anchor *CitationAnchor `json:"anchor,omitempty"`
+}
+
+// TextQuoteSelector identifies a passage by its text plus a little context on
+// either side, so readers can find it even when offsets drift. Whitespace in
+// Exact is normalized.
+type TextQuoteSelector struct {
+	Prefix string `json:"prefix,omitempty"`
+	Exact  string `json:"exact"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// CitationAnchor locates a cited chunk inside the text of its source page
+// (PDF) or section (EPUB). CharStart and CharEnd are rune offsets into that
+// extracted text.
+type CitationAnchor struct {
+	CharStart int               `json:"charStart"`
+	CharEnd   int               `json:"charEnd"`
+	Quote     TextQuoteSelector `json:"quote"`
+}
+
+// ReaderLocation is a citation resolved into reader coordinates: a PDF page
+// with a text range, or an EPUB spine item with a CFI range.
+type ReaderLocation struct {
+	BookID     string            `json:"bookId"`
+	ChunkID    string            `json:"chunkId"`
+	Format     string            `json:"format"`
+	Page       int               `json:"page,omitempty"`
+	SpineIndex *int              `json:"spineIndex,omitempty"`
+	SpineIDRef string            `json:"spineIdref,omitempty"`
+	SpineHref  string            `json:"spineHref,omitempty"`
+	CFI        string            `json:"cfi,omitempty"`
+	Anchored   bool              `json:"anchored"`
+	CharStart  int               `json:"charStart"`
+	CharEnd    int               `json:"charEnd"`
+	Quote      TextQuoteSelector `json:"quote"`
+}
+
+// ClaimCitation maps one sentence of an answer to the citations it references
+// and the evidence span that best supports it. Start and End are rune offsets
+// into Answer.Answer; citation numbers are 1-based like the [n] markers.
+type ClaimCitation struct {
+	Text               string  `json:"text"`
+	Start              int     `json:"start"`
+	End                int     `json:"end"`
+	Citations          []int   `json:"citations,omitempty"`
+	SupportingCitation int     `json:"supportingCitation,omitempty"`
+	EvidenceSpan       string  `json:"evidenceSpan,omitempty"`
+	Support            float64 `json:"support"`
+	Supported          bool    `json:"supported"`
+	// Entailment is the LLM verdict (entailed, not_entailed) when the optional
+	// entailment check ran for this claim.
+	Entailment string `json:"entailment,omitempty"`
 }
 
 type QueryPlan struct {
@@ -220,6 +405,12 @@ type QueryPlan struct {
 	ReuseChunkIDs         []string `json:"reuseChunkIds,omitempty"`
 	NeedsRetrieval        bool     `json:"needsRetrieval"`
 	NeedsHistory          bool     `json:"needsHistory"`
+	// RouteSource is model or heuristic; RouteConfidence is the model's
+	// confidence when it chose the route.
+	RouteSource     string  `json:"routeSource,omitempty"`
+	RouteConfidence float64 `json:"routeConfidence,omitempty"`
+	// Scope is the passage scope the question was asked with.
+	Scope *PassageScope `json:"scope,omitempty"`
 }
 
 type Evidence struct {
@@ -235,12 +426,152 @@ type Evidence struct {
 type ValidationResult struct {
 	Passed bool   `json:"passed"`
 	Reason string `json:"reason,omitempty"`
+	// UnsupportedClaims counts answer sentences no citation supports.
+	UnsupportedClaims int `json:"unsupportedClaims,omitempty"`
 }
 
 type AnswerTrace struct {
 	QueryPlan        QueryPlan        `json:"queryPlan"`
 	SelectedEvidence []Evidence       `json:"selectedEvidence,omitempty"`
 	ValidationResult ValidationResult `json:"validationResult"`
+	// GenerationProvider is the provider/model that produced the answer text.
+	GenerationProvider string `json:"generationProvider,omitempty"`
+	// GenerationFailovers lists providers that failed or were skipped first.
+	GenerationFailovers []string `json:"generationFailovers,omitempty"`
+	// RetrievalSteps records the follow-up retrieval rounds run after the
+	// first retrieval found too little evidence.
+	RetrievalSteps []RetrievalStep `json:"retrievalSteps,omitempty"`
+	// AnswerLanguage is the language the answer was asked for, zh or en.
+	AnswerLanguage string `json:"answerLanguage,omitempty"`
+	// PromptTemplate is the versioned prompt set the answer was generated
+	// with, e.g. en-v1, so eval runs can be compared across prompt changes.
+	PromptTemplate string `json:"promptTemplate,omitempty"`
+	// AnswerCache is set when the answer was served from the answer cache.
+	AnswerCache *AnswerCacheHit `json:"answerCache,omitempty"`
+}
+
+// AnswerCacheHit identifies the earlier answer reused for a similar question.
+type AnswerCacheHit struct {
+	MessageID  string  `json:"messageId"`
+	Question   string  `json:"question"`
+	Similarity float64 `json:"similarity"`
+}
+
+// RetrievalStep is one follow-up retrieval round: the sub-queries the
+// planner proposed, what they added, and why the loop stopped, if it did.
+type RetrievalStep struct {
+	Step int `json:"step"`
+	// Action is follow_up (queries for missing evidence) or decompose
+	// (sub-questions of a multi-hop question).
+	Action      string   `json:"action"`
+	Reasoning   string   `json:"reasoning,omitempty"`
+	Queries     []string `json:"queries,omitempty"`
+	NewEvidence int      `json:"newEvidence"`
+	Evidence    int      `json:"evidence"`
+	ElapsedMs   int64    `json:"elapsedMs"`
+	// StopReason is set on the last step: satisfied, planner_stop,
+	// no_new_queries, retrieval_error, step_budget or time_budget.
+	StopReason string `json:"stopReason,omitempty"`
+}
+
+// PassageHit is one ranked passage returned by library search.
+type PassageHit struct {
+	ChunkID    string   `json:"chunkId"`
+	BookID     string   `json:"bookId"`
+	BookTitle  string   `json:"bookTitle"`
+	Category   string   `json:"category,omitempty"`
+	Location   string   `json:"location,omitempty"`
+	Page       string   `json:"page,omitempty"`
+	Section    string   `json:"section,omitempty"`
+	Snippet    string   `json:"snippet"`
+	Highlights []string `json:"highlights,omitempty"`
+	Score      float64  `json:"score"`
+}
+
+type SearchFacet struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+type PassageSearchFacets struct {
+	Books      []SearchFacet `json:"books"`
+	Categories []SearchFacet `json:"categories"`
+}
+
+type PassageSearchResult struct {
+	Query    string              `json:"query"`
+	Items    []PassageHit        `json:"items"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+	Facets   PassageSearchFacets `json:"facets"`
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+// ConversationSearchHit is a message from the user's history matching a
+// conversation search, with the exchange it belongs to for context.
+type ConversationSearchHit struct {
+	ConversationID    string    `json:"conversationId"`
+	ConversationTitle string    `json:"conversationTitle"`
+	MessageID         string    `json:"messageId"`
+	Role              string    `json:"role"`
+	BookID            string    `json:"bookId,omitempty"`
+	Snippet           string    `json:"snippet"`
+	Question          string    `json:"question,omitempty"`
+	Answer            string    `json:"answer,omitempty"`
+	Score             float64   `json:"score"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+type ConversationSearchResult struct {
+	Query    string                  `json:"query"`
+	Items    []ConversationSearchHit `json:"items"`
+	Total    int                     `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+}
+
+// ConversationShare is a read-only public link to a conversation. Only a
+// hash of the link token is stored; Token is set once, on creation.
+// LeafMessageID pins the branch the link shows to the one active when it was
+// created.
+type ConversationShare struct {
+	ID              string     `json:"id"`
+	ConversationID  string     `json:"conversationId"`
+	UserID          string     `json:"userId"`
+	Token           string     `json:"token,omitempty"`
+	LeafMessageID   string     `json:"leafMessageId,omitempty"`
+	IncludeSnippets bool       `json:"includeSnippets"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// SharedConversation is the public view behind a share link: the branch
+// shared, as it stood when the link was created, with citations, without chunk, book or file references.
+type SharedConversation struct {
+	Title           string          `json:"title"`
+	BookTitles      []string        `json:"bookTitles"`
+	IncludeSnippets bool            `json:"includeSnippets"`
+	Messages        []SharedMessage `json:"messages"`
+	ExpiresAt       *time.Time      `json:"expiresAt,omitempty"`
+	SharedAt        time.Time       `json:"sharedAt"`
+}
+
+type SharedMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Abstained bool             `json:"abstained,omitempty"`
+	Citations []SharedCitation `json:"citations,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+type SharedCitation struct {
+	Number    int    `json:"number"`
+	BookTitle string `json:"bookTitle,omitempty"`
+	Location  string `json:"location,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
 }
 
 type RetrievalHit struct {
@@ -252,20 +583,33 @@ type RetrievalHit struct {
 }
 
 type RetrievalDebug struct {
-	Language              string         `json:"language"`
-	Queries               []string       `json:"queries"`
-	Route                 string         `json:"route,omitempty"`
-	QuestionType          string         `json:"questionType,omitempty"`
-	StandaloneQuestion    string         `json:"standaloneQuestion,omitempty"`
-	RequiredEvidenceCount int            `json:"requiredEvidenceCount,omitempty"`
-	SelectedChunkIDs      []string       `json:"selectedChunkIds,omitempty"`
-	SelectedEvidence      []Evidence     `json:"selectedEvidence,omitempty"`
-	ValidationReason      string         `json:"validationReason,omitempty"`
-	QueryPlan             *QueryPlan     `json:"queryPlan,omitempty"`
-	Dense                 []RetrievalHit `json:"dense"`
-	Lexical               []RetrievalHit `json:"lexical"`
-	Fused                 []RetrievalHit `json:"fused"`
-	Reranked              []RetrievalHit `json:"reranked"`
+	Language              string          `json:"language"`
+	Queries               []string        `json:"queries"`
+	Route                 string          `json:"route,omitempty"`
+	QuestionType          string          `json:"questionType,omitempty"`
+	StandaloneQuestion    string          `json:"standaloneQuestion,omitempty"`
+	RequiredEvidenceCount int             `json:"requiredEvidenceCount,omitempty"`
+	SelectedChunkIDs      []string        `json:"selectedChunkIds,omitempty"`
+	SelectedEvidence      []Evidence      `json:"selectedEvidence,omitempty"`
+	ValidationReason      string          `json:"validationReason,omitempty"`
+	QueryPlan             *QueryPlan      `json:"queryPlan,omitempty"`
+	Dense                 []RetrievalHit  `json:"dense"`
+	Lexical               []RetrievalHit  `json:"lexical"`
+	Sparse                []RetrievalHit  `json:"sparse,omitempty"`
+	Fused                 []RetrievalHit  `json:"fused"`
+	Reranked              []RetrievalHit  `json:"reranked"`
+	Counts                RetrievalCounts `json:"counts"`
+	Steps                 []RetrievalStep `json:"steps,omitempty"`
+}
+
+// RetrievalCounts is the number of candidates each retrieval stage produced,
+// before the debug hit lists are truncated.
+type RetrievalCounts struct {
+	Dense    int `json:"dense"`
+	Lexical  int `json:"lexical"`
+	Sparse   int `json:"sparse"`
+	Fused    int `json:"fused"`
+	Reranked int `json:"reranked"`
 }
 
 type Chunk struct {
@@ -315,6 +659,39 @@ type BookIndexStatusSummary struct {
 	Items         []ChunkIndexStatus `json:"items"`
 }
 
+// BookIndexConsistency describes how one book's index backends diverge from Postgres.
+type BookIndexConsistency struct {
+	BookID             string   `json:"bookId"`
+	Title              string   `json:"title,omitempty"`
+	ExpectedQdrant     int      `json:"expectedQdrant"`
+	ExpectedOpenSearch int      `json:"expectedOpenSearch"`
+	QdrantMissing      []string `json:"qdrantMissing,omitempty"`
+	QdrantOrphaned     []string `json:"qdrantOrphaned,omitempty"`
+	QdrantStale        []string `json:"qdrantStale,omitempty"`
+	OpenSearchMissing  []string `json:"openSearchMissing,omitempty"`
+	OpenSearchOrphaned []string `json:"openSearchOrphaned,omitempty"`
+	OpenSearchStale    []string `json:"openSearchStale,omitempty"`
+	Repaired           bool     `json:"repaired"`
+	Error              string   `json:"error,omitempty"`
+}
+
+// IndexConsistencyReport is the library-wide result of one reconciler pass.
+type IndexConsistencyReport struct {
+	ID                string                 `json:"id"`
+	AutoRepair        bool                   `json:"autoRepair"`
+	BooksChecked      int                    `json:"booksChecked"`
+	InconsistentBooks int                    `json:"inconsistentBooks"`
+	MissingChunks     int                    `json:"missingChunks"`
+	OrphanedChunks    int                    `json:"orphanedChunks"`
+	StaleChunks       int                    `json:"staleChunks"`
+	RepairedBooks     int                    `json:"repairedBooks"`
+	PurgedBookIDs     []string               `json:"purgedBookIds,omitempty"`
+	Books             []BookIndexConsistency `json:"books"`
+	Errors            []string               `json:"errors,omitempty"`
+	StartedAt         time.Time              `json:"startedAt"`
+	FinishedAt        time.Time              `json:"finishedAt"`
+}
+
 type AdminAuditLog struct {
 	ID         string         `json:"id"`
 	ActorID    string         `json:"actorId"`
@@ -347,6 +724,65 @@ type AdminOverview struct {
 	WindowHours     int               `json:"windowHours"`
 }
 
+// LLMUsageTotals sums token usage over a set of answered questions.
+type LLMUsageTotals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	CostUSD          float64 `json:"costUsd"`
+}
+
+// LLMUsageBucket is usage grouped by a user ID, book ID or UTC day (YYYY-MM-DD).
+type LLMUsageBucket struct {
+	Key string `json:"key"`
+	LLMUsageTotals
+}
+
+// LLMUsageReport is the admin view of LLM spend over a time window.
+type LLMUsageReport struct {
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Totals LLMUsageTotals   `json:"totals"`
+	ByUser []LLMUsageBucket `json:"byUser"`
+	ByBook []LLMUsageBucket `json:"byBook"`
+	ByDay  []LLMUsageBucket `json:"byDay"`
+}
+
+// QuotaLimits are the effective caps for one user. Zero means unlimited.
+type QuotaLimits struct {
+	Plan            string `json:"plan"`
+	DailyQuestions  int    `json:"dailyQuestions"`
+	MonthlyTokens   int64  `json:"monthlyTokens"`
+	MaxBooks        int    `json:"maxBooks"`
+	MaxStorageBytes int64  `json:"maxStorageBytes"`
+}
+
+// UserQuotaOverride is an admin-set plan or per-limit override for one user;
+// nil limits inherit the plan.
+type UserQuotaOverride struct {
+	UserID          string    `json:"userId"`
+	Plan            string    `json:"plan,omitempty"`
+	DailyQuestions  *int      `json:"dailyQuestions,omitempty"`
+	MonthlyTokens   *int64    `json:"monthlyTokens,omitempty"`
+	MaxBooks        *int      `json:"maxBooks,omitempty"`
+	MaxStorageBytes *int64    `json:"maxStorageBytes,omitempty"`
+	UpdatedBy       string    `json:"updatedBy,omitempty"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// UserUsage reports a user's consumption against their QuotaLimits.
+type UserUsage struct {
+	Limits           QuotaLimits        `json:"limits"`
+	QuestionsToday   int                `json:"questionsToday"`
+	TokensThisMonth  int64              `json:"tokensThisMonth"`
+	Books            int                `json:"books"`
+	StorageBytes     int64              `json:"storageBytes"`
+	QuestionsResetAt time.Time          `json:"questionsResetAt"`
+	TokensResetAt    time.Time          `json:"tokensResetAt"`
+	Override         *UserQuotaOverride `json:"override,omitempty"`
+}
+
 type EvalDatasetSourceType string
 
 const (
@@ -448,6 +884,50 @@ type EvalRun struct {
 	UpdatedAt      time.Time             `json:"updatedAt"`
 }
 
+type AnswerFeedbackRating string
+
+const (
+	AnswerFeedbackUp   AnswerFeedbackRating = "up"
+	AnswerFeedbackDown AnswerFeedbackRating = "down"
+)
+
+type AnswerFeedbackStatus string
+
+const (
+	AnswerFeedbackStatusPending   AnswerFeedbackStatus = "pending"
+	AnswerFeedbackStatusAccepted  AnswerFeedbackStatus = "accepted"
+	AnswerFeedbackStatusRejected  AnswerFeedbackStatus = "rejected"
+	AnswerFeedbackStatusConverted AnswerFeedbackStatus = "converted"
+)
+
+// AnswerFeedback is a user's judgment of one assistant message. The question,
+// answer, trace and selected chunks are snapshotted when the feedback is
+// given so reviewers and eval datasets see what the user saw.
+// CorrectedChunkIDs are the chunks the user (or reviewer) says should have
+// been cited; DatasetID is set once the feedback is converted into an eval
+// dataset version.
+type AnswerFeedback struct {
+	ID                string               `json:"id"`
+	MessageID         string               `json:"messageId"`
+	ConversationID    string               `json:"conversationId"`
+	UserID            string               `json:"userId"`
+	BookID            string               `json:"bookId,omitempty"`
+	Rating            AnswerFeedbackRating `json:"rating"`
+	Reason            string               `json:"reason,omitempty"`
+	CorrectedChunkIDs []string             `json:"correctedChunkIds,omitempty"`
+	Question          string               `json:"question"`
+	Answer            string               `json:"answer"`
+	AnswerTrace       *AnswerTrace         `json:"answerTrace,omitempty"`
+	SelectedChunkIDs  []string             `json:"selectedChunkIds,omitempty"`
+	Status            AnswerFeedbackStatus `json:"status"`
+	ReviewNote        string               `json:"reviewNote,omitempty"`
+	ReviewedBy        string               `json:"reviewedBy,omitempty"`
+	ReviewedAt        *time.Time           `json:"reviewedAt,omitempty"`
+	DatasetID         string               `json:"datasetId,omitempty"`
+	CreatedAt         time.Time            `json:"createdAt"`
+	UpdatedAt         time.Time            `json:"updatedAt"`
+}
+
 type IdempotencyState string
 
 const (
@@ -497,6 +977,7 @@ type AdminEvalOverview struct {
 	CanceledRuns     int       `json:"canceledRuns"`
 	RecentRuns       int       `json:"recentRuns"`
 	RecentGateFailed int       `json:"recentGateFailed"`
+	PendingFeedback  int       `json:"pendingFeedback"`
 	SuccessRate      float64   `json:"successRate"`
 	RefreshedAt      time.Time `json:"refreshedAt"`
 }
@@ -0,0 +1,110 @@
+package retrieval
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ChunkFilter narrows a query to part of a book: an inclusive page range
+// and/or one section. The zero value matches every chunk.
+type ChunkFilter struct {
+	PageFrom    int
+	PageTo      int
+	SectionPath string
+}
+
+// IsZero reports whether the filter matches every chunk.
+func (f ChunkFilter) IsZero() bool {
+	return f.PageFrom <= 0 && f.PageTo <= 0 && strings.TrimSpace(f.SectionPath) == ""
+}
+
+// Matches applies the filter to chunk metadata, so hits from indexes built
+// before page and section payloads existed cannot leak past a scope.
+func (f ChunkFilter) Matches(metadata map[string]string) bool {
+	if f.PageFrom > 0 || f.PageTo > 0 {
+		page, err := strconv.Atoi(strings.TrimSpace(metadata["page"]))
+		if err != nil {
+			return false
+		}
+		if f.PageFrom > 0 && page < f.PageFrom {
+			return false
+		}
+		if f.PageTo > 0 && page > f.PageTo {
+			return false
+		}
+	}
+	if section := strings.TrimSpace(f.SectionPath); section != "" {
+		path := strings.TrimSpace(metadata["section_path"])
+		if path == "" {
+			path = strings.TrimSpace(metadata["section"])
+		}
+		if path != section {
+			return false
+		}
+	}
+	return true
+}
+
+// qdrantConditions renders the filter as Qdrant "must" conditions on the
+// page and section_path payload fields.
+func (f ChunkFilter) qdrantConditions() []map[string]any {
+	var out []map[string]any
+	if f.PageFrom > 0 || f.PageTo > 0 {
+		bounds := map[string]any{}
+		if f.PageFrom > 0 {
+			bounds["gte"] = f.PageFrom
+		}
+		if f.PageTo > 0 {
+			bounds["lte"] = f.PageTo
+		}
+		out = append(out, map[string]any{"key": "page", "range": bounds})
+	}
+	if section := strings.TrimSpace(f.SectionPath); section != "" {
+		out = append(out, map[string]any{"key": "section_path", "match": map[string]any{"value": section}})
+	}
+	return out
+}
+
+// openSearchClauses renders the filter as OpenSearch bool filter clauses.
+func (f ChunkFilter) openSearchClauses() []any {
+	var out []any
+	if f.PageFrom > 0 || f.PageTo > 0 {
+		bounds := map[string]any{}
+		if f.PageFrom > 0 {
+			bounds["gte"] = f.PageFrom
+		}
+		if f.PageTo > 0 {
+			bounds["lte"] = f.PageTo
+		}
+		out = append(out, map[string]any{"range": map[string]any{"page": bounds}})
+	}
+	if section := strings.TrimSpace(f.SectionPath); section != "" {
+		out = append(out, map[string]any{"term": map[string]any{"section_path": section}})
+	}
+	return out
+}
+
+// sqlConditions renders the filter as SQL conditions on a jsonb payload
+// column, with their arguments.
+func (f ChunkFilter) sqlConditions(column string) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if f.PageFrom > 0 {
+		conditions = append(conditions, "("+column+"->>'page')::int >= ?")
+		args = append(args, f.PageFrom)
+	}
+	if f.PageTo > 0 {
+		conditions = append(conditions, "("+column+"->>'page')::int <= ?")
+		args = append(args, f.PageTo)
+	}
+	if section := strings.TrimSpace(f.SectionPath); section != "" {
+		conditions = append(conditions, column+"->>'section_path' = ?")
+		args = append(args, section)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(conditions, " AND "), args
+}
@@ -87,6 +87,75 @@ func Tokenize(text, language string) []string {
 	}
 }
 
+// SearchTerms tokenizes mixed-language text for "simple" full-text search:
+// runs of Han characters become unigrams plus bigrams, other letter and digit
+// runs are kept as lower-cased words.
+func SearchTerms(text string) []string {
+	var out []string
+	for _, run := range searchRuns(text) {
+		if run.han {
+			out = append(out, tokenizeChinese(run.text)...)
+		} else {
+			out = append(out, run.text)
+		}
+	}
+	return out
+}
+
+// SearchQueryTerms tokenizes a query against SearchTerms output. Han runs
+// become bigrams only, so every term must appear contiguously in a match.
+func SearchQueryTerms(text string) []string {
+	var out []string
+	for _, run := range searchRuns(text) {
+		runes := []rune(run.text)
+		if !run.han || len(runes) == 1 {
+			out = append(out, run.text)
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			out = append(out, string(runes[i:i+2]))
+		}
+	}
+	return uniqueStrings(out)
+}
+
+type searchRun struct {
+	text string
+	han  bool
+}
+
+func searchRuns(text string) []searchRun {
+	var runs []searchRun
+	var current []rune
+	han := false
+	flush := func() {
+		if len(current) > 0 {
+			runs = append(runs, searchRun{text: string(current), han: han})
+			current = current[:0]
+		}
+	}
+	for _, r := range strings.ToLower(strings.ToValidUTF8(text, "")) {
+		switch {
+		case isCJK(r):
+			if !han {
+				flush()
+			}
+			han = true
+			current = append(current, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if han {
+				flush()
+			}
+			han = false
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return runs
+}
+
 func BuildSparseVector(text, language string) SparseVector {
 	tokens := Tokenize(text, language)
 	if len(tokens) == 0 {
@@ -1,6 +1,9 @@
 package retrieval
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestDetectLanguage(t *testing.T) {
 	if got := DetectLanguage("请总结第一章的核心观点"); got != "zh" {
@@ -27,3 +30,15 @@ func TestBuildQueryVariants(t *testing.T) {
 		t.Fatalf("BuildQueryVariants() returned empty result")
 	}
 }
+
+func TestSearchTermsSplitsMixedText(t *testing.T) {
+	got := strings.Join(SearchTerms("Treaty of 凡尔赛条约, 1919"), " ")
+	want := "treaty of 凡 凡尔 尔 尔赛 赛 赛条 条 条约 约 1919"
+	if got != want {
+		t.Fatalf("SearchTerms = %q, want %q", got, want)
+	}
+	query := strings.Join(SearchQueryTerms("凡尔赛 treaty"), " ")
+	if query != "凡尔 尔赛 treaty" {
+		t.Fatalf("SearchQueryTerms = %q", query)
+	}
+}
@@ -0,0 +1,242 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	LexicalStoreOpenSearch = "opensearch"
+	LexicalStorePostgres   = "postgres"
+)
+
+// LexicalStore indexes and searches lexical chunk documents. OpenSearch and
+// Postgres full-text search implement it.
+type LexicalStore interface {
+	EnsureIndex(ctx context.Context) error
+	IndexDocuments(ctx context.Context, docs []LexicalDocument) error
+	DeleteByBook(ctx context.Context, bookID string) error
+	DeleteDocuments(ctx context.Context, ids []string) error
+	QueryBM25(ctx context.Context, bookID string, filter ChunkFilter, terms string, limit int) ([]Point, error)
+	ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error)
+	ListBookIDs(ctx context.Context) ([]string, error)
+}
+
+// HighlightedPoint is a lexical hit with highlighted content fragments.
+type HighlightedPoint struct {
+	Point
+	Highlights []string
+}
+
+// LexicalHighlighter is implemented by lexical stores that can return
+// highlighted fragments with their hits.
+type LexicalHighlighter interface {
+	QueryBM25Highlighted(ctx context.Context, bookID, terms string, limit int) ([]HighlightedPoint, error)
+}
+
+// LexicalStoreConfig selects and configures a LexicalStore backend.
+type LexicalStoreConfig struct {
+	Backend            string
+	OpenSearchURL      string
+	OpenSearchIndex    string
+	OpenSearchUsername string
+	OpenSearchPassword string
+	DatabaseURL        string
+}
+
+// NormalizeLexicalStoreBackend lowercases the backend name and defaults to opensearch.
+func NormalizeLexicalStoreBackend(backend string) string {
+	backend = strings.ToLower(strings.TrimSpace(backend))
+	if backend == "" {
+		return LexicalStoreOpenSearch
+	}
+	return backend
+}
+
+// NewLexicalStore builds the configured lexical store backend.
+func NewLexicalStore(cfg LexicalStoreConfig) (LexicalStore, error) {
+	switch NormalizeLexicalStoreBackend(cfg.Backend) {
+	case LexicalStoreOpenSearch:
+		return NewOpenSearchClient(cfg.OpenSearchURL, cfg.OpenSearchIndex, cfg.OpenSearchUsername, cfg.OpenSearchPassword)
+	case LexicalStorePostgres:
+		return NewPostgresLexicalStore(cfg.DatabaseURL)
+	default:
+		return nil, fmt.Errorf("unsupported lexical store %q", cfg.Backend)
+	}
+}
+
+// MirroredLexicalStore writes every document to a primary and a standby store
+// so the standby can serve queries while the primary is unavailable. Reads go
+// to the primary only. Only primary failures fail a write: a failed standby
+// write is counted and its book marked, and the indexer's reconciler repairs
+// the standby from TakeStandbyRepairs.
+type MirroredLexicalStore struct {
+	Primary LexicalStore
+	Standby LexicalStore
+
+	mu        sync.Mutex
+	repairs   map[string]struct{}
+	repairAll bool
+	failures  int
+	lastErr   error
+}
+
+// StandbyRepairs is what the standby missed since the last TakeStandbyRepairs.
+type StandbyRepairs struct {
+	BookIDs []string
+	// AllBooks is set when a write that cannot be tied to a book failed.
+	AllBooks  bool
+	Failures  int
+	LastError string
+}
+
+// MarkStandbyRepair marks a book whose standby documents need to be checked
+// against Postgres on the next reconcile.
+func (m *MirroredLexicalStore) MarkStandbyRepair(bookID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.repairs == nil {
+		m.repairs = map[string]struct{}{}
+	}
+	m.repairs[bookID] = struct{}{}
+}
+
+// TakeStandbyRepairs returns and clears the books marked for standby repair.
+func (m *MirroredLexicalStore) TakeStandbyRepairs() StandbyRepairs {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := StandbyRepairs{AllBooks: m.repairAll, Failures: m.failures}
+	if m.lastErr != nil {
+		out.LastError = m.lastErr.Error()
+	}
+	for id := range m.repairs {
+		out.BookIDs = append(out.BookIDs, id)
+	}
+	sort.Strings(out.BookIDs)
+	m.repairs, m.repairAll, m.failures, m.lastErr = nil, false, 0, nil
+	return out
+}
+
+// standbyFailed records a failed standby write for bookIDs; no book IDs means
+// the write could have touched any book.
+func (m *MirroredLexicalStore) standbyFailed(err error, bookIDs ...string) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures++
+	m.lastErr = err
+	if len(bookIDs) == 0 {
+		m.repairAll = true
+		return
+	}
+	if m.repairs == nil {
+		m.repairs = map[string]struct{}{}
+	}
+	for _, id := range bookIDs {
+		m.repairs[id] = struct{}{}
+	}
+}
+
+func (m *MirroredLexicalStore) EnsureIndex(ctx context.Context) error {
+	if err := m.Primary.EnsureIndex(ctx); err != nil {
+		return err
+	}
+	// A standby without an index may have missed anything; have every book
+	// checked.
+	m.standbyFailed(m.Standby.EnsureIndex(ctx))
+	return nil
+}
+
+func (m *MirroredLexicalStore) IndexDocuments(ctx context.Context, docs []LexicalDocument) error {
+	if err := m.Primary.IndexDocuments(ctx, docs); err != nil {
+		return err
+	}
+	m.standbyFailed(m.Standby.IndexDocuments(ctx, docs), documentBookIDs(docs)...)
+	return nil
+}
+
+// documentBookIDs lists the books of docs, or nil when a document carries no
+// book ID.
+func documentBookIDs(docs []LexicalDocument) []string {
+	seen := map[string]struct{}{}
+	var ids []string
+	for _, doc := range docs {
+		id := strings.TrimSpace(anyString(doc.Payload["book_id"]))
+		if id == "" {
+			return nil
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (m *MirroredLexicalStore) DeleteByBook(ctx context.Context, bookID string) error {
+	if err := m.Primary.DeleteByBook(ctx, bookID); err != nil {
+		return err
+	}
+	m.standbyFailed(m.Standby.DeleteByBook(ctx, bookID), bookID)
+	return nil
+}
+
+func (m *MirroredLexicalStore) DeleteDocuments(ctx context.Context, ids []string) error {
+	if err := m.Primary.DeleteDocuments(ctx, ids); err != nil {
+		return err
+	}
+	m.standbyFailed(m.Standby.DeleteDocuments(ctx, ids))
+	return nil
+}
+
+func (m *MirroredLexicalStore) QueryBM25(ctx context.Context, bookID string, filter ChunkFilter, terms string, limit int) ([]Point, error) {
+	return m.Primary.QueryBM25(ctx, bookID, filter, terms, limit)
+}
+
+// QueryBM25Highlighted highlights through the primary when it supports it and
+// falls back to plain hits otherwise.
+func (m *MirroredLexicalStore) QueryBM25Highlighted(ctx context.Context, bookID, terms string, limit int) ([]HighlightedPoint, error) {
+	if highlighter, ok := m.Primary.(LexicalHighlighter); ok {
+		return highlighter.QueryBM25Highlighted(ctx, bookID, terms, limit)
+	}
+	points, err := m.Primary.QueryBM25(ctx, bookID, ChunkFilter{}, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HighlightedPoint, 0, len(points))
+	for _, point := range points {
+		out = append(out, HighlightedPoint{Point: point})
+	}
+	return out, nil
+}
+
+func (m *MirroredLexicalStore) ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error) {
+	return m.Primary.ListBookChunks(ctx, bookID)
+}
+
+// ListBookIDs merges both stores so deleted books are purged from each.
+func (m *MirroredLexicalStore) ListBookIDs(ctx context.Context) ([]string, error) {
+	primary, err := m.Primary.ListBookIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	standby, err := m.Standby.ListBookIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(primary)+len(standby))
+	out := make([]string, 0, len(primary)+len(standby))
+	for _, id := range append(primary, standby...) {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out, nil
+}
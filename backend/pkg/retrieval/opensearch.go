@@ -8,10 +8,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const openSearchPageSize = 500
+
 // LexicalDocument is the canonical lexical record indexed into OpenSearch.
 type LexicalDocument struct {
 	ID      string         `json:"id"`
@@ -48,31 +51,36 @@ func NewOpenSearchClient(baseURL, index, username, password string) (*OpenSearch
 	}, nil
 }
 
-// EnsureIndex creates the lexical index if missing.
+// EnsureIndex creates the lexical index if missing. An existing index gets
+// the current field mappings put on it so fields added since it was created
+// (section_path, page) are mapped instead of left to dynamic mapping.
 func (c *OpenSearchClient) EnsureIndex(ctx context.Context) error {
-	body := map[string]any{
-		"mappings": map[string]any{
-			"properties": map[string]any{
-				"chunk_id":      map[string]any{"type": "keyword"},
-				"book_id":       map[string]any{"type": "keyword"},
-				"chunk_family":  map[string]any{"type": "keyword"},
-				"section_id":    map[string]any{"type": "keyword"},
-				"title":         map[string]any{"type": "text"},
-				"section_title": map[string]any{"type": "text"},
-				"keywords":      map[string]any{"type": "text"},
-				"tags":          map[string]any{"type": "keyword"},
-				"block_type":    map[string]any{"type": "keyword"},
-				"language":      map[string]any{"type": "keyword"},
-				"content_text":  map[string]any{"type": "text"},
-				"content_terms": map[string]any{"type": "text"},
-			},
-		},
-	}
+	properties := map[string]any{
+		"chunk_id":       map[string]any{"type": "keyword"},
+		"book_id":        map[string]any{"type": "keyword"},
+		"chunk_family":   map[string]any{"type": "keyword"},
+		"section_id":     map[string]any{"type": "keyword"},
+		"section_path":   map[string]any{"type": "keyword"},
+		"page":           map[string]any{"type": "integer"},
+		"title":          map[string]any{"type": "text"},
+		"section_title":  map[string]any{"type": "text"},
+		"keywords":       map[string]any{"type": "text"},
+		"tags":           map[string]any{"type": "keyword"},
+		"block_type":     map[string]any{"type": "keyword"},
+		"language":       map[string]any{"type": "keyword"},
+		"content_sha256": map[string]any{"type": "keyword"},
+		"content_text":   map[string]any{"type": "text"},
+		"content_terms":  map[string]any{"type": "text"},
+	}
+	body := map[string]any{"mappings": map[string]any{"properties": properties}}
 	err := c.do(ctx, http.MethodPut, "/"+url.PathEscape(c.index), body, nil)
 	var apiErr *apiError
 	if err != nil && errorAs(err, &apiErr) {
 		bodyLower := strings.ToLower(apiErr.Body)
 		if apiErr.Status == http.StatusBadRequest && strings.Contains(bodyLower, "already_exists") {
+			if err := c.do(ctx, http.MethodPut, "/"+url.PathEscape(c.index)+"/_mapping", map[string]any{"properties": properties}, nil); err != nil {
+				return fmt.Errorf("update opensearch mapping: %w", err)
+			}
 			return nil
 		}
 	}
@@ -113,27 +121,58 @@ func (c *OpenSearchClient) IndexDocuments(ctx context.Context, docs []LexicalDoc
 			return err
 		}
 		source := map[string]any{
-			"chunk_id":      doc.ID,
-			"content_text":  doc.Content,
-			"content_terms": doc.Terms,
-			"book_id":       strings.TrimSpace(anyString(doc.Payload["book_id"])),
-			"chunk_family":  strings.TrimSpace(anyString(doc.Payload["chunk_family"])),
-			"section_id":    strings.TrimSpace(anyString(doc.Payload["section_id"])),
-			"title":         strings.TrimSpace(anyString(doc.Payload["title"])),
-			"section_title": strings.TrimSpace(anyString(doc.Payload["section_title"])),
-			"keywords":      strings.TrimSpace(anyString(doc.Payload["keywords"])),
-			"tags":          strings.TrimSpace(anyString(doc.Payload["tags"])),
-			"block_type":    strings.TrimSpace(anyString(doc.Payload["block_type"])),
-			"language":      strings.TrimSpace(anyString(doc.Payload["language"])),
-			"is_first_page": strings.TrimSpace(anyString(doc.Payload["is_first_page"])),
-			"entities":      strings.TrimSpace(anyString(doc.Payload["entities"])),
-			"facts":         strings.TrimSpace(anyString(doc.Payload["facts"])),
+			"chunk_id":       doc.ID,
+			"content_text":   doc.Content,
+			"content_terms":  doc.Terms,
+			"book_id":        strings.TrimSpace(anyString(doc.Payload["book_id"])),
+			"chunk_family":   strings.TrimSpace(anyString(doc.Payload["chunk_family"])),
+			"section_id":     strings.TrimSpace(anyString(doc.Payload["section_id"])),
+			"section_path":   strings.TrimSpace(anyString(doc.Payload["section_path"])),
+			"title":          strings.TrimSpace(anyString(doc.Payload["title"])),
+			"section_title":  strings.TrimSpace(anyString(doc.Payload["section_title"])),
+			"keywords":       strings.TrimSpace(anyString(doc.Payload["keywords"])),
+			"tags":           strings.TrimSpace(anyString(doc.Payload["tags"])),
+			"block_type":     strings.TrimSpace(anyString(doc.Payload["block_type"])),
+			"language":       strings.TrimSpace(anyString(doc.Payload["language"])),
+			"is_first_page":  strings.TrimSpace(anyString(doc.Payload["is_first_page"])),
+			"entities":       strings.TrimSpace(anyString(doc.Payload["entities"])),
+			"facts":          strings.TrimSpace(anyString(doc.Payload["facts"])),
+			"content_sha256": strings.TrimSpace(anyString(doc.Payload["content_sha256"])),
+		}
+		// page is an integer field so scoped questions can range-filter it.
+		if page, err := strconv.Atoi(anyString(doc.Payload["page"])); err == nil && page > 0 {
+			source["page"] = page
 		}
 		if err := enc.Encode(source); err != nil {
 			return err
 		}
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/_bulk?refresh=true", &buf)
+	return c.bulk(ctx, &buf)
+}
+
+// DeleteDocuments bulk deletes lexical docs by chunk ID.
+func (c *OpenSearchClient) DeleteDocuments(ctx context.Context, ids []string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	count := 0
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if err := enc.Encode(map[string]any{"delete": map[string]any{"_index": c.index, "_id": id}}); err != nil {
+			return err
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	return c.bulk(ctx, &buf)
+}
+
+func (c *OpenSearchClient) bulk(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/_bulk?refresh=true", body)
 	if err != nil {
 		return err
 	}
@@ -157,13 +196,31 @@ func (c *OpenSearchClient) IndexDocuments(ctx context.Context, docs []LexicalDoc
 		return err
 	}
 	if result.Errors {
-		return fmt.Errorf("opensearch bulk index reported partial errors")
+		return fmt.Errorf("opensearch bulk request reported partial errors")
 	}
 	return nil
 }
 
 // QueryBM25 runs lexical retrieval against tokenized content.
-func (c *OpenSearchClient) QueryBM25(ctx context.Context, bookID, terms string, limit int) ([]Point, error) {
+func (c *OpenSearchClient) QueryBM25(ctx context.Context, bookID string, filter ChunkFilter, terms string, limit int) ([]Point, error) {
+	hits, err := c.searchBM25(ctx, bookID, filter, terms, limit, false)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, 0, len(hits))
+	for _, hit := range hits {
+		points = append(points, hit.Point)
+	}
+	return points, nil
+}
+
+// QueryBM25Highlighted runs QueryBM25 and asks the OpenSearch highlighter for
+// HTML-escaped content fragments with matches wrapped in <mark> tags.
+func (c *OpenSearchClient) QueryBM25Highlighted(ctx context.Context, bookID, terms string, limit int) ([]HighlightedPoint, error) {
+	return c.searchBM25(ctx, bookID, ChunkFilter{}, terms, limit, true)
+}
+
+func (c *OpenSearchClient) searchBM25(ctx context.Context, bookID string, filter ChunkFilter, terms string, limit int, highlight bool) ([]HighlightedPoint, error) {
 	bookID = strings.TrimSpace(bookID)
 	terms = strings.TrimSpace(terms)
 	if terms == "" || limit <= 0 {
@@ -188,20 +245,43 @@ func (c *OpenSearchClient) QueryBM25(ctx context.Context, bookID, terms string,
 			},
 		}, must...)
 	}
+	query := map[string]any{"must": must}
+	if clauses := filter.openSearchClauses(); len(clauses) > 0 {
+		query["filter"] = clauses
+	}
 	body := map[string]any{
 		"size": limit,
 		"query": map[string]any{
-			"bool": map[string]any{
-				"must": must,
-			},
+			"bool": query,
 		},
 	}
+	if highlight {
+		// content_terms is the tokenized copy used for matching; highlight the
+		// readable content_text with the same terms instead.
+		body["highlight"] = map[string]any{
+			"encoder":   "html",
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"fields": map[string]any{
+				"content_text": map[string]any{
+					"fragment_size":       160,
+					"number_of_fragments": 3,
+					"highlight_query": map[string]any{
+						"match": map[string]any{
+							"content_text": map[string]any{"query": terms, "operator": "or"},
+						},
+					},
+				},
+			},
+		}
+	}
 	var resp struct {
 		Hits struct {
 			Hits []struct {
-				ID     string         `json:"_id"`
-				Score  float64        `json:"_score"`
-				Source map[string]any `json:"_source"`
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Source    map[string]any      `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
@@ -212,13 +292,15 @@ func (c *OpenSearchClient) QueryBM25(ctx context.Context, bookID, terms string,
 		}
 		return nil, err
 	}
-	points := make([]Point, 0, len(resp.Hits.Hits))
+	points := make([]HighlightedPoint, 0, len(resp.Hits.Hits))
 	for _, hit := range resp.Hits.Hits {
 		payload := map[string]any{
 			"chunk_id":      strings.TrimSpace(anyString(hit.Source["chunk_id"])),
 			"book_id":       strings.TrimSpace(anyString(hit.Source["book_id"])),
 			"chunk_family":  strings.TrimSpace(anyString(hit.Source["chunk_family"])),
 			"section_id":    strings.TrimSpace(anyString(hit.Source["section_id"])),
+			"section_path":  strings.TrimSpace(anyString(hit.Source["section_path"])),
+			"page":          strings.TrimSpace(anyString(hit.Source["page"])),
 			"title":         strings.TrimSpace(anyString(hit.Source["title"])),
 			"section_title": strings.TrimSpace(anyString(hit.Source["section_title"])),
 			"keywords":      strings.TrimSpace(anyString(hit.Source["keywords"])),
@@ -229,15 +311,126 @@ func (c *OpenSearchClient) QueryBM25(ctx context.Context, bookID, terms string,
 			"entities":      strings.TrimSpace(anyString(hit.Source["entities"])),
 			"facts":         strings.TrimSpace(anyString(hit.Source["facts"])),
 		}
-		points = append(points, Point{
-			ID:      strings.TrimSpace(anyString(payload["chunk_id"])),
-			Payload: payload,
-			Score:   hit.Score,
+		points = append(points, HighlightedPoint{
+			Point: Point{
+				ID:      strings.TrimSpace(anyString(payload["chunk_id"])),
+				Payload: payload,
+				Score:   hit.Score,
+			},
+			Highlights: hit.Highlight["content_text"],
 		})
 	}
 	return points, nil
 }
 
+// ListBookChunks returns every lexical doc stored for a book.
+func (c *OpenSearchClient) ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error) {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil, nil
+	}
+	out := make([]IndexedChunk, 0, 64)
+	var after []any
+	for {
+		body := map[string]any{
+			"size":    openSearchPageSize,
+			"_source": []string{"chunk_id", "book_id", "content_sha256"},
+			"query": map[string]any{
+				"term": map[string]any{
+					"book_id": bookID,
+				},
+			},
+			"sort": []any{map[string]any{"chunk_id": "asc"}},
+		}
+		if after != nil {
+			body["search_after"] = after
+		}
+		var resp struct {
+			Hits struct {
+				Hits []struct {
+					ID     string         `json:"_id"`
+					Source map[string]any `json:"_source"`
+					Sort   []any          `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(c.index)+"/_search", body, &resp); err != nil {
+			var apiErr *apiError
+			if errorAs(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				return out, nil
+			}
+			return nil, err
+		}
+		for _, hit := range resp.Hits.Hits {
+			chunkID := strings.TrimSpace(anyString(hit.Source["chunk_id"]))
+			if chunkID == "" {
+				chunkID = strings.TrimSpace(hit.ID)
+			}
+			out = append(out, IndexedChunk{
+				ChunkID:       chunkID,
+				BookID:        strings.TrimSpace(anyString(hit.Source["book_id"])),
+				ContentSHA256: strings.TrimSpace(anyString(hit.Source["content_sha256"])),
+			})
+		}
+		if len(resp.Hits.Hits) < openSearchPageSize {
+			return out, nil
+		}
+		after = resp.Hits.Hits[len(resp.Hits.Hits)-1].Sort
+		if len(after) == 0 {
+			return out, nil
+		}
+	}
+}
+
+// ListBookIDs returns the distinct book IDs present in the lexical index.
+func (c *OpenSearchClient) ListBookIDs(ctx context.Context) ([]string, error) {
+	out := make([]string, 0, 16)
+	var after map[string]any
+	for {
+		composite := map[string]any{
+			"size": openSearchPageSize,
+			"sources": []any{
+				map[string]any{"book_id": map[string]any{"terms": map[string]any{"field": "book_id"}}},
+			},
+		}
+		if after != nil {
+			composite["after"] = after
+		}
+		body := map[string]any{
+			"size": 0,
+			"aggs": map[string]any{
+				"books": map[string]any{"composite": composite},
+			},
+		}
+		var resp struct {
+			Aggregations struct {
+				Books struct {
+					AfterKey map[string]any `json:"after_key"`
+					Buckets  []struct {
+						Key map[string]any `json:"key"`
+					} `json:"buckets"`
+				} `json:"books"`
+			} `json:"aggregations"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(c.index)+"/_search", body, &resp); err != nil {
+			var apiErr *apiError
+			if errorAs(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				return out, nil
+			}
+			return nil, err
+		}
+		for _, bucket := range resp.Aggregations.Books.Buckets {
+			if id := strings.TrimSpace(anyString(bucket.Key["book_id"])); id != "" {
+				out = append(out, id)
+			}
+		}
+		if len(resp.Aggregations.Books.Buckets) == 0 || len(resp.Aggregations.Books.AfterKey) == 0 {
+			return out, nil
+		}
+		after = resp.Aggregations.Books.AfterKey
+	}
+}
+
 // DeleteIndex removes the lexical index.
 func (c *OpenSearchClient) DeleteIndex(ctx context.Context) error {
 	err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(c.index), nil, nil)
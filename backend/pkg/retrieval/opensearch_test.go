@@ -0,0 +1,81 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenSearchQueryBM25HighlightedRequestsContentFragments(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"hits":{"hits":[{"_id":"c1","_score":2.5,"_source":{"chunk_id":"c1","book_id":"b1"},"highlight":{"content_text":["讲解 <mark>goroutine</mark> 调度"]}}]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOpenSearchClient(server.URL, "chunks", "", "")
+	if err != nil {
+		t.Fatalf("NewOpenSearchClient: %v", err)
+	}
+	hits, err := client.QueryBM25Highlighted(context.Background(), "", "goroutine", 5)
+	if err != nil {
+		t.Fatalf("QueryBM25Highlighted: %v", err)
+	}
+	if _, ok := body["highlight"]; !ok {
+		t.Fatalf("expected highlight block in request, got %v", body)
+	}
+	if len(hits) != 1 || hits[0].ID != "c1" || len(hits[0].Highlights) != 1 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+
+	body = nil
+	if _, err := client.QueryBM25(context.Background(), "b1", ChunkFilter{}, "goroutine", 5); err != nil {
+		t.Fatalf("QueryBM25: %v", err)
+	}
+	if _, ok := body["highlight"]; ok {
+		t.Fatal("plain BM25 query should not request highlights")
+	}
+}
+
+func TestOpenSearchEnsureIndexPutsMappingOnExistingIndex(t *testing.T) {
+	var mapping map[string]any
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/chunks":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"type":"resource_already_exists_exception"},"status":400}`))
+		case "/chunks/_mapping":
+			if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+				t.Fatalf("decode mapping: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewOpenSearchClient(server.URL, "chunks", "", "")
+	if err != nil {
+		t.Fatalf("NewOpenSearchClient: %v", err)
+	}
+	if err := client.EnsureIndex(context.Background()); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	if len(calls) != 2 || calls[1] != "PUT /chunks/_mapping" {
+		t.Fatalf("expected a mapping update after the create, got %v", calls)
+	}
+	properties, _ := mapping["properties"].(map[string]any)
+	sectionPath, _ := properties["section_path"].(map[string]any)
+	page, _ := properties["page"].(map[string]any)
+	if sectionPath["type"] != "keyword" || page["type"] != "integer" {
+		t.Fatalf("expected section_path and page mappings, got %v", mapping)
+	}
+}
@@ -0,0 +1,350 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	pgvectorTable = "chunk_vectors"
+	// HNSW indexes plain vectors up to 2000 dimensions; larger embeddings are
+	// indexed (and queried) through a halfvec cast, which allows 4000.
+	pgvectorMaxIndexedDim  = 2000
+	pgvectorMaxHalfvecDim  = 4000
+	pgvectorUpsertPageSize = 128
+	// Per-book dense queries raise hnsw.ef_search from its default of 40 to
+	// at least pgvectorMinEfSearch candidates; pgvector caps it at 1000.
+	pgvectorMinEfSearch = 200
+	pgvectorMaxEfSearch = 1000
+)
+
+// PgvectorStore implements VectorStore on Postgres with the pgvector
+// extension. Dense vectors are searched through an HNSW cosine index; sparse
+// vectors are stored as parallel index/value arrays and scored by dot product.
+// It requires pgvector 0.8 or newer, whose iterative index scans keep per-book
+// dense queries from losing recall to the global HNSW index.
+type PgvectorStore struct {
+	db        *gorm.DB
+	denseSize int
+}
+
+func NewPgvectorStore(dsn string, denseSize int) (*PgvectorStore, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, fmt.Errorf("pgvector database url required")
+	}
+	if denseSize <= 0 {
+		return nil, fmt.Errorf("pgvector dense size required")
+	}
+	if denseSize > pgvectorMaxHalfvecDim {
+		return nil, fmt.Errorf("pgvector supports at most %d dimensions, got %d", pgvectorMaxHalfvecDim, denseSize)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Warn)})
+	if err != nil {
+		return nil, fmt.Errorf("open pgvector db: %w", err)
+	}
+	return &PgvectorStore{db: db, denseSize: denseSize}, nil
+}
+
+// distanceExpr returns the column expression the HNSW index is built on, so
+// queries ordering by it can use the index.
+func (s *PgvectorStore) distanceExpr() string {
+	if s.denseSize > pgvectorMaxIndexedDim {
+		return fmt.Sprintf("(dense::halfvec(%d))", s.denseSize)
+	}
+	return "dense"
+}
+
+func (s *PgvectorStore) queryVectorType() string {
+	if s.denseSize > pgvectorMaxIndexedDim {
+		return fmt.Sprintf("halfvec(%d)", s.denseSize)
+	}
+	return fmt.Sprintf("vector(%d)", s.denseSize)
+}
+
+func (s *PgvectorStore) EnsureCollection(ctx context.Context) error {
+	opsClass := "vector_cosine_ops"
+	if s.denseSize > pgvectorMaxIndexedDim {
+		opsClass = "halfvec_cosine_ops"
+	}
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			book_id text NOT NULL,
+			chunk_id text NOT NULL,
+			content_sha256 text NOT NULL DEFAULT '',
+			dense vector(%d) NOT NULL,
+			sparse_indices bigint[] NOT NULL DEFAULT '{}',
+			sparse_values real[] NOT NULL DEFAULT '{}',
+			payload jsonb NOT NULL DEFAULT '{}'::jsonb,
+			updated_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (book_id, chunk_id)
+		)`, pgvectorTable, s.denseSize),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_dense_hnsw ON %s USING hnsw (%s %s)`, pgvectorTable, pgvectorTable, s.distanceExpr(), opsClass),
+	}
+	db := s.db.WithContext(ctx)
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("ensure pgvector table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PgvectorStore) UpsertPoints(ctx context.Context, points []UpsertPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(points); start += pgvectorUpsertPageSize {
+			end := start + pgvectorUpsertPageSize
+			if end > len(points) {
+				end = len(points)
+			}
+			var sql strings.Builder
+			sql.WriteString("INSERT INTO " + pgvectorTable + " (book_id, chunk_id, content_sha256, dense, sparse_indices, sparse_values, payload, updated_at) VALUES ")
+			args := make([]any, 0, (end-start)*7)
+			for i, point := range points[start:end] {
+				bookID := strings.TrimSpace(anyString(point.Payload["book_id"]))
+				chunkID := strings.TrimSpace(anyString(point.Payload["chunk_id"]))
+				if chunkID == "" {
+					chunkID = strings.TrimSpace(point.ID)
+				}
+				if bookID == "" || chunkID == "" {
+					return fmt.Errorf("pgvector point requires book_id and chunk_id")
+				}
+				if len(point.Dense) != s.denseSize {
+					return fmt.Errorf("pgvector point %s has %d dimensions, want %d", chunkID, len(point.Dense), s.denseSize)
+				}
+				payload, err := json.Marshal(point.Payload)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					sql.WriteString(", ")
+				}
+				sql.WriteString("(?, ?, ?, ?::vector, ?::bigint[], ?::real[], ?::jsonb, now())")
+				args = append(args, bookID, chunkID, anyString(point.Payload["content_sha256"]), formatPgVector(point.Dense),
+					formatPgBigintArray(point.Sparse.Indices), formatPgRealArray(point.Sparse.Values), string(payload))
+			}
+			sql.WriteString(` ON CONFLICT (book_id, chunk_id) DO UPDATE SET
+				content_sha256 = EXCLUDED.content_sha256,
+				dense = EXCLUDED.dense,
+				sparse_indices = EXCLUDED.sparse_indices,
+				sparse_values = EXCLUDED.sparse_values,
+				payload = EXCLUDED.payload,
+				updated_at = EXCLUDED.updated_at`)
+			if err := tx.Exec(sql.String(), args...).Error; err != nil {
+				return fmt.Errorf("upsert pgvector points: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *PgvectorStore) DeleteByBook(ctx context.Context, bookID string) error {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil
+	}
+	return ignoreUndefinedTable(s.db.WithContext(ctx).Exec("DELETE FROM "+pgvectorTable+" WHERE book_id = ?", bookID).Error)
+}
+
+func (s *PgvectorStore) DeleteChunks(ctx context.Context, bookID string, chunkIDs []string) error {
+	bookID = strings.TrimSpace(bookID)
+	ids := make([]string, 0, len(chunkIDs))
+	for _, chunkID := range chunkIDs {
+		if chunkID = strings.TrimSpace(chunkID); chunkID != "" {
+			ids = append(ids, chunkID)
+		}
+	}
+	if bookID == "" || len(ids) == 0 {
+		return nil
+	}
+	return ignoreUndefinedTable(s.db.WithContext(ctx).Exec("DELETE FROM "+pgvectorTable+" WHERE book_id = ? AND chunk_id IN ?", bookID, ids).Error)
+}
+
+func (s *PgvectorStore) QueryDense(ctx context.Context, bookID string, filter ChunkFilter, vector []float32, limit int) ([]Point, error) {
+	if len(vector) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	if len(vector) != s.denseSize {
+		return nil, fmt.Errorf("pgvector query has %d dimensions, want %d", len(vector), s.denseSize)
+	}
+	distance := fmt.Sprintf("%s <=> ?::%s", s.distanceExpr(), s.queryVectorType())
+	scope, scopeArgs := filter.sqlConditions("payload")
+	args := append([]any{formatPgVector(vector), strings.TrimSpace(bookID)}, scopeArgs...)
+	// The HNSW index spans every book and book_id is filtered after the index
+	// scan, so with the default ef_search a book that is a small share of the
+	// table would come back with far fewer than limit chunks. An iterative
+	// scan keeps walking the graph until enough rows pass the filter; relaxed
+	// order is re-sorted below.
+	var points []Point
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, setting := range denseScanSettings(limit) {
+			if err := tx.Exec(setting).Error; err != nil {
+				return fmt.Errorf("configure pgvector scan: %w", err)
+			}
+		}
+		var err error
+		points, err = s.query(tx, `
+			WITH candidates AS MATERIALIZED (
+				SELECT chunk_id, payload, `+distance+` AS distance
+				FROM `+pgvectorTable+`
+				WHERE book_id = ?`+scope+`
+				ORDER BY distance
+				LIMIT ?
+			)
+			SELECT chunk_id, payload, 1 - distance AS score
+			FROM candidates
+			ORDER BY distance`, append(args, limit)...)
+		return err
+	})
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return points, nil
+}
+
+// denseScanSettings returns the transaction-local HNSW settings for a
+// per-book dense query. hnsw.iterative_scan needs pgvector 0.8 or newer.
+func denseScanSettings(limit int) []string {
+	efSearch := min(max(limit, pgvectorMinEfSearch), pgvectorMaxEfSearch)
+	return []string{
+		"SET LOCAL hnsw.iterative_scan = relaxed_order",
+		fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", efSearch),
+	}
+}
+
+func (s *PgvectorStore) QuerySparse(ctx context.Context, bookID string, filter ChunkFilter, vector SparseVector, limit int) ([]Point, error) {
+	if len(vector.Indices) == 0 || len(vector.Values) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	scope, scopeArgs := filter.sqlConditions("p.payload")
+	args := append([]any{formatPgBigintArray(vector.Indices), formatPgRealArray(vector.Values), strings.TrimSpace(bookID)}, scopeArgs...)
+	points, err := s.query(s.db.WithContext(ctx), `
+		WITH q AS (
+			SELECT * FROM unnest(?::bigint[], ?::real[]) AS q(idx, value)
+		), scores AS (
+			SELECT p.chunk_id, SUM(d.value * q.value) AS score
+			FROM `+pgvectorTable+` p
+			CROSS JOIN LATERAL unnest(p.sparse_indices, p.sparse_values) AS d(idx, value)
+			JOIN q ON q.idx = d.idx
+			WHERE p.book_id = ?`+scope+`
+			GROUP BY p.chunk_id
+			ORDER BY score DESC
+			LIMIT ?
+		)
+		SELECT p.chunk_id, p.payload, s.score
+		FROM scores s
+		JOIN `+pgvectorTable+` p ON p.book_id = ? AND p.chunk_id = s.chunk_id
+		ORDER BY s.score DESC`,
+		append(args, limit, strings.TrimSpace(bookID))...)
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return points, nil
+}
+
+func (s *PgvectorStore) query(db *gorm.DB, sql string, args ...any) ([]Point, error) {
+	var rows []struct {
+		ChunkID string
+		Payload []byte
+		Score   float64
+	}
+	if err := db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("query pgvector: %w", err)
+	}
+	points := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		point := Point{ID: row.ChunkID, Score: row.Score, Payload: map[string]any{}}
+		if len(row.Payload) > 0 {
+			if err := json.Unmarshal(row.Payload, &point.Payload); err != nil {
+				return nil, fmt.Errorf("decode pgvector payload: %w", err)
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func (s *PgvectorStore) ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error) {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil, nil
+	}
+	var rows []IndexedChunk
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT chunk_id, book_id, content_sha256
+		FROM `+pgvectorTable+`
+		WHERE book_id = ?
+		ORDER BY chunk_id`, bookID).Scan(&rows).Error
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return rows, nil
+}
+
+func (s *PgvectorStore) ListBookIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Raw(`SELECT DISTINCT book_id FROM ` + pgvectorTable + ` ORDER BY book_id`).Scan(&ids).Error
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return ids, nil
+}
+
+// ignoreUndefinedTable treats a missing table like an empty collection, the
+// same way the Qdrant client treats a missing collection.
+func ignoreUndefinedTable(err error) error {
+	if err != nil && strings.Contains(err.Error(), "SQLSTATE 42P01") {
+		return nil
+	}
+	return err
+}
+
+func formatPgVector(values []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func formatPgBigintArray(values []uint32) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatUint(uint64(value), 10))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatPgRealArray(values []float32) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package retrieval
+
+import "testing"
+
+func TestFormatPgVectorLiterals(t *testing.T) {
+	if got := formatPgVector([]float32{0.5, -1, 0.125}); got != "[0.5,-1,0.125]" {
+		t.Fatalf("formatPgVector() = %q", got)
+	}
+	if got := formatPgBigintArray([]uint32{3, 4294967295}); got != "{3,4294967295}" {
+		t.Fatalf("formatPgBigintArray() = %q", got)
+	}
+	if got := formatPgRealArray(nil); got != "{}" {
+		t.Fatalf("formatPgRealArray(nil) = %q", got)
+	}
+}
+
+func TestPgvectorIndexesLargeEmbeddingsAsHalfvec(t *testing.T) {
+	small := &PgvectorStore{denseSize: 1024}
+	if small.distanceExpr() != "dense" || small.queryVectorType() != "vector(1024)" {
+		t.Fatalf("unexpected expressions for 1024 dims: %q %q", small.distanceExpr(), small.queryVectorType())
+	}
+	large := &PgvectorStore{denseSize: 3072}
+	if large.distanceExpr() != "(dense::halfvec(3072))" || large.queryVectorType() != "halfvec(3072)" {
+		t.Fatalf("unexpected expressions for 3072 dims: %q %q", large.distanceExpr(), large.queryVectorType())
+	}
+}
+
+func TestDenseScanSettingsUseIterativeScan(t *testing.T) {
+	tests := map[int]string{10: "SET LOCAL hnsw.ef_search = 200", 500: "SET LOCAL hnsw.ef_search = 500", 5000: "SET LOCAL hnsw.ef_search = 1000"}
+	for limit, want := range tests {
+		settings := denseScanSettings(limit)
+		if len(settings) != 2 || settings[0] != "SET LOCAL hnsw.iterative_scan = relaxed_order" || settings[1] != want {
+			t.Fatalf("denseScanSettings(%d) = %q, want iterative scan and %q", limit, settings, want)
+		}
+	}
+}
+
+func TestNewVectorStoreSelectsBackend(t *testing.T) {
+	store, err := NewVectorStore(VectorStoreConfig{QdrantURL: "http://localhost:6333", QdrantCollection: "chunks", DenseSize: 8})
+	if err != nil {
+		t.Fatalf("NewVectorStore(default): %v", err)
+	}
+	if _, ok := store.(*Client); !ok {
+		t.Fatalf("expected qdrant client by default, got %T", store)
+	}
+	if _, err := NewVectorStore(VectorStoreConfig{Backend: "milvus"}); err == nil {
+		t.Fatal("expected unsupported backend error")
+	}
+	if _, err := NewVectorStore(VectorStoreConfig{Backend: "pgvector", DenseSize: 8}); err == nil {
+		t.Fatal("expected pgvector to require a database url")
+	}
+}
@@ -24,15 +24,20 @@ type SearchFunc func(ctx context.Context, query, language string, topK int) ([]S
 type ChunkLoadFunc func(ctx context.Context, ids []string) (map[string]domain.Chunk, error)
 
 type PipelineOptions struct {
-	Query         string
-	Queries       []string
-	BookID        string
+	Query   string
+	Queries []string
+	BookID  string
+	// Filter is passed to the search functions by their callers; Run also
+	// drops hydrated hits outside it.
+	Filter        ChunkFilter
 	RetrievalMode string
 	TopK          int
 	DenseTopK     int
 	LexicalTopK   int
+	SparseTopK    int
 	DenseWeight   float64
 	LexicalWeight float64
+	SparseWeight  float64
 	FusionTopK    int
 	RerankTopN    int
 	ContextBudget int
@@ -43,6 +48,7 @@ type PipelineResult struct {
 	Queries  []string
 	Dense    []StageHit
 	Lexical  []StageHit
+	Sparse   []StageHit
 	Fused    []StageHit
 	Reranked []StageHit
 	Final    []StageHit
@@ -53,6 +59,7 @@ type Pipeline struct {
 	Rewrite     RewriteFunc
 	Dense       SearchFunc
 	Lexical     SearchFunc
+	Sparse      SearchFunc
 	ChunkLoader ChunkLoadFunc
 	Reranker    Reranker
 }
@@ -75,8 +82,17 @@ func (p Pipeline) Run(ctx context.Context, opts PipelineOptions) (PipelineResult
 
 	denseHits := make(map[string]StageHit)
 	lexicalHits := make(map[string]StageHit)
-	warnings := make([]string, 0, 2)
-	denseWeight, lexicalWeight := normalizedFusionWeights(opts.DenseWeight, opts.LexicalWeight)
+	sparseHits := make(map[string]StageHit)
+	warnings := make([]string, 0, 3)
+	// The sparse channel is opt-in: it only runs in hybrid modes with a positive weight.
+	useSparse := p.Sparse != nil && opts.SparseWeight > 0 && opts.RetrievalMode != "dense_only" && opts.RetrievalMode != "lexical_only"
+	sparseWeight := 0.0
+	if useSparse {
+		sparseWeight = opts.SparseWeight
+	}
+	weights := normalizedFusionWeights(opts.DenseWeight, opts.LexicalWeight, sparseWeight)
+	denseWeight, lexicalWeight := weights[0], weights[1]
+	sparseWeight = weights[2]
 
 	for _, item := range queries {
 		if strings.TrimSpace(item) == "" {
@@ -98,6 +114,14 @@ func (p Pipeline) Run(ctx context.Context, opts PipelineOptions) (PipelineResult
 				accumulateStageHits(lexicalHits, hits, "lexical", lexicalWeight)
 			}
 		}
+		if useSparse {
+			hits, err := p.Sparse(ctx, item, language, opts.SparseTopK)
+			if err != nil {
+				warnings = append(warnings, "sparse retrieval unavailable")
+			} else {
+				accumulateStageHits(sparseHits, hits, "sparse", sparseWeight)
+			}
+		}
 	}
 
 	result := PipelineResult{
@@ -105,24 +129,32 @@ func (p Pipeline) Run(ctx context.Context, opts PipelineOptions) (PipelineResult
 		Queries:  queries,
 		Warnings: uniquePipelineStrings(warnings),
 	}
-	if len(denseHits) == 0 && len(lexicalHits) == 0 {
+	if len(denseHits) == 0 && len(lexicalHits) == 0 && len(sparseHits) == 0 {
 		return result, nil
 	}
 
 	result.Dense = orderedStageHits(denseHits, opts.DenseTopK)
 	result.Lexical = orderedStageHits(lexicalHits, opts.LexicalTopK)
-	result.Fused = fuseStageHits(denseHits, lexicalHits, opts.FusionTopK)
+	result.Sparse = orderedStageHits(sparseHits, opts.SparseTopK)
+	result.Fused = fuseStageHits(opts.FusionTopK, denseHits, lexicalHits, sparseHits)
 
 	if p.ChunkLoader != nil {
-		loaded, err := p.ChunkLoader(ctx, uniqueChunkIDs(result.Dense, result.Lexical, result.Fused))
+		loaded, err := p.ChunkLoader(ctx, uniqueChunkIDs(result.Dense, result.Lexical, result.Sparse, result.Fused))
 		if err != nil {
 			result.Warnings = uniquePipelineStrings(append(result.Warnings, "chunk hydration unavailable"))
 		} else {
 			result.Dense = hydrateStageHits(result.Dense, loaded)
 			result.Lexical = hydrateStageHits(result.Lexical, loaded)
+			result.Sparse = hydrateStageHits(result.Sparse, loaded)
 			result.Fused = hydrateStageHits(result.Fused, loaded)
 		}
 	}
+	if !opts.Filter.IsZero() {
+		result.Dense = filterStageHits(result.Dense, opts.Filter)
+		result.Lexical = filterStageHits(result.Lexical, opts.Filter)
+		result.Sparse = filterStageHits(result.Sparse, opts.Filter)
+		result.Fused = filterStageHits(result.Fused, opts.Filter)
+	}
 
 	switch strings.TrimSpace(opts.RetrievalMode) {
 	case "dense_only":
@@ -150,6 +182,18 @@ func (p Pipeline) Run(ctx context.Context, opts PipelineOptions) (PipelineResult
 	return result, nil
 }
 
+// filterStageHits keeps hits whose metadata (merged with the chunk's once
+// hydrated) satisfies filter.
+func filterStageHits(hits []StageHit, filter ChunkFilter) []StageHit {
+	out := make([]StageHit, 0, len(hits))
+	for _, hit := range hits {
+		if filter.Matches(hit.Metadata) {
+			out = append(out, hit)
+		}
+	}
+	return out
+}
+
 func accumulateStageHits(target map[string]StageHit, hits []StageHit, stage string, weight float64) {
 	for rank, hit := range hits {
 		hit.Stage = stage
@@ -196,32 +240,34 @@ func orderedStageHits(items map[string]StageHit, topK int) []StageHit {
 	return out
 }
 
-func fuseStageHits(denseHits, lexicalHits map[string]StageHit, topK int) []StageHit {
-	fused := make(map[string]StageHit, len(denseHits)+len(lexicalHits))
-	for id, hit := range denseHits {
-		copy := hit
-		copy.Stage = "fusion"
-		fused[id] = copy
-	}
-	for id, hit := range lexicalHits {
-		current, ok := fused[id]
-		if !ok {
-			hit.Stage = "fusion"
-			fused[id] = hit
-			continue
-		}
-		if current.ChunkID == "" {
-			current.ChunkID = hit.ChunkID
-		}
-		if current.BookID == "" {
-			current.BookID = hit.BookID
-		}
-		if strings.TrimSpace(current.Content) == "" {
-			current.Content = hit.Content
+// fuseStageHits sums the weighted reciprocal-rank scores of every channel.
+func fuseStageHits(topK int, channels ...map[string]StageHit) []StageHit {
+	size := 0
+	for _, hits := range channels {
+		size += len(hits)
+	}
+	fused := make(map[string]StageHit, size)
+	for _, hits := range channels {
+		for id, hit := range hits {
+			current, ok := fused[id]
+			if !ok {
+				hit.Stage = "fusion"
+				fused[id] = hit
+				continue
+			}
+			if current.ChunkID == "" {
+				current.ChunkID = hit.ChunkID
+			}
+			if current.BookID == "" {
+				current.BookID = hit.BookID
+			}
+			if strings.TrimSpace(current.Content) == "" {
+				current.Content = hit.Content
+			}
+			current.Metadata = mergeMetadata(current.Metadata, hit.Metadata)
+			current.Score += hit.Score
+			fused[id] = current
 		}
-		current.Metadata = mergeMetadata(current.Metadata, hit.Metadata)
-		current.Score += hit.Score
-		fused[id] = current
 	}
 	return orderedStageHits(fused, topK)
 }
@@ -334,21 +380,29 @@ func reciprocalRank(rank int) float64 {
 	return 1.0 / float64(rank+61)
 }
 
-func normalizedFusionWeights(denseWeight, lexicalWeight float64) (float64, float64) {
-	if denseWeight <= 0 && lexicalWeight <= 0 {
-		return 0.5, 0.5
-	}
-	if denseWeight < 0 {
-		denseWeight = 0
+// normalizedFusionWeights scales channel weights to sum to 1. When no weight
+// is positive, the first two channels (dense, lexical) split evenly and any
+// further channel is left out.
+func normalizedFusionWeights(weights ...float64) []float64 {
+	out := make([]float64, len(weights))
+	total := 0.0
+	for i, weight := range weights {
+		if weight > 0 {
+			out[i] = weight
+			total += weight
+		}
 	}
-	if lexicalWeight < 0 {
-		lexicalWeight = 0
+	if total > 0 {
+		for i := range out {
+			out[i] /= total
+		}
+		return out
 	}
-	total := denseWeight + lexicalWeight
-	if total <= 0 {
-		return 0.5, 0.5
+	base := minInt(len(out), 2)
+	for i := 0; i < base; i++ {
+		out[i] = 1 / float64(base)
 	}
-	return denseWeight / total, lexicalWeight / total
+	return out
 }
 
 func uniquePipelineStrings(items []string) []string {
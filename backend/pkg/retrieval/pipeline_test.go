@@ -0,0 +1,100 @@
+package retrieval
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+func TestNormalizedFusionWeights(t *testing.T) {
+	got := normalizedFusionWeights(0.45, 0.55, 0.5)
+	want := []float64{0.3, 0.55 / 1.5, 0.5 / 1.5}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("weights = %v, want %v", got, want)
+		}
+	}
+	got = normalizedFusionWeights(0, 0, 0)
+	if got[0] != 0.5 || got[1] != 0.5 || got[2] != 0 {
+		t.Fatalf("default weights = %v, want [0.5 0.5 0]", got)
+	}
+}
+
+func TestFuseStageHitsSumsAllChannels(t *testing.T) {
+	dense := map[string]StageHit{"a": {ChunkID: "a", Score: 0.1}}
+	lexical := map[string]StageHit{"b": {ChunkID: "b", Score: 0.15}}
+	sparse := map[string]StageHit{"a": {ChunkID: "a", Score: 0.1}}
+	fused := fuseStageHits(10, dense, lexical, sparse)
+	if len(fused) != 2 || fused[0].ChunkID != "a" || math.Abs(fused[0].Score-0.2) > 1e-9 {
+		t.Fatalf("unexpected fusion: %+v", fused)
+	}
+	if fused[0].Stage != "fusion" {
+		t.Fatalf("expected fusion stage, got %q", fused[0].Stage)
+	}
+}
+
+func TestPipelineRunsSparseChannelInHybridMode(t *testing.T) {
+	search := func(ids ...string) SearchFunc {
+		return func(context.Context, string, string, int) ([]StageHit, error) {
+			hits := make([]StageHit, 0, len(ids))
+			for _, id := range ids {
+				hits = append(hits, StageHit{ChunkID: id, Content: id})
+			}
+			return hits, nil
+		}
+	}
+	pipeline := Pipeline{Dense: search("a"), Lexical: search("b"), Sparse: search("c", "a")}
+	opts := PipelineOptions{Query: "concurrency", Queries: []string{"concurrency"}, TopK: 5, DenseTopK: 5, LexicalTopK: 5, SparseTopK: 5, FusionTopK: 5, DenseWeight: 1, LexicalWeight: 1, SparseWeight: 1}
+	result, err := pipeline.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Sparse) != 2 || result.Sparse[0].Stage != "sparse" {
+		t.Fatalf("expected sparse hits, got %+v", result.Sparse)
+	}
+	if len(result.Fused) != 3 || result.Fused[0].ChunkID != "a" {
+		t.Fatalf("expected chunk found by two channels to rank first, got %+v", result.Fused)
+	}
+
+	opts.SparseWeight = 0
+	result, err = pipeline.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Sparse) != 0 || len(result.Fused) != 2 {
+		t.Fatalf("expected sparse channel disabled at zero weight, got sparse=%d fused=%d", len(result.Sparse), len(result.Fused))
+	}
+}
+
+func TestPipelineDropsHitsOutsideFilter(t *testing.T) {
+	dense := func(context.Context, string, string, int) ([]StageHit, error) {
+		return []StageHit{{ChunkID: "p3"}, {ChunkID: "p9"}, {ChunkID: "epub"}}, nil
+	}
+	loader := func(_ context.Context, ids []string) (map[string]domain.Chunk, error) {
+		return map[string]domain.Chunk{
+			"p3":   {ID: "p3", Content: "three", Metadata: map[string]string{"page": "3"}},
+			"p9":   {ID: "p9", Content: "nine", Metadata: map[string]string{"page": "9"}},
+			"epub": {ID: "epub", Content: "chapter", Metadata: map[string]string{"section_path": "ch05.xhtml"}},
+		}, nil
+	}
+	pipeline := Pipeline{Dense: dense, ChunkLoader: loader}
+	opts := PipelineOptions{Query: "q", Queries: []string{"q"}, RetrievalMode: "dense_only", TopK: 5, DenseTopK: 5, FusionTopK: 5, DenseWeight: 1, Filter: ChunkFilter{PageFrom: 1, PageTo: 5}}
+	result, err := pipeline.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Final) != 1 || result.Final[0].ChunkID != "p3" {
+		t.Fatalf("expected only page 3 within pages 1-5, got %+v", result.Final)
+	}
+
+	opts.Filter = ChunkFilter{SectionPath: "ch05.xhtml"}
+	result, err = pipeline.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Final) != 1 || result.Final[0].ChunkID != "epub" {
+		t.Fatalf("expected only the ch05 chunk, got %+v", result.Final)
+	}
+}
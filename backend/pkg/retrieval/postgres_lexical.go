@@ -0,0 +1,216 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	postgresLexicalTable    = "chunk_lexical_docs"
+	postgresLexicalPageSize = 200
+)
+
+// PostgresLexicalStore implements LexicalStore with Postgres full-text search.
+// Documents are indexed from the pre-tokenized content_terms (CJK text is
+// already split into bigrams by Tokenize) under the "simple" configuration,
+// so no language-specific parser or extension is required.
+type PostgresLexicalStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresLexicalStore(dsn string) (*PostgresLexicalStore, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, fmt.Errorf("postgres lexical database url required")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Warn)})
+	if err != nil {
+		return nil, fmt.Errorf("open postgres lexical db: %w", err)
+	}
+	return &PostgresLexicalStore{db: db}, nil
+}
+
+func (s *PostgresLexicalStore) EnsureIndex(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			chunk_id text PRIMARY KEY,
+			book_id text NOT NULL,
+			content_sha256 text NOT NULL DEFAULT '',
+			content_text text NOT NULL DEFAULT '',
+			content_terms text NOT NULL DEFAULT '',
+			payload jsonb NOT NULL DEFAULT '{}'::jsonb,
+			terms_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', content_terms)) STORED,
+			updated_at timestamptz NOT NULL DEFAULT now()
+		)`, postgresLexicalTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_book ON %s (book_id)`, postgresLexicalTable, postgresLexicalTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_terms ON %s USING gin (terms_tsv)`, postgresLexicalTable, postgresLexicalTable),
+	}
+	db := s.db.WithContext(ctx)
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("ensure postgres lexical table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresLexicalStore) IndexDocuments(ctx context.Context, docs []LexicalDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(docs); start += postgresLexicalPageSize {
+			end := start + postgresLexicalPageSize
+			if end > len(docs) {
+				end = len(docs)
+			}
+			var sql strings.Builder
+			sql.WriteString("INSERT INTO " + postgresLexicalTable + " (chunk_id, book_id, content_sha256, content_text, content_terms, payload, updated_at) VALUES ")
+			args := make([]any, 0, (end-start)*6)
+			for i, doc := range docs[start:end] {
+				id := strings.TrimSpace(doc.ID)
+				if id == "" {
+					return fmt.Errorf("postgres lexical document requires an id")
+				}
+				payload, err := json.Marshal(doc.Payload)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					sql.WriteString(", ")
+				}
+				sql.WriteString("(?, ?, ?, ?, ?, ?::jsonb, now())")
+				args = append(args, id, anyString(doc.Payload["book_id"]), anyString(doc.Payload["content_sha256"]), doc.Content, doc.Terms, string(payload))
+			}
+			sql.WriteString(` ON CONFLICT (chunk_id) DO UPDATE SET
+				book_id = EXCLUDED.book_id,
+				content_sha256 = EXCLUDED.content_sha256,
+				content_text = EXCLUDED.content_text,
+				content_terms = EXCLUDED.content_terms,
+				payload = EXCLUDED.payload,
+				updated_at = EXCLUDED.updated_at`)
+			if err := tx.Exec(sql.String(), args...).Error; err != nil {
+				return fmt.Errorf("index postgres lexical documents: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *PostgresLexicalStore) DeleteByBook(ctx context.Context, bookID string) error {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil
+	}
+	return ignoreUndefinedTable(s.db.WithContext(ctx).Exec("DELETE FROM "+postgresLexicalTable+" WHERE book_id = ?", bookID).Error)
+}
+
+func (s *PostgresLexicalStore) DeleteDocuments(ctx context.Context, ids []string) error {
+	clean := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			clean = append(clean, id)
+		}
+	}
+	if len(clean) == 0 {
+		return nil
+	}
+	return ignoreUndefinedTable(s.db.WithContext(ctx).Exec("DELETE FROM "+postgresLexicalTable+" WHERE chunk_id IN ?", clean).Error)
+}
+
+// QueryBM25 ranks documents matching any query term with ts_rank_cd. It is
+// not true BM25, but the scores only feed rank-based fusion.
+func (s *PostgresLexicalStore) QueryBM25(ctx context.Context, bookID string, filter ChunkFilter, terms string, limit int) ([]Point, error) {
+	bookID = strings.TrimSpace(bookID)
+	query := buildOrTSQuery(terms)
+	if query == "" || limit <= 0 {
+		return nil, nil
+	}
+	sql := `
+		SELECT chunk_id, payload, ts_rank_cd(terms_tsv, q) AS score
+		FROM ` + postgresLexicalTable + `, to_tsquery('simple', ?) AS q
+		WHERE terms_tsv @@ q`
+	args := []any{query}
+	if bookID != "" {
+		sql += ` AND book_id = ?`
+		args = append(args, bookID)
+	}
+	scope, scopeArgs := filter.sqlConditions("payload")
+	sql += scope
+	args = append(args, scopeArgs...)
+	sql += ` ORDER BY score DESC, chunk_id LIMIT ?`
+	args = append(args, limit)
+	var rows []struct {
+		ChunkID string
+		Payload []byte
+		Score   float64
+	}
+	if err := s.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		if ignoreUndefinedTable(err) == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query postgres lexical: %w", err)
+	}
+	points := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		payload := map[string]any{}
+		if len(row.Payload) > 0 {
+			if err := json.Unmarshal(row.Payload, &payload); err != nil {
+				return nil, fmt.Errorf("decode postgres lexical payload: %w", err)
+			}
+		}
+		payload["chunk_id"] = row.ChunkID
+		points = append(points, Point{ID: row.ChunkID, Payload: payload, Score: row.Score})
+	}
+	return points, nil
+}
+
+func (s *PostgresLexicalStore) ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error) {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil, nil
+	}
+	var rows []IndexedChunk
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT chunk_id, book_id, content_sha256
+		FROM `+postgresLexicalTable+`
+		WHERE book_id = ?
+		ORDER BY chunk_id`, bookID).Scan(&rows).Error
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return rows, nil
+}
+
+func (s *PostgresLexicalStore) ListBookIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Raw(`SELECT DISTINCT book_id FROM ` + postgresLexicalTable + ` ORDER BY book_id`).Scan(&ids).Error
+	if err != nil {
+		return nil, ignoreUndefinedTable(err)
+	}
+	return ids, nil
+}
+
+// buildOrTSQuery turns space-separated terms into a tsquery matching any of
+// them, quoting each term so tsquery operators in user text stay literal.
+func buildOrTSQuery(terms string) string {
+	fields := strings.Fields(terms)
+	seen := make(map[string]struct{}, len(fields))
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ToLower(field)
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		escaped := strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(field)
+		parts = append(parts, "'"+escaped+"'")
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,105 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuildOrTSQueryQuotesAndDeduplicatesTerms(t *testing.T) {
+	got := buildOrTSQuery("Go go 并发 it's a\\b")
+	want := `'go' | '并发' | 'it''s' | 'a\\b'`
+	if got != want {
+		t.Fatalf("buildOrTSQuery() = %q, want %q", got, want)
+	}
+	if buildOrTSQuery("   ") != "" {
+		t.Fatal("expected empty query for blank terms")
+	}
+}
+
+type stubLexicalStore struct {
+	indexed []string
+	bookIDs []string
+	err     error
+}
+
+func (s *stubLexicalStore) EnsureIndex(context.Context) error { return s.err }
+func (s *stubLexicalStore) IndexDocuments(_ context.Context, docs []LexicalDocument) error {
+	for _, doc := range docs {
+		s.indexed = append(s.indexed, doc.ID)
+	}
+	return s.err
+}
+func (s *stubLexicalStore) DeleteByBook(context.Context, string) error      { return s.err }
+func (s *stubLexicalStore) DeleteDocuments(context.Context, []string) error { return s.err }
+func (s *stubLexicalStore) QueryBM25(context.Context, string, ChunkFilter, string, int) ([]Point, error) {
+	return []Point{{ID: "primary"}}, s.err
+}
+func (s *stubLexicalStore) ListBookChunks(context.Context, string) ([]IndexedChunk, error) {
+	return nil, s.err
+}
+func (s *stubLexicalStore) ListBookIDs(context.Context) ([]string, error) { return s.bookIDs, s.err }
+
+func TestMirroredLexicalStoreWritesBothAndMergesBookIDs(t *testing.T) {
+	primary := &stubLexicalStore{bookIDs: []string{"b1", "b2"}}
+	standby := &stubLexicalStore{bookIDs: []string{"b2", "b3"}}
+	store := &MirroredLexicalStore{Primary: primary, Standby: standby}
+	if err := store.IndexDocuments(context.Background(), []LexicalDocument{{ID: "c1"}}); err != nil {
+		t.Fatalf("IndexDocuments: %v", err)
+	}
+	if len(primary.indexed) != 1 || len(standby.indexed) != 1 {
+		t.Fatalf("expected both stores to receive the document, got %v / %v", primary.indexed, standby.indexed)
+	}
+	ids, err := store.ListBookIDs(context.Background())
+	if err != nil {
+		t.Fatalf("ListBookIDs: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected merged book ids, got %v", ids)
+	}
+}
+
+func TestMirroredLexicalStoreMarksBooksWhenStandbyFails(t *testing.T) {
+	primary := &stubLexicalStore{}
+	standby := &stubLexicalStore{err: errors.New("postgres down")}
+	store := &MirroredLexicalStore{Primary: primary, Standby: standby}
+	docs := []LexicalDocument{{ID: "c1", Payload: map[string]any{"book_id": "b1"}}, {ID: "c2", Payload: map[string]any{"book_id": "b1"}}}
+	if err := store.IndexDocuments(context.Background(), docs); err != nil {
+		t.Fatalf("IndexDocuments: standby failure should not fail the write, got %v", err)
+	}
+	if err := store.DeleteByBook(context.Background(), "b2"); err != nil {
+		t.Fatalf("DeleteByBook: standby failure should not fail the write, got %v", err)
+	}
+	if len(primary.indexed) != 2 {
+		t.Fatalf("primary indexed %v, want both documents", primary.indexed)
+	}
+	repairs := store.TakeStandbyRepairs()
+	if !reflect.DeepEqual(repairs.BookIDs, []string{"b1", "b2"}) || repairs.AllBooks || repairs.Failures != 2 || repairs.LastError != "postgres down" {
+		t.Fatalf("TakeStandbyRepairs() = %+v", repairs)
+	}
+	if repairs := store.TakeStandbyRepairs(); len(repairs.BookIDs) != 0 || repairs.Failures != 0 {
+		t.Fatalf("repairs should be cleared once taken, got %+v", repairs)
+	}
+
+	if err := store.DeleteDocuments(context.Background(), []string{"c9"}); err != nil {
+		t.Fatalf("DeleteDocuments: %v", err)
+	}
+	if repairs := store.TakeStandbyRepairs(); !repairs.AllBooks {
+		t.Fatalf("a failed delete without a book should mark every book, got %+v", repairs)
+	}
+
+	primary.err = errors.New("opensearch down")
+	if err := store.DeleteByBook(context.Background(), "b1"); err == nil {
+		t.Fatal("expected primary failure to surface")
+	}
+}
+
+func TestNewLexicalStoreRejectsUnknownBackend(t *testing.T) {
+	if _, err := NewLexicalStore(LexicalStoreConfig{Backend: "solr"}); err == nil {
+		t.Fatal("expected unsupported backend error")
+	}
+	if _, err := NewLexicalStore(LexicalStoreConfig{Backend: "postgres"}); err == nil {
+		t.Fatal("expected postgres backend to require a database url")
+	}
+}
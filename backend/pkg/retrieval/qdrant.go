@@ -32,6 +32,13 @@ type UpsertPoint struct {
 	Payload map[string]any `json:"payload"`
 }
 
+// IndexedChunk is the minimal view of an indexed record used by consistency checks.
+type IndexedChunk struct {
+	ChunkID       string
+	BookID        string
+	ContentSHA256 string
+}
+
 type Client struct {
 	baseURL    string
 	apiKey     string
@@ -42,6 +49,8 @@ type Client struct {
 
 var qdrantPointNamespace = uuid.MustParse("2b6d13ed-63bb-4d0d-9a8e-2e6bdb5d2f15")
 
+const qdrantScrollPageSize = 256
+
 type apiError struct {
 	Status int
 	Body   string
@@ -146,11 +155,118 @@ func (c *Client) DeleteByBook(ctx context.Context, bookID string) error {
 	return err
 }
 
-func (c *Client) QueryDense(ctx context.Context, bookID string, vector []float32, limit int) ([]Point, error) {
+// DeleteChunks removes the points of specific chunks of a book.
+func (c *Client) DeleteChunks(ctx context.Context, bookID string, chunkIDs []string) error {
+	bookID = strings.TrimSpace(bookID)
+	ids := make([]string, 0, len(chunkIDs))
+	for _, chunkID := range chunkIDs {
+		chunkID = strings.TrimSpace(chunkID)
+		if chunkID == "" {
+			continue
+		}
+		ids = append(ids, qdrantPointID(chunkID, map[string]any{"book_id": bookID, "chunk_id": chunkID}))
+	}
+	if bookID == "" || len(ids) == 0 {
+		return nil
+	}
+	err := c.do(ctx, http.MethodPost, "/collections/"+url.PathEscape(c.collection)+"/points/delete?wait=true", map[string]any{
+		"points": ids,
+	}, nil)
+	var apiErr *apiError
+	if err != nil && errorAs(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// ListBookChunks scrolls every point stored for a book.
+func (c *Client) ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error) {
+	bookID = strings.TrimSpace(bookID)
+	if bookID == "" {
+		return nil, nil
+	}
+	return c.scroll(ctx, map[string]any{
+		"must": []map[string]any{
+			{
+				"key": "book_id",
+				"match": map[string]any{
+					"value": bookID,
+				},
+			},
+		},
+	}, []string{"chunk_id", "book_id", "content_sha256"})
+}
+
+// ListBookIDs returns the distinct book IDs present in the collection.
+func (c *Client) ListBookIDs(ctx context.Context) ([]string, error) {
+	items, err := c.scroll(ctx, nil, []string{"book_id"})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(items))
+	out := make([]string, 0, 16)
+	for _, item := range items {
+		if item.BookID == "" {
+			continue
+		}
+		if _, ok := seen[item.BookID]; ok {
+			continue
+		}
+		seen[item.BookID] = struct{}{}
+		out = append(out, item.BookID)
+	}
+	return out, nil
+}
+
+func (c *Client) scroll(ctx context.Context, filter map[string]any, fields []string) ([]IndexedChunk, error) {
+	out := make([]IndexedChunk, 0, 64)
+	var offset any
+	for {
+		payload := map[string]any{
+			"limit":        qdrantScrollPageSize,
+			"with_payload": map[string]any{"include": fields},
+			"with_vector":  false,
+		}
+		if filter != nil {
+			payload["filter"] = filter
+		}
+		if offset != nil {
+			payload["offset"] = offset
+		}
+		var resp struct {
+			Result struct {
+				Points []struct {
+					Payload map[string]any `json:"payload"`
+				} `json:"points"`
+				NextPageOffset any `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/collections/"+url.PathEscape(c.collection)+"/points/scroll", payload, &resp); err != nil {
+			var apiErr *apiError
+			if errorAs(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				return out, nil
+			}
+			return nil, err
+		}
+		for _, point := range resp.Result.Points {
+			out = append(out, IndexedChunk{
+				ChunkID:       anyString(point.Payload["chunk_id"]),
+				BookID:        anyString(point.Payload["book_id"]),
+				ContentSHA256: anyString(point.Payload["content_sha256"]),
+			})
+		}
+		if resp.Result.NextPageOffset == nil || len(resp.Result.Points) == 0 {
+			return out, nil
+		}
+		offset = resp.Result.NextPageOffset
+	}
+}
+
+func (c *Client) QueryDense(ctx context.Context, bookID string, filter ChunkFilter, vector []float32, limit int) ([]Point, error) {
 	if len(vector) == 0 || limit <= 0 {
 		return nil, nil
 	}
-	return c.query(ctx, bookID, map[string]any{
+	return c.query(ctx, bookID, filter, map[string]any{
 		"using":        "dense",
 		"query":        vector,
 		"limit":        limit,
@@ -158,11 +274,11 @@ func (c *Client) QueryDense(ctx context.Context, bookID string, vector []float32
 	})
 }
 
-func (c *Client) QuerySparse(ctx context.Context, bookID string, vector SparseVector, limit int) ([]Point, error) {
+func (c *Client) QuerySparse(ctx context.Context, bookID string, filter ChunkFilter, vector SparseVector, limit int) ([]Point, error) {
 	if len(vector.Indices) == 0 || len(vector.Values) == 0 || limit <= 0 {
 		return nil, nil
 	}
-	return c.query(ctx, bookID, map[string]any{
+	return c.query(ctx, bookID, filter, map[string]any{
 		"using":        "sparse",
 		"query":        vector,
 		"limit":        limit,
@@ -170,17 +286,18 @@ func (c *Client) QuerySparse(ctx context.Context, bookID string, vector SparseVe
 	})
 }
 
-func (c *Client) query(ctx context.Context, bookID string, payload map[string]any) ([]Point, error) {
-	payload["filter"] = map[string]any{
-		"must": []map[string]any{
-			{
-				"key": "book_id",
-				"match": map[string]any{
-					"value": strings.TrimSpace(bookID),
-				},
+func (c *Client) query(ctx context.Context, bookID string, filter ChunkFilter, payload map[string]any) ([]Point, error) {
+	must := []map[string]any{
+		{
+			"key": "book_id",
+			"match": map[string]any{
+				"value": strings.TrimSpace(bookID),
 			},
 		},
 	}
+	payload["filter"] = map[string]any{
+		"must": append(must, filter.qdrantConditions()...),
+	}
 	var resp qdrantQueryResponse
 	if err := c.do(ctx, http.MethodPost, "/collections/"+url.PathEscape(c.collection)+"/points/query", payload, &resp); err != nil {
 		var apiErr *apiError
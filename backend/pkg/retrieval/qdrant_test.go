@@ -2,6 +2,7 @@ package retrieval
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -89,7 +90,7 @@ func TestQueryDenseReturnsEmptyWhenCollectionMissing(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewQdrantClient() error = %v", err)
 	}
-	points, err := client.QueryDense(context.Background(), "book-1", []float32{0.1, 0.2, 0.3}, 5)
+	points, err := client.QueryDense(context.Background(), "book-1", ChunkFilter{}, []float32{0.1, 0.2, 0.3}, 5)
 	if err != nil {
 		t.Fatalf("QueryDense() error = %v, want nil", err)
 	}
@@ -97,3 +98,80 @@ func TestQueryDenseReturnsEmptyWhenCollectionMissing(t *testing.T) {
 		t.Fatalf("QueryDense() len = %d, want 0", len(points))
 	}
 }
+
+func TestQueryDenseAppliesChunkFilter(t *testing.T) {
+	var filter map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		filter, _ = body["filter"].(map[string]any)
+		_, _ = w.Write([]byte(`{"result":{"points":[]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewQdrantClient(server.URL, "", "onebook_chunks", 3)
+	if err != nil {
+		t.Fatalf("NewQdrantClient() error = %v", err)
+	}
+	scope := ChunkFilter{PageFrom: 10, PageTo: 20, SectionPath: "ch05.xhtml"}
+	if _, err := client.QueryDense(context.Background(), "book-1", scope, []float32{0.1, 0.2, 0.3}, 5); err != nil {
+		t.Fatalf("QueryDense() error = %v", err)
+	}
+	must, _ := filter["must"].([]any)
+	if len(must) != 3 {
+		t.Fatalf("must = %v, want book, page and section conditions", must)
+	}
+	page, _ := must[1].(map[string]any)
+	bounds, _ := page["range"].(map[string]any)
+	if page["key"] != "page" || bounds["gte"] != float64(10) || bounds["lte"] != float64(20) {
+		t.Fatalf("page condition = %v", page)
+	}
+	section, _ := must[2].(map[string]any)
+	if section["key"] != "section_path" {
+		t.Fatalf("section condition = %v", section)
+	}
+}
+
+func TestListBookChunksFollowsScrollOffset(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/collections/onebook_chunks/points/scroll" {
+			t.Fatalf("path = %s, want scroll endpoint", r.URL.Path)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			if _, ok := body["offset"]; ok {
+				t.Fatalf("first scroll should not send offset: %#v", body)
+			}
+			_, _ = w.Write([]byte(`{"result":{"points":[{"id":"p1","payload":{"chunk_id":"c1","book_id":"book-1","content_sha256":"h1"}}],"next_page_offset":"p2"}}`))
+			return
+		}
+		if body["offset"] != "p2" {
+			t.Fatalf("offset = %#v, want p2", body["offset"])
+		}
+		_, _ = w.Write([]byte(`{"result":{"points":[{"id":"p2","payload":{"chunk_id":"c2","book_id":"book-1"}}],"next_page_offset":null}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewQdrantClient(server.URL, "", "onebook_chunks", 8)
+	if err != nil {
+		t.Fatalf("NewQdrantClient() error = %v", err)
+	}
+	items, err := client.ListBookChunks(context.Background(), "book-1")
+	if err != nil {
+		t.Fatalf("ListBookChunks() error = %v", err)
+	}
+	if calls != 2 || len(items) != 2 {
+		t.Fatalf("calls = %d, items = %#v, want 2 pages with 2 items", calls, items)
+	}
+	if items[0].ChunkID != "c1" || items[0].ContentSHA256 != "h1" || items[1].ChunkID != "c2" {
+		t.Fatalf("items = %#v", items)
+	}
+}
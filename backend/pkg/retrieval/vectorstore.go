@@ -0,0 +1,56 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	VectorStoreQdrant   = "qdrant"
+	VectorStorePgvector = "pgvector"
+)
+
+// VectorStore stores dense and sparse chunk vectors per book. Qdrant and
+// pgvector implement it; callers never depend on a concrete backend.
+type VectorStore interface {
+	EnsureCollection(ctx context.Context) error
+	UpsertPoints(ctx context.Context, points []UpsertPoint) error
+	DeleteByBook(ctx context.Context, bookID string) error
+	DeleteChunks(ctx context.Context, bookID string, chunkIDs []string) error
+	QueryDense(ctx context.Context, bookID string, filter ChunkFilter, vector []float32, limit int) ([]Point, error)
+	QuerySparse(ctx context.Context, bookID string, filter ChunkFilter, vector SparseVector, limit int) ([]Point, error)
+	ListBookChunks(ctx context.Context, bookID string) ([]IndexedChunk, error)
+	ListBookIDs(ctx context.Context) ([]string, error)
+}
+
+// VectorStoreConfig selects and configures a VectorStore backend.
+type VectorStoreConfig struct {
+	Backend          string
+	QdrantURL        string
+	QdrantAPIKey     string
+	QdrantCollection string
+	DatabaseURL      string
+	DenseSize        int
+}
+
+// NormalizeVectorStoreBackend lowercases the backend name and defaults to qdrant.
+func NormalizeVectorStoreBackend(backend string) string {
+	backend = strings.ToLower(strings.TrimSpace(backend))
+	if backend == "" {
+		return VectorStoreQdrant
+	}
+	return backend
+}
+
+// NewVectorStore builds the configured vector store backend.
+func NewVectorStore(cfg VectorStoreConfig) (VectorStore, error) {
+	switch NormalizeVectorStoreBackend(cfg.Backend) {
+	case VectorStoreQdrant:
+		return NewQdrantClient(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection, cfg.DenseSize)
+	case VectorStorePgvector:
+		return NewPgvectorStore(cfg.DatabaseURL, cfg.DenseSize)
+	default:
+		return nil, fmt.Errorf("unsupported vector store %q", cfg.Backend)
+	}
+}
@@ -54,7 +54,7 @@ func NewGormStore(dsn string) (*GormStore, error) {
 		if err := tx.Exec(`DROP INDEX IF EXISTS uni_user_models_email;`).Error; err != nil {
 			return fmt.Errorf("drop legacy user email unique constraint index: %w", err)
 		}
-		if err := tx.AutoMigrate(&UserModel{}, &UserIdentityModel{}, &UserProfileModel{}, &BookModel{}, &ConversationModel{}, &MessageModel{}, &ChunkModel{}, &ChunkIndexStatusModel{}, &AdminAuditLogModel{}, &EvalDatasetModel{}, &EvalRunModel{}, &IdempotencyRecordModel{}, &OutboxMessageModel{}); err != nil {
+		if err := tx.AutoMigrate(&UserModel{}, &UserIdentityModel{}, &UserProfileModel{}, &BookModel{}, &ConversationModel{}, &ConversationShareModel{}, &MessageModel{}, &LLMUsageModel{}, &UserQuotaModel{}, &ChunkModel{}, &ChunkIndexStatusModel{}, &IndexConsistencyReportModel{}, &AdminAuditLogModel{}, &EvalDatasetModel{}, &EvalRunModel{}, &AnswerFeedbackModel{}, &IdempotencyRecordModel{}, &OutboxMessageModel{}); err != nil {
 			return fmt.Errorf("auto migrate: %w", err)
 		}
 		if err := ensureUserIdentityIndexes(tx); err != nil {
@@ -63,6 +63,12 @@ func NewGormStore(dsn string) (*GormStore, error) {
 		if err := backfillUserEmailIdentities(tx); err != nil {
 			return err
 		}
+		if err := backfillMessageParents(tx); err != nil {
+			return err
+		}
+		if err := ensureMessageSearchIndex(tx); err != nil {
+			return err
+		}
 		if err := tx.Exec(`
 			UPDATE chunk_models
 			SET metadata = jsonb_set(
@@ -173,11 +179,8 @@ func cleanupLegacyVectorArtifacts(tx *gorm.DB) error {
 	`).Error; err != nil {
 		return fmt.Errorf("drop legacy chunk embedding column: %w", err)
 	}
-	if err := tx.Exec(`
-		DROP EXTENSION IF EXISTS vector;
-	`).Error; err != nil {
-		return fmt.Errorf("drop legacy vector extension: %w", err)
-	}
+	// The vector extension itself stays: the pgvector vector store owns the
+	// chunk_vectors table and depends on it.
 	return nil
 }
 
@@ -203,6 +206,77 @@ func backfillUserEmailIdentities(tx *gorm.DB) error {
 	return nil
 }
 
+// backfillMessageParents chains the messages of conversations saved before
+// branching in creation order and points them at their latest message. Every
+// exchange since sets active_leaf_id, so each conversation is backfilled once.
+// ensureMessageSearchIndex adds the generated tsvector behind conversation
+// search and tokenizes messages saved before it existed.
+func ensureMessageSearchIndex(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		ALTER TABLE message_models
+		ADD COLUMN IF NOT EXISTS search_tsv tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', search_terms)) STORED;
+	`).Error; err != nil {
+		return fmt.Errorf("add message search column: %w", err)
+	}
+	if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_message_models_search ON message_models USING gin (search_tsv);`).Error; err != nil {
+		return fmt.Errorf("create message search index: %w", err)
+	}
+	for {
+		var rows []struct {
+			ID      string
+			Content string
+		}
+		if err := tx.Raw(`SELECT id, content FROM message_models WHERE search_terms = '' AND content <> '' LIMIT 500`).Scan(&rows).Error; err != nil {
+			return fmt.Errorf("load unindexed messages: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for _, row := range rows {
+			terms := strings.Join(retrieval.SearchTerms(row.Content), " ")
+			if terms == "" {
+				// Keep punctuation-only messages from being selected again.
+				terms = " "
+			}
+			if err := tx.Exec(`UPDATE message_models SET search_terms = ? WHERE id = ?`, terms, row.ID).Error; err != nil {
+				return fmt.Errorf("backfill message search terms: %w", err)
+			}
+		}
+	}
+}
+
+func backfillMessageParents(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		WITH ordered AS (
+			SELECT m.id, LAG(m.id) OVER (PARTITION BY m.conversation_id ORDER BY m.created_at, m.id) AS prev_id
+			FROM message_models m
+			JOIN conversation_models c ON c.id = m.conversation_id
+			WHERE c.active_leaf_id IS NULL
+		)
+		UPDATE message_models m
+		SET parent_id = ordered.prev_id
+		FROM ordered
+		WHERE m.id = ordered.id AND ordered.prev_id IS NOT NULL AND m.parent_id IS NULL;
+	`).Error; err != nil {
+		return fmt.Errorf("backfill message parents: %w", err)
+	}
+	if err := tx.Exec(`
+		UPDATE conversation_models c
+		SET active_leaf_id = (
+			SELECT m.id FROM message_models m
+			WHERE m.conversation_id = c.id
+			ORDER BY m.created_at DESC, m.id DESC
+			LIMIT 1
+		)
+		WHERE c.active_leaf_id IS NULL
+		  AND EXISTS (SELECT 1 FROM message_models m WHERE m.conversation_id = c.id);
+	`).Error; err != nil {
+		return fmt.Errorf("backfill conversation active leaves: %w", err)
+	}
+	return nil
+}
+
 func ensureUserIdentityIndexes(tx *gorm.DB) error {
 	if err := tx.Exec(`
 		UPDATE user_identity_models SET provider = '' WHERE provider IS NULL;
@@ -257,6 +331,32 @@ func withMigrationLock(db *gorm.DB, fn func(*gorm.DB) error) error {
 	return fn(db)
 }
 
+// TryAdvisoryLock runs fn on a dedicated session holding lockID, or returns
+// false when another session (usually another replica) already holds it.
+func (s *GormStore) TryAdvisoryLock(lockID int64, fn func() error) (bool, error) {
+	ctx := context.Background()
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return false, fmt.Errorf("get sql db: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("open sql conn: %w", err)
+	}
+	defer conn.Close()
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("try advisory lock: %w", err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		_ = execAdvisory(ctx, conn, "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+	return true, fn()
+}
+
 func execAdvisory(ctx context.Context, conn *sql.Conn, query string, lockID int64) error {
 	_, err := conn.ExecContext(ctx, query, lockID)
 	return err
@@ -519,6 +619,9 @@ func (s *GormStore) DeleteUser(userID string) error {
 		if err := tx.Delete(&ConversationModel{}, "user_id = ?", userID).Error; err != nil {
 			return err
 		}
+		if err := tx.Delete(&UserQuotaModel{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
 		if err := tx.Delete(&UserModel{}, "id = ?", userID).Error; err != nil {
 			return err
 		}
@@ -673,6 +776,22 @@ func (s *GormStore) ListBooksByOwner(ownerID string) ([]domain.Book, error) {
 	return s.listBooks("created_at ASC", "owner_id = ?", ownerID)
 }
 
+// GetOwnerBookUsage returns the number and total size of an owner's books,
+// excluding deleted ones.
+func (s *GormStore) GetOwnerBookUsage(ownerID string) (int, int64, error) {
+	var row struct {
+		Books int64
+		Bytes int64
+	}
+	if err := s.db.Model(&BookModel{}).
+		Select("COUNT(*) AS books, COALESCE(SUM(size_bytes), 0) AS bytes").
+		Where("owner_id = ? AND deleted_at IS NULL", strings.TrimSpace(ownerID)).
+		Scan(&row).Error; err != nil {
+		return 0, 0, err
+	}
+	return int(row.Books), row.Bytes, nil
+}
+
 // ListBooksWithOptions returns books with filtering and pagination.
 func (s *GormStore) ListBooksWithOptions(opts BookListOptions) ([]domain.Book, int, error) {
 	tx := s.db.Model(&BookModel{})
@@ -928,6 +1047,84 @@ func (s *GormStore) ListConversationsByUser(userID string, limit int) ([]domain.
 	return items, nil
 }
 
+// SearchConversationMessages ranks a user's messages whose search terms
+// contain every query term, best match first.
+func (s *GormStore) SearchConversationMessages(opts MessageSearchOptions) ([]MessageSearchHit, int, error) {
+	page, pageSize := normalizePage(opts.Page, opts.PageSize)
+	terms := make([]string, 0, len(opts.Terms))
+	for _, term := range opts.Terms {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, "'"+strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(term)+"'")
+		}
+	}
+	if len(terms) == 0 || strings.TrimSpace(opts.UserID) == "" {
+		return nil, 0, nil
+	}
+	where := `c.user_id = ? AND m.search_tsv @@ q`
+	args := []any{strings.Join(terms, " & "), opts.UserID}
+	bookIDs := make([]string, 0, len(opts.BookIDs))
+	for _, id := range opts.BookIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			bookIDs = append(bookIDs, id)
+		}
+	}
+	if len(bookIDs) > 0 {
+		where += ` AND (m.book_id IN ? OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(c.book_ids) AS b(id) WHERE b.id IN ?))`
+		args = append(args, bookIDs, bookIDs)
+	}
+	if opts.From != nil {
+		where += ` AND m.created_at >= ?`
+		args = append(args, opts.From.UTC())
+	}
+	if opts.To != nil {
+		where += ` AND m.created_at < ?`
+		args = append(args, opts.To.UTC())
+	}
+	args = append(args, pageSize, (page-1)*pageSize)
+	var rows []struct {
+		MessageModel
+		ConversationTitle string
+		ParentContent     string
+		ReplyContent      string
+		Score             float64
+		Total             int
+	}
+	err := s.db.Raw(`
+		SELECT m.id, m.conversation_id, m.user_id, m.book_id, m.parent_id, m.role, m.content, m.sources, m.metadata, m.created_at,
+			c.title AS conversation_title,
+			COALESCE(p.content, '') AS parent_content,
+			COALESCE((
+				SELECT a.content FROM message_models a
+				WHERE a.parent_id = m.id AND a.role = 'assistant'
+				ORDER BY a.created_at DESC LIMIT 1
+			), '') AS reply_content,
+			ts_rank_cd(m.search_tsv, q) AS score,
+			COUNT(*) OVER () AS total
+		FROM message_models m
+		JOIN conversation_models c ON c.id = m.conversation_id
+		LEFT JOIN message_models p ON p.id = m.parent_id,
+		to_tsquery('simple', ?) AS q
+		WHERE `+where+`
+		ORDER BY score DESC, m.created_at DESC
+		LIMIT ? OFFSET ?`, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	hits := make([]MessageSearchHit, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		total = row.Total
+		hits = append(hits, MessageSearchHit{
+			Message:           messageFromModel(row.MessageModel),
+			ConversationTitle: row.ConversationTitle,
+			ParentContent:     row.ParentContent,
+			ReplyContent:      row.ReplyContent,
+			Score:             row.Score,
+		})
+	}
+	return hits, total, nil
+}
+
 // UpdateConversation refreshes title and last-message timestamp.
 func (s *GormStore) UpdateConversation(id string, title string, lastMessageAt time.Time) error {
 	updates := map[string]any{
@@ -952,10 +1149,88 @@ func (s *GormStore) DeleteConversation(id string) error {
 		if err := tx.Delete(&MessageModel{}, "conversation_id = ?", id).Error; err != nil {
 			return err
 		}
+		if err := tx.Delete(&ConversationShareModel{}, "conversation_id = ?", id).Error; err != nil {
+			return err
+		}
 		return tx.Delete(&ConversationModel{}, "id = ?", id).Error
 	})
 }
 
+// CreateConversationShare stores a share link and its audit entry together.
+func (s *GormStore) CreateConversationShare(share domain.ConversationShare, tokenHash string, audit domain.AdminAuditLog) error {
+	model := ConversationShareModel{
+		ID:              share.ID,
+		TokenHash:       tokenHash,
+		ConversationID:  share.ConversationID,
+		UserID:          share.UserID,
+		LeafMessageID:   strings.TrimSpace(share.LeafMessageID),
+		IncludeSnippets: share.IncludeSnippets,
+		ExpiresAt:       normalizeTimePtr(share.ExpiresAt),
+		CreatedAt:       share.CreatedAt.UTC(),
+	}
+	auditModel, err := adminAuditLogToModel(audit)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		return tx.Create(&auditModel).Error
+	})
+}
+
+// GetConversationShare fetches a share link by ID.
+func (s *GormStore) GetConversationShare(id string) (domain.ConversationShare, bool, error) {
+	return s.getConversationShare("id = ?", strings.TrimSpace(id))
+}
+
+// GetConversationShareByTokenHash fetches the share link a token opens.
+func (s *GormStore) GetConversationShareByTokenHash(tokenHash string) (domain.ConversationShare, bool, error) {
+	return s.getConversationShare("token_hash = ?", tokenHash)
+}
+
+func (s *GormStore) getConversationShare(query string, arg string) (domain.ConversationShare, bool, error) {
+	var model ConversationShareModel
+	if err := s.db.First(&model, query, arg).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domain.ConversationShare{}, false, nil
+		}
+		return domain.ConversationShare{}, false, err
+	}
+	return conversationShareFromModel(model), true, nil
+}
+
+// ListConversationShares returns a conversation's share links, newest first.
+func (s *GormStore) ListConversationShares(conversationID string) ([]domain.ConversationShare, error) {
+	var models []ConversationShareModel
+	if err := s.db.Where("conversation_id = ?", conversationID).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	items := make([]domain.ConversationShare, 0, len(models))
+	for _, model := range models {
+		items = append(items, conversationShareFromModel(model))
+	}
+	return items, nil
+}
+
+// RevokeConversationShare marks a share link revoked and records the audit
+// entry in the same transaction.
+func (s *GormStore) RevokeConversationShare(id string, at time.Time, audit domain.AdminAuditLog) error {
+	auditModel, err := adminAuditLogToModel(audit)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ConversationShareModel{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", at.UTC()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&auditModel).Error
+	})
+}
+
 // AppendMessage records a message.
 func (s *GormStore) AppendMessage(bookID string, msg domain.Message) error {
 	model := messageToModel(msg)
@@ -983,18 +1258,7 @@ func (s *GormStore) SaveConversationExchange(conversation domain.Conversation, c
 		if err := tx.Create(&userModel).Error; err != nil {
 			return err
 		}
-		assistantModel := messageToModel(assistantMsg)
-		if err := tx.Create(&assistantModel).Error; err != nil {
-			return err
-		}
-		updates := map[string]any{
-			"updated_at":      time.Now().UTC(),
-			"last_message_at": assistantMsg.CreatedAt.UTC(),
-		}
-		if strings.TrimSpace(conversation.Title) != "" {
-			updates["title"] = strings.TrimSpace(conversation.Title)
-		}
-		if err := tx.Model(&ConversationModel{}).Where("id = ?", conversation.ID).Updates(updates).Error; err != nil {
+		if err := saveConversationReplyTx(tx, conversation, assistantMsg); err != nil {
 			return err
 		}
 		if record != nil {
@@ -1006,6 +1270,63 @@ func (s *GormStore) SaveConversationExchange(conversation domain.Conversation, c
 	})
 }
 
+// SaveConversationReply stores a new answer to a question already in the
+// conversation and makes it the active leaf, completing the idempotency
+// record when there is one.
+func (s *GormStore) SaveConversationReply(conversation domain.Conversation, assistantMsg domain.Message, record *domain.IdempotencyRecord) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveConversationReplyTx(tx, conversation, assistantMsg); err != nil {
+			return err
+		}
+		if record != nil {
+			return saveIdempotencyRecordTx(tx, *record)
+		}
+		return nil
+	})
+}
+
+func saveConversationReplyTx(tx *gorm.DB, conversation domain.Conversation, assistantMsg domain.Message) error {
+	assistantModel := messageToModel(assistantMsg)
+	if err := tx.Create(&assistantModel).Error; err != nil {
+		return err
+	}
+	if usage := assistantMsg.Metadata.Usage; usage != nil && len(usage.Calls) > 0 {
+		usageModel := LLMUsageModel{
+			MessageID:        assistantMsg.ID,
+			ConversationID:   conversation.ID,
+			UserID:           assistantMsg.UserID,
+			BookID:           assistantMsg.BookID,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+			CostUSD:          usage.CostUSD,
+			Calls:            len(usage.Calls),
+			CreatedAt:        assistantMsg.CreatedAt.UTC(),
+		}
+		if err := tx.Create(&usageModel).Error; err != nil {
+			return err
+		}
+	}
+	updates := map[string]any{
+		"updated_at":      time.Now().UTC(),
+		"last_message_at": assistantMsg.CreatedAt.UTC(),
+		"active_leaf_id":  assistantMsg.ID,
+	}
+	if strings.TrimSpace(conversation.Title) != "" {
+		updates["title"] = strings.TrimSpace(conversation.Title)
+	}
+	return tx.Model(&ConversationModel{}).Where("id = ?", conversation.ID).Updates(updates).Error
+}
+
+// SetConversationActiveLeaf switches the branch a conversation shows and
+// continues.
+func (s *GormStore) SetConversationActiveLeaf(conversationID, leafID string) error {
+	return s.db.Model(&ConversationModel{}).Where("id = ?", conversationID).Updates(map[string]any{
+		"active_leaf_id": leafID,
+		"updated_at":     time.Now().UTC(),
+	}).Error
+}
+
 // ListMessages returns recent messages for a book (newest first, then reversed to chronological).
 func (s *GormStore) ListMessages(bookID string, limit int) ([]domain.Message, error) {
 	if limit <= 0 {
@@ -1050,6 +1371,46 @@ func (s *GormStore) ListConversationMessages(conversationID string, limit int) (
 	return msgs, nil
 }
 
+// SaveConversationSummary stores a new rolling summary and records the tokens
+// spent producing it under usageID. The summary is only replaced while the
+// stored one still ends at previousThrough, so concurrent updates don't
+// overwrite each other.
+func (s *GormStore) SaveConversationSummary(conversation domain.Conversation, previousThrough *time.Time, usageID string, usage *domain.LLMUsage) error {
+	if conversation.SummarizedThrough == nil {
+		return fmt.Errorf("summarized through time required")
+	}
+	through := conversation.SummarizedThrough.UTC()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&ConversationModel{}).Where("id = ?", conversation.ID)
+		if previousThrough == nil {
+			query = query.Where("summarized_through IS NULL")
+		} else {
+			query = query.Where("summarized_through = ?", previousThrough.UTC())
+		}
+		if err := query.Updates(map[string]any{
+			"summary":            conversation.Summary,
+			"summarized_through": through,
+		}).Error; err != nil {
+			return err
+		}
+		if usage == nil || len(usage.Calls) == 0 || strings.TrimSpace(usageID) == "" {
+			return nil
+		}
+		return tx.Create(&LLMUsageModel{
+			MessageID:        usageID,
+			ConversationID:   conversation.ID,
+			UserID:           conversation.UserID,
+			BookID:           conversation.BookID,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+			CostUSD:          usage.CostUSD,
+			Calls:            len(usage.Calls),
+			CreatedAt:        time.Now().UTC(),
+		}).Error
+	})
+}
+
 // ReplaceChunks replaces all chunks for a book.
 func (s *GormStore) ReplaceChunks(bookID string, chunks []domain.Chunk) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
@@ -1206,6 +1567,51 @@ func (s *GormStore) UpdateChunkIndexStatus(chunkIDs []string, backend domain.Chu
 	return s.db.Model(&ChunkIndexStatusModel{}).Where("chunk_id IN ?", clean).Updates(updates).Error
 }
 
+const indexConsistencyReportRetention = 7 * 24 * time.Hour
+
+// SaveIndexConsistencyReport persists one reconciler pass and prunes old reports.
+func (s *GormStore) SaveIndexConsistencyReport(report domain.IndexConsistencyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshal index consistency report: %w", err)
+	}
+	model := IndexConsistencyReportModel{
+		ID:                strings.TrimSpace(report.ID),
+		AutoRepair:        report.AutoRepair,
+		BooksChecked:      report.BooksChecked,
+		InconsistentBooks: report.InconsistentBooks,
+		Report:            datatypes.JSON(data),
+		StartedAt:         report.StartedAt.UTC(),
+		FinishedAt:        report.FinishedAt.UTC(),
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		cutoff := model.FinishedAt.Add(-indexConsistencyReportRetention)
+		return tx.Where("finished_at < ?", cutoff).Delete(&IndexConsistencyReportModel{}).Error
+	})
+}
+
+// GetLatestIndexConsistencyReport returns the most recent reconciler pass.
+func (s *GormStore) GetLatestIndexConsistencyReport() (domain.IndexConsistencyReport, bool, error) {
+	var model IndexConsistencyReportModel
+	if err := s.db.Order("finished_at DESC").First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domain.IndexConsistencyReport{}, false, nil
+		}
+		return domain.IndexConsistencyReport{}, false, err
+	}
+	var report domain.IndexConsistencyReport
+	if len(model.Report) > 0 {
+		if err := json.Unmarshal(model.Report, &report); err != nil {
+			return domain.IndexConsistencyReport{}, false, fmt.Errorf("unmarshal index consistency report: %w", err)
+		}
+	}
+	report.ID = model.ID
+	return report, true, nil
+}
+
 // SaveAdminAuditLog persists an admin audit event.
 func (s *GormStore) SaveAdminAuditLog(entry domain.AdminAuditLog) error {
 	model, err := adminAuditLogToModel(entry)
@@ -1259,6 +1665,47 @@ func (s *GormStore) ListAdminAuditLogs(opts AdminAuditLogListOptions) ([]domain.
 	return items, int(total), nil
 }
 
+func (s *GormStore) SaveUserQuotaOverride(override domain.UserQuotaOverride) error {
+	model := UserQuotaModel{
+		UserID:          strings.TrimSpace(override.UserID),
+		Plan:            strings.TrimSpace(override.Plan),
+		DailyQuestions:  override.DailyQuestions,
+		MonthlyTokens:   override.MonthlyTokens,
+		MaxBooks:        override.MaxBooks,
+		MaxStorageBytes: override.MaxStorageBytes,
+		UpdatedBy:       override.UpdatedBy,
+		UpdatedAt:       override.UpdatedAt.UTC(),
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan", "daily_questions", "monthly_tokens", "max_books", "max_storage_bytes", "updated_by", "updated_at"}),
+	}).Create(&model).Error
+}
+
+func (s *GormStore) GetUserQuotaOverride(userID string) (domain.UserQuotaOverride, bool, error) {
+	var model UserQuotaModel
+	if err := s.db.First(&model, "user_id = ?", strings.TrimSpace(userID)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domain.UserQuotaOverride{}, false, nil
+		}
+		return domain.UserQuotaOverride{}, false, err
+	}
+	return domain.UserQuotaOverride{
+		UserID:          model.UserID,
+		Plan:            model.Plan,
+		DailyQuestions:  model.DailyQuestions,
+		MonthlyTokens:   model.MonthlyTokens,
+		MaxBooks:        model.MaxBooks,
+		MaxStorageBytes: model.MaxStorageBytes,
+		UpdatedBy:       model.UpdatedBy,
+		UpdatedAt:       model.UpdatedAt,
+	}, true, nil
+}
+
+func (s *GormStore) DeleteUserQuotaOverride(userID string) error {
+	return s.db.Delete(&UserQuotaModel{}, "user_id = ?", strings.TrimSpace(userID)).Error
+}
+
 // GetAdminOverview returns aggregate metrics for admin dashboard.
 func (s *GormStore) GetAdminOverview(windowStart time.Time, windowHours int) (domain.AdminOverview, error) {
 	if windowHours <= 0 {
@@ -1322,6 +1769,79 @@ func (s *GormStore) GetAdminOverview(windowStart time.Time, windowHours int) (do
 	}, nil
 }
 
+// GetLLMUsageReport sums recorded LLM usage in [From, To) overall and grouped
+// by user, book and UTC day. User and book groups keep the Limit heaviest.
+func (s *GormStore) GetLLMUsageReport(opts LLMUsageReportOptions) (domain.LLMUsageReport, error) {
+	to := opts.To.UTC()
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	from := opts.From.UTC()
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	scoped := func() *gorm.DB {
+		query := s.db.Model(&LLMUsageModel{}).Where("created_at >= ? AND created_at < ?", from, to)
+		if v := strings.TrimSpace(opts.UserID); v != "" {
+			query = query.Where("user_id = ?", v)
+		}
+		if v := strings.TrimSpace(opts.BookID); v != "" {
+			query = query.Where("book_id = ?", v)
+		}
+		return query
+	}
+	const sums = "COUNT(*) AS requests, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens, COALESCE(SUM(cost_usd), 0) AS cost_usd"
+	type usageRow struct {
+		Key              string
+		Requests         int
+		PromptTokens     int64
+		CompletionTokens int64
+		TotalTokens      int64
+		CostUSD          float64 `gorm:"column:cost_usd"`
+	}
+	toBuckets := func(rows []usageRow) []domain.LLMUsageBucket {
+		out := make([]domain.LLMUsageBucket, 0, len(rows))
+		for _, row := range rows {
+			out = append(out, domain.LLMUsageBucket{Key: row.Key, LLMUsageTotals: domain.LLMUsageTotals{
+				Requests:         row.Requests,
+				PromptTokens:     row.PromptTokens,
+				CompletionTokens: row.CompletionTokens,
+				TotalTokens:      row.TotalTokens,
+				CostUSD:          row.CostUSD,
+			}})
+		}
+		return out
+	}
+
+	var total usageRow
+	if err := scoped().Select(sums).Scan(&total).Error; err != nil {
+		return domain.LLMUsageReport{}, err
+	}
+	var byUser, byBook, byDay []usageRow
+	if err := scoped().Select("user_id AS key, " + sums).Group("user_id").Order("total_tokens DESC, key").Limit(limit).Scan(&byUser).Error; err != nil {
+		return domain.LLMUsageReport{}, err
+	}
+	if err := scoped().Select("book_id AS key, " + sums).Group("book_id").Order("total_tokens DESC, key").Limit(limit).Scan(&byBook).Error; err != nil {
+		return domain.LLMUsageReport{}, err
+	}
+	day := "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	if err := scoped().Select(day + " AS key, " + sums).Group(day).Order("key").Scan(&byDay).Error; err != nil {
+		return domain.LLMUsageReport{}, err
+	}
+	return domain.LLMUsageReport{
+		From:   from,
+		To:     to,
+		Totals: toBuckets([]usageRow{total})[0].LLMUsageTotals,
+		ByUser: toBuckets(byUser),
+		ByBook: toBuckets(byBook),
+		ByDay:  toBuckets(byDay),
+	}, nil
+}
+
 // SaveEvalDataset persists an eval dataset.
 func (s *GormStore) SaveEvalDataset(dataset domain.EvalDataset) error {
 	model, err := evalDatasetToModel(dataset)
@@ -1560,6 +2080,10 @@ func (s *GormStore) GetAdminEvalOverview(windowStart time.Time) (domain.AdminEva
 	if err := s.db.Model(&EvalRunModel{}).Where("created_at >= ? AND gate_status = ?", start, string(domain.EvalGateStatusFailed)).Count(&recentGateFailed).Error; err != nil {
 		return domain.AdminEvalOverview{}, err
 	}
+	pendingFeedback, err := countStatus(&AnswerFeedbackModel{}, "status", string(domain.AnswerFeedbackStatusPending))
+	if err != nil {
+		return domain.AdminEvalOverview{}, err
+	}
 	return domain.AdminEvalOverview{
 		TotalDatasets:    int(totalDatasets),
 		ActiveDatasets:   int(activeDatasets),
@@ -1571,11 +2095,110 @@ func (s *GormStore) GetAdminEvalOverview(windowStart time.Time) (domain.AdminEva
 		CanceledRuns:     int(canceledRuns),
 		RecentRuns:       int(recentRuns),
 		RecentGateFailed: int(recentGateFailed),
+		PendingFeedback:  int(pendingFeedback),
 		SuccessRate:      safeDivFloat(float64(successfulRuns), float64(totalRuns)),
 		RefreshedAt:      time.Now().UTC(),
 	}, nil
 }
 
+// SaveAnswerFeedback upserts a user's feedback on an assistant message.
+func (s *GormStore) SaveAnswerFeedback(feedback domain.AnswerFeedback) error {
+	model, err := answerFeedbackToModel(feedback)
+	if err != nil {
+		return err
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "message_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"rating", "reason", "corrected_chunk_ids", "question", "answer", "answer_trace", "selected_chunk_ids",
+			"status", "review_note", "reviewed_by", "reviewed_at", "dataset_id", "updated_at",
+		}),
+	}).Create(&model).Error
+}
+
+// GetAnswerFeedback fetches feedback by ID.
+func (s *GormStore) GetAnswerFeedback(id string) (domain.AnswerFeedback, bool, error) {
+	var model AnswerFeedbackModel
+	if err := s.db.First(&model, "id = ?", strings.TrimSpace(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domain.AnswerFeedback{}, false, nil
+		}
+		return domain.AnswerFeedback{}, false, err
+	}
+	item, err := answerFeedbackFromModel(model)
+	if err != nil {
+		return domain.AnswerFeedback{}, false, err
+	}
+	return item, true, nil
+}
+
+// GetAnswerFeedbackByMessage fetches a user's feedback on a message.
+func (s *GormStore) GetAnswerFeedbackByMessage(messageID, userID string) (domain.AnswerFeedback, bool, error) {
+	var model AnswerFeedbackModel
+	if err := s.db.First(&model, "message_id = ? AND user_id = ?", strings.TrimSpace(messageID), strings.TrimSpace(userID)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domain.AnswerFeedback{}, false, nil
+		}
+		return domain.AnswerFeedback{}, false, err
+	}
+	item, err := answerFeedbackFromModel(model)
+	if err != nil {
+		return domain.AnswerFeedback{}, false, err
+	}
+	return item, true, nil
+}
+
+// ListAnswerFeedback lists feedback with filtering, oldest first so the
+// review queue is worked in arrival order.
+func (s *GormStore) ListAnswerFeedback(opts AnswerFeedbackListOptions) ([]domain.AnswerFeedback, int, error) {
+	page, pageSize := normalizePage(opts.Page, opts.PageSize)
+	tx := s.db.Model(&AnswerFeedbackModel{})
+	if status := strings.TrimSpace(strings.ToLower(opts.Status)); status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if rating := strings.TrimSpace(strings.ToLower(opts.Rating)); rating != "" {
+		tx = tx.Where("rating = ?", rating)
+	}
+	if bookID := strings.TrimSpace(opts.BookID); bookID != "" {
+		tx = tx.Where("book_id = ?", bookID)
+	}
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var models []AnswerFeedbackModel
+	if err := tx.Order("created_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	items := make([]domain.AnswerFeedback, 0, len(models))
+	for _, model := range models {
+		item, err := answerFeedbackFromModel(model)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	return items, int(total), nil
+}
+
+// MarkAnswerFeedbackConverted records the dataset version feedback was
+// converted into.
+func (s *GormStore) MarkAnswerFeedbackConverted(ids []string, datasetID string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.db.Model(&AnswerFeedbackModel{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":     string(domain.AnswerFeedbackStatusConverted),
+			"dataset_id": strings.TrimSpace(datasetID),
+			"updated_at": at.UTC(),
+		}).Error
+}
+
 func (s *GormStore) SaveIdempotencyRecord(record domain.IdempotencyRecord) error {
 	return saveIdempotencyRecordTx(s.db, record)
 }
@@ -1800,6 +2423,7 @@ func userProfileToModel(profile domain.UserProfile) UserProfileModel {
 		LoginCount:         profile.LoginCount,
 		LastLoginIP:        strings.TrimSpace(profile.LastLoginIP),
 		LastLoginUserAgent: strings.TrimSpace(profile.LastLoginUserAgent),
+		AnswerLanguage:     strings.TrimSpace(profile.AnswerLanguage),
 		CreatedAt:          profile.CreatedAt,
 		UpdatedAt:          profile.UpdatedAt,
 	}
@@ -1817,6 +2441,7 @@ func userProfileFromModel(m UserProfileModel) domain.UserProfile {
 		LoginCount:         m.LoginCount,
 		LastLoginIP:        m.LastLoginIP,
 		LastLoginUserAgent: m.LastLoginUserAgent,
+		AnswerLanguage:     m.AnswerLanguage,
 		CreatedAt:          m.CreatedAt,
 		UpdatedAt:          m.UpdatedAt,
 	}
@@ -1963,14 +2588,19 @@ func conversationToModel(c domain.Conversation) ConversationModel {
 		value := strings.TrimSpace(c.BookID)
 		bookID = &value
 	}
+	bookIDs, _ := marshalStringSliceJSON(c.BookIDs)
 	return ConversationModel{
-		ID:            c.ID,
-		UserID:        c.UserID,
-		BookID:        bookID,
-		Title:         c.Title,
-		LastMessageAt: c.LastMessageAt,
-		CreatedAt:     c.CreatedAt,
-		UpdatedAt:     c.UpdatedAt,
+		ID:                c.ID,
+		UserID:            c.UserID,
+		BookID:            bookID,
+		BookIDs:           bookIDs,
+		Title:             c.Title,
+		LastMessageAt:     c.LastMessageAt,
+		ActiveLeafID:      optionalString(c.ActiveLeafID),
+		Summary:           c.Summary,
+		SummarizedThrough: c.SummarizedThrough,
+		CreatedAt:         c.CreatedAt,
+		UpdatedAt:         c.UpdatedAt,
 	}
 }
 
@@ -1979,14 +2609,35 @@ func conversationFromModel(m ConversationModel) domain.Conversation {
 	if m.BookID != nil {
 		bookID = strings.TrimSpace(*m.BookID)
 	}
+	bookIDs, _ := unmarshalStringSliceJSON(m.BookIDs)
+	if len(bookIDs) == 0 {
+		bookIDs = nil
+	}
 	return domain.Conversation{
-		ID:            m.ID,
-		UserID:        m.UserID,
-		BookID:        bookID,
-		Title:         m.Title,
-		LastMessageAt: m.LastMessageAt,
-		CreatedAt:     m.CreatedAt,
-		UpdatedAt:     m.UpdatedAt,
+		ID:                m.ID,
+		UserID:            m.UserID,
+		BookID:            bookID,
+		BookIDs:           bookIDs,
+		Title:             m.Title,
+		LastMessageAt:     m.LastMessageAt,
+		ActiveLeafID:      derefString(m.ActiveLeafID),
+		Summary:           m.Summary,
+		SummarizedThrough: m.SummarizedThrough,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+func conversationShareFromModel(m ConversationShareModel) domain.ConversationShare {
+	return domain.ConversationShare{
+		ID:              m.ID,
+		ConversationID:  m.ConversationID,
+		UserID:          m.UserID,
+		LeafMessageID:   m.LeafMessageID,
+		IncludeSnippets: m.IncludeSnippets,
+		ExpiresAt:       normalizeTimePtr(m.ExpiresAt),
+		RevokedAt:       normalizeTimePtr(m.RevokedAt),
+		CreatedAt:       m.CreatedAt.UTC(),
 	}
 }
 
@@ -2003,10 +2654,12 @@ func messageToModel(msg domain.Message) MessageModel {
 		ConversationID: conversationID,
 		UserID:         msg.UserID,
 		BookID:         msg.BookID,
+		ParentID:       optionalString(msg.ParentID),
 		Role:           msg.Role,
 		Content:        msg.Content,
 		Sources:        rawSources,
 		Metadata:       rawMetadata,
+		SearchTerms:    strings.Join(retrieval.SearchTerms(msg.Content), " "),
 		CreatedAt:      msg.CreatedAt,
 	}
 }
@@ -2029,6 +2682,7 @@ func messageFromModel(m MessageModel) domain.Message {
 		ConversationID: conversationID,
 		UserID:         m.UserID,
 		BookID:         m.BookID,
+		ParentID:       derefString(m.ParentID),
 		Role:           m.Role,
 		Content:        m.Content,
 		Sources:        sources,
@@ -2175,6 +2829,90 @@ func evalDatasetFromModel(model EvalDatasetModel) (domain.EvalDataset, error) {
 	}, nil
 }
 
+func answerFeedbackToModel(feedback domain.AnswerFeedback) (AnswerFeedbackModel, error) {
+	corrected, err := marshalStringSliceJSON(feedback.CorrectedChunkIDs)
+	if err != nil {
+		return AnswerFeedbackModel{}, fmt.Errorf("marshal answer feedback chunks: %w", err)
+	}
+	selected, err := marshalStringSliceJSON(feedback.SelectedChunkIDs)
+	if err != nil {
+		return AnswerFeedbackModel{}, fmt.Errorf("marshal answer feedback chunks: %w", err)
+	}
+	var trace datatypes.JSON
+	if feedback.AnswerTrace != nil {
+		raw, err := json.Marshal(feedback.AnswerTrace)
+		if err != nil {
+			return AnswerFeedbackModel{}, fmt.Errorf("marshal answer feedback trace: %w", err)
+		}
+		trace = raw
+	}
+	var reviewedAt *time.Time
+	if feedback.ReviewedAt != nil {
+		value := feedback.ReviewedAt.UTC()
+		reviewedAt = &value
+	}
+	return AnswerFeedbackModel{
+		ID:                strings.TrimSpace(feedback.ID),
+		MessageID:         strings.TrimSpace(feedback.MessageID),
+		UserID:            strings.TrimSpace(feedback.UserID),
+		ConversationID:    strings.TrimSpace(feedback.ConversationID),
+		BookID:            strings.TrimSpace(feedback.BookID),
+		Rating:            string(feedback.Rating),
+		Reason:            strings.TrimSpace(feedback.Reason),
+		CorrectedChunkIDs: corrected,
+		Question:          feedback.Question,
+		Answer:            feedback.Answer,
+		AnswerTrace:       trace,
+		SelectedChunkIDs:  selected,
+		Status:            string(feedback.Status),
+		ReviewNote:        strings.TrimSpace(feedback.ReviewNote),
+		ReviewedBy:        strings.TrimSpace(feedback.ReviewedBy),
+		ReviewedAt:        reviewedAt,
+		DatasetID:         strings.TrimSpace(feedback.DatasetID),
+		CreatedAt:         feedback.CreatedAt.UTC(),
+		UpdatedAt:         feedback.UpdatedAt.UTC(),
+	}, nil
+}
+
+func answerFeedbackFromModel(model AnswerFeedbackModel) (domain.AnswerFeedback, error) {
+	corrected, err := unmarshalStringSliceJSON(model.CorrectedChunkIDs)
+	if err != nil {
+		return domain.AnswerFeedback{}, fmt.Errorf("unmarshal answer feedback chunks: %w", err)
+	}
+	selected, err := unmarshalStringSliceJSON(model.SelectedChunkIDs)
+	if err != nil {
+		return domain.AnswerFeedback{}, fmt.Errorf("unmarshal answer feedback chunks: %w", err)
+	}
+	var trace *domain.AnswerTrace
+	if len(model.AnswerTrace) > 0 && string(model.AnswerTrace) != "null" {
+		trace = &domain.AnswerTrace{}
+		if err := json.Unmarshal(model.AnswerTrace, trace); err != nil {
+			return domain.AnswerFeedback{}, fmt.Errorf("unmarshal answer feedback trace: %w", err)
+		}
+	}
+	return domain.AnswerFeedback{
+		ID:                model.ID,
+		MessageID:         model.MessageID,
+		ConversationID:    model.ConversationID,
+		UserID:            model.UserID,
+		BookID:            model.BookID,
+		Rating:            domain.AnswerFeedbackRating(model.Rating),
+		Reason:            model.Reason,
+		CorrectedChunkIDs: corrected,
+		Question:          model.Question,
+		Answer:            model.Answer,
+		AnswerTrace:       trace,
+		SelectedChunkIDs:  selected,
+		Status:            domain.AnswerFeedbackStatus(model.Status),
+		ReviewNote:        model.ReviewNote,
+		ReviewedBy:        model.ReviewedBy,
+		ReviewedAt:        model.ReviewedAt,
+		DatasetID:         model.DatasetID,
+		CreatedAt:         model.CreatedAt,
+		UpdatedAt:         model.UpdatedAt,
+	}, nil
+}
+
 func evalRunToModel(run domain.EvalRun) (EvalRunModel, error) {
 	params, err := marshalOptionalJSON(run.Params)
 	if err != nil {
@@ -2424,3 +3162,19 @@ func normalizeTimePtr(value *time.Time) *time.Time {
 	normalized := value.UTC()
 	return &normalized
 }
+
+// optionalString maps a blank string to a NULL column.
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
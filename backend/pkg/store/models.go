@@ -40,6 +40,7 @@ type UserProfileModel struct {
 	LoginCount         int        `gorm:"not null;default:0"`
 	LastLoginIP        string     `gorm:"not null;default:''"`
 	LastLoginUserAgent string     `gorm:"not null;default:''"`
+	AnswerLanguage     string     `gorm:"not null;default:''"`
 	CreatedAt          time.Time  `gorm:"not null"`
 	UpdatedAt          time.Time  `gorm:"not null"`
 }
@@ -74,13 +75,31 @@ type BookModel struct {
 }
 
 type ConversationModel struct {
-	ID            string     `gorm:"primaryKey"`
-	UserID        string     `gorm:"not null;index"`
-	BookID        *string    `gorm:"index"`
-	Title         string     `gorm:"not null"`
-	LastMessageAt *time.Time `gorm:"index"`
-	CreatedAt     time.Time  `gorm:"not null"`
-	UpdatedAt     time.Time  `gorm:"not null"`
+	ID            string         `gorm:"primaryKey"`
+	UserID        string         `gorm:"not null;index"`
+	BookID        *string        `gorm:"index"`
+	BookIDs       datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'"`
+	Title         string         `gorm:"not null"`
+	LastMessageAt *time.Time     `gorm:"index"`
+	ActiveLeafID  *string
+	// Summary and SummarizedThrough hold the rolling conversation summary.
+	Summary           string `gorm:"type:text;not null;default:''"`
+	SummarizedThrough *time.Time
+	CreatedAt         time.Time `gorm:"not null"`
+	UpdatedAt         time.Time `gorm:"not null"`
+}
+
+// ConversationShareModel is a share link; the token itself is never stored.
+type ConversationShareModel struct {
+	ID              string `gorm:"primaryKey"`
+	TokenHash       string `gorm:"not null;uniqueIndex"`
+	ConversationID  string `gorm:"not null;index"`
+	UserID          string `gorm:"not null;index"`
+	LeafMessageID   string `gorm:"not null;default:''"`
+	IncludeSnippets bool   `gorm:"not null;default:false"`
+	ExpiresAt       *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time `gorm:"not null"`
 }
 
 type MessageModel struct {
@@ -88,11 +107,43 @@ type MessageModel struct {
 	ConversationID *string        `gorm:"index"`
 	UserID         string         `gorm:"index"`
 	BookID         string         `gorm:"not null;index"`
+	ParentID       *string        `gorm:"index"`
 	Role           string         `gorm:"not null"`
 	Content        string         `gorm:"not null"`
 	Sources        datatypes.JSON `gorm:"type:jsonb"`
 	Metadata       datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'"`
-	CreatedAt      time.Time      `gorm:"not null;index"`
+	// SearchTerms is the content split by retrieval.SearchTerms; the
+	// search_tsv column generated from it backs conversation search.
+	SearchTerms string    `gorm:"type:text;not null;default:''"`
+	CreatedAt   time.Time `gorm:"not null;index"`
+}
+
+// LLMUsageModel is one row per answered question, denormalised from the
+// assistant message usage metadata so admin reports aggregate cheaply.
+type LLMUsageModel struct {
+	MessageID        string    `gorm:"primaryKey"`
+	ConversationID   string    `gorm:"index"`
+	UserID           string    `gorm:"not null;index"`
+	BookID           string    `gorm:"not null;index"`
+	PromptTokens     int       `gorm:"not null;default:0"`
+	CompletionTokens int       `gorm:"not null;default:0"`
+	TotalTokens      int       `gorm:"not null;default:0"`
+	CostUSD          float64   `gorm:"not null;default:0"`
+	Calls            int       `gorm:"not null;default:0"`
+	CreatedAt        time.Time `gorm:"not null;index"`
+}
+
+// UserQuotaModel stores an admin override of a user's quota plan or limits.
+// Nil limit columns inherit the plan.
+type UserQuotaModel struct {
+	UserID          string `gorm:"primaryKey"`
+	Plan            string `gorm:"not null;default:''"`
+	DailyQuestions  *int
+	MonthlyTokens   *int64
+	MaxBooks        *int
+	MaxStorageBytes *int64
+	UpdatedBy       string    `gorm:"not null;default:''"`
+	UpdatedAt       time.Time `gorm:"not null"`
 }
 
 type ChunkModel struct {
@@ -118,6 +169,16 @@ type ChunkIndexStatusModel struct {
 	UpdatedAt          time.Time `gorm:"not null;index"`
 }
 
+type IndexConsistencyReportModel struct {
+	ID                string         `gorm:"primaryKey"`
+	AutoRepair        bool           `gorm:"not null;default:false"`
+	BooksChecked      int            `gorm:"not null;default:0"`
+	InconsistentBooks int            `gorm:"not null;default:0"`
+	Report            datatypes.JSON `gorm:"type:jsonb"`
+	StartedAt         time.Time      `gorm:"not null"`
+	FinishedAt        time.Time      `gorm:"not null;index"`
+}
+
 type AdminAuditLogModel struct {
 	ID         string         `gorm:"primaryKey"`
 	ActorID    string         `gorm:"not null;index"`
@@ -169,6 +230,29 @@ type EvalRunModel struct {
 	UpdatedAt      time.Time  `gorm:"not null;index"`
 }
 
+// AnswerFeedbackModel keeps one feedback row per user and assistant message.
+type AnswerFeedbackModel struct {
+	ID                string `gorm:"primaryKey"`
+	MessageID         string `gorm:"not null;uniqueIndex:idx_answer_feedback_message_user,priority:1"`
+	UserID            string `gorm:"not null;uniqueIndex:idx_answer_feedback_message_user,priority:2"`
+	ConversationID    string `gorm:"not null;index"`
+	BookID            string `gorm:"index"`
+	Rating            string `gorm:"not null;index"`
+	Reason            string
+	CorrectedChunkIDs datatypes.JSON `gorm:"type:jsonb"`
+	Question          string         `gorm:"not null"`
+	Answer            string         `gorm:"not null"`
+	AnswerTrace       datatypes.JSON `gorm:"type:jsonb"`
+	SelectedChunkIDs  datatypes.JSON `gorm:"type:jsonb"`
+	Status            string         `gorm:"not null;index"`
+	ReviewNote        string
+	ReviewedBy        string
+	ReviewedAt        *time.Time
+	DatasetID         string    `gorm:"index"`
+	CreatedAt         time.Time `gorm:"not null;index"`
+	UpdatedAt         time.Time `gorm:"not null;index"`
+}
+
 type IdempotencyRecordModel struct {
 	ID             string `gorm:"primaryKey"`
 	Scope          string `gorm:"not null;uniqueIndex:idx_idempotency_scope_actor_key,priority:1"`
@@ -59,6 +59,44 @@ type EvalRunListOptions struct {
 	PageSize      int
 }
 
+type AnswerFeedbackListOptions struct {
+	Status   string
+	Rating   string
+	BookID   string
+	Page     int
+	PageSize int
+}
+
+// MessageSearchOptions filters a full-text search over a user's conversation
+// messages. Terms are SearchQueryTerms output and must all match.
+type MessageSearchOptions struct {
+	UserID   string
+	Terms    []string
+	BookIDs  []string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// MessageSearchHit is a matching message with the exchange around it: the
+// question it answers, or the latest answer to it.
+type MessageSearchHit struct {
+	Message           domain.Message
+	ConversationTitle string
+	ParentContent     string
+	ReplyContent      string
+	Score             float64
+}
+
+type LLMUsageReportOptions struct {
+	From   time.Time
+	To     time.Time
+	UserID string
+	BookID string
+	Limit  int
+}
+
 // Store defines persistence operations for users, books, and messages.
 type Store interface {
 	// users
@@ -92,6 +130,7 @@ type Store interface {
 	ListBooks() ([]domain.Book, error)
 	ListBooksWithOptions(BookListOptions) ([]domain.Book, int, error)
 	ListBooksByOwner(ownerID string) ([]domain.Book, error)
+	GetOwnerBookUsage(ownerID string) (books int, bytes int64, err error)
 	GetBook(id string) (domain.Book, bool, error)
 	GetBookIncludingDeleted(id string) (domain.Book, bool, error)
 	ListBooksPendingCleanup(limit int) ([]domain.Book, error)
@@ -110,7 +149,16 @@ type Store interface {
 	DeleteConversation(id string) error
 	AppendConversationMessage(conversationID string, msg domain.Message) error
 	ListConversationMessages(conversationID string, limit int) ([]domain.Message, error)
+	SaveConversationSummary(conversation domain.Conversation, previousThrough *time.Time, usageID string, usage *domain.LLMUsage) error
 	SaveConversationExchange(domain.Conversation, bool, domain.Message, domain.Message, *domain.IdempotencyRecord) error
+	SaveConversationReply(conversation domain.Conversation, assistantMsg domain.Message, record *domain.IdempotencyRecord) error
+	SetConversationActiveLeaf(conversationID, leafID string) error
+	SearchConversationMessages(MessageSearchOptions) ([]MessageSearchHit, int, error)
+	CreateConversationShare(share domain.ConversationShare, tokenHash string, audit domain.AdminAuditLog) error
+	GetConversationShare(id string) (domain.ConversationShare, bool, error)
+	GetConversationShareByTokenHash(tokenHash string) (domain.ConversationShare, bool, error)
+	ListConversationShares(conversationID string) ([]domain.ConversationShare, error)
+	RevokeConversationShare(id string, at time.Time, audit domain.AdminAuditLog) error
 
 	// chunks
 	ReplaceChunks(bookID string, chunks []domain.Chunk) error
@@ -118,11 +166,20 @@ type Store interface {
 	GetChunksByIDs(ids []string) ([]domain.Chunk, error)
 	ListChunkIndexStatusesByBook(bookID string) ([]domain.ChunkIndexStatus, error)
 	UpdateChunkIndexStatus(chunkIDs []string, backend domain.ChunkIndexBackend, status domain.ChunkIndexSyncStatus, embeddingModel string, embeddingDim int, errMsg string) error
+	SaveIndexConsistencyReport(domain.IndexConsistencyReport) error
+	GetLatestIndexConsistencyReport() (domain.IndexConsistencyReport, bool, error)
+	// TryAdvisoryLock runs fn while holding the Postgres advisory lock lockID.
+	// It returns false without running fn when another session holds the lock.
+	TryAdvisoryLock(lockID int64, fn func() error) (bool, error)
 
 	// admin
 	SaveAdminAuditLog(domain.AdminAuditLog) error
 	ListAdminAuditLogs(AdminAuditLogListOptions) ([]domain.AdminAuditLog, int, error)
 	GetAdminOverview(windowStart time.Time, windowHours int) (domain.AdminOverview, error)
+	GetLLMUsageReport(LLMUsageReportOptions) (domain.LLMUsageReport, error)
+	SaveUserQuotaOverride(domain.UserQuotaOverride) error
+	GetUserQuotaOverride(userID string) (domain.UserQuotaOverride, bool, error)
+	DeleteUserQuotaOverride(userID string) error
 	SaveEvalDataset(domain.EvalDataset) error
 	GetEvalDataset(id string) (domain.EvalDataset, bool, error)
 	ListEvalDatasets(EvalDatasetListOptions) ([]domain.EvalDataset, int, error)
@@ -134,6 +191,11 @@ type Store interface {
 	ListEvalRuns(EvalRunListOptions) ([]domain.EvalRun, int, error)
 	CountEvalRunsByDataset(datasetID string) (int, error)
 	GetAdminEvalOverview(windowStart time.Time) (domain.AdminEvalOverview, error)
+	SaveAnswerFeedback(domain.AnswerFeedback) error
+	GetAnswerFeedback(id string) (domain.AnswerFeedback, bool, error)
+	GetAnswerFeedbackByMessage(messageID, userID string) (domain.AnswerFeedback, bool, error)
+	ListAnswerFeedback(AnswerFeedbackListOptions) ([]domain.AnswerFeedback, int, error)
+	MarkAnswerFeedbackConverted(ids []string, datasetID string, at time.Time) error
 	SaveIdempotencyRecord(domain.IdempotencyRecord) error
 	GetIdempotencyRecord(scope, actorID, key string) (domain.IdempotencyRecord, bool, error)
 	ClaimOutboxMessages(topic string, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"onebookai/internal/quota"
 	"onebookai/internal/util"
 	"onebookai/services/auth/internal/app"
 	"onebookai/services/auth/internal/config"
@@ -42,6 +43,10 @@ func main() {
 	if err != nil {
 		util.Fatal("failed to parse eval worker poll interval", "err", err)
 	}
+	quotaPlans, err := quota.ParsePlans(cfg.QuotaPlans, cfg.QuotaDefaultPlan)
+	if err != nil {
+		util.Fatal("invalid quota plans", "err", err)
+	}
 	verifyPublicKeys, err := config.ParseVerifyPublicKeys(cfg.JWTVerifyPublicKeys)
 	if err != nil {
 		util.Fatal("failed to parse jwt verify public keys", "err", err)
@@ -67,6 +72,7 @@ func main() {
 		MinioUseSSL:         cfg.MinioUseSSL,
 		EvalStorageDir:      cfg.EvalStorageDir,
 		EvalWorkerPoll:      evalWorkerPoll,
+		QuotaPlans:          quotaPlans,
 	})
 	if err != nil {
 		util.Fatal("failed to init app", "err", err)
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"onebookai/internal/quota"
 	"onebookai/internal/util"
 	"onebookai/pkg/auth"
 	"onebookai/pkg/domain"
@@ -44,6 +45,7 @@ type Config struct {
 	MinioUseSSL         bool
 	EvalStorageDir      string
 	EvalWorkerPoll      time.Duration
+	QuotaPlans          quota.Plans
 }
 
 // App is the core application service wiring together storage and auth logic.
@@ -54,6 +56,8 @@ type App struct {
 	avatarObjects storage.ObjectStore
 	refreshTTL    time.Duration
 	evals         *evalCenter
+	quotas        quota.Plans
+	quotaCounter  *quota.Counter
 }
 
 type LoginActivity struct {
@@ -145,6 +149,14 @@ func New(cfg Config) (*App, error) {
 		return nil, fmt.Errorf("init eval center: %w", err)
 	}
 
+	var quotaCounter *quota.Counter
+	if cfg.QuotaPlans.Enabled() {
+		quotaCounter, err = quota.NewRedisCounter(cfg.RedisAddr, cfg.RedisPassword, "")
+		if err != nil {
+			return nil, fmt.Errorf("init quota counter: %w", err)
+		}
+	}
+
 	return &App{
 		store:         dataStore,
 		sessions:      sessionStore,
@@ -152,6 +164,8 @@ func New(cfg Config) (*App, error) {
 		avatarObjects: avatarObjects,
 		refreshTTL:    cfg.RefreshTTL,
 		evals:         evals,
+		quotas:        cfg.QuotaPlans,
+		quotaCounter:  quotaCounter,
 	}, nil
 }
 
@@ -429,6 +443,7 @@ func (a *App) userWithProfile(user domain.User, profile *domain.UserProfile) dom
 	user.AvatarURL = effectiveAvatarURL(user.ID, *profile)
 	user.LastLoginAt = profile.LastLoginAt
 	user.LoginCount = profile.LoginCount
+	user.AnswerLanguage = profile.AnswerLanguage
 	return user
 }
 
@@ -471,7 +486,15 @@ func (a *App) ensureUserProfile(userID, displayName, avatarURL string) error {
 	return a.store.SaveUserProfile(profile)
 }
 
-func (a *App) UpdateMyProfile(user domain.User, email *string, displayName *string) (domain.User, error) {
+func (a *App) UpdateMyProfile(user domain.User, email *string, displayName *string, answerLanguage *string) (domain.User, error) {
+	var language string
+	if answerLanguage != nil {
+		normalized, err := domain.NormalizeAnswerLanguage(*answerLanguage)
+		if err != nil {
+			return domain.User{}, err
+		}
+		language = normalized
+	}
 	if email != nil {
 		updated, err := a.UpdateMyEmail(user, *email)
 		if err != nil {
@@ -479,12 +502,17 @@ func (a *App) UpdateMyProfile(user domain.User, email *string, displayName *stri
 		}
 		user = updated
 	}
-	if displayName != nil {
+	if displayName != nil || answerLanguage != nil {
 		profile, err := a.profileForUpdate(user.ID)
 		if err != nil {
 			return domain.User{}, err
 		}
-		profile.DisplayName = sanitizeDisplayName(*displayName)
+		if displayName != nil {
+			profile.DisplayName = sanitizeDisplayName(*displayName)
+		}
+		if answerLanguage != nil {
+			profile.AnswerLanguage = language
+		}
 		profile.UpdatedAt = time.Now().UTC()
 		if err := a.store.SaveUserProfile(profile); err != nil {
 			return domain.User{}, fmt.Errorf("update profile: %w", err)
@@ -1196,6 +1224,14 @@ func (a *App) ListAdminAuditLogs(opts store.AdminAuditLogListOptions) ([]domain.
 	return a.store.ListAdminAuditLogs(opts)
 }
 
+// GetLLMUsageReport returns recorded LLM token usage and estimated cost.
+func (a *App) GetLLMUsageReport(opts store.LLMUsageReportOptions) (domain.LLMUsageReport, error) {
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.From.Before(opts.To) {
+		return domain.LLMUsageReport{}, fmt.Errorf("from must be before to")
+	}
+	return a.store.GetLLMUsageReport(opts)
+}
+
 // GetAdminOverview returns aggregate admin metrics.
 func (a *App) GetAdminOverview(windowStart time.Time, windowHours int) (domain.AdminOverview, error) {
 	return a.store.GetAdminOverview(windowStart, windowHours)
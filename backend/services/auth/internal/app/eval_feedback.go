@@ -0,0 +1,372 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/domain"
+	"onebookai/pkg/store"
+)
+
+// maxFeedbackPerConversion bounds one conversion call.
+const maxFeedbackPerConversion = 500
+
+type AnswerFeedbackReviewInput struct {
+	Status            *domain.AnswerFeedbackStatus
+	CorrectedChunkIDs *[]string
+	ReviewNote        *string
+}
+
+// AnswerFeedbackConvertInput turns accepted feedback into an eval dataset
+// version. With DatasetID the feedback extends that book dataset as its next
+// version; otherwise a new book dataset called Name is created.
+type AnswerFeedbackConvertInput struct {
+	FeedbackIDs []string
+	DatasetID   string
+	Name        string
+	Description string
+}
+
+func (a *App) AdminListAnswerFeedback(opts store.AnswerFeedbackListOptions) ([]domain.AnswerFeedback, int, error) {
+	return a.store.ListAnswerFeedback(opts)
+}
+
+func (a *App) AdminGetAnswerFeedback(id string) (domain.AnswerFeedback, error) {
+	item, ok, err := a.store.GetAnswerFeedback(id)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	if !ok {
+		return domain.AnswerFeedback{}, fmt.Errorf("feedback not found")
+	}
+	return item, nil
+}
+
+// AdminReviewAnswerFeedback accepts or rejects feedback and lets the reviewer
+// fix the chunks that should have been cited.
+func (a *App) AdminReviewAnswerFeedback(actor domain.User, id string, input AnswerFeedbackReviewInput) (domain.AnswerFeedback, error) {
+	item, err := a.AdminGetAnswerFeedback(id)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	if item.Status == domain.AnswerFeedbackStatusConverted {
+		return domain.AnswerFeedback{}, fmt.Errorf("feedback already converted")
+	}
+	if input.Status != nil {
+		switch *input.Status {
+		case domain.AnswerFeedbackStatusPending, domain.AnswerFeedbackStatusAccepted, domain.AnswerFeedbackStatusRejected:
+			item.Status = *input.Status
+		default:
+			return domain.AnswerFeedback{}, fmt.Errorf("invalid feedback status")
+		}
+	}
+	if input.CorrectedChunkIDs != nil {
+		item.CorrectedChunkIDs = uniqueTrimmed(*input.CorrectedChunkIDs)
+	}
+	if input.ReviewNote != nil {
+		item.ReviewNote = strings.TrimSpace(*input.ReviewNote)
+	}
+	if item.Status == domain.AnswerFeedbackStatusAccepted && len(feedbackRelevantChunks(item)) == 0 {
+		return domain.AnswerFeedback{}, fmt.Errorf("accepted feedback needs corrected chunks")
+	}
+	now := time.Now().UTC()
+	item.ReviewedBy = actor.ID
+	item.ReviewedAt = &now
+	item.UpdatedAt = now
+	if err := a.store.SaveAnswerFeedback(item); err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	return item, nil
+}
+
+// AdminConvertAnswerFeedback writes accepted feedback as queries and qrels of
+// a new book dataset version. Queries of an extended dataset are carried over,
+// except those re-judged by this feedback; the book's chunks are exported
+// afresh so the judgments resolve against the book as currently indexed.
+func (a *App) AdminConvertAnswerFeedback(actor domain.User, input AnswerFeedbackConvertInput) (domain.EvalDataset, error) {
+	if a.evals == nil {
+		return domain.EvalDataset{}, fmt.Errorf("eval center disabled")
+	}
+	ids := uniqueTrimmed(input.FeedbackIDs)
+	if len(ids) == 0 {
+		return domain.EvalDataset{}, fmt.Errorf("feedbackIds is required")
+	}
+	if len(ids) > maxFeedbackPerConversion {
+		return domain.EvalDataset{}, fmt.Errorf("at most %d feedback items per conversion", maxFeedbackPerConversion)
+	}
+	items := make([]domain.AnswerFeedback, 0, len(ids))
+	for _, id := range ids {
+		item, err := a.AdminGetAnswerFeedback(id)
+		if err != nil {
+			return domain.EvalDataset{}, err
+		}
+		if item.Status != domain.AnswerFeedbackStatusAccepted {
+			return domain.EvalDataset{}, fmt.Errorf("feedback %s is not accepted", item.ID)
+		}
+		if len(feedbackRelevantChunks(item)) == 0 {
+			return domain.EvalDataset{}, fmt.Errorf("feedback %s has no relevant chunks", item.ID)
+		}
+		if len(items) > 0 && item.BookID != items[0].BookID {
+			return domain.EvalDataset{}, fmt.Errorf("feedback spans several books")
+		}
+		items = append(items, item)
+	}
+	bookID := items[0].BookID
+	if bookID == "" {
+		return domain.EvalDataset{}, fmt.Errorf("feedback has no book")
+	}
+
+	now := time.Now().UTC()
+	dataset := domain.EvalDataset{
+		ID:          util.NewID(),
+		Name:        strings.TrimSpace(input.Name),
+		SourceType:  domain.EvalDatasetSourceBook,
+		BookID:      bookID,
+		Version:     1,
+		Status:      domain.EvalDatasetStatusActive,
+		Description: strings.TrimSpace(input.Description),
+		Files:       map[string]string{},
+		CreatedBy:   actor.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	var base *domain.EvalDataset
+	if datasetID := strings.TrimSpace(input.DatasetID); datasetID != "" {
+		item, err := a.AdminGetEvalDataset(datasetID)
+		if err != nil {
+			return domain.EvalDataset{}, err
+		}
+		if item.SourceType != domain.EvalDatasetSourceBook {
+			return domain.EvalDataset{}, fmt.Errorf("feedback can only extend book datasets")
+		}
+		if item.BookID != bookID {
+			return domain.EvalDataset{}, fmt.Errorf("feedback book does not match dataset")
+		}
+		base = &item
+		dataset.Name = item.Name
+		if dataset.Description == "" {
+			dataset.Description = item.Description
+		}
+	}
+	if dataset.Name == "" {
+		return domain.EvalDataset{}, fmt.Errorf("name is required")
+	}
+	for _, item := range items {
+		if err := a.checkFeedbackLineage(item, base); err != nil {
+			return domain.EvalDataset{}, err
+		}
+	}
+	version, err := a.nextEvalDatasetVersion(dataset.Name, bookID)
+	if err != nil {
+		return domain.EvalDataset{}, err
+	}
+	dataset.Version = version
+
+	queries, qrels := feedbackEvalRows(items)
+	if base != nil {
+		rejudged := make(map[string]struct{}, len(items))
+		for _, item := range items {
+			rejudged[feedbackQueryID(item)] = struct{}{}
+		}
+		baseQueries, baseQrels, err := a.evals.readDatasetJudgments(*base, rejudged)
+		if err != nil {
+			return domain.EvalDataset{}, err
+		}
+		queries = append(baseQueries, queries...)
+		qrels = append(baseQrels, qrels...)
+	}
+
+	if err := os.MkdirAll(a.evals.datasetDir(dataset.ID, dataset.Version), 0o755); err != nil {
+		return domain.EvalDataset{}, err
+	}
+	for key, lines := range map[string][]string{"queries": queries, "qrels": qrels} {
+		relativePath := filepath.ToSlash(filepath.Join("datasets", dataset.ID, fmt.Sprintf("v%d", dataset.Version), datasetFilenameForKey(key)))
+		if err := os.WriteFile(a.evals.absPath(relativePath), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			return domain.EvalDataset{}, err
+		}
+		dataset.Files[key] = relativePath
+	}
+	if err := a.evals.exportBookChunks(dataset); err != nil {
+		return domain.EvalDataset{}, err
+	}
+	dataset.Files["chunks"] = filepath.ToSlash(filepath.Join("datasets", dataset.ID, fmt.Sprintf("v%d", dataset.Version), "chunks.jsonl"))
+	if err := a.store.SaveEvalDataset(dataset); err != nil {
+		return domain.EvalDataset{}, err
+	}
+	if err := a.store.MarkAnswerFeedbackConverted(ids, dataset.ID, now); err != nil {
+		return domain.EvalDataset{}, err
+	}
+	return dataset, nil
+}
+
+// checkFeedbackLineage makes feedback that was already converted extend a
+// version of the dataset it went into, where its fb_<id> query is re-judged,
+// instead of being copied into a second dataset.
+func (a *App) checkFeedbackLineage(item domain.AnswerFeedback, base *domain.EvalDataset) error {
+	if item.DatasetID == "" {
+		return nil
+	}
+	converted, ok, err := a.store.GetEvalDataset(item.DatasetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if base == nil || base.Name != converted.Name || base.BookID != converted.BookID {
+		return fmt.Errorf("feedback %s was converted into dataset %s; extend a version of it instead", item.ID, converted.ID)
+	}
+	return nil
+}
+
+// nextEvalDatasetVersion returns one past the highest version of the named
+// book dataset.
+func (a *App) nextEvalDatasetVersion(name, bookID string) (int, error) {
+	version := 1
+	for page := 1; ; page++ {
+		items, total, err := a.store.ListEvalDatasets(store.EvalDatasetListOptions{
+			Query:      name,
+			SourceType: string(domain.EvalDatasetSourceBook),
+			BookID:     bookID,
+			Page:       page,
+			PageSize:   100,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, item := range items {
+			if item.Name == name && item.Version >= version {
+				version = item.Version + 1
+			}
+		}
+		if len(items) == 0 || page*100 >= total {
+			return version, nil
+		}
+	}
+}
+
+// readDatasetJudgments returns the query and qrels lines of a dataset,
+// leaving out queries in skip.
+func (c *evalCenter) readDatasetJudgments(dataset domain.EvalDataset, skip map[string]struct{}) ([]string, []string, error) {
+	var queries, qrels []string
+	err := readDatasetLines(c.datasetFilePath(dataset, "queries"), func(line string) {
+		var row map[string]any
+		if json.Unmarshal([]byte(line), &row) != nil {
+			return
+		}
+		if _, ok := skip[firstRowString(row, "qid", "query_id", "id")]; !ok {
+			queries = append(queries, line)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = readDatasetLines(c.datasetFilePath(dataset, "qrels"), func(line string) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+		if _, ok := skip[fields[0]]; !ok {
+			qrels = append(qrels, line)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return queries, qrels, nil
+}
+
+func readDatasetLines(path string, fn func(line string)) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 0, 64*1024), 8*1024*1024)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			fn(line)
+		}
+	}
+	return s.Err()
+}
+
+// feedbackEvalRows renders feedback as queries.jsonl rows and qrels.tsv
+// lines. Thumbs-up answers also become the expected answer.
+func feedbackEvalRows(items []domain.AnswerFeedback) ([]string, []string) {
+	queries := make([]string, 0, len(items))
+	var qrels []string
+	for _, item := range items {
+		qid := feedbackQueryID(item)
+		row := map[string]any{
+			"qid":         qid,
+			"query":       item.Question,
+			"book_id":     item.BookID,
+			"feedback_id": item.ID,
+		}
+		if item.Rating == domain.AnswerFeedbackUp {
+			row["expected_answer"] = item.Answer
+		}
+		raw, _ := json.Marshal(row)
+		queries = append(queries, string(raw))
+		for _, chunkID := range feedbackRelevantChunks(item) {
+			qrels = append(qrels, qid+"\t"+chunkID+"\t1")
+		}
+	}
+	return queries, qrels
+}
+
+// feedbackRelevantChunks are the corrected chunks, or for a thumbs-up without
+// corrections the chunks the answer was built from.
+func feedbackRelevantChunks(item domain.AnswerFeedback) []string {
+	if len(item.CorrectedChunkIDs) > 0 {
+		return item.CorrectedChunkIDs
+	}
+	if item.Rating == domain.AnswerFeedbackUp {
+		return item.SelectedChunkIDs
+	}
+	return nil
+}
+
+func feedbackQueryID(item domain.AnswerFeedback) string {
+	return "fb_" + item.ID
+}
+
+func firstRowString(row map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := row[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func uniqueTrimmed(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"onebookai/internal/quota"
+	"onebookai/pkg/domain"
+)
+
+// ErrQuotaUnavailable is returned when usage counters cannot be read.
+var ErrQuotaUnavailable = errors.New("quota service unavailable")
+
+// UserQuotaInput is an admin override request. Nil limits inherit the plan.
+type UserQuotaInput struct {
+	Plan            string
+	DailyQuestions  *int
+	MonthlyTokens   *int64
+	MaxBooks        *int
+	MaxStorageBytes *int64
+}
+
+// GetUserUsage returns the user's effective limits and current consumption.
+func (a *App) GetUserUsage(ctx context.Context, user domain.User) (domain.UserUsage, error) {
+	override, err := a.quotaOverride(user.ID)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	return a.buildUserUsage(ctx, user, override)
+}
+
+// AdminGetUserQuota returns a user's usage together with any admin override.
+func (a *App) AdminGetUserQuota(ctx context.Context, userID string) (domain.UserUsage, error) {
+	user, err := a.quotaTarget(userID)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	override, err := a.quotaOverride(user.ID)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	usage, err := a.buildUserUsage(ctx, user, override)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	usage.Override = override
+	return usage, nil
+}
+
+// AdminSetUserQuota stores a plan or per-limit override for a user.
+func (a *App) AdminSetUserQuota(ctx context.Context, actor domain.User, userID string, input UserQuotaInput) (domain.UserUsage, error) {
+	if !a.quotas.Enabled() {
+		return domain.UserUsage{}, fmt.Errorf("quotas are not enabled")
+	}
+	user, err := a.quotaTarget(userID)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	plan := strings.ToLower(strings.TrimSpace(input.Plan))
+	if plan != "" && !a.quotas.Has(plan) {
+		return domain.UserUsage{}, fmt.Errorf("invalid plan")
+	}
+	if (input.DailyQuestions != nil && *input.DailyQuestions < 0) ||
+		(input.MonthlyTokens != nil && *input.MonthlyTokens < 0) ||
+		(input.MaxBooks != nil && *input.MaxBooks < 0) ||
+		(input.MaxStorageBytes != nil && *input.MaxStorageBytes < 0) {
+		return domain.UserUsage{}, fmt.Errorf("invalid quota limit")
+	}
+	override := domain.UserQuotaOverride{
+		UserID:          user.ID,
+		Plan:            plan,
+		DailyQuestions:  input.DailyQuestions,
+		MonthlyTokens:   input.MonthlyTokens,
+		MaxBooks:        input.MaxBooks,
+		MaxStorageBytes: input.MaxStorageBytes,
+		UpdatedBy:       actor.ID,
+		UpdatedAt:       time.Now().UTC(),
+	}
+	if err := a.store.SaveUserQuotaOverride(override); err != nil {
+		return domain.UserUsage{}, fmt.Errorf("save quota override: %w", err)
+	}
+	return a.AdminGetUserQuota(ctx, user.ID)
+}
+
+// AdminClearUserQuota removes a user's override so the default plan applies.
+func (a *App) AdminClearUserQuota(ctx context.Context, userID string) (domain.UserUsage, error) {
+	user, err := a.quotaTarget(userID)
+	if err != nil {
+		return domain.UserUsage{}, err
+	}
+	if err := a.store.DeleteUserQuotaOverride(user.ID); err != nil {
+		return domain.UserUsage{}, fmt.Errorf("delete quota override: %w", err)
+	}
+	return a.AdminGetUserQuota(ctx, user.ID)
+}
+
+func (a *App) quotaTarget(userID string) (domain.User, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return domain.User{}, fmt.Errorf("user id required")
+	}
+	user, ok, err := a.store.GetUserByID(userID)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("fetch user: %w", err)
+	}
+	if !ok {
+		return domain.User{}, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+func (a *App) quotaOverride(userID string) (*domain.UserQuotaOverride, error) {
+	override, ok, err := a.store.GetUserQuotaOverride(userID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch quota override: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &override, nil
+}
+
+func (a *App) buildUserUsage(ctx context.Context, user domain.User, override *domain.UserQuotaOverride) (domain.UserUsage, error) {
+	now := time.Now().UTC()
+	usage := domain.UserUsage{
+		Limits:           a.quotas.Limits(user, override),
+		QuestionsResetAt: quota.QuestionsResetAt(now),
+		TokensResetAt:    quota.TokensResetAt(now),
+	}
+	books, bytes, err := a.store.GetOwnerBookUsage(user.ID)
+	if err != nil {
+		return domain.UserUsage{}, fmt.Errorf("fetch book usage: %w", err)
+	}
+	usage.Books = books
+	usage.StorageBytes = bytes
+	if a.quotaCounter != nil {
+		questions, tokens, err := a.quotaCounter.Usage(ctx, user.ID)
+		if err != nil {
+			return domain.UserUsage{}, fmt.Errorf("%w: %v", ErrQuotaUnavailable, err)
+		}
+		usage.QuestionsToday = questions
+		usage.TokensThisMonth = tokens
+	}
+	return usage, nil
+}
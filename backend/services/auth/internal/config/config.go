@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"onebookai/internal/quota"
 )
 
 // FileConfig represents configuration loaded from YAML.
@@ -52,6 +53,8 @@ type FileConfig struct {
 	AliyunSMSChangePhoneTemplate   string   `yaml:"aliyunSmsChangePhoneTemplate"`
 	AliyunSMSBindPhoneTemplate     string   `yaml:"aliyunSmsBindPhoneTemplate"`
 	AliyunSMSVerifyBindingTemplate string   `yaml:"aliyunSmsVerifyBindingTemplate"`
+	QuotaPlans                     string   `yaml:"quotaPlans"`
+	QuotaDefaultPlan               string   `yaml:"quotaDefaultPlan"`
 }
 
 // Load reads config from path (defaults to config.yaml).
@@ -125,6 +128,12 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("AUTH_EVAL_WORKER_POLL_INTERVAL"); v != "" {
 		cfg.EvalWorkerPollInterval = v
 	}
+	if v := os.Getenv("QUOTA_PLANS"); v != "" {
+		cfg.QuotaPlans = v
+	}
+	if v := os.Getenv("QUOTA_DEFAULT_PLAN"); v != "" {
+		cfg.QuotaDefaultPlan = v
+	}
 	if v := os.Getenv("AUTH_SIGNUP_RATE_LIMIT_PER_MINUTE"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.SignupRateLimitPerMinute = n
@@ -221,6 +230,9 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.SignupRateLimitPerMinute < 0 || cfg.LoginRateLimitPerMinute < 0 || cfg.RefreshRateLimitPerMinute < 0 || cfg.PasswordRateLimitPerMinute < 0 {
 		return errors.New("config: rate limits must be >= 0")
 	}
+	if _, err := quota.ParsePlans(cfg.QuotaPlans, cfg.QuotaDefaultPlan); err != nil {
+		return fmt.Errorf("config: %w (set QUOTA_PLANS / QUOTA_DEFAULT_PLAN)", err)
+	}
 	return nil
 }
 
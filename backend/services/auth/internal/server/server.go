@@ -177,6 +177,7 @@ func (s *Server) routes() {
 	s.mux.Handle("/auth/me", s.authenticated(s.handleMe))
 	s.mux.Handle("/auth/me/avatar", s.authenticated(s.handleMeAvatar))
 	s.mux.Handle("/auth/me/password", s.authenticated(s.handleChangePassword))
+	s.mux.Handle("/auth/me/usage", s.authenticated(s.handleMeUsage))
 	s.mux.Handle("/auth/users/", s.authenticated(s.handleUserAvatar))
 
 	// admin
@@ -184,11 +185,15 @@ func (s *Server) routes() {
 	s.mux.Handle("/auth/admin/users/", s.adminOnly(s.handleAdminUserByID))
 	s.mux.Handle("/auth/admin/audit-logs", s.adminOnly(s.handleAdminAuditLogs))
 	s.mux.Handle("/auth/admin/overview", s.adminOnly(s.handleAdminOverview))
+	s.mux.Handle("/auth/admin/llm-usage", s.adminOnly(s.handleAdminLLMUsage))
 	s.mux.Handle("/auth/admin/evals/overview", s.adminOnly(s.handleAdminEvalOverview))
 	s.mux.Handle("/auth/admin/evals/datasets", s.adminOnly(s.handleAdminEvalDatasets))
 	s.mux.Handle("/auth/admin/evals/datasets/", s.adminOnly(s.handleAdminEvalDatasetByID))
 	s.mux.Handle("/auth/admin/evals/runs", s.adminOnly(s.handleAdminEvalRuns))
 	s.mux.Handle("/auth/admin/evals/runs/", s.adminOnly(s.handleAdminEvalRunByID))
+	s.mux.Handle("/auth/admin/evals/feedback", s.adminOnly(s.handleAdminAnswerFeedback))
+	s.mux.Handle("/auth/admin/evals/feedback/convert", s.adminOnly(s.handleAdminConvertAnswerFeedback))
+	s.mux.Handle("/auth/admin/evals/feedback/", s.adminOnly(s.handleAdminAnswerFeedbackByID))
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -822,11 +827,11 @@ func (s *Server) handleMe(w http.ResponseWriter, r *http.Request, user domain.Us
 			writeError(w, http.StatusBadRequest, "invalid JSON body")
 			return
 		}
-		if req.Email == nil && req.DisplayName == nil {
-			writeError(w, http.StatusBadRequest, "email or displayName is required")
+		if req.Email == nil && req.DisplayName == nil && req.AnswerLanguage == nil {
+			writeError(w, http.StatusBadRequest, "email, displayName or answerLanguage is required")
 			return
 		}
-		updated, err := s.app.UpdateMyProfile(user, req.Email, req.DisplayName)
+		updated, err := s.app.UpdateMyProfile(user, req.Email, req.DisplayName, req.AnswerLanguage)
 		if err != nil {
 			writeError(w, http.StatusBadRequest, err.Error())
 			return
@@ -1009,6 +1014,10 @@ func (s *Server) handleAdminUserByID(w http.ResponseWriter, r *http.Request, use
 		s.handleAdminDeleteUser(w, r, user, id)
 		return
 	}
+	if action == "quota" {
+		s.handleAdminUserQuota(w, r, user, id)
+		return
+	}
 	if r.Method == http.MethodPost && (action == "disable" || action == "enable") {
 		status := domain.StatusDisabled
 		if action == "enable" {
@@ -1162,6 +1171,102 @@ func (s *Server) handleAdminOverview(w http.ResponseWriter, r *http.Request, _ d
 	writeJSON(w, http.StatusOK, overview)
 }
 
+func (s *Server) handleAdminLLMUsage(w http.ResponseWriter, r *http.Request, _ domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	from, err := parseOptionalRFC3339(strings.TrimSpace(r.URL.Query().Get("from")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from")
+		return
+	}
+	to, err := parseOptionalRFC3339(strings.TrimSpace(r.URL.Query().Get("to")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to")
+		return
+	}
+	report, err := s.app.GetLLMUsageReport(store.LLMUsageReportOptions{
+		From:   from,
+		To:     to,
+		UserID: strings.TrimSpace(r.URL.Query().Get("userId")),
+		BookID: strings.TrimSpace(r.URL.Query().Get("bookId")),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleMeUsage(w http.ResponseWriter, r *http.Request, user domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	usage, err := s.app.GetUserUsage(r.Context(), user)
+	if err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
+
+type adminUserQuotaRequest struct {
+	Plan            string `json:"plan"`
+	DailyQuestions  *int   `json:"dailyQuestions"`
+	MonthlyTokens   *int64 `json:"monthlyTokens"`
+	MaxBooks        *int   `json:"maxBooks"`
+	MaxStorageBytes *int64 `json:"maxStorageBytes"`
+}
+
+func (s *Server) handleAdminUserQuota(w http.ResponseWriter, r *http.Request, actor domain.User, id string) {
+	var (
+		usage domain.UserUsage
+		err   error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		usage, err = s.app.AdminGetUserQuota(r.Context(), id)
+	case http.MethodPut:
+		var req adminUserQuotaRequest
+		if decodeErr := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); decodeErr != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		usage, err = s.app.AdminSetUserQuota(r.Context(), actor, id, app.UserQuotaInput{
+			Plan:            req.Plan,
+			DailyQuestions:  req.DailyQuestions,
+			MonthlyTokens:   req.MonthlyTokens,
+			MaxBooks:        req.MaxBooks,
+			MaxStorageBytes: req.MaxStorageBytes,
+		})
+	case http.MethodDelete:
+		usage, err = s.app.AdminClearUserQuota(r.Context(), id)
+	default:
+		methodNotAllowed(w)
+		return
+	}
+	if err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
+
+func writeQuotaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrQuotaUnavailable):
+		writeError(w, http.StatusServiceUnavailable, app.ErrQuotaUnavailable.Error())
+	case err.Error() == "user not found":
+		writeError(w, http.StatusNotFound, err.Error())
+	case strings.HasPrefix(err.Error(), "fetch "), strings.HasPrefix(err.Error(), "save "), strings.HasPrefix(err.Error(), "delete "):
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
 func methodNotAllowed(w http.ResponseWriter) {
 	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 }
@@ -1318,8 +1423,9 @@ type logoutRequest struct {
 }
 
 type updateMeRequest struct {
-	Email       *string `json:"email"`
-	DisplayName *string `json:"displayName"`
+	Email          *string `json:"email"`
+	DisplayName    *string `json:"displayName"`
+	AnswerLanguage *string `json:"answerLanguage"`
 }
 
 type changePasswordRequest struct {
@@ -1547,6 +1653,10 @@ func errorCodeForAuth(status int, msg string) string {
 		return "ADMIN_INVALID_STATUS"
 	case message == "role or status is required":
 		return "ADMIN_UPDATE_FIELDS_REQUIRED"
+	case message == "invalid plan", message == "invalid quota limit", message == "quotas are not enabled":
+		return "ADMIN_INVALID_QUOTA"
+	case message == app.ErrQuotaUnavailable.Error():
+		return "QUOTA_UNAVAILABLE"
 	case message == "invalid pagination":
 		return "ADMIN_INVALID_PAGINATION"
 	case message == "invalid sort":
@@ -310,6 +310,109 @@ func (s *Server) handleAdminEvalRunByID(w http.ResponseWriter, r *http.Request,
 	writeError(w, http.StatusNotFound, "not found")
 }
 
+func (s *Server) handleAdminAnswerFeedback(w http.ResponseWriter, r *http.Request, _ domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	page, pageSize, err := parsePageParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, total, err := s.app.AdminListAnswerFeedback(store.AnswerFeedbackListOptions{
+		Status:   strings.TrimSpace(r.URL.Query().Get("status")),
+		Rating:   strings.TrimSpace(r.URL.Query().Get("rating")),
+		BookID:   strings.TrimSpace(r.URL.Query().Get("bookId")),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = (total + pageSize - 1) / pageSize
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":      items,
+		"count":      len(items),
+		"page":       page,
+		"pageSize":   pageSize,
+		"total":      total,
+		"totalPages": totalPages,
+	})
+}
+
+func (s *Server) handleAdminAnswerFeedbackByID(w http.ResponseWriter, r *http.Request, user domain.User) {
+	path := strings.TrimPrefix(r.URL.Path, "/auth/admin/evals/feedback/")
+	id := strings.Trim(path, "/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		item, err := s.app.AdminGetAnswerFeedback(id)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodPatch:
+		var req adminAnswerFeedbackReviewRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		var status *domain.AnswerFeedbackStatus
+		if strings.TrimSpace(req.Status) != "" {
+			parsed := domain.AnswerFeedbackStatus(strings.TrimSpace(req.Status))
+			status = &parsed
+		}
+		item, err := s.app.AdminReviewAnswerFeedback(user, id, app.AnswerFeedbackReviewInput{
+			Status:            status,
+			CorrectedChunkIDs: req.CorrectedChunkIDs,
+			ReviewNote:        req.ReviewNote,
+		})
+		if err != nil {
+			status := http.StatusBadRequest
+			if err.Error() == "feedback not found" {
+				status = http.StatusNotFound
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) handleAdminConvertAnswerFeedback(w http.ResponseWriter, r *http.Request, user domain.User) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	var req adminAnswerFeedbackConvertRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	item, err := s.app.AdminConvertAnswerFeedback(user, app.AnswerFeedbackConvertInput{
+		FeedbackIDs: req.FeedbackIDs,
+		DatasetID:   req.DatasetID,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
 type adminEvalDatasetUpdateRequest struct {
 	Name        *string `json:"name,omitempty"`
 	Description *string `json:"description,omitempty"`
@@ -323,3 +426,16 @@ type adminEvalRunCreateRequest struct {
 	GateMode      string         `json:"gateMode"`
 	Params        map[string]any `json:"params,omitempty"`
 }
+
+type adminAnswerFeedbackReviewRequest struct {
+	Status            string    `json:"status,omitempty"`
+	CorrectedChunkIDs *[]string `json:"correctedChunkIds,omitempty"`
+	ReviewNote        *string   `json:"reviewNote,omitempty"`
+}
+
+type adminAnswerFeedbackConvertRequest struct {
+	FeedbackIDs []string `json:"feedbackIds"`
+	DatasetID   string   `json:"datasetId,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
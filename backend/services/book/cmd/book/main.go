@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"onebookai/internal/quota"
 	"onebookai/internal/servicetoken"
 	"onebookai/internal/usertoken"
 	"onebookai/internal/util"
@@ -48,6 +49,10 @@ func main() {
 		util.Fatal("failed to init jwks verifier", "err", err)
 	}
 
+	quotaPlans, err := quota.ParsePlans(cfg.QuotaPlans, cfg.QuotaDefaultPlan)
+	if err != nil {
+		util.Fatal("invalid quota plans", "err", err)
+	}
 	appCore, err := app.New(app.Config{
 		DatabaseURL:               cfg.DatabaseURL,
 		MinioEndpoint:             cfg.MinioEndpoint,
@@ -60,9 +65,11 @@ func main() {
 		InternalJWTPrivateKeyPath: cfg.InternalJWTPrivateKeyPath,
 		MaxUploadBytes:            cfg.MaxUploadBytes,
 		AllowedExtensions:         cfg.AllowedExtensions,
+		VectorStore:               cfg.VectorStore,
 		QdrantURL:                 cfg.QdrantURL,
 		QdrantAPIKey:              cfg.QdrantAPIKey,
 		QdrantCollection:          cfg.QdrantCollection,
+		QuotaPlans:                quotaPlans,
 	})
 	if err != nil {
 		util.Fatal("failed to init app", "err", err)
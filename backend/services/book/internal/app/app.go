@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"onebookai/internal/quota"
 	"onebookai/internal/servicetoken"
 	"onebookai/internal/util"
 	"onebookai/pkg/domain"
@@ -34,9 +35,11 @@ type Config struct {
 	InternalJWTPrivateKeyPath string
 	MaxUploadBytes            int64
 	AllowedExtensions         []string
+	VectorStore               string
 	QdrantURL                 string
 	QdrantAPIKey              string
 	QdrantCollection          string
+	QuotaPlans                quota.Plans
 }
 
 // App is the core application service wiring together storage and domain logic.
@@ -44,14 +47,21 @@ type App struct {
 	store             store.Store
 	objects           storage.ObjectStore
 	ingest            ingestClient
-	search            *retrieval.Client
+	search            retrieval.VectorStore
 	presignExpiry     time.Duration
 	maxUploadBytes    int64
 	allowedExtensions map[string]struct{}
+	quotas            quota.Plans
 }
 
 var ErrStaleBookGeneration = errors.New("stale book generation")
 
+// ErrQuotaExceeded is returned when an upload would exceed the owner's plan.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrCitationNotFound is returned when a cited chunk does not belong to the book.
+var ErrCitationNotFound = errors.New("citation not found")
+
 // New constructs the application with database-backed metadata storage and filesystem file storage.
 func New(cfg Config) (*App, error) {
 	objStore, err := storage.NewMinioStore(cfg.MinioEndpoint, cfg.MinioAccessKey, cfg.MinioSecretKey, cfg.MinioBucket, cfg.MinioUseSSL)
@@ -86,9 +96,16 @@ func New(cfg Config) (*App, error) {
 	if err != nil {
 		return nil, fmt.Errorf("init ingest client: %w", err)
 	}
-	searchClient, err := retrieval.NewQdrantClient(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection, 1)
+	searchClient, err := retrieval.NewVectorStore(retrieval.VectorStoreConfig{
+		Backend:          cfg.VectorStore,
+		QdrantURL:        cfg.QdrantURL,
+		QdrantAPIKey:     cfg.QdrantAPIKey,
+		QdrantCollection: cfg.QdrantCollection,
+		DatabaseURL:      cfg.DatabaseURL,
+		DenseSize:        1,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("init qdrant client: %w", err)
+		return nil, fmt.Errorf("init vector store: %w", err)
 	}
 
 	app := &App{
@@ -99,6 +116,7 @@ func New(cfg Config) (*App, error) {
 		presignExpiry:     15 * time.Minute,
 		maxUploadBytes:    normalizeMaxBytes(cfg.MaxUploadBytes),
 		allowedExtensions: normalizeExtensions(cfg.AllowedExtensions),
+		quotas:            cfg.QuotaPlans,
 	}
 	app.startCleanupWorker()
 	app.startOutboxWorker()
@@ -132,6 +150,10 @@ func (a *App) UploadBook(owner domain.User, filename string, r io.Reader, size i
 	if replayed {
 		return replayBook, true, nil
 	}
+	if err := a.checkUploadQuota(owner, size); err != nil {
+		_ = a.markBookIdempotencyFailed(record, http.StatusTooManyRequests)
+		return domain.Book{}, false, err
+	}
 	id := util.NewID()
 	storageKey := buildStorageKey(id, filename)
 	book := domain.Book{
@@ -293,6 +315,11 @@ func (a *App) GetBookIndexStatusSummary(id string) (domain.BookIndexStatusSummar
 	return summary, nil
 }
 
+// GetIndexConsistencyReport returns the latest library-wide reconciler report.
+func (a *App) GetIndexConsistencyReport() (domain.IndexConsistencyReport, bool, error) {
+	return a.store.GetLatestIndexConsistencyReport()
+}
+
 // DeleteBook removes book metadata and files.
 func (a *App) DeleteBook(id string) error {
 	book, ok, err := a.store.GetBookIncludingDeleted(id)
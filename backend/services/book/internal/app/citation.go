@@ -0,0 +1,24 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"onebookai/pkg/domain"
+)
+
+// ResolveCitation turns a cited chunk of a book into reader coordinates.
+func (a *App) ResolveCitation(bookID, chunkID string) (domain.ReaderLocation, error) {
+	chunkID = strings.TrimSpace(chunkID)
+	if chunkID == "" {
+		return domain.ReaderLocation{}, ErrCitationNotFound
+	}
+	chunks, err := a.store.GetChunksByIDs([]string{chunkID})
+	if err != nil {
+		return domain.ReaderLocation{}, fmt.Errorf("load chunk: %w", err)
+	}
+	if len(chunks) == 0 || chunks[0].BookID != bookID {
+		return domain.ReaderLocation{}, ErrCitationNotFound
+	}
+	return domain.ChunkReaderLocation(chunks[0]), nil
+}
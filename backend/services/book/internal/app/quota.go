@@ -0,0 +1,38 @@
+package app
+
+import (
+	"fmt"
+
+	"onebookai/pkg/domain"
+)
+
+// checkUploadQuota rejects an upload that would take the owner past the
+// book count or storage cap of their plan.
+func (a *App) checkUploadQuota(owner domain.User, size int64) error {
+	if !a.quotas.Enabled() || owner.Role == domain.RoleAdmin {
+		return nil
+	}
+	var override *domain.UserQuotaOverride
+	stored, ok, err := a.store.GetUserQuotaOverride(owner.ID)
+	if err != nil {
+		return fmt.Errorf("load quota: %w", err)
+	}
+	if ok {
+		override = &stored
+	}
+	limits := a.quotas.Limits(owner, override)
+	if limits.MaxBooks <= 0 && limits.MaxStorageBytes <= 0 {
+		return nil
+	}
+	books, bytes, err := a.store.GetOwnerBookUsage(owner.ID)
+	if err != nil {
+		return fmt.Errorf("load book usage: %w", err)
+	}
+	if limits.MaxBooks > 0 && books >= limits.MaxBooks {
+		return fmt.Errorf("%w: book limit of %d reached", ErrQuotaExceeded, limits.MaxBooks)
+	}
+	if limits.MaxStorageBytes > 0 && bytes+size > limits.MaxStorageBytes {
+		return fmt.Errorf("%w: storage limit of %d bytes reached", ErrQuotaExceeded, limits.MaxStorageBytes)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"onebookai/internal/quota"
+	"onebookai/pkg/retrieval"
 )
 
 // FileConfig represents configuration loaded from YAML.
@@ -34,9 +36,12 @@ type FileConfig struct {
 	InternalJWTKeyID            string   `yaml:"internalJwtKeyId"`
 	MaxUploadBytes              int64    `yaml:"maxUploadBytes"`
 	AllowedExtensions           []string `yaml:"allowedExtensions"`
+	VectorStore                 string   `yaml:"vectorStore"`
 	QdrantURL                   string   `yaml:"qdrantURL"`
 	QdrantAPIKey                string   `yaml:"qdrantAPIKey"`
 	QdrantCollection            string   `yaml:"qdrantCollection"`
+	QuotaPlans                  string   `yaml:"quotaPlans"`
+	QuotaDefaultPlan            string   `yaml:"quotaDefaultPlan"`
 }
 
 // Load reads config from path (defaults to config.yaml).
@@ -109,6 +114,9 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("BOOK_ALLOWED_EXTENSIONS"); v != "" {
 		cfg.AllowedExtensions = splitCSV(v)
 	}
+	if v := os.Getenv("VECTOR_STORE"); v != "" {
+		cfg.VectorStore = v
+	}
 	if v := os.Getenv("QDRANT_URL"); v != "" {
 		cfg.QdrantURL = v
 	}
@@ -118,6 +126,12 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("QDRANT_COLLECTION"); v != "" {
 		cfg.QdrantCollection = v
 	}
+	if v := os.Getenv("QUOTA_PLANS"); v != "" {
+		cfg.QuotaPlans = v
+	}
+	if v := os.Getenv("QUOTA_DEFAULT_PLAN"); v != "" {
+		cfg.QuotaDefaultPlan = v
+	}
 	if err := validateConfig(cfg); err != nil {
 		return cfg, err
 	}
@@ -152,15 +166,24 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.IngestURL == "" {
 		return errors.New("config: ingestURL is required (set in config.yaml)")
 	}
-	if strings.TrimSpace(cfg.QdrantURL) == "" {
-		return errors.New("config: qdrantURL is required (set QDRANT_URL)")
-	}
-	if strings.TrimSpace(cfg.QdrantCollection) == "" {
-		return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
+	switch retrieval.NormalizeVectorStoreBackend(cfg.VectorStore) {
+	case retrieval.VectorStoreQdrant:
+		if strings.TrimSpace(cfg.QdrantURL) == "" {
+			return errors.New("config: qdrantURL is required (set QDRANT_URL)")
+		}
+		if strings.TrimSpace(cfg.QdrantCollection) == "" {
+			return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
+		}
+	case retrieval.VectorStorePgvector:
+	default:
+		return fmt.Errorf("config: unsupported vectorStore %q (set VECTOR_STORE to qdrant or pgvector)", cfg.VectorStore)
 	}
 	if strings.TrimSpace(cfg.InternalJWTPrivateKeyPath) == "" || strings.TrimSpace(cfg.InternalJWTPublicKeyPath) == "" {
 		return errors.New("config: internal service auth requires ONEBOOK_INTERNAL_JWT_PRIVATE_KEY_PATH + ONEBOOK_INTERNAL_JWT_PUBLIC_KEY_PATH")
 	}
+	if _, err := quota.ParsePlans(cfg.QuotaPlans, cfg.QuotaDefaultPlan); err != nil {
+		return fmt.Errorf("config: %w (set QUOTA_PLANS / QUOTA_DEFAULT_PLAN)", err)
+	}
 	return nil
 }
 
@@ -78,6 +78,9 @@ func (s *Server) routes() {
 	// books
 	s.mux.Handle("/books", s.withUser(s.handleBooks))
 	s.mux.Handle("/books/", s.withUser(s.handleBookByID))
+
+	// admin
+	s.mux.Handle("/admin/index-consistency", s.withUser(s.handleIndexConsistency))
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -169,6 +172,10 @@ func (s *Server) handleBookByID(w http.ResponseWriter, r *http.Request, user dom
 		s.handleRepairBookIndex(w, r, user, id)
 		return
 	}
+	if len(parts) == 2 && strings.HasPrefix(parts[1], "citations/") {
+		s.handleResolveCitation(w, r, user, id, strings.TrimPrefix(parts[1], "citations/"))
+		return
+	}
 	if len(parts) == 2 {
 		notFound(w, "not found")
 		return
@@ -343,6 +350,28 @@ func (s *Server) handleRepairBookIndex(w http.ResponseWriter, r *http.Request, u
 	writeJSON(w, http.StatusOK, updated)
 }
 
+// handleIndexConsistency returns the indexer's latest consistency report (admin only).
+func (s *Server) handleIndexConsistency(w http.ResponseWriter, r *http.Request, user domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if user.Role != domain.RoleAdmin {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	report, ok, err := s.app.GetIndexConsistencyReport()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		notFound(w, "index consistency report not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
 // handleDownloadBook returns a pre-signed download URL for the book file.
 func (s *Server) handleDownloadBook(w http.ResponseWriter, r *http.Request, user domain.User, id string) {
 	if r.Method != http.MethodGet {
@@ -373,6 +402,37 @@ func (s *Server) handleDownloadBook(w http.ResponseWriter, r *http.Request, user
 	})
 }
 
+// handleResolveCitation maps a cited chunk to a PDF page or EPUB CFI range.
+func (s *Server) handleResolveCitation(w http.ResponseWriter, r *http.Request, user domain.User, id, chunkID string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	book, ok, err := s.app.GetBook(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		notFound(w, "book not found")
+		return
+	}
+	if book.OwnerID != user.ID && user.Role != domain.RoleAdmin {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	location, err := s.app.ResolveCitation(id, chunkID)
+	if err != nil {
+		if errors.Is(err, app.ErrCitationNotFound) {
+			notFound(w, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, location)
+}
+
 // /internal/books/{id}/file or /internal/books/{id}/status
 func (s *Server) handleInternalBook(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/internal/books/")
@@ -472,12 +532,7 @@ func (s *Server) handleUploadBook(w http.ResponseWriter, r *http.Request, user d
 		idempotencyKey,
 	)
 	if err != nil {
-		status := http.StatusBadRequest
-		lower := strings.ToLower(strings.TrimSpace(err.Error()))
-		if strings.Contains(lower, "different request") || strings.Contains(lower, "in progress") {
-			status = http.StatusConflict
-		}
-		writeError(w, status, err.Error())
+		writeUploadError(w, err)
 		return
 	}
 	if replayed {
@@ -488,6 +543,21 @@ func (s *Server) handleUploadBook(w http.ResponseWriter, r *http.Request, user d
 	writeJSON(w, http.StatusCreated, book)
 }
 
+// writeUploadError maps an upload failure to its status. Quota rejections
+// use 429, the status their idempotency record stores, so a replay answers
+// the same way.
+func writeUploadError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	lower := strings.ToLower(strings.TrimSpace(err.Error()))
+	if strings.Contains(lower, "different request") || strings.Contains(lower, "in progress") {
+		status = http.StatusConflict
+	}
+	if errors.Is(err, app.ErrQuotaExceeded) {
+		status = http.StatusTooManyRequests
+	}
+	writeError(w, status, err.Error())
+}
+
 func (s *Server) handleListBooks(w http.ResponseWriter, r *http.Request, user domain.User) {
 	books, err := s.app.ListBooks(user, store.BookListOptions{
 		Query:           strings.TrimSpace(r.URL.Query().Get("query")),
@@ -564,6 +634,10 @@ func errorCodeForBook(status int, msg string) string {
 		return "BOOK_FORBIDDEN"
 	case message == "book not found":
 		return "BOOK_NOT_FOUND"
+	case message == "index consistency report not found":
+		return "BOOK_INDEX_REPORT_NOT_FOUND"
+	case message == "citation not found":
+		return "BOOK_CITATION_NOT_FOUND"
 	case message == "file too large":
 		return "BOOK_FILE_TOO_LARGE"
 	case message == "filename required", strings.Contains(message, "file is required"):
@@ -584,6 +658,8 @@ func errorCodeForBook(status int, msg string) string {
 		return "IDEMPOTENCY_KEY_REUSED"
 	case strings.Contains(message, "idempotent request already in progress"):
 		return "IDEMPOTENT_REQUEST_IN_PROGRESS"
+	case strings.HasPrefix(message, "quota exceeded"):
+		return "QUOTA_EXCEEDED"
 	case message == "method not allowed":
 		return "SYSTEM_METHOD_NOT_ALLOWED"
 	case message == "not found":
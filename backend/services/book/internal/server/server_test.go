@@ -0,0 +1,33 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"onebookai/services/book/internal/app"
+)
+
+func TestWriteUploadErrorMapsQuotaToTooManyRequests(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{err: fmt.Errorf("%w: book limit of 3 reached", app.ErrQuotaExceeded), wantStatus: http.StatusTooManyRequests, wantCode: "QUOTA_EXCEEDED"},
+		{err: fmt.Errorf("%w: storage limit of 100 bytes reached", app.ErrQuotaExceeded), wantStatus: http.StatusTooManyRequests, wantCode: "QUOTA_EXCEEDED"},
+		{err: fmt.Errorf("idempotency key reused with different request"), wantStatus: http.StatusConflict, wantCode: "IDEMPOTENCY_KEY_REUSED"},
+		{err: fmt.Errorf("unsupported file type"), wantStatus: http.StatusBadRequest, wantCode: "BOOK_UNSUPPORTED_FILE_TYPE"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeUploadError(rec, tt.err)
+		var body errorResponse
+		_ = json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Code != tt.wantStatus || body.Code != tt.wantCode {
+			t.Fatalf("writeUploadError(%q) = %d %q, want %d %q", tt.err, rec.Code, body.Code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"onebookai/internal/usertoken"
@@ -46,38 +47,56 @@ func main() {
 	bookClient := bookclient.NewClient(cfg.BookServiceURL)
 
 	appCore, err := app.New(app.Config{
-		DatabaseURL:              cfg.DatabaseURL,
-		GenerationProvider:       cfg.GenerationProvider,
-		GenerationBaseURL:        cfg.GenerationBaseURL,
-		GenerationAPIKey:         cfg.GenerationAPIKey,
-		GenerationModel:          cfg.GenerationModel,
-		GenerationEnableThinking: cfg.GenerationEnableThinking,
-		EmbeddingProvider:        cfg.EmbeddingProvider,
-		EmbeddingBaseURL:         cfg.EmbeddingBaseURL,
-		EmbeddingModel:           cfg.EmbeddingModel,
-		EmbeddingDim:             cfg.EmbeddingDim,
-		TopK:                     cfg.TopK,
-		DenseRecallTopK:          cfg.DenseRecallTopK,
-		LexicalRecallTopK:        cfg.LexicalRecallTopK,
-		DenseWeight:              cfg.DenseWeight,
-		LexicalWeight:            cfg.LexicalWeight,
-		FusionTopK:               cfg.FusionTopK,
-		HistoryLimit:             cfg.HistoryLimit,
-		QdrantURL:                cfg.QdrantURL,
-		QdrantAPIKey:             cfg.QdrantAPIKey,
-		QdrantCollection:         cfg.QdrantCollection,
-		OpenSearchURL:            cfg.OpenSearchURL,
-		OpenSearchIndex:          cfg.OpenSearchIndex,
-		OpenSearchUsername:       cfg.OpenSearchUsername,
-		OpenSearchPassword:       cfg.OpenSearchPassword,
-		RerankTopN:               cfg.RerankTopN,
-		RetrievalMode:            cfg.RetrievalMode,
-		RerankerURL:              cfg.RerankerURL,
-		ContextBudget:            cfg.ContextBudget,
-		MinEvidenceCount:         cfg.MinEvidenceCount,
-		QueryRewriteEnabled:      cfg.QueryRewriteEnabled,
-		MultiQueryEnabled:        cfg.MultiQueryEnabled,
-		AbstainEnabled:           cfg.AbstainEnabled,
+		DatabaseURL:               cfg.DatabaseURL,
+		GenerationProvider:        cfg.GenerationProvider,
+		GenerationBaseURL:         cfg.GenerationBaseURL,
+		GenerationAPIKey:          cfg.GenerationAPIKey,
+		GenerationModel:           cfg.GenerationModel,
+		GenerationEnableThinking:  cfg.GenerationEnableThinking,
+		GenerationFallbacks:       generationFallbacks(cfg.GenerationFallbacks),
+		GenerationBreakerFailures: cfg.GenerationBreakerFailures,
+		GenerationBreakerOpen:     time.Duration(cfg.GenerationBreakerOpenSeconds) * time.Second,
+		GenerationSlowCall:        time.Duration(cfg.GenerationSlowCallMs) * time.Millisecond,
+		GenerationPricing:         generationPricing(cfg.GenerationPricing),
+		EmbeddingProvider:         cfg.EmbeddingProvider,
+		EmbeddingBaseURL:          cfg.EmbeddingBaseURL,
+		EmbeddingModel:            cfg.EmbeddingModel,
+		EmbeddingDim:              cfg.EmbeddingDim,
+		TopK:                      cfg.TopK,
+		DenseRecallTopK:           cfg.DenseRecallTopK,
+		LexicalRecallTopK:         cfg.LexicalRecallTopK,
+		DenseWeight:               cfg.DenseWeight,
+		LexicalWeight:             cfg.LexicalWeight,
+		SparseWeight:              cfg.SparseWeight,
+		FusionTopK:                cfg.FusionTopK,
+		HistoryLimit:              cfg.HistoryLimit,
+		VectorStore:               cfg.VectorStore,
+		QdrantURL:                 cfg.QdrantURL,
+		QdrantAPIKey:              cfg.QdrantAPIKey,
+		QdrantCollection:          cfg.QdrantCollection,
+		LexicalStore:              cfg.LexicalStore,
+		LexicalFallback:           cfg.LexicalFallback,
+		OpenSearchURL:             cfg.OpenSearchURL,
+		OpenSearchIndex:           cfg.OpenSearchIndex,
+		OpenSearchUsername:        cfg.OpenSearchUsername,
+		OpenSearchPassword:        cfg.OpenSearchPassword,
+		RerankTopN:                cfg.RerankTopN,
+		RetrievalMode:             cfg.RetrievalMode,
+		RerankerURL:               cfg.RerankerURL,
+		ContextBudget:             cfg.ContextBudget,
+		MinEvidenceCount:          cfg.MinEvidenceCount,
+		QueryRewriteEnabled:       cfg.QueryRewriteEnabled,
+		MultiQueryEnabled:         cfg.MultiQueryEnabled,
+		AbstainEnabled:            cfg.AbstainEnabled,
+		CitationEntailmentEnabled: cfg.CitationEntailmentEnabled,
+		SummaryEnabled:            cfg.SummaryEnabled,
+		FollowUpRetrievalSteps:    cfg.FollowUpRetrievalSteps,
+		FollowUpRetrievalBudget:   time.Duration(cfg.FollowUpRetrievalBudgetMs) * time.Millisecond,
+		ModelRouterEnabled:        cfg.ModelRouterEnabled,
+		ModelRouterMinConfidence:  cfg.ModelRouterMinConfidence,
+		AnswerCacheEnabled:        cfg.AnswerCacheEnabled,
+		AnswerCacheMinSimilarity:  cfg.AnswerCacheMinSimilarity,
+		AnswerCacheMaxEntries:     cfg.AnswerCacheMaxEntries,
 	})
 	if err != nil {
 		util.Fatal("failed to init app", "err", err)
@@ -104,3 +123,27 @@ func main() {
 		logger.Error("server error", "err", err)
 	}
 }
+
+func generationFallbacks(items []config.GenerationFallback) []app.GenerationFallback {
+	out := make([]app.GenerationFallback, 0, len(items))
+	for _, item := range items {
+		out = append(out, app.GenerationFallback{
+			Provider: item.Provider,
+			BaseURL:  item.BaseURL,
+			APIKey:   item.APIKey,
+			Model:    item.Model,
+		})
+	}
+	return out
+}
+
+func generationPricing(items map[string]config.ModelPrice) map[string]app.ModelPrice {
+	out := make(map[string]app.ModelPrice, len(items))
+	for model, price := range items {
+		out[strings.ToLower(strings.TrimSpace(model))] = app.ModelPrice{
+			InputPerMillion:  price.InputPerMillion,
+			OutputPerMillion: price.OutputPerMillion,
+		}
+	}
+	return out
+}
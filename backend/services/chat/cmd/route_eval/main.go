@@ -0,0 +1,103 @@
+// Command route_eval runs the chat query router over a labeled routing
+// dataset and writes the routing metrics in the rag_eval report layout.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"onebookai/internal/eval"
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+	"onebookai/services/chat/internal/app"
+	"onebookai/services/chat/internal/config"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "route_eval error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("route_eval", flag.ContinueOnError)
+	configPath := fs.String("config", config.ConfigPath, "chat service config.yaml")
+	dataset := fs.String("dataset", "", "path to labeled routing.jsonl")
+	outDir := fs.String("out-dir", "", "output directory")
+	gateMode := fs.String("gate-mode", "warn", "gate mode: off|warn|strict")
+	heuristic := fs.Bool("heuristic", false, "route with the keyword heuristics only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	var generator ai.TextGenerator
+	if !*heuristic {
+		generator, err = app.NewTextGenerator(app.Config{
+			GenerationProvider:       cfg.GenerationProvider,
+			GenerationBaseURL:        cfg.GenerationBaseURL,
+			GenerationAPIKey:         cfg.GenerationAPIKey,
+			GenerationModel:          cfg.GenerationModel,
+			GenerationEnableThinking: cfg.GenerationEnableThinking,
+		})
+		if err != nil {
+			return fmt.Errorf("init text generator: %w", err)
+		}
+	}
+	router := app.NewQueryRouter(generator, cfg.ModelRouterMinConfidence)
+	res, err := eval.EvaluateRouting(eval.RoutingOptions{
+		DatasetPath: *dataset,
+		Router: func(record eval.RoutingRecord) (eval.RoutingPrediction, error) {
+			decision := router.Route(context.Background(), record.Question, historyMessages(record.History))
+			return eval.RoutingPrediction{
+				Route:        decision.Route,
+				QuestionType: decision.QuestionType,
+				Confidence:   decision.Confidence,
+				Source:       decision.Source,
+			}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	runID := time.Now().UTC().Format("20060102T150405Z")
+	dir := strings.TrimSpace(*outDir)
+	if dir == "" {
+		dir = filepath.Join(".cache", "rag-eval", "routing", runID)
+	}
+	gate := eval.EvaluateGate(*gateMode, res.Warnings)
+	report := eval.ReportRun{
+		RunID:     runID,
+		Command:   "routing",
+		CreatedAt: time.Now().UTC(),
+		Inputs:    map[string]string{"dataset": *dataset},
+		Params:    map[string]any{"heuristic": *heuristic, "model": cfg.GenerationModel, "min_confidence": cfg.ModelRouterMinConfidence},
+	}
+	if err := eval.WriteReport(dir, report, res.Metrics, res.PerQuery, gate); err != nil {
+		return err
+	}
+	fmt.Printf("route_accuracy=%.4f question_type_accuracy=%.4f report=%s\n", res.Metrics["route_accuracy"], res.Metrics["question_type_accuracy"], dir)
+	return nil
+}
+
+// historyMessages turns dataset turns, alternating user and assistant, into
+// conversation messages.
+func historyMessages(turns []string) []domain.Message {
+	out := make([]domain.Message, 0, len(turns))
+	for i, turn := range turns {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		out = append(out, domain.Message{Role: role, Content: turn})
+	}
+	return out
+}
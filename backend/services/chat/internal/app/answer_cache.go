@@ -0,0 +1,290 @@
+package app
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+const (
+	// defaultAnswerCacheMinSimilarity is the cosine similarity between
+	// question embeddings above which a cached answer is reused.
+	defaultAnswerCacheMinSimilarity = 0.95
+	// defaultAnswerCacheEntries bounds the cached answers kept per book; the
+	// oldest is dropped first.
+	defaultAnswerCacheEntries = 200
+	// answerCacheBookLimit bounds how many books have cached answers; the
+	// least recently used book is dropped first.
+	answerCacheBookLimit = 512
+)
+
+// cachedAnswer is a grounded answer kept for reuse by similar questions.
+type cachedAnswer struct {
+	// PromptTemplate keeps answers in one language from serving another.
+	PromptTemplate   string
+	Vector           []float32
+	Question         string
+	MessageID        string
+	Answer           string
+	Citations        []domain.Source
+	Claims           []domain.ClaimCitation
+	SelectedEvidence []domain.Evidence
+}
+
+type bookAnswers struct {
+	version  int64
+	lastUsed uint64
+	entries  []cachedAnswer
+}
+
+// AnswerCacheStats counts answer cache use since the process started.
+type AnswerCacheStats struct {
+	Lookups      int64 `json:"lookups"`
+	Hits         int64 `json:"hits"`
+	Books        int   `json:"books"`
+	EvictedBooks int64 `json:"evictedBooks"`
+}
+
+// answerCache holds grounded answers per book version and finds one whose
+// question embedding is close to a new question's. The version is the
+// book's UpdatedAt, which reprocessing moves forward, so a reprocessed
+// book's answers are dropped on the next lookup or store.
+//
+// The cache lives in this process only: replicas and restarts start cold,
+// so its hit rate (see Stats) is per process.
+type answerCache struct {
+	minSimilarity float64
+	maxEntries    int
+
+	mu    sync.Mutex
+	books map[string]*bookAnswers
+	tick  uint64
+	stats AnswerCacheStats
+}
+
+func newAnswerCache(minSimilarity float64, maxEntries int) *answerCache {
+	if minSimilarity <= 0 {
+		minSimilarity = defaultAnswerCacheMinSimilarity
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultAnswerCacheEntries
+	}
+	return &answerCache{minSimilarity: minSimilarity, maxEntries: maxEntries, books: map[string]*bookAnswers{}}
+}
+
+// lookup returns the most similar cached answer for the book version and
+// prompt template, if it clears the similarity threshold.
+func (c *answerCache) lookup(bookID string, version int64, promptTemplate string, vector []float32) (cachedAnswer, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Lookups++
+	book := c.current(bookID, version)
+	if book == nil {
+		return cachedAnswer{}, 0, false
+	}
+	var (
+		best      cachedAnswer
+		bestScore float64
+		found     bool
+	)
+	for _, entry := range book.entries {
+		if entry.PromptTemplate != promptTemplate {
+			continue
+		}
+		score := cosineSimilarity(vector, entry.Vector)
+		if score >= c.minSimilarity && (!found || score > bestScore) {
+			best, bestScore, found = entry, score, true
+		}
+	}
+	if found {
+		c.stats.Hits++
+	}
+	return best, bestScore, found
+}
+
+func (c *answerCache) store(bookID string, version int64, entry cachedAnswer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	book := c.current(bookID, version)
+	if book == nil {
+		if len(c.books) >= answerCacheBookLimit {
+			c.evictLeastRecentlyUsed()
+		}
+		book = &bookAnswers{version: version}
+		c.books[bookID] = book
+		c.touch(book)
+	}
+	if len(book.entries) >= c.maxEntries {
+		book.entries = append(book.entries[:0], book.entries[len(book.entries)-c.maxEntries+1:]...)
+	}
+	book.entries = append(book.entries, entry)
+}
+
+// invalidate drops every cached answer for a book.
+func (c *answerCache) invalidate(bookID string) {
+	c.mu.Lock()
+	delete(c.books, bookID)
+	c.mu.Unlock()
+}
+
+// Stats returns the cache's counters.
+func (c *answerCache) Stats() AnswerCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Books = len(c.books)
+	return stats
+}
+
+// current returns the book's answers for version, dropping answers from an
+// earlier version, and marks them used. Callers hold mu.
+func (c *answerCache) current(bookID string, version int64) *bookAnswers {
+	book, ok := c.books[bookID]
+	if !ok {
+		return nil
+	}
+	if book.version != version {
+		delete(c.books, bookID)
+		return nil
+	}
+	c.touch(book)
+	return book
+}
+
+func (c *answerCache) touch(book *bookAnswers) {
+	c.tick++
+	book.lastUsed = c.tick
+}
+
+// evictLeastRecentlyUsed drops the book whose answers were looked up or
+// stored longest ago. Callers hold mu.
+func (c *answerCache) evictLeastRecentlyUsed() {
+	var (
+		oldestID string
+		oldest   uint64
+	)
+	for id, book := range c.books {
+		if oldestID == "" || book.lastUsed < oldest {
+			oldestID, oldest = id, book.lastUsed
+		}
+	}
+	if oldestID != "" {
+		delete(c.books, oldestID)
+		c.stats.EvictedBooks++
+	}
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+type answerCacheBypassKey struct{}
+
+// WithoutAnswerCache makes answering skip the answer cache for ctx: the
+// question is retrieved and generated afresh, and its answer is not cached.
+func WithoutAnswerCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, answerCacheBypassKey{}, true)
+}
+
+func answerCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(answerCacheBypassKey{}).(bool)
+	return bypass
+}
+
+// answerCacheProbe is a cache lookup for one question, kept so a fresh
+// answer can be stored under the same key.
+type answerCacheProbe struct {
+	bookID         string
+	version        int64
+	promptTemplate string
+	vector         []float32
+}
+
+// probeAnswerCache embeds the first retrieval query of a single-book,
+// unscoped retrieval question and looks it up. The embedding is kept on ctx
+// so dense retrieval reuses it on a miss. The book is the one the request
+// was authorized against, so its status and version are current. It returns
+// a nil probe when the question is not cacheable or the cache cannot be
+// consulted.
+func (a *App) probeAnswerCache(ctx context.Context, book domain.Book, plan domain.QueryPlan, prompts promptSet) (*answerCacheProbe, *cachedAnswer, float64) {
+	if a.answerCache == nil || a.embedder == nil || answerCacheBypassed(ctx) {
+		return nil, nil, 0
+	}
+	if queryRoute(plan.Route) != queryRouteRAG || plan.Scope != nil {
+		return nil, nil, 0
+	}
+	if book.Status != domain.StatusReady {
+		a.answerCache.invalidate(book.ID)
+		return nil, nil, 0
+	}
+	query := answerCacheQuery(plan)
+	if query == "" {
+		return nil, nil, 0
+	}
+	vector, err := a.embedQuery(ctx, query)
+	if err != nil || len(vector) == 0 {
+		return nil, nil, 0
+	}
+	probe := &answerCacheProbe{bookID: book.ID, version: book.UpdatedAt.UnixNano(), promptTemplate: prompts.Version, vector: vector}
+	hit, similarity, ok := a.answerCache.lookup(probe.bookID, probe.version, probe.promptTemplate, vector)
+	if !ok {
+		return probe, nil, 0
+	}
+	return probe, &hit, similarity
+}
+
+// answerCacheQuery is the text a question is cached under: its first
+// retrieval query, normally the normalized standalone question, which dense
+// retrieval embeds anyway.
+func answerCacheQuery(plan domain.QueryPlan) string {
+	for _, query := range plan.RetrievalQueries {
+		if query = retrieval.NormalizeText(query); query != "" {
+			return query
+		}
+	}
+	return retrieval.NormalizeText(firstNonEmpty(plan.StandaloneQuestion, plan.OriginalQuestion))
+}
+
+// AnswerCacheStats reports answer cache use in this process; ok is false
+// when the cache is disabled.
+func (a *App) AnswerCacheStats() (AnswerCacheStats, bool) {
+	if a.answerCache == nil {
+		return AnswerCacheStats{}, false
+	}
+	return a.answerCache.Stats(), true
+}
+
+// storeCachedAnswer keeps a fresh answer that passed grounding validation
+// for later similar questions. Best-effort answers, given when abstaining is
+// disabled, are never cached.
+func (a *App) storeCachedAnswer(probe *answerCacheProbe, answer domain.Answer, trace domain.AnswerTrace) {
+	if probe == nil || !a.abstainEnabled || answer.Abstained || !trace.ValidationResult.Passed || strings.TrimSpace(answer.Answer) == "" {
+		return
+	}
+	a.answerCache.store(probe.bookID, probe.version, cachedAnswer{
+		PromptTemplate:   probe.promptTemplate,
+		Vector:           probe.vector,
+		Question:         answer.Question,
+		MessageID:        answer.MessageID,
+		Answer:           answer.Answer,
+		Citations:        answer.Citations,
+		Claims:           answer.Claims,
+		SelectedEvidence: trace.SelectedEvidence,
+	})
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+)
+
+func TestAnswerCacheReturnsSimilarAnswerForSameVersionAndTemplate(t *testing.T) {
+	cache := newAnswerCache(0.9, 10)
+	cache.store("b1", 1, cachedAnswer{PromptTemplate: "en-v1", Vector: []float32{1, 0, 0}, MessageID: "m1", Answer: "Hayek argues..."})
+
+	hit, similarity, ok := cache.lookup("b1", 1, "en-v1", []float32{0.98, 0.1, 0})
+	if !ok || hit.MessageID != "m1" || similarity < 0.9 {
+		t.Fatalf("lookup() = %+v, %.3f, %v; want the cached answer", hit, similarity, ok)
+	}
+	misses := []struct {
+		name     string
+		book     string
+		version  int64
+		template string
+		vector   []float32
+	}{
+		{name: "dissimilar question", book: "b1", version: 1, template: "en-v1", vector: []float32{0, 1, 0}},
+		{name: "other language", book: "b1", version: 1, template: "zh-v1", vector: []float32{1, 0, 0}},
+		{name: "other book", book: "b2", version: 1, template: "en-v1", vector: []float32{1, 0, 0}},
+	}
+	for _, tt := range misses {
+		if _, _, ok := cache.lookup(tt.book, tt.version, tt.template, tt.vector); ok {
+			t.Fatalf("%s: lookup() hit, want a miss", tt.name)
+		}
+	}
+}
+
+func TestAnswerCacheDropsAnswersFromEarlierVersion(t *testing.T) {
+	cache := newAnswerCache(0.9, 10)
+	cache.store("b1", 1, cachedAnswer{PromptTemplate: "en-v1", Vector: []float32{1, 0}, MessageID: "m1"})
+
+	if _, _, ok := cache.lookup("b1", 2, "en-v1", []float32{1, 0}); ok {
+		t.Fatal("lookup() after reprocess hit, want a miss")
+	}
+	if _, _, ok := cache.lookup("b1", 1, "en-v1", []float32{1, 0}); ok {
+		t.Fatal("answers from the earlier version should have been dropped")
+	}
+}
+
+func TestAnswerCacheEvictsOldestPerBook(t *testing.T) {
+	cache := newAnswerCache(0.99, 2)
+	for i, vector := range [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		cache.store("b1", 1, cachedAnswer{PromptTemplate: "en-v1", Vector: vector, MessageID: string(rune('a' + i))})
+	}
+	if _, _, ok := cache.lookup("b1", 1, "en-v1", []float32{1, 0, 0}); ok {
+		t.Fatal("oldest answer should have been evicted")
+	}
+	if hit, _, ok := cache.lookup("b1", 1, "en-v1", []float32{0, 0, 1}); !ok || hit.MessageID != "c" {
+		t.Fatalf("lookup() = %+v, %v; want the newest answer", hit, ok)
+	}
+}
+
+func TestAnswerCacheEvictsLeastRecentlyUsedBook(t *testing.T) {
+	cache := newAnswerCache(0.9, 10)
+	entry := cachedAnswer{PromptTemplate: "en-v1", Vector: []float32{1, 0}, MessageID: "m1"}
+	for i := 0; i < answerCacheBookLimit; i++ {
+		cache.store(fmt.Sprintf("b%d", i), 1, entry)
+	}
+	// b0 is the oldest store but was just used, so b1 goes first.
+	if _, _, ok := cache.lookup("b0", 1, "en-v1", []float32{1, 0}); !ok {
+		t.Fatal("lookup(b0) missed before the cache was full")
+	}
+	cache.store("new", 1, entry)
+
+	for _, id := range []string{"b0", "b2", "new"} {
+		if _, _, ok := cache.lookup(id, 1, "en-v1", []float32{1, 0}); !ok {
+			t.Fatalf("lookup(%s) missed, want it kept", id)
+		}
+	}
+	if _, _, ok := cache.lookup("b1", 1, "en-v1", []float32{1, 0}); ok {
+		t.Fatal("least recently used book should have been evicted")
+	}
+	stats := cache.Stats()
+	if stats.Books != answerCacheBookLimit || stats.EvictedBooks != 1 || stats.Hits != 4 || stats.Lookups != 5 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+}
+
+type stubEmbedder struct {
+	vector  []float32
+	calls   int
+	queries []string
+}
+
+func (s *stubEmbedder) EmbedText(_ context.Context, text, _ string) ([]float32, error) {
+	s.calls++
+	s.queries = append(s.queries, text)
+	return s.vector, nil
+}
+
+func TestProbeAnswerCacheFollowsBookVersion(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	book := domain.Book{ID: "b1", Status: domain.StatusReady, UpdatedAt: updated}
+	embedder := &stubEmbedder{vector: []float32{1, 0}}
+	a := &App{embedder: embedder, answerCache: newAnswerCache(0.95, 10), abstainEnabled: true}
+	plan := domain.QueryPlan{Route: string(queryRouteRAG), OriginalQuestion: "Who wrote it?", StandaloneQuestion: "Who wrote it?"}
+
+	probe, hit, _ := a.probeAnswerCache(context.Background(), book, plan, enPrompts)
+	if probe == nil || hit != nil || probe.version != updated.UnixNano() {
+		t.Fatalf("first probe = %+v, %+v; want a miss for the book's version", probe, hit)
+	}
+	a.storeCachedAnswer(probe, domain.Answer{MessageID: "m1", Question: "Who wrote it?", Answer: "Hayek [1]."}, domain.AnswerTrace{ValidationResult: domain.ValidationResult{Passed: true}})
+
+	if _, hit, _ := a.probeAnswerCache(context.Background(), book, plan, enPrompts); hit == nil || hit.MessageID != "m1" {
+		t.Fatalf("second probe hit = %+v, want m1", hit)
+	}
+	if probe, _, _ := a.probeAnswerCache(WithoutAnswerCache(context.Background()), book, plan, enPrompts); probe != nil {
+		t.Fatal("probe with the cache bypassed should not consult the cache")
+	}
+
+	book.UpdatedAt = updated.Add(time.Hour)
+	if _, hit, _ := a.probeAnswerCache(context.Background(), book, plan, enPrompts); hit != nil {
+		t.Fatalf("probe after reprocess hit = %+v, want a miss", hit)
+	}
+	book.Status = domain.StatusProcessing
+	if probe, _, _ := a.probeAnswerCache(context.Background(), book, plan, enPrompts); probe != nil {
+		t.Fatal("probe for a book that is not ready should not consult the cache")
+	}
+}
+
+func TestProbeAnswerCacheSharesEmbeddingWithRetrieval(t *testing.T) {
+	embedder := &stubEmbedder{vector: []float32{1, 0}}
+	a := &App{embedder: embedder, answerCache: newAnswerCache(0.95, 10)}
+	book := domain.Book{ID: "b1", Status: domain.StatusReady}
+	plan := domain.QueryPlan{Route: string(queryRouteRAG), StandaloneQuestion: "Who wrote it?", RetrievalQueries: []string{"who wrote it?", "author"}}
+	ctx := withQueryEmbeddings(context.Background())
+
+	if probe, _, _ := a.probeAnswerCache(ctx, book, plan, enPrompts); probe == nil {
+		t.Fatal("expected a cache probe")
+	}
+	for _, query := range plan.RetrievalQueries {
+		if _, err := a.embedQuery(ctx, query); err != nil {
+			t.Fatalf("embedQuery(%q): %v", query, err)
+		}
+	}
+	if embedder.calls != 2 || embedder.queries[0] != "who wrote it?" {
+		t.Fatalf("embedded %v, want each retrieval query once", embedder.queries)
+	}
+}
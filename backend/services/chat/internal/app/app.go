@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"onebookai/internal/util"
@@ -24,41 +25,87 @@ type Config struct {
 	GenerationAPIKey         string
 	GenerationModel          string
 	GenerationEnableThinking *bool
-	EmbeddingProvider        string
-	EmbeddingBaseURL         string
-	EmbeddingModel           string
-	EmbeddingDim             int
-	TopK                     int
-	DenseRecallTopK          int
-	LexicalRecallTopK        int
-	DenseWeight              float64
-	LexicalWeight            float64
-	FusionTopK               int
-	HistoryLimit             int
-	QdrantURL                string
-	QdrantAPIKey             string
-	QdrantCollection         string
-	OpenSearchURL            string
-	OpenSearchIndex          string
-	OpenSearchUsername       string
-	OpenSearchPassword       string
-	RerankTopN               int
-	RetrievalMode            string
-	RerankerURL              string
-	ContextBudget            int
-	MinEvidenceCount         int
-	QueryRewriteEnabled      bool
-	MultiQueryEnabled        bool
-	AbstainEnabled           bool
+	// GenerationFallbacks are tried in order when the primary provider fails
+	// or its circuit breaker is open.
+	GenerationFallbacks       []GenerationFallback
+	GenerationBreakerFailures int
+	GenerationBreakerOpen     time.Duration
+	GenerationSlowCall        time.Duration
+	// GenerationPricing maps lower-cased model names to token prices used to
+	// estimate the cost recorded with each answer.
+	GenerationPricing   map[string]ModelPrice
+	EmbeddingProvider   string
+	EmbeddingBaseURL    string
+	EmbeddingModel      string
+	EmbeddingDim        int
+	TopK                int
+	DenseRecallTopK     int
+	LexicalRecallTopK   int
+	DenseWeight         float64
+	LexicalWeight       float64
+	SparseWeight        float64
+	FusionTopK          int
+	HistoryLimit        int
+	VectorStore         string
+	QdrantURL           string
+	QdrantAPIKey        string
+	QdrantCollection    string
+	LexicalStore        string
+	LexicalFallback     bool
+	OpenSearchURL       string
+	OpenSearchIndex     string
+	OpenSearchUsername  string
+	OpenSearchPassword  string
+	RerankTopN          int
+	RetrievalMode       string
+	RerankerURL         string
+	ContextBudget       int
+	MinEvidenceCount    int
+	QueryRewriteEnabled bool
+	MultiQueryEnabled   bool
+	AbstainEnabled      bool
+	// CitationEntailmentEnabled asks the generator whether each answer claim
+	// is entailed by its evidence span, on top of the lexical alignment.
+	CitationEntailmentEnabled bool
+	// SummaryEnabled keeps a rolling summary of turns that have scrolled out
+	// of the history window and feeds it back into later questions.
+	SummaryEnabled bool
+	// FollowUpRetrievalSteps bounds the follow-up retrieval rounds run when
+	// the first retrieval finds too little evidence; 0 abstains at once.
+	FollowUpRetrievalSteps int
+	// FollowUpRetrievalBudget bounds the wall time of those rounds.
+	FollowUpRetrievalBudget time.Duration
+	// ModelRouterEnabled routes questions with the generation model before
+	// the keyword heuristics, which remain the fallback on error or when the
+	// model's confidence is below ModelRouterMinConfidence.
+	ModelRouterEnabled       bool
+	ModelRouterMinConfidence float64
+	// AnswerCacheEnabled reuses a grounded answer for a question on the same
+	// book version whose embedding is at least AnswerCacheMinSimilarity
+	// similar to an earlier one; at most AnswerCacheMaxEntries per book.
+	AnswerCacheEnabled       bool
+	AnswerCacheMinSimilarity float64
+	AnswerCacheMaxEntries    int
+}
+
+// GenerationFallback describes one provider in the generation failover chain.
+type GenerationFallback struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	Model    string
 }
 
 // App is the core application service wiring together storage and chat logic.
 type App struct {
 	store               store.Store
 	generator           ai.TextGenerator
+	generatorName       string
+	pricing             map[string]ModelPrice
 	embedder            ai.Embedder
-	search              *retrieval.Client
-	lexical             *retrieval.OpenSearchClient
+	search              retrieval.VectorStore
+	lexical             retrieval.LexicalStore
+	lexicalFallback     retrieval.LexicalStore
 	rewriter            QueryRewriter
 	reranker            retrieval.Reranker
 	validator           GroundingValidator
@@ -67,6 +114,7 @@ type App struct {
 	lexicalRecallTopK   int
 	denseWeight         float64
 	lexicalWeight       float64
+	sparseWeight        float64
 	fusionTopK          int
 	historyLimit        int
 	rerankTopN          int
@@ -76,6 +124,15 @@ type App struct {
 	queryRewriteEnabled bool
 	multiQueryEnabled   bool
 	abstainEnabled      bool
+	entailment          EntailmentChecker
+	summaryEnabled      bool
+	followUpPlanner     FollowUpPlanner
+	followUpSteps       int
+	followUpBudget      time.Duration
+	router              *QueryRouter
+	answerCache         *answerCache
+	// summarizing holds conversation IDs with a summary update in flight.
+	summarizing sync.Map
 }
 
 // New constructs the application with database-backed storage for messages.
@@ -98,17 +155,40 @@ func New(cfg Config) (*App, error) {
 	if cfg.EmbeddingModel == "" {
 		return nil, fmt.Errorf("embedding model required")
 	}
-	searchClient, err := retrieval.NewQdrantClient(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection, cfg.EmbeddingDim)
+	searchClient, err := retrieval.NewVectorStore(retrieval.VectorStoreConfig{
+		Backend:          cfg.VectorStore,
+		QdrantURL:        cfg.QdrantURL,
+		QdrantAPIKey:     cfg.QdrantAPIKey,
+		QdrantCollection: cfg.QdrantCollection,
+		DatabaseURL:      cfg.DatabaseURL,
+		DenseSize:        cfg.EmbeddingDim,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("init qdrant client: %w", err)
-	}
-	lexicalClient, err := retrieval.NewOpenSearchClient(cfg.OpenSearchURL, cfg.OpenSearchIndex, cfg.OpenSearchUsername, cfg.OpenSearchPassword)
+		return nil, fmt.Errorf("init vector store: %w", err)
+	}
+	lexicalClient, err := retrieval.NewLexicalStore(retrieval.LexicalStoreConfig{
+		Backend:            cfg.LexicalStore,
+		OpenSearchURL:      cfg.OpenSearchURL,
+		OpenSearchIndex:    cfg.OpenSearchIndex,
+		OpenSearchUsername: cfg.OpenSearchUsername,
+		OpenSearchPassword: cfg.OpenSearchPassword,
+		DatabaseURL:        cfg.DatabaseURL,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("init opensearch client: %w", err)
+		return nil, fmt.Errorf("init lexical store: %w", err)
+	}
+	// The Postgres full-text index is only queried when the primary lexical
+	// backend errors; the indexer keeps it in sync when the fallback is enabled.
+	var lexicalFallback retrieval.LexicalStore
+	if cfg.LexicalFallback && retrieval.NormalizeLexicalStoreBackend(cfg.LexicalStore) != retrieval.LexicalStorePostgres {
+		lexicalFallback, err = retrieval.NewPostgresLexicalStore(cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("init lexical fallback: %w", err)
+		}
 	}
 
 	// Build text generator based on provider.
-	generator, err := buildGenerator(cfg)
+	generator, err := NewTextGenerator(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("init text generator: %w", err)
 	}
@@ -173,14 +253,34 @@ func New(cfg Config) (*App, error) {
 	queryRewriteEnabled := cfg.QueryRewriteEnabled
 	multiQueryEnabled := cfg.MultiQueryEnabled
 	abstainEnabled := cfg.AbstainEnabled
+	var entailment EntailmentChecker
+	if cfg.CitationEntailmentEnabled {
+		entailment = newModelEntailmentChecker(generator)
+	}
+	var routeGenerator ai.TextGenerator
+	if cfg.ModelRouterEnabled {
+		routeGenerator = generator
+	}
+	var answers *answerCache
+	if cfg.AnswerCacheEnabled {
+		answers = newAnswerCache(cfg.AnswerCacheMinSimilarity, cfg.AnswerCacheMaxEntries)
+	}
+	followUpSteps := max(cfg.FollowUpRetrievalSteps, 0)
+	followUpBudget := cfg.FollowUpRetrievalBudget
+	if followUpBudget <= 0 {
+		followUpBudget = defaultFollowUpBudget
+	}
 
 	return &App{
-		store:     dataStore,
-		generator: generator,
-		embedder:  embedder,
-		search:    searchClient,
-		lexical:   lexicalClient,
-		rewriter:  newModelQueryRewriter(generator),
+		store:           dataStore,
+		generator:       generator,
+		generatorName:   generationProviderName(cfg.GenerationProvider, cfg.GenerationModel),
+		pricing:         cfg.GenerationPricing,
+		embedder:        embedder,
+		search:          searchClient,
+		lexical:         lexicalClient,
+		lexicalFallback: lexicalFallback,
+		rewriter:        newModelQueryRewriter(generator),
 		reranker: retrieval.ChainReranker{
 			Primary:  retrieval.NewServiceReranker(cfg.RerankerURL, 8*time.Second, 50, 2400),
 			Fallback: retrieval.FallbackReranker{},
@@ -191,6 +291,7 @@ func New(cfg Config) (*App, error) {
 		lexicalRecallTopK:   lexicalRecallTopK,
 		denseWeight:         denseWeight,
 		lexicalWeight:       lexicalWeight,
+		sparseWeight:        cfg.SparseWeight,
 		fusionTopK:          fusionTopK,
 		historyLimit:        historyLimit,
 		rerankTopN:          rerankTopN,
@@ -200,43 +301,89 @@ func New(cfg Config) (*App, error) {
 		queryRewriteEnabled: queryRewriteEnabled,
 		multiQueryEnabled:   multiQueryEnabled,
 		abstainEnabled:      abstainEnabled,
+		entailment:          entailment,
+		summaryEnabled:      cfg.SummaryEnabled,
+		followUpPlanner:     newModelFollowUpPlanner(generator),
+		followUpSteps:       followUpSteps,
+		followUpBudget:      followUpBudget,
+		router:              NewQueryRouter(routeGenerator, cfg.ModelRouterMinConfidence),
+		answerCache:         answers,
 	}, nil
 }
 
-// buildGenerator constructs the TextGenerator for the configured provider.
-func buildGenerator(cfg Config) (ai.TextGenerator, error) {
-	provider := strings.ToLower(strings.TrimSpace(cfg.GenerationProvider))
+// NewTextGenerator constructs the TextGenerator for the configured provider,
+// wrapped in a failover chain when fallback providers are configured.
+func NewTextGenerator(cfg Config) (ai.TextGenerator, error) {
+	primary, err := buildProviderGenerator(cfg.GenerationProvider, cfg.GenerationBaseURL, cfg.GenerationAPIKey, cfg.GenerationModel, cfg.GenerationEnableThinking)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.GenerationFallbacks) == 0 {
+		return primary, nil
+	}
+	providers := []ai.FailoverProvider{{Name: generationProviderName(cfg.GenerationProvider, cfg.GenerationModel), Generator: primary}}
+	for _, fallback := range cfg.GenerationFallbacks {
+		apiKey := fallback.APIKey
+		if apiKey == "" {
+			apiKey = cfg.GenerationAPIKey
+		}
+		generator, err := buildProviderGenerator(fallback.Provider, fallback.BaseURL, apiKey, fallback.Model, cfg.GenerationEnableThinking)
+		if err != nil {
+			return nil, fmt.Errorf("generation fallback %s: %w", fallback.Provider, err)
+		}
+		providers = append(providers, ai.FailoverProvider{Name: generationProviderName(fallback.Provider, fallback.Model), Generator: generator})
+	}
+	return ai.NewFailoverGenerator(providers, ai.BreakerConfig{
+		FailureThreshold:  cfg.GenerationBreakerFailures,
+		OpenDuration:      cfg.GenerationBreakerOpen,
+		SlowCallThreshold: cfg.GenerationSlowCall,
+	})
+}
+
+func buildProviderGenerator(provider, baseURL, apiKey, model string, enableThinking *bool) (ai.TextGenerator, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
 	if provider == "" {
 		provider = "gemini"
 	}
 	switch provider {
 	case "gemini":
-		client, err := ai.NewGeminiClient(cfg.GenerationAPIKey)
+		client, err := ai.NewGeminiClient(apiKey)
 		if err != nil {
 			return nil, err
 		}
-		return ai.NewGeminiGenerator(client, cfg.GenerationModel), nil
+		return ai.NewGeminiGenerator(client, model), nil
 	case "ollama":
-		client := ai.NewOllamaClient(cfg.GenerationBaseURL)
-		return ai.NewOllamaGenerator(client, cfg.GenerationModel), nil
+		client := ai.NewOllamaClient(baseURL)
+		return ai.NewOllamaGenerator(client, model), nil
 	case "openai-compat":
-		if cfg.GenerationBaseURL == "" {
+		if baseURL == "" {
 			return nil, fmt.Errorf("generationBaseURL required for openai-compat provider")
 		}
 		return ai.NewOpenAICompatGenerator(
-			cfg.GenerationBaseURL,
-			cfg.GenerationAPIKey,
-			cfg.GenerationModel,
-			ai.OpenAICompatConfig{EnableThinking: cfg.GenerationEnableThinking},
+			baseURL,
+			apiKey,
+			model,
+			ai.OpenAICompatConfig{EnableThinking: enableThinking},
 		), nil
 	default:
 		return nil, fmt.Errorf("unknown generation provider: %s", provider)
 	}
 }
 
-// AskQuestion performs an evidence-grounded question/answer flow bound to a book and conversation.
-func (a *App) AskQuestion(user domain.User, book domain.Book, question string, conversationID string, idempotencyKey string, includeDebug bool) (domain.Answer, bool, error) {
-	return a.askQuestion(context.Background(), user, book, question, conversationID, idempotencyKey, includeDebug, nil)
+// generationProviderName labels a provider/model pair in answer traces.
+func generationProviderName(provider, model string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = "gemini"
+	}
+	return provider + "/" + strings.TrimSpace(model)
+}
+
+// AskQuestion performs an evidence-grounded question/answer flow bound to a
+// book and conversation. scope, when set, narrows the question to part of the
+// book; see NormalizePassageScope.
+func (a *App) AskQuestion(ctx context.Context, user domain.User, book domain.Book, question string, scope *domain.PassageScope, conversationID string, idempotencyKey string, includeDebug bool) (domain.Answer, bool, error) {
+	return a.askQuestion(ctx, user, []domain.Book{book}, question, scope, conversationID, idempotencyKey, includeDebug, nil, branchPoint{})
 }
 
 // AskQuestionStream performs the same question/answer flow as AskQuestion but
@@ -246,60 +393,108 @@ func (a *App) AskQuestionStream(
 	user domain.User,
 	book domain.Book,
 	question string,
+	scope *domain.PassageScope,
 	conversationID string,
 	idempotencyKey string,
 	includeDebug bool,
 	onChunk func(string) error,
 ) (domain.Answer, bool, error) {
-	return a.askQuestion(ctx, user, book, question, conversationID, idempotencyKey, includeDebug, onChunk)
+	return a.askQuestion(ctx, user, []domain.Book{book}, question, scope, conversationID, idempotencyKey, includeDebug, onChunk, branchPoint{})
 }
 
 func (a *App) askQuestion(
 	ctx context.Context,
 	user domain.User,
-	book domain.Book,
+	books []domain.Book,
 	question string,
+	scope *domain.PassageScope,
 	conversationID string,
 	idempotencyKey string,
 	includeDebug bool,
 	onChunk func(string) error,
+	at branchPoint,
 ) (domain.Answer, bool, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	if book.OwnerID != user.ID && user.Role != domain.RoleAdmin {
-		return domain.Answer{}, false, fmt.Errorf("forbidden")
+	if len(books) == 0 {
+		return domain.Answer{}, false, fmt.Errorf("book required")
 	}
-	if book.Status != domain.StatusReady {
-		return domain.Answer{}, false, ErrBookNotReady
+	for _, book := range books {
+		if book.OwnerID != user.ID && user.Role != domain.RoleAdmin {
+			return domain.Answer{}, false, fmt.Errorf("forbidden")
+		}
+		if book.Status != domain.StatusReady {
+			return domain.Answer{}, false, ErrBookNotReady
+		}
 	}
 	if strings.TrimSpace(question) == "" {
 		return domain.Answer{}, false, fmt.Errorf("question required")
 	}
-	record, replayedAnswer, replayed, err := a.beginChatIdempotency(user.ID, book.ID, conversationID, question, idempotencyKey)
+	book := books[0]
+	shelf := len(books) > 1
+	// Regenerating an answer keeps the scope its question was asked with.
+	if scope == nil && at.question != nil && at.question.Metadata.QueryPlan != nil {
+		scope = at.question.Metadata.QueryPlan.Scope
+	}
+	if shelf {
+		scope = nil
+	}
+	ctx, usage := ai.WithUsageRecorder(ctx)
+	record, replayedAnswer, replayed, err := a.beginChatIdempotency(user.ID, strings.Join(bookIDsOf(books), ","), conversationID, question, scopeFingerprint(scope), at.fingerprint(), idempotencyKey)
 	if err != nil {
 		return domain.Answer{}, false, err
 	}
 	if replayed {
 		return replayedAnswer, true, nil
 	}
-	conversation, createConversation, err := a.ensureConversation(user, book, question, conversationID)
+	conversation, createConversation, err := a.ensureConversation(user, books, question, conversationID)
 	if err != nil {
 		return domain.Answer{}, false, err
 	}
+	// The question follows the active branch, or the fork point when
+	// regenerating or editing; history comes from that branch only.
+	var branch []domain.Message
+	if !createConversation {
+		tree, err := a.loadMessageTree(conversation.ID)
+		if err != nil {
+			return domain.Answer{}, false, fmt.Errorf("load history: %w", err)
+		}
+		if at.fork {
+			branch = tree.path(at.parentID)
+		} else {
+			branch = tree.path(tree.leaf(conversation.ActiveLeafID))
+		}
+	}
+	parentID := ""
+	if len(branch) > 0 {
+		parentID = branch[len(branch)-1].ID
+	}
 	var history []domain.Message
 	if a.historyLimit > 0 {
 		historyLimit := a.historyLimit * 2
 		if historyLimit < a.historyLimit {
 			historyLimit = a.historyLimit
 		}
-		history, err = a.store.ListConversationMessages(conversation.ID, historyLimit)
-		if err != nil {
-			return domain.Answer{}, false, fmt.Errorf("load history: %w", err)
-		}
+		history = branch[max(len(branch)-historyLimit, 0):]
+	}
+	summary := ""
+	if a.summaryEnabled && summaryCovers(conversation, branch) {
+		summary = conversation.Summary
 	}
-	historyText := buildHistory(history)
-	plan := a.buildQueryPlan(ctx, book, question, history)
+	prompts := promptsFor(ctx, question)
+	historyText := buildConversationMemory(prompts.Labels, summary, history)
+	var plan domain.QueryPlan
+	if shelf {
+		plan = a.buildShelfQueryPlan(ctx, books, question, history, summary)
+	} else {
+		plan = a.buildQueryPlan(ctx, book, question, history, summary)
+	}
+	plan = applyPassageScope(plan, scope)
+	reportProgress(ctx, ProgressPlan, planEvent(plan))
+	// Planning may call the generator too; only the answer generation below
+	// should be attributed in the trace.
+	ctx, generation := ai.WithGenerationReport(ctx)
 
 	var (
 		answerText string
@@ -307,56 +502,114 @@ func (a *App) askQuestion(
 		citations  []domain.Source
 		debugInfo  *domain.RetrievalDebug
 		trace      domain.AnswerTrace
+		claims     []domain.ClaimCitation
+		cacheProbe *answerCacheProbe
+		// evidenceText holds the full content of retrieved chunks so answer
+		// claims can be aligned beyond the truncated citation snippets.
+		evidenceText map[string]string
 	)
 	switch queryRoute(plan.Route) {
 	case queryRouteHistoryOnly:
-		answerText, citations, abstained, err = a.answerFromHistoryWithChunk(ctx, book, question, historyText, history, onChunk)
+		answerText, citations, abstained, err = a.answerFromHistoryWithChunk(ctx, prompts, book, question, historyText, history, onChunk)
 		if err != nil {
 			return domain.Answer{}, false, err
 		}
 		trace = domain.AnswerTrace{QueryPlan: plan, ValidationResult: domain.ValidationResult{Passed: !abstained, Reason: validationReason(abstained, "history only answer")}}
 	case queryRouteDocumentOverview:
-		answerText, citations, abstained, err = a.answerDocumentOverview(ctx, book, question, onChunk)
+		answerText, citations, abstained, err = a.answerDocumentOverview(ctx, prompts, book, question, onChunk)
 		if err != nil {
 			return domain.Answer{}, false, err
 		}
 		trace = domain.AnswerTrace{QueryPlan: plan, SelectedEvidence: sourcesToEvidence(citations, "document_overview"), ValidationResult: domain.ValidationResult{Passed: !abstained, Reason: validationReason(abstained, "document overview answer")}}
 	case queryRouteOutOfScopeReject:
 		if a.abstainEnabled {
-			answerText = outOfScopeAbstainAnswer + "\n\n缺少证据原因：这个问题超出当前上传文档和会话上下文。"
+			answerText = prompts.OutOfScopeAnswer + "\n\n" + prompts.ReasonLabel + prompts.OutOfScopeReason
 			abstained = true
 			trace = domain.AnswerTrace{QueryPlan: plan, ValidationResult: domain.ValidationResult{Passed: false, Reason: "out of scope"}}
 			break
 		}
 		fallthrough
 	default:
-		retrieved, routeDebug, routeErr := a.retrieveEvidenceForPlan(ctx, book, plan)
-		if routeErr != nil {
-			return domain.Answer{}, false, routeErr
+		ctx = withQueryEmbeddings(ctx)
+		// Regenerating asks for a new answer, so it never reuses one.
+		if !shelf && at.question == nil {
+			var cached *cachedAnswer
+			var similarity float64
+			cacheProbe, cached, similarity = a.probeAnswerCache(ctx, book, plan, prompts)
+			if cached != nil {
+				answerText = cached.Answer
+				citations = append([]domain.Source(nil), cached.Citations...)
+				claims = append([]domain.ClaimCitation(nil), cached.Claims...)
+				trace = domain.AnswerTrace{
+					QueryPlan:        plan,
+					SelectedEvidence: cached.SelectedEvidence,
+					ValidationResult: domain.ValidationResult{Passed: true, Reason: "answer cache hit"},
+					AnswerCache:      &domain.AnswerCacheHit{MessageID: cached.MessageID, Question: cached.Question, Similarity: similarity},
+				}
+				reportProgress(ctx, ProgressCitations, domain.CitationsEvent{Citations: citations})
+				if onChunk != nil {
+					if err := onChunk(answerText); err != nil {
+						return domain.Answer{}, false, err
+					}
+				}
+				break
+			}
 		}
-		selectedEvidence := []domain.Evidence{}
-		retrieved, selectedEvidence, err = a.selectEvidence(ctx, book, plan, retrieved, history)
-		if err != nil {
-			return domain.Answer{}, false, err
+		var (
+			retrieved        []retrieval.StageHit
+			routeDebug       *domain.RetrievalDebug
+			selectedEvidence []domain.Evidence
+			retrievalSteps   []domain.RetrievalStep
+		)
+		if shelf {
+			retrieved, routeDebug, err = a.retrieveShelfEvidence(ctx, books, plan)
+			if err != nil {
+				return domain.Answer{}, false, err
+			}
+			selectedEvidence = make([]domain.Evidence, 0, len(retrieved))
+			for _, hit := range retrieved {
+				selectedEvidence = append(selectedEvidence, hitToEvidence(hit))
+			}
+		} else {
+			var candidates []retrieval.StageHit
+			candidates, routeDebug, err = a.retrieveEvidenceForPlan(ctx, book, plan)
+			if err != nil {
+				return domain.Answer{}, false, err
+			}
+			retrieved, selectedEvidence, err = a.selectEvidence(ctx, book, plan, candidates, history)
+			if err != nil {
+				return domain.Answer{}, false, err
+			}
+			retrieved, selectedEvidence, retrievalSteps, err = a.retrieveFollowUpEvidence(ctx, book, plan, candidates, retrieved, selectedEvidence, history, routeDebug)
+			if err != nil {
+				return domain.Answer{}, false, err
+			}
 		}
 		debugInfo = routeDebug
-		contextText, routeCitations := buildContextWithEvidence(retrieved, selectedEvidence)
+		reportProgress(ctx, ProgressRetrieval, retrievalEvent(debugInfo, len(selectedEvidence)))
+		evidenceText = make(map[string]string, len(retrieved))
+		for _, hit := range retrieved {
+			evidenceText[hit.Chunk.ID] = hit.Chunk.Content
+		}
+		contextText, routeCitations := buildContextWithEvidence(retrieved, selectedEvidence, books)
 		citations = routeCitations
-		validation := validateEvidenceSelection(plan, retrieved, citations, a.abstainEnabled)
+		validation := validateEvidenceSelection(prompts, plan, retrieved, citations, a.abstainEnabled)
 		abstained = !validation.Passed
-		answerText = abstainAnswerForPlan(plan, validation.Reason)
+		answerText = prompts.abstainWithReason(validation.Reason)
 		if !abstained {
+			reportProgress(ctx, ProgressCitations, domain.CitationsEvent{Citations: citations})
 			var userPrompt string
-			promptRequirement := answerRequirementForPlan(plan)
-			systemPrompt := "你是一个严格基于证据回答的文档专家。不要使用证据外知识。每个结论都必须可由提供证据支持；引用相关编号。"
+			promptRequirement := prompts.requirement(plan.QuestionType)
+			systemPrompt := prompts.StrictSystem
 			if !a.abstainEnabled {
-				promptRequirement = "要求：优先基于证据回答；引用相关编号；证据不足时可以给出谨慎的最佳努力回答，并明确说明不确定性，但不要编造引用。"
-				systemPrompt = "你是一个优先基于证据回答的读书助手。可以在证据不足时给出谨慎的最佳努力回答，但必须明确不确定性，且不要虚构引用或把证据外信息说成确定事实。"
+				promptRequirement = prompts.BestEffortRequirement
+				systemPrompt = prompts.BestEffortSystem
 			}
-			if historyText != "" {
-				userPrompt = buildStructuredAnswerPrompt(book, plan, historyText, contextText, promptRequirement)
+			if shelf {
+				systemPrompt += prompts.ShelfSystemSuffix
+				userPrompt = buildShelfAnswerPrompt(prompts, books, plan, historyText, contextText, promptRequirement)
 			} else {
-				userPrompt = buildStructuredAnswerPrompt(book, plan, "", contextText, promptRequirement)
+				userPrompt = buildStructuredAnswerPrompt(prompts, book, plan, historyText, contextText, promptRequirement)
 			}
 			response, genErr := a.generateAnswerText(ctx, systemPrompt, userPrompt, onChunk)
 			if genErr == nil {
@@ -366,13 +619,13 @@ func (a *App) askQuestion(
 			}
 			if strings.TrimSpace(answerText) == "" {
 				abstained = true
-				validation = domain.ValidationResult{Passed: false, Reason: "模型没有生成可用回答"}
-				answerText = abstainAnswerForPlan(plan, validation.Reason)
+				validation = domain.ValidationResult{Passed: false, Reason: prompts.EmptyAnswerReason}
+				answerText = prompts.abstainWithReason(validation.Reason)
 			}
 			if !abstained && a.abstainEnabled && !a.validator.Validate(question, answerText, citations) {
 				abstained = true
-				validation = domain.ValidationResult{Passed: false, Reason: "回答没有充分绑定到已选证据"}
-				answerText = abstainAnswerForPlan(plan, validation.Reason)
+				validation = domain.ValidationResult{Passed: false, Reason: prompts.UngroundedReason}
+				answerText = prompts.abstainWithReason(validation.Reason)
 				citations = nil
 				selectedEvidence = nil
 			}
@@ -380,15 +633,32 @@ func (a *App) askQuestion(
 		if !abstained && validation.Reason == "" {
 			validation = domain.ValidationResult{Passed: true, Reason: "selected evidence satisfies answer policy"}
 		}
-		trace = domain.AnswerTrace{QueryPlan: plan, SelectedEvidence: selectedEvidence, ValidationResult: validation}
+		trace = domain.AnswerTrace{QueryPlan: plan, SelectedEvidence: selectedEvidence, ValidationResult: validation, RetrievalSteps: retrievalSteps}
 		enrichRetrievalDebug(debugInfo, trace)
 	}
+	if abstained {
+		reportProgress(ctx, ProgressAbstain, domain.AbstainEvent{Reason: trace.ValidationResult.Reason})
+	}
+	trace.AnswerLanguage = prompts.Language
+	trace.PromptTemplate = prompts.Version
+	trace.GenerationProvider = generation.Provider()
+	trace.GenerationFailovers = generation.FailedOver()
+	// Alignment may call the generator for entailment checks, so it runs after
+	// the answering provider has been read from the generation report.
+	if !abstained && evidenceText != nil {
+		claims, citations = a.alignAnswerClaims(ctx, answerText, citations, evidenceText)
+		trace.ValidationResult.UnsupportedClaims = countUnsupportedClaims(claims)
+	}
+	answerUsage := summarizeUsage(usage.Calls(), a.pricing)
 	answer := domain.Answer{
 		Conversation: conversation,
 		Question:     question,
 		Answer:       answerText,
 		Citations:    citations,
+		Claims:       claims,
 		Abstained:    abstained,
+		Cached:       trace.AnswerCache != nil,
+		Usage:        answerUsage,
 		CreatedAt:    time.Now().UTC(),
 	}
 	if includeDebug {
@@ -400,24 +670,32 @@ func (a *App) askQuestion(
 		ConversationID: conversation.ID,
 		UserID:         user.ID,
 		BookID:         book.ID,
+		ParentID:       parentID,
 		Role:           "user",
 		Content:        question,
 		Metadata:       domain.MessageMetadata{QueryPlan: &plan},
 		CreatedAt:      userMessageTime,
 	}
+	if at.question != nil {
+		userMessage = *at.question
+	}
 	assistantMessageTime := time.Now().UTC()
 	assistantMessage := domain.Message{
 		ID:             util.NewID(),
 		ConversationID: conversation.ID,
 		UserID:         user.ID,
 		BookID:         book.ID,
+		ParentID:       userMessage.ID,
 		Role:           "assistant",
 		Content:        answer.Answer,
 		Sources:        answer.Citations,
-		Metadata:       domain.MessageMetadata{AnswerTrace: &trace, KeyEntities: book.DocumentEntities, SelectedChunkIDs: selectedChunkIDs(trace.SelectedEvidence)},
+		Metadata:       domain.MessageMetadata{AnswerTrace: &trace, KeyEntities: book.DocumentEntities, SelectedChunkIDs: selectedChunkIDs(trace.SelectedEvidence), Claims: claims, Usage: answerUsage},
 		Abstained:      abstained,
 		CreatedAt:      assistantMessageTime,
 	}
+	answer.QuestionMessageID = userMessage.ID
+	answer.MessageID = assistantMessage.ID
+	answer.Conversation.ActiveLeafID = assistantMessage.ID
 	answer.Conversation.LastMessageAt = &assistantMessageTime
 	answer.Conversation.UpdatedAt = assistantMessageTime
 	var completedRecord *domain.IdempotencyRecord
@@ -434,22 +712,32 @@ func (a *App) askQuestion(
 		record.ResponseJSON = responseJSON
 		completedRecord = &record
 	}
-	if err := a.store.SaveConversationExchange(conversation, createConversation, userMessage, assistantMessage, completedRecord); err != nil {
+	if at.question != nil {
+		if err := a.store.SaveConversationReply(conversation, assistantMessage, completedRecord); err != nil {
+			return domain.Answer{}, false, fmt.Errorf("save conversation reply: %w", err)
+		}
+	} else if err := a.store.SaveConversationExchange(conversation, createConversation, userMessage, assistantMessage, completedRecord); err != nil {
 		return domain.Answer{}, false, fmt.Errorf("save conversation exchange: %w", err)
 	}
+	if trace.AnswerCache == nil {
+		a.storeCachedAnswer(cacheProbe, answer, trace)
+	}
+	a.scheduleConversationSummary(ctx, conversation.ID)
 	return answer, false, nil
 }
 
 func (a *App) answerFromHistory(ctx context.Context, book domain.Book, question string, historyText string, history []domain.Message) (string, []domain.Source, bool) {
-	answer, citations, abstained, err := a.answerFromHistoryWithChunk(ctx, book, question, historyText, history, nil)
+	prompts := promptsFor(ctx, question)
+	answer, citations, abstained, err := a.answerFromHistoryWithChunk(ctx, prompts, book, question, historyText, history, nil)
 	if err != nil {
-		return defaultAbstainAnswer, nil, true
+		return prompts.AbstainAnswer, nil, true
 	}
 	return answer, citations, abstained
 }
 
 func (a *App) answerFromHistoryWithChunk(
 	ctx context.Context,
+	prompts promptSet,
 	book domain.Book,
 	question string,
 	historyText string,
@@ -457,16 +745,16 @@ func (a *App) answerFromHistoryWithChunk(
 	onChunk func(string) error,
 ) (string, []domain.Source, bool, error) {
 	if strings.TrimSpace(historyText) == "" {
-		return defaultAbstainAnswer, nil, true, nil
+		return prompts.AbstainAnswer, nil, true, nil
 	}
-	requirement := "要求：只基于当前对话历史继续回答，不要引入对话外知识；如果历史不足以支撑回答，就明确说明证据不足。"
-	systemPrompt := "你是一个延续当前会话上下文的读书助手。只使用给定对话历史，不要虚构新的事实。"
+	requirement := prompts.HistoryRequirement
+	systemPrompt := prompts.HistorySystem
 	if !a.abstainEnabled {
-		requirement = "要求：只基于当前对话历史继续回答，不要引入对话外知识；如果历史不足，也可以给出谨慎的最佳努力回答，并明确说明不确定性。"
-		systemPrompt = "你是一个延续当前会话上下文的读书助手。只使用给定对话历史，不要虚构新的事实；在信息不足时可以给出谨慎回答，但要明确不确定性。"
+		requirement = prompts.HistoryBestEffortRequirement
+		systemPrompt = prompts.HistoryBestEffortSystem
 	}
-	userPrompt := fmt.Sprintf("书名：%s\n对话历史：\n%s\n\n当前问题：%s\n\n%s", book.Title, historyText, question, requirement)
-	answerText := defaultAbstainAnswer
+	userPrompt := fmt.Sprintf(prompts.HistoryUser, book.Title, historyText, question, requirement)
+	answerText := prompts.AbstainAnswer
 	response, err := a.generateAnswerText(ctx, systemPrompt, userPrompt, onChunk)
 	if err == nil {
 		answerText = strings.TrimSpace(response)
@@ -474,18 +762,23 @@ func (a *App) answerFromHistoryWithChunk(
 		return "", nil, false, err
 	}
 	if strings.TrimSpace(answerText) == "" {
-		return defaultAbstainAnswer, nil, true, nil
+		return prompts.AbstainAnswer, nil, true, nil
 	}
-	if a.abstainEnabled && (strings.Contains(answerText, "证据不足") || strings.Contains(strings.ToLower(answerText), "insufficient")) {
-		return defaultAbstainAnswer, nil, true, nil
+	if a.abstainEnabled && signalsInsufficientEvidence(answerText) {
+		return prompts.AbstainAnswer, nil, true, nil
 	}
 	return answerText, latestAssistantSources(history), false, nil
 }
 
 func (a *App) generateAnswerText(ctx context.Context, systemPrompt string, userPrompt string, onChunk func(string) error) (string, error) {
+	ctx = ai.WithUsageStage(ctx, usageStageAnswer)
 	if onChunk != nil {
 		if streamer, ok := a.generator.(ai.StreamingTextGenerator); ok {
-			return streamer.GenerateTextStream(ctx, systemPrompt, userPrompt, onChunk)
+			response, err := streamer.GenerateTextStream(ctx, systemPrompt, userPrompt, onChunk)
+			if err == nil {
+				ai.RecordGenerationProvider(ctx, a.generatorName)
+			}
+			return response, err
 		}
 	}
 
@@ -493,6 +786,7 @@ func (a *App) generateAnswerText(ctx context.Context, systemPrompt string, userP
 	if err != nil {
 		return "", err
 	}
+	ai.RecordGenerationProvider(ctx, a.generatorName)
 	response = strings.TrimSpace(response)
 	if onChunk != nil && response != "" {
 		if err := onChunk(response); err != nil {
@@ -573,54 +867,52 @@ func (a *App) DeleteConversation(user domain.User, conversationID string) error
 	return nil
 }
 
-// GetConversationBookID returns the bookId stored on an existing conversation.
-// This allows the server layer to resolve the correct book without requiring the
-// frontend to track which book each conversation belongs to.
-func (a *App) GetConversationBookID(user domain.User, conversationID string) (string, error) {
+// GetConversationBookIDs returns the books stored on an existing conversation,
+// primary book first. This allows the server layer to resolve the correct books
+// without requiring the frontend to track which books a conversation covers.
+func (a *App) GetConversationBookIDs(user domain.User, conversationID string) ([]string, error) {
 	conversationID = strings.TrimSpace(conversationID)
 	if conversationID == "" {
-		return "", fmt.Errorf("conversation id required")
+		return nil, fmt.Errorf("conversation id required")
 	}
 	conversation, ok, err := a.store.GetConversation(conversationID)
 	if err != nil {
-		return "", fmt.Errorf("load conversation: %w", err)
+		return nil, fmt.Errorf("load conversation: %w", err)
 	}
 	if !ok {
-		return "", ErrConversationNotFound
+		return nil, ErrConversationNotFound
 	}
 	if conversation.UserID != user.ID && user.Role != domain.RoleAdmin {
-		return "", ErrConversationForbidden
+		return nil, ErrConversationForbidden
+	}
+	if len(conversation.BookIDs) > 0 {
+		return conversation.BookIDs, nil
+	}
+	if conversation.BookID == "" {
+		return nil, nil
 	}
-	return conversation.BookID, nil
+	return []string{conversation.BookID}, nil
 }
 
-// ListConversationMessages lists conversation messages in chronological order.
+// ListConversationMessages lists the last limit messages of the active branch
+// in chronological order.
 func (a *App) ListConversationMessages(user domain.User, conversationID string, limit int) ([]domain.Message, error) {
-	conversationID = strings.TrimSpace(conversationID)
-	if conversationID == "" {
-		return nil, fmt.Errorf("conversation id required")
-	}
-	conversation, ok, err := a.store.GetConversation(conversationID)
+	conversation, err := a.ownedConversation(user, conversationID)
 	if err != nil {
-		return nil, fmt.Errorf("load conversation: %w", err)
-	}
-	if !ok {
-		return nil, ErrConversationNotFound
-	}
-	if conversation.UserID != user.ID && user.Role != domain.RoleAdmin {
-		return nil, ErrConversationForbidden
+		return nil, err
 	}
 	if limit <= 0 || limit > 500 {
 		limit = 200
 	}
-	items, err := a.store.ListConversationMessages(conversationID, limit)
+	tree, err := a.loadMessageTree(conversation.ID)
 	if err != nil {
-		return nil, fmt.Errorf("list conversation messages: %w", err)
+		return nil, err
 	}
-	return items, nil
+	items := tree.branch(conversation.ActiveLeafID)
+	return items[max(len(items)-limit, 0):], nil
 }
 
-func (a *App) ensureConversation(user domain.User, book domain.Book, question string, conversationID string) (domain.Conversation, bool, error) {
+func (a *App) ensureConversation(user domain.User, books []domain.Book, question string, conversationID string) (domain.Conversation, bool, error) {
 	conversationID = strings.TrimSpace(conversationID)
 	if conversationID != "" {
 		conversation, ok, err := a.store.GetConversation(conversationID)
@@ -640,12 +932,15 @@ func (a *App) ensureConversation(user domain.User, book domain.Book, question st
 	conversation := domain.Conversation{
 		ID:            util.NewID(),
 		UserID:        user.ID,
-		BookID:        book.ID,
+		BookID:        books[0].ID,
 		Title:         generateConversationTitle(question),
 		LastMessageAt: &now,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
+	if len(books) > 1 {
+		conversation.BookIDs = bookIDsOf(books)
+	}
 	return conversation, true, nil
 }
 
@@ -701,12 +996,13 @@ func buildContext(hits []retrieval.StageHit) (string, []domain.Source) {
 			SourceRef: strings.TrimSpace(chunk.Metadata["source_ref"]),
 			Score:     hit.Score,
 			Language:  strings.TrimSpace(chunk.Metadata["language"]),
+			Anchor:    domain.ChunkCitationAnchor(chunk),
 		})
 	}
 	return sb.String(), sources
 }
 
-func buildHistory(messages []domain.Message) string {
+func buildHistory(labels promptLabels, messages []domain.Message) string {
 	if len(messages) == 0 {
 		return ""
 	}
@@ -715,12 +1011,12 @@ func buildHistory(messages []domain.Message) string {
 		role := strings.ToLower(strings.TrimSpace(msg.Role))
 		switch role {
 		case "user":
-			role = "用户"
+			role = labels.UserRole
 		case "assistant":
-			role = "助手"
+			role = labels.AssistantRole
 		default:
 			if role == "" {
-				role = "消息"
+				role = labels.OtherRole
 			}
 		}
 		sb.WriteString(role)
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"onebookai/pkg/domain"
+)
+
+// branchPoint says where a new exchange attaches in the conversation tree.
+// The zero value continues the active branch.
+type branchPoint struct {
+	// fork starts a sibling branch after parentID instead of continuing the
+	// active leaf; an empty parentID forks at the root.
+	fork     bool
+	parentID string
+	// question is an existing user message to answer again; no new question
+	// message is stored.
+	question *domain.Message
+}
+
+// fingerprint tells regenerating and editing apart from asking the same
+// question, so an idempotency key cannot replay across them.
+func (at branchPoint) fingerprint() string {
+	switch {
+	case at.question != nil:
+		return "regenerate:" + at.question.ID
+	case at.fork:
+		return "edit:" + at.parentID
+	default:
+		return ""
+	}
+}
+
+// messageTree indexes a conversation's messages, given in creation order.
+type messageTree struct {
+	messages []domain.Message
+	byID     map[string]int
+	parents  map[string]string
+	children map[string][]string
+}
+
+func newMessageTree(messages []domain.Message) *messageTree {
+	tree := &messageTree{
+		messages: messages,
+		byID:     make(map[string]int, len(messages)),
+		parents:  make(map[string]string, len(messages)),
+		children: make(map[string][]string, len(messages)),
+	}
+	for i, msg := range messages {
+		tree.byID[msg.ID] = i
+	}
+	for _, msg := range messages {
+		parent := strings.TrimSpace(msg.ParentID)
+		if _, ok := tree.byID[parent]; !ok {
+			parent = ""
+		}
+		tree.parents[msg.ID] = parent
+		tree.children[parent] = append(tree.children[parent], msg.ID)
+	}
+	return tree
+}
+
+func (t *messageTree) message(id string) (domain.Message, bool) {
+	i, ok := t.byID[id]
+	if !ok {
+		return domain.Message{}, false
+	}
+	return t.messages[i], true
+}
+
+// leaf resolves the branch ending at or below id: from a message with
+// replies, the most recent reply is followed down. An unknown or empty id
+// resolves to the latest message.
+func (t *messageTree) leaf(id string) string {
+	if _, ok := t.byID[id]; !ok {
+		if len(t.messages) == 0 {
+			return ""
+		}
+		return t.messages[len(t.messages)-1].ID
+	}
+	for {
+		children := t.children[id]
+		if len(children) == 0 {
+			return id
+		}
+		id = children[len(children)-1]
+	}
+}
+
+// path returns the messages from the root down to id, oldest first.
+func (t *messageTree) path(id string) []domain.Message {
+	var reversed []domain.Message
+	for id != "" {
+		msg, ok := t.message(id)
+		if !ok {
+			break
+		}
+		reversed = append(reversed, msg)
+		id = t.parents[id]
+	}
+	out := make([]domain.Message, len(reversed))
+	for i, msg := range reversed {
+		out[len(reversed)-1-i] = msg
+	}
+	return out
+}
+
+// branch returns the active branch for leafID, annotating messages that have
+// siblings.
+func (t *messageTree) branch(leafID string) []domain.Message {
+	path := t.path(t.leaf(leafID))
+	for i := range path {
+		if siblings := t.children[t.parents[path[i].ID]]; len(siblings) > 1 {
+			path[i].SiblingIDs = append([]string(nil), siblings...)
+		}
+	}
+	return path
+}
+
+// branches lists every root-to-leaf path, most recently updated first.
+func (t *messageTree) branches(activeLeafID string) []domain.ConversationBranch {
+	active := t.leaf(activeLeafID)
+	var out []domain.ConversationBranch
+	for _, msg := range t.messages {
+		if len(t.children[msg.ID]) > 0 {
+			continue
+		}
+		path := t.path(msg.ID)
+		preview := ""
+		for i := len(path) - 1; i >= 0; i-- {
+			if path[i].Role == "user" {
+				preview = truncateRunes(strings.Join(strings.Fields(path[i].Content), " "), 80)
+				break
+			}
+		}
+		out = append(out, domain.ConversationBranch{
+			LeafID:       msg.ID,
+			MessageCount: len(path),
+			Preview:      preview,
+			Active:       msg.ID == active,
+			UpdatedAt:    msg.CreatedAt,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out
+}
+
+// summaryCovers reports whether the conversation summary was built from this
+// branch, i.e. the last summarized message is on it.
+func summaryCovers(conversation domain.Conversation, branch []domain.Message) bool {
+	if conversation.SummarizedThrough == nil || strings.TrimSpace(conversation.Summary) == "" {
+		return false
+	}
+	for _, msg := range branch {
+		if msg.CreatedAt.Equal(*conversation.SummarizedThrough) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) loadMessageTree(conversationID string) (*messageTree, error) {
+	messages, err := a.store.ListConversationMessages(conversationID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("load conversation messages: %w", err)
+	}
+	return newMessageTree(messages), nil
+}
+
+func (a *App) ownedConversation(user domain.User, conversationID string) (domain.Conversation, error) {
+	conversationID = strings.TrimSpace(conversationID)
+	if conversationID == "" {
+		return domain.Conversation{}, fmt.Errorf("conversation id required")
+	}
+	conversation, ok, err := a.store.GetConversation(conversationID)
+	if err != nil {
+		return domain.Conversation{}, fmt.Errorf("load conversation: %w", err)
+	}
+	if !ok {
+		return domain.Conversation{}, ErrConversationNotFound
+	}
+	if conversation.UserID != user.ID && user.Role != domain.RoleAdmin {
+		return domain.Conversation{}, ErrConversationForbidden
+	}
+	return conversation, nil
+}
+
+// RegenerateAnswer answers the question behind an assistant message again.
+// The new answer is stored as a sibling of the old one and becomes the active
+// branch. A replayed idempotency key returns the stored answer instead of
+// adding another branch.
+func (a *App) RegenerateAnswer(ctx context.Context, user domain.User, books []domain.Book, conversationID string, messageID string, idempotencyKey string, includeDebug bool) (domain.Answer, bool, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	msg, ok := tree.message(strings.TrimSpace(messageID))
+	if !ok {
+		return domain.Answer{}, false, ErrMessageNotFound
+	}
+	if msg.Role != "assistant" {
+		return domain.Answer{}, false, ErrMessageNotAnswer
+	}
+	question, ok := tree.message(tree.parents[msg.ID])
+	if !ok || question.Role != "user" {
+		return domain.Answer{}, false, ErrMessageNotAnswer
+	}
+	at := branchPoint{fork: true, parentID: tree.parents[question.ID], question: &question}
+	return a.askQuestion(ctx, user, books, question.Content, nil, conversation.ID, idempotencyKey, includeDebug, nil, at)
+}
+
+// EditQuestion asks an edited version of a user message. The new question
+// starts a sibling branch from the same point and becomes the active branch.
+// Idempotency keys replay as in RegenerateAnswer.
+func (a *App) EditQuestion(ctx context.Context, user domain.User, books []domain.Book, conversationID string, messageID string, question string, idempotencyKey string, includeDebug bool) (domain.Answer, bool, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	msg, ok := tree.message(strings.TrimSpace(messageID))
+	if !ok {
+		return domain.Answer{}, false, ErrMessageNotFound
+	}
+	if msg.Role != "user" {
+		return domain.Answer{}, false, ErrMessageNotQuestion
+	}
+	at := branchPoint{fork: true, parentID: tree.parents[msg.ID]}
+	return a.askQuestion(ctx, user, books, question, nil, conversation.ID, idempotencyKey, includeDebug, nil, at)
+}
+
+// ListConversationBranches lists the branches of a conversation.
+func (a *App) ListConversationBranches(user domain.User, conversationID string) ([]domain.ConversationBranch, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+	return tree.branches(conversation.ActiveLeafID), nil
+}
+
+// SwitchConversationBranch makes the branch through messageID active. A
+// message with replies selects its most recent continuation.
+func (a *App) SwitchConversationBranch(user domain.User, conversationID string, messageID string) (domain.Conversation, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.Conversation{}, err
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.Conversation{}, err
+	}
+	if _, ok := tree.message(strings.TrimSpace(messageID)); !ok {
+		return domain.Conversation{}, ErrMessageNotFound
+	}
+	leaf := tree.leaf(strings.TrimSpace(messageID))
+	if err := a.store.SetConversationActiveLeaf(conversation.ID, leaf); err != nil {
+		return domain.Conversation{}, fmt.Errorf("switch conversation branch: %w", err)
+	}
+	conversation.ActiveLeafID = leaf
+	return conversation, nil
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/store"
+)
+
+// branchedMessages builds q1 → a1 → q2 → a2 with a2 regenerated as a2b and q2
+// edited as q2b → a3, in creation order.
+func branchedMessages() []domain.Message {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return base.Add(time.Duration(minute) * time.Minute) }
+	return []domain.Message{
+		{ID: "q1", Role: "user", Content: "第一章讲了什么", CreatedAt: at(0)},
+		{ID: "a1", ParentID: "q1", Role: "assistant", Content: "讲了背景。", CreatedAt: at(1)},
+		{ID: "q2", ParentID: "a1", Role: "user", Content: "作者是谁", CreatedAt: at(2)},
+		{ID: "a2", ParentID: "q2", Role: "assistant", Content: "张三。", CreatedAt: at(3)},
+		{ID: "a2b", ParentID: "q2", Role: "assistant", Content: "作者是张三。", CreatedAt: at(4)},
+		{ID: "q2b", ParentID: "a1", Role: "user", Content: "作者的生平", CreatedAt: at(5)},
+		{ID: "a3", ParentID: "q2b", Role: "assistant", Content: "生于 1900 年。", CreatedAt: at(6)},
+	}
+}
+
+func messageIDs(messages []domain.Message) []string {
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestMessageTreeBranchFollowsActiveLeaf(t *testing.T) {
+	tree := newMessageTree(branchedMessages())
+
+	if got, want := messageIDs(tree.branch("a2")), []string{"q1", "a1", "q2", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("branch(a2) = %v, want %v", got, want)
+	}
+	// Without an active leaf the latest message wins.
+	if got, want := messageIDs(tree.branch("")), []string{"q1", "a1", "q2b", "a3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("branch() = %v, want %v", got, want)
+	}
+	// Selecting a message with replies follows its most recent continuation.
+	if got := tree.leaf("q2"); got != "a2b" {
+		t.Fatalf("leaf(q2) = %q, want a2b", got)
+	}
+	if got := tree.path(""); len(got) != 0 {
+		t.Fatalf("path at root = %v, want empty", messageIDs(got))
+	}
+}
+
+func TestMessageTreeBranchAnnotatesSiblings(t *testing.T) {
+	branch := newMessageTree(branchedMessages()).branch("a2b")
+	siblings := map[string][]string{}
+	for _, msg := range branch {
+		siblings[msg.ID] = msg.SiblingIDs
+	}
+	if got, want := siblings["q2"], []string{"q2", "q2b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("q2 siblings = %v, want %v", got, want)
+	}
+	if got, want := siblings["a2b"], []string{"a2", "a2b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("a2b siblings = %v, want %v", got, want)
+	}
+	if siblings["q1"] != nil || siblings["a1"] != nil {
+		t.Fatalf("unbranched messages should have no siblings: %v", siblings)
+	}
+}
+
+func TestMessageTreeBranchesListsLeaves(t *testing.T) {
+	branches := newMessageTree(branchedMessages()).branches("a2")
+	if len(branches) != 3 {
+		t.Fatalf("branches = %+v, want 3", branches)
+	}
+	if got, want := []string{branches[0].LeafID, branches[1].LeafID, branches[2].LeafID}, []string{"a3", "a2b", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("branch order = %v, want %v", got, want)
+	}
+	for _, branch := range branches {
+		if branch.Active != (branch.LeafID == "a2") {
+			t.Fatalf("branch %s active = %v", branch.LeafID, branch.Active)
+		}
+		if branch.MessageCount != 4 {
+			t.Fatalf("branch %s message count = %d, want 4", branch.LeafID, branch.MessageCount)
+		}
+	}
+	if branches[0].Preview != "作者的生平" {
+		t.Fatalf("preview = %q, want the last question", branches[0].Preview)
+	}
+}
+
+func TestSummaryCoversOnlyItsBranch(t *testing.T) {
+	messages := branchedMessages()
+	tree := newMessageTree(messages)
+	through := messages[3].CreatedAt // a2
+	conversation := domain.Conversation{Summary: "用户在问作者。", SummarizedThrough: &through}
+
+	if !summaryCovers(conversation, tree.branch("a2")) {
+		t.Fatal("expected summary to cover the branch it was built on")
+	}
+	if summaryCovers(conversation, tree.branch("a3")) {
+		t.Fatal("expected summary from another branch to be ignored")
+	}
+	if summaryCovers(domain.Conversation{}, tree.branch("a2")) {
+		t.Fatal("expected no summary to cover nothing")
+	}
+}
+
+// replyStore holds one conversation and its idempotency records, and keeps
+// the replies saved to it.
+type replyStore struct {
+	store.Store
+	conversation domain.Conversation
+	messages     []domain.Message
+	records      map[string]domain.IdempotencyRecord
+	replies      int
+}
+
+func (s *replyStore) GetConversation(id string) (domain.Conversation, bool, error) {
+	return s.conversation, id == s.conversation.ID, nil
+}
+
+func (s *replyStore) ListConversationMessages(string, int) ([]domain.Message, error) {
+	return s.messages, nil
+}
+
+func (s *replyStore) GetIdempotencyRecord(scope, actorID, key string) (domain.IdempotencyRecord, bool, error) {
+	record, ok := s.records[scope+"/"+actorID+"/"+key]
+	return record, ok, nil
+}
+
+func (s *replyStore) SaveIdempotencyRecord(record domain.IdempotencyRecord) error {
+	s.records[record.Scope+"/"+record.ActorID+"/"+record.IdempotencyKey] = record
+	return nil
+}
+
+func (s *replyStore) SaveConversationReply(conversation domain.Conversation, reply domain.Message, record *domain.IdempotencyRecord) error {
+	s.replies++
+	s.messages = append(s.messages, reply)
+	s.conversation.ActiveLeafID = reply.ID
+	if record != nil {
+		return s.SaveIdempotencyRecord(*record)
+	}
+	return nil
+}
+
+func TestRegenerateAnswerReplaysIdempotencyKey(t *testing.T) {
+	user := domain.User{ID: "u1"}
+	book := domain.Book{ID: "b1", OwnerID: user.ID, Status: domain.StatusReady}
+	st := &replyStore{
+		conversation: domain.Conversation{ID: "c1", UserID: user.ID, BookID: book.ID, ActiveLeafID: "a1"},
+		messages: []domain.Message{
+			{ID: "q1", Role: "user", Content: "今天北京天气怎么样", CreatedAt: time.Now().Add(-time.Minute)},
+			{ID: "a1", ParentID: "q1", Role: "assistant", Content: "无法回答。", CreatedAt: time.Now().Add(-time.Minute)},
+		},
+		records: map[string]domain.IdempotencyRecord{},
+	}
+	a := &App{store: st, abstainEnabled: true}
+	books := []domain.Book{book}
+
+	first, replayed, err := a.RegenerateAnswer(context.Background(), user, books, "c1", "a1", "key-1", false)
+	if err != nil || replayed {
+		t.Fatalf("first regenerate = %v, replayed %v", err, replayed)
+	}
+	again, replayed, err := a.RegenerateAnswer(context.Background(), user, books, "c1", "a1", "key-1", false)
+	if err != nil || !replayed {
+		t.Fatalf("replayed regenerate = %v, replayed %v; want a replay", err, replayed)
+	}
+	if st.replies != 1 || again.MessageID != first.MessageID {
+		t.Fatalf("replay saved %d replies, answer %q vs %q; want one branch", st.replies, again.MessageID, first.MessageID)
+	}
+	if _, _, err := a.EditQuestion(context.Background(), user, books, "c1", "q1", "今天北京天气怎么样", "key-1", false); err == nil {
+		t.Fatal("reusing a regenerate key for an edit should be rejected")
+	}
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+// claimSupportThreshold is the share of a claim's tokens that must appear in
+// a citation for the claim to count as supported by it.
+const claimSupportThreshold = 0.3
+
+const (
+	entailmentEntailed    = "entailed"
+	entailmentNotEntailed = "not_entailed"
+)
+
+var citationMarkerPattern = regexp.MustCompile(`\[(\d+(?:\s*[-–,，、]\s*\d+)*)\]`)
+
+// EntailmentChecker decides whether each claim follows from its paired
+// evidence span. claims and evidence have the same length.
+type EntailmentChecker interface {
+	Check(ctx context.Context, claims, evidence []string) ([]bool, error)
+}
+
+type modelEntailmentChecker struct {
+	generator ai.TextGenerator
+}
+
+func newModelEntailmentChecker(generator ai.TextGenerator) EntailmentChecker {
+	return &modelEntailmentChecker{generator: generator}
+}
+
+func (c *modelEntailmentChecker) Check(ctx context.Context, claims, evidence []string) ([]bool, error) {
+	if c.generator == nil || len(claims) == 0 {
+		return nil, nil
+	}
+	var sb strings.Builder
+	for i, claim := range claims {
+		fmt.Fprintf(&sb, "%d.\nClaim: %s\nEvidence: %s\n\n", i+1, claim, evidence[i])
+	}
+	sb.WriteString(fmt.Sprintf("Return a JSON array of %d booleans; item i is true only if claim i is fully supported by its evidence.", len(claims)))
+	out, err := c.generator.GenerateText(ai.WithUsageStage(ctx, usageStageCitationVerify), "You check whether claims are entailed by evidence. Output valid JSON only.", sb.String())
+	if err != nil {
+		return nil, err
+	}
+	var verdicts []bool
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &verdicts); err != nil {
+		return nil, err
+	}
+	if len(verdicts) != len(claims) {
+		return nil, fmt.Errorf("entailment check returned %d verdicts for %d claims", len(verdicts), len(claims))
+	}
+	return verdicts, nil
+}
+
+// answerSpan is one sentence of a text with rune offsets into it.
+type answerSpan struct {
+	text  string
+	start int
+	end   int
+}
+
+// alignAnswerClaims splits an answer into sentences, maps each to the [n]
+// markers it carries and to the best-supporting sentence of the cited
+// evidence, and marks citations nothing in the answer uses. evidenceText maps
+// chunk IDs to full chunk content; snippets are used when it is missing.
+func (a *App) alignAnswerClaims(ctx context.Context, answer string, citations []domain.Source, evidenceText map[string]string) ([]domain.ClaimCitation, []domain.Source) {
+	claims := alignClaims(answer, citations, evidenceText)
+	if len(claims) == 0 {
+		return nil, citations
+	}
+	if a.entailment != nil {
+		a.checkClaimEntailment(ctx, claims)
+	}
+	return claims, markUnusedCitations(citations, claims)
+}
+
+func alignClaims(answer string, citations []domain.Source, evidenceText map[string]string) []domain.ClaimCitation {
+	if len(citations) == 0 || strings.TrimSpace(answer) == "" {
+		return nil
+	}
+	language := retrieval.DetectLanguage(answer)
+	evidence := make([]string, len(citations))
+	for i, citation := range citations {
+		evidence[i] = firstNonEmpty(evidenceText[citation.ChunkID], citation.Snippet)
+	}
+	claims := make([]domain.ClaimCitation, 0, 8)
+	for _, span := range splitSentences(answer) {
+		plain := strings.TrimSpace(citationMarkerPattern.ReplaceAllString(span.text, ""))
+		tokens := claimTokens(plain, language)
+		heading := (strings.HasSuffix(plain, ":") || strings.HasSuffix(plain, "：")) && plain == strings.TrimSpace(span.text)
+		if len(tokens) < 2 || heading {
+			continue
+		}
+		claim := domain.ClaimCitation{
+			Text:      span.text,
+			Start:     span.start,
+			End:       span.end,
+			Citations: citationNumbers(span.text, len(citations)),
+		}
+		candidates := claim.Citations
+		if len(candidates) == 0 {
+			candidates = make([]int, len(citations))
+			for i := range citations {
+				candidates[i] = i + 1
+			}
+		}
+		for _, number := range candidates {
+			text := evidence[number-1]
+			support := validationTokenOverlap(tokens, claimTokens(text, language))
+			if support <= claim.Support && claim.SupportingCitation != 0 {
+				continue
+			}
+			claim.Support = support
+			claim.SupportingCitation = number
+			claim.EvidenceSpan = bestEvidenceSpan(tokens, text, language)
+		}
+		claim.Supported = claim.Support >= claimSupportThreshold
+		claims = append(claims, claim)
+	}
+	return claims
+}
+
+// checkClaimEntailment asks the entailment checker about every claim with an
+// evidence span. The LLM verdict overrides the lexical one; failures keep it.
+func (a *App) checkClaimEntailment(ctx context.Context, claims []domain.ClaimCitation) {
+	indexes := make([]int, 0, len(claims))
+	texts := make([]string, 0, len(claims))
+	spans := make([]string, 0, len(claims))
+	for i, claim := range claims {
+		if claim.EvidenceSpan == "" {
+			continue
+		}
+		indexes = append(indexes, i)
+		texts = append(texts, strings.TrimSpace(citationMarkerPattern.ReplaceAllString(claim.Text, "")))
+		spans = append(spans, claim.EvidenceSpan)
+	}
+	if len(indexes) == 0 {
+		return
+	}
+	verdicts, err := a.entailment.Check(ctx, texts, spans)
+	if err != nil {
+		util.LoggerFromContext(ctx).Warn("citation_entailment_failed", "claims", len(indexes), "err", err)
+		return
+	}
+	for i, index := range indexes {
+		claims[index].Supported = verdicts[i]
+		claims[index].Entailment = entailmentNotEntailed
+		if verdicts[i] {
+			claims[index].Entailment = entailmentEntailed
+		}
+	}
+}
+
+// markUnusedCitations returns a copy of citations where sources that no claim
+// references or is supported by carry Unused. Labels keep their numbering.
+func markUnusedCitations(citations []domain.Source, claims []domain.ClaimCitation) []domain.Source {
+	used := make(map[int]bool, len(citations))
+	for _, claim := range claims {
+		for _, number := range claim.Citations {
+			used[number] = true
+		}
+		if claim.Supported && claim.SupportingCitation > 0 {
+			used[claim.SupportingCitation] = true
+		}
+	}
+	out := make([]domain.Source, len(citations))
+	for i, citation := range citations {
+		citation.Unused = !used[i+1]
+		out[i] = citation
+	}
+	return out
+}
+
+func countUnsupportedClaims(claims []domain.ClaimCitation) int {
+	count := 0
+	for _, claim := range claims {
+		if !claim.Supported {
+			count++
+		}
+	}
+	return count
+}
+
+// splitSentences splits text at sentence terminators and line breaks. Citation
+// markers directly after a terminator stay with the sentence they follow.
+func splitSentences(text string) []answerSpan {
+	runes := []rune(text)
+	spans := make([]answerSpan, 0, 8)
+	start := 0
+	emit := func(end int) {
+		from, to := start, end
+		for from < to && unicode.IsSpace(runes[from]) {
+			from++
+		}
+		for to > from && unicode.IsSpace(runes[to-1]) {
+			to--
+		}
+		if from < to {
+			spans = append(spans, answerSpan{text: string(runes[from:to]), start: from, end: to})
+		}
+		start = end
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' {
+			emit(i + 1)
+			continue
+		}
+		if !isSentenceTerminator(runes, i) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(`"'”’」』）)`, runes[end]) {
+			end++
+		}
+		end = absorbCitationMarkers(runes, end)
+		emit(end)
+		i = end - 1
+	}
+	emit(len(runes))
+	return spans
+}
+
+func isSentenceTerminator(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '；', '!', '?', ';':
+		return true
+	case '.':
+		return i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || runes[i+1] == '['
+	}
+	return false
+}
+
+// absorbCitationMarkers extends end over "[n]" markers, optionally preceded by
+// spaces, that follow a sentence terminator.
+func absorbCitationMarkers(runes []rune, end int) int {
+	for {
+		next := end
+		for next < len(runes) && runes[next] == ' ' {
+			next++
+		}
+		loc := citationMarkerPattern.FindStringIndex(string(runes[next:]))
+		if loc == nil || loc[0] != 0 {
+			return end
+		}
+		end = next + utf8.RuneCountInString(string(runes[next:])[:loc[1]])
+	}
+}
+
+// citationNumbers returns the distinct 1-based citation numbers referenced by
+// [n], [n, m], [n、m] and [n-m] markers, ignoring numbers out of range.
+func citationNumbers(text string, count int) []int {
+	var numbers []int
+	seen := map[int]bool{}
+	add := func(n int) {
+		if n >= 1 && n <= count && !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(text, -1) {
+		parts := strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == '，' || r == '、' })
+		for _, part := range parts {
+			from, to, isRange := strings.Cut(strings.ReplaceAll(part, "–", "-"), "-")
+			low, err := strconv.Atoi(strings.TrimSpace(from))
+			if err != nil {
+				continue
+			}
+			high := low
+			if isRange {
+				if high, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+					continue
+				}
+			}
+			for n := low; n <= min(high, count); n++ {
+				add(n)
+			}
+		}
+	}
+	return numbers
+}
+
+// bestEvidenceSpan returns the evidence sentence covering most claim tokens.
+func bestEvidenceSpan(tokens []string, evidence, language string) string {
+	best, bestScore := "", -1.0
+	for _, span := range splitSentences(evidence) {
+		score := validationTokenOverlap(tokens, claimTokens(span.text, language))
+		if score > bestScore {
+			best, bestScore = span.text, score
+		}
+	}
+	if bestScore <= 0 {
+		return ""
+	}
+	return truncateRunes(best, 240)
+}
+
+// claimTokens tokenizes text for alignment. Chinese keeps only bigrams since
+// single characters match almost any evidence.
+func claimTokens(text, language string) []string {
+	tokens := retrieval.Tokenize(text, language)
+	if language != "zh" {
+		return tokens
+	}
+	out := tokens[:0:0]
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) >= 2 {
+			out = append(out, token)
+		}
+	}
+	if len(out) == 0 {
+		return tokens
+	}
+	return out
+}
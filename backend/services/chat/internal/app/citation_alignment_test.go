@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+func TestSplitSentencesKeepsTrailingCitationMarkers(t *testing.T) {
+	text := "Go 使用 goroutine 实现并发。[1] 通道用于通信[2]。\nChannels are typed. [2][3] Done"
+	spans := splitSentences(text)
+	got := make([]string, 0, len(spans))
+	for _, span := range spans {
+		got = append(got, span.text)
+		if string([]rune(text)[span.start:span.end]) != span.text {
+			t.Fatalf("span offsets %d..%d do not match %q", span.start, span.end, span.text)
+		}
+	}
+	want := []string{"Go 使用 goroutine 实现并发。[1]", "通道用于通信[2]。", "Channels are typed. [2][3]", "Done"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitSentences() = %#v, want %#v", got, want)
+	}
+}
+
+func TestCitationNumbersParsesListsAndRanges(t *testing.T) {
+	got := citationNumbers("见 [1, 3] 与 [2-4]、[5、9] 以及 [3]", 5)
+	want := []int{1, 3, 2, 4, 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("citationNumbers() = %v, want %v", got, want)
+	}
+}
+
+func TestAlignClaimsFlagsUnsupportedClaimsAndUnusedCitations(t *testing.T) {
+	citations := []domain.Source{
+		{Label: "[1]", ChunkID: "c1", Snippet: "Goroutines are lightweight threads"},
+		{Label: "[2]", ChunkID: "c2", Snippet: "Channels"},
+		{Label: "[3]", ChunkID: "c3", Snippet: "Garbage collection"},
+	}
+	evidence := map[string]string{
+		"c1": "Goroutines are lightweight threads managed by the Go runtime. They start with a small stack.",
+		"c2": "Channels let goroutines communicate by sending typed values.",
+	}
+	answer := "Goroutines are lightweight threads managed by the runtime [1]. The compiler emits WebAssembly for every program [2]. Channels send typed values between goroutines."
+
+	claims := alignClaims(answer, citations, evidence)
+	if len(claims) != 3 {
+		t.Fatalf("expected 3 claims, got %+v", claims)
+	}
+	first := claims[0]
+	if !first.Supported || first.SupportingCitation != 1 || !reflect.DeepEqual(first.Citations, []int{1}) {
+		t.Fatalf("unexpected first claim: %+v", first)
+	}
+	if first.EvidenceSpan != "Goroutines are lightweight threads managed by the Go runtime." {
+		t.Fatalf("unexpected evidence span %q", first.EvidenceSpan)
+	}
+	if claims[1].Supported {
+		t.Fatalf("expected second claim to be unsupported: %+v", claims[1])
+	}
+	if !claims[2].Supported || claims[2].SupportingCitation != 2 || len(claims[2].Citations) != 0 {
+		t.Fatalf("expected uncited claim to align to citation 2: %+v", claims[2])
+	}
+	if got := countUnsupportedClaims(claims); got != 1 {
+		t.Fatalf("countUnsupportedClaims() = %d, want 1", got)
+	}
+
+	marked := markUnusedCitations(citations, claims)
+	if marked[0].Unused || marked[1].Unused || !marked[2].Unused {
+		t.Fatalf("unexpected unused flags: %+v", marked)
+	}
+	if citations[2].Unused {
+		t.Fatal("markUnusedCitations must not modify its input")
+	}
+}
+
+type stubEntailmentChecker struct {
+	verdicts []bool
+	claims   []string
+}
+
+func (s *stubEntailmentChecker) Check(_ context.Context, claims, _ []string) ([]bool, error) {
+	s.claims = claims
+	return s.verdicts, nil
+}
+
+func TestAlignAnswerClaimsAppliesEntailmentVerdicts(t *testing.T) {
+	checker := &stubEntailmentChecker{verdicts: []bool{false}}
+	a := &App{entailment: checker}
+	citations := []domain.Source{{Label: "[1]", ChunkID: "c1", Snippet: "Go 语言通过 goroutine 实现轻量级并发。"}}
+
+	claims, marked := a.alignAnswerClaims(context.Background(), "Go 通过 goroutine 实现轻量级并发[1]。", citations, nil)
+	if len(claims) != 1 || len(checker.claims) != 1 || checker.claims[0] != "Go 通过 goroutine 实现轻量级并发。" {
+		t.Fatalf("unexpected claims %+v sent %+v", claims, checker.claims)
+	}
+	if claims[0].Supported || claims[0].Entailment != entailmentNotEntailed {
+		t.Fatalf("expected entailment verdict to override lexical support: %+v", claims[0])
+	}
+	if marked[0].Unused {
+		t.Fatalf("referenced citation must stay used: %+v", marked[0])
+	}
+}
+
+func TestModelEntailmentCheckerParsesVerdicts(t *testing.T) {
+	checker := newModelEntailmentChecker(stubGenerator{response: "[true, false]"})
+
+	got, err := checker.Check(context.Background(), []string{"a", "b"}, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !reflect.DeepEqual(got, []bool{true, false}) {
+		t.Fatalf("Check() = %v", got)
+	}
+	if _, err := checker.Check(context.Background(), []string{"a"}, []string{"x"}); err == nil {
+		t.Fatal("expected verdict count mismatch to fail")
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+	"onebookai/pkg/store"
+)
+
+const (
+	defaultConversationSearchPerPage = 20
+	maxConversationSearchPerPage     = 50
+	conversationSearchContextRunes   = 200
+)
+
+// ConversationSearchOptions controls one search over the caller's
+// conversation history. To is exclusive.
+type ConversationSearchOptions struct {
+	Query    string
+	BookIDs  []string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// SearchConversations finds the caller's questions and answers containing
+// every query term, using the Postgres full-text index over CJK bigrams and
+// Latin words. Hits carry a highlighted snippet and the surrounding exchange.
+func (a *App) SearchConversations(user domain.User, opts ConversationSearchOptions) (domain.ConversationSearchResult, error) {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return domain.ConversationSearchResult{}, fmt.Errorf("query required")
+	}
+	if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+		return domain.ConversationSearchResult{}, ErrSearchDateRangeInvalid
+	}
+	page := max(opts.Page, 1)
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultConversationSearchPerPage
+	}
+	pageSize = min(pageSize, maxConversationSearchPerPage)
+	result := domain.ConversationSearchResult{
+		Query:    query,
+		Items:    []domain.ConversationSearchHit{},
+		Page:     page,
+		PageSize: pageSize,
+	}
+	terms := retrieval.SearchQueryTerms(query)
+	if len(terms) == 0 {
+		return result, nil
+	}
+	hits, total, err := a.store.SearchConversationMessages(store.MessageSearchOptions{
+		UserID:   user.ID,
+		Terms:    terms,
+		BookIDs:  opts.BookIDs,
+		From:     opts.From,
+		To:       opts.To,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		return domain.ConversationSearchResult{}, fmt.Errorf("search conversations: %w", err)
+	}
+	result.Total = total
+	for _, hit := range hits {
+		msg := hit.Message
+		snippet := highlightTerms(msg.Content, append([]string(nil), terms...))
+		if snippet == "" {
+			snippet = html.EscapeString(truncateRunes(msg.Content, searchSnippetRunes))
+		}
+		question, answer := msg.Content, hit.ReplyContent
+		if msg.Role == "assistant" {
+			question, answer = hit.ParentContent, msg.Content
+		}
+		result.Items = append(result.Items, domain.ConversationSearchHit{
+			ConversationID:    msg.ConversationID,
+			ConversationTitle: hit.ConversationTitle,
+			MessageID:         msg.ID,
+			Role:              msg.Role,
+			BookID:            msg.BookID,
+			Snippet:           snippet,
+			Question:          truncateRunes(question, conversationSearchContextRunes),
+			Answer:            truncateRunes(answer, conversationSearchContextRunes),
+			Score:             hit.Score,
+			CreatedAt:         msg.CreatedAt,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+)
+
+func TestSearchConversationsRejectsInvertedDateRange(t *testing.T) {
+	a := &App{}
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	_, err := a.SearchConversations(domain.User{ID: "u1"}, ConversationSearchOptions{Query: "条约", From: &from, To: &to})
+	if !errors.Is(err, ErrSearchDateRangeInvalid) {
+		t.Fatalf("err = %v, want ErrSearchDateRangeInvalid", err)
+	}
+}
+
+func TestSearchConversationsSkipsQueriesWithoutTerms(t *testing.T) {
+	a := &App{}
+	result, err := a.SearchConversations(domain.User{ID: "u1"}, ConversationSearchOptions{Query: "？！…", PageSize: 500})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Items) != 0 || result.PageSize != maxConversationSearchPerPage {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestHighlightTermsMarksCJKBigrams(t *testing.T) {
+	got := highlightTerms("凡尔赛条约签订于1919年", []string{"条约"})
+	if got != "凡尔赛<mark>条约</mark>签订于1919年" {
+		t.Fatalf("highlight = %q", got)
+	}
+}
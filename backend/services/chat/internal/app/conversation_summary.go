@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+)
+
+const (
+	// summaryBatchLimit caps how many scrolled-out messages one update folds
+	// into the summary; a backlog is caught up over later exchanges.
+	summaryBatchLimit = 40
+	// summaryMessageRunes truncates each folded message in the prompt.
+	summaryMessageRunes = 600
+	// summaryMaxRunes bounds the stored summary.
+	summaryMaxRunes = 800
+	summaryTimeout  = 60 * time.Second
+)
+
+// scheduleConversationSummary folds turns that have left the history window
+// into the conversation summary in the background. At most one update runs
+// per conversation; a skipped update is picked up by the next exchange.
+func (a *App) scheduleConversationSummary(ctx context.Context, conversationID string) {
+	if !a.summaryEnabled || a.generator == nil || a.store == nil {
+		return
+	}
+	if _, busy := a.summarizing.LoadOrStore(conversationID, struct{}{}); busy {
+		return
+	}
+	logger := util.LoggerFromContext(ctx)
+	go func() {
+		defer a.summarizing.Delete(conversationID)
+		ctx, cancel := context.WithTimeout(util.ContextWithLogger(context.Background(), logger), summaryTimeout)
+		defer cancel()
+		if err := a.updateConversationSummary(ctx, conversationID); err != nil {
+			logger.Warn("conversation_summary_failed", "conversation_id", conversationID, "err", err)
+		}
+	}()
+}
+
+// updateConversationSummary summarizes the active branch's messages between
+// the previous summary and the oldest message still inside the history window.
+func (a *App) updateConversationSummary(ctx context.Context, conversationID string) error {
+	conversation, ok, err := a.store.GetConversation(conversationID)
+	if err != nil {
+		return fmt.Errorf("load conversation: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	tree, err := a.loadMessageTree(conversationID)
+	if err != nil {
+		return err
+	}
+	branch := tree.path(tree.leaf(conversation.ActiveLeafID))
+	end := len(branch)
+	if window := a.historyLimit * 2; window > 0 {
+		if len(branch) <= window {
+			return nil
+		}
+		end = len(branch) - window
+	}
+	// A summary built on another branch is rebuilt from the start of this one.
+	previousThrough := conversation.SummarizedThrough
+	var after time.Time
+	if summaryCovers(conversation, branch) {
+		after = *conversation.SummarizedThrough
+	} else {
+		conversation.Summary = ""
+	}
+	var pending []domain.Message
+	for _, msg := range branch[:end] {
+		if !msg.CreatedAt.After(after) {
+			continue
+		}
+		pending = append(pending, msg)
+		if len(pending) == summaryBatchLimit {
+			break
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	prompts := promptsFor(ctx, latestUserContent(pending))
+	ctx, recorder := ai.WithUsageRecorder(ctx)
+	out, err := a.generator.GenerateText(
+		ai.WithUsageStage(ctx, usageStageSummary),
+		prompts.SummarySystem,
+		buildSummaryPrompt(prompts, conversation.Summary, pending),
+	)
+	if err != nil {
+		return fmt.Errorf("generate summary: %w", err)
+	}
+	summary := cleanConversationSummary(out)
+	if summary == "" {
+		return fmt.Errorf("generate summary: empty output")
+	}
+	through := pending[len(pending)-1].CreatedAt
+	conversation.Summary = summary
+	conversation.SummarizedThrough = &through
+	conversation.UpdatedAt = time.Now().UTC()
+	return a.store.SaveConversationSummary(conversation, previousThrough, util.NewID(), summarizeUsage(recorder.Calls(), a.pricing))
+}
+
+func buildSummaryPrompt(prompts promptSet, previous string, messages []domain.Message) string {
+	var sb strings.Builder
+	instruction := prompts.SummaryFresh
+	if previous = strings.TrimSpace(previous); previous != "" {
+		instruction = prompts.SummaryMerge
+		sb.WriteString(prompts.SummaryPrevious)
+		sb.WriteString("\n")
+		sb.WriteString(previous)
+		sb.WriteString("\n\n")
+	}
+	truncated := make([]domain.Message, len(messages))
+	for i, msg := range messages {
+		msg.Content = truncateRunes(strings.TrimSpace(msg.Content), summaryMessageRunes)
+		truncated[i] = msg
+	}
+	sb.WriteString(prompts.SummaryNew)
+	sb.WriteString("\n")
+	sb.WriteString(buildHistory(prompts.Labels, truncated))
+	sb.WriteString("\n\n")
+	fmt.Fprintf(&sb, prompts.SummaryLength, instruction, summaryMaxRunes/2)
+	return sb.String()
+}
+
+// latestUserContent returns the last user message, which picks the summary's
+// prompt language.
+func latestUserContent(messages []domain.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(strings.TrimSpace(messages[i].Role), "user") {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+func cleanConversationSummary(text string) string {
+	text = strings.TrimSpace(text)
+	for _, prefix := range []string{"更新后的摘要：", "摘要：", "摘要:", "Updated summary:", "Summary:"} {
+		text = strings.TrimSpace(strings.TrimPrefix(text, prefix))
+	}
+	return truncateRunes(text, summaryMaxRunes)
+}
+
+// buildConversationMemory renders the rolling summary ahead of the recent
+// turns for answer prompts.
+func buildConversationMemory(labels promptLabels, summary string, history []domain.Message) string {
+	historyText := buildHistory(labels, history)
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return historyText
+	}
+	if historyText == "" {
+		return labels.EarlierSummary + "\n" + summary
+	}
+	return labels.EarlierSummary + "\n" + summary + "\n\n" + labels.History + "\n" + historyText
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+type promptRecordingGenerator struct {
+	response string
+	prompts  *[]string
+}
+
+func (g promptRecordingGenerator) GenerateText(_ context.Context, _, userPrompt string) (string, error) {
+	*g.prompts = append(*g.prompts, userPrompt)
+	return g.response, nil
+}
+
+func TestBuildConversationMemoryPrependsSummary(t *testing.T) {
+	history := []domain.Message{
+		{Role: "user", Content: "第三章讲了什么"},
+		{Role: "assistant", Content: "第三章讨论了实验设计。"},
+	}
+	if got, want := buildConversationMemory(zhPrompts.Labels, "", history), buildHistory(zhPrompts.Labels, history); got != want {
+		t.Fatalf("memory without summary = %q, want %q", got, want)
+	}
+
+	got := buildConversationMemory(zhPrompts.Labels, "用户在比较第一章和第二章的方法。", history)
+	summaryAt := strings.Index(got, "早前对话摘要")
+	recentAt := strings.Index(got, "最近对话")
+	if summaryAt < 0 || recentAt < summaryAt {
+		t.Fatalf("memory = %q, want summary before recent turns", got)
+	}
+	if !strings.Contains(got, "第三章讨论了实验设计") {
+		t.Fatalf("memory = %q, want recent turns kept", got)
+	}
+	if got := buildConversationMemory(zhPrompts.Labels, "只有摘要", nil); got != "早前对话摘要：\n只有摘要" {
+		t.Fatalf("summary-only memory = %q", got)
+	}
+}
+
+func TestBuildSummaryPromptMergesPreviousSummaryAndTruncates(t *testing.T) {
+	long := strings.Repeat("长", summaryMessageRunes+50)
+	prompt := buildSummaryPrompt(zhPrompts, "之前在讨论作者背景。", []domain.Message{
+		{Role: "user", Content: "作者后来去了哪里"},
+		{Role: "assistant", Content: long},
+	})
+	for _, want := range []string{"已有摘要：\n之前在讨论作者背景。", "用户: 作者后来去了哪里", "助手: "} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt = %q, want it to contain %q", prompt, want)
+		}
+	}
+	if strings.Contains(prompt, long) {
+		t.Fatal("expected long message to be truncated")
+	}
+
+	if prompt := buildSummaryPrompt(zhPrompts, "", []domain.Message{{Role: "user", Content: "你好"}}); strings.Contains(prompt, "已有摘要") {
+		t.Fatalf("prompt = %q, want no previous summary section", prompt)
+	}
+}
+
+func TestCleanConversationSummary(t *testing.T) {
+	if got := cleanConversationSummary("  摘要：用户关心第二章的结论。 "); got != "用户关心第二章的结论。" {
+		t.Fatalf("summary = %q", got)
+	}
+	long := strings.Repeat("字", summaryMaxRunes+10)
+	if got := cleanConversationSummary(long); len([]rune(got)) > summaryMaxRunes+1 {
+		t.Fatalf("summary length = %d, want at most %d", len([]rune(got)), summaryMaxRunes+1)
+	}
+}
+
+func TestContextualizeRetrievalQuestionIncludesSummary(t *testing.T) {
+	var prompts []string
+	a := &App{generator: promptRecordingGenerator{response: "张三后来在哪家公司工作？", prompts: &prompts}}
+	book := domain.Book{Title: "人物传记"}
+	history := []domain.Message{
+		{Role: "assistant", Content: "他在第二章换了工作。"},
+	}
+
+	got := a.contextualizeRetrievalQuestion(context.Background(), book, "他后来呢", history, "用户一直在问张三的职业经历。")
+	if got != "张三后来在哪家公司工作？" {
+		t.Fatalf("contextualized question = %q", got)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "早前对话摘要：\n用户一直在问张三的职业经历。") {
+		t.Fatalf("prompts = %q, want the summary in the rewrite prompt", prompts)
+	}
+}
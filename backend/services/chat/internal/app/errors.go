@@ -7,4 +7,26 @@ var (
 	ErrBookNotReady          = errors.New("book not ready")
 	ErrConversationNotFound  = errors.New("conversation not found")
 	ErrConversationForbidden = errors.New("conversation forbidden")
+	ErrMessageNotFound       = errors.New("message not found")
+	// ErrMessageNotAnswer and ErrMessageNotQuestion reject regenerating a
+	// question or editing an answer.
+	ErrMessageNotAnswer   = errors.New("message is not an answer")
+	ErrMessageNotQuestion = errors.New("message is not a question")
+	// Answer feedback validation errors.
+	ErrFeedbackRatingInvalid = errors.New("rating must be up or down")
+	ErrFeedbackChunkInvalid  = errors.New("corrected chunk not found")
+	ErrFeedbackTooManyChunks = errors.New("too many corrected chunks")
+	// ErrExportFormatInvalid rejects an export format other than md, pdf or
+	// json.
+	ErrExportFormatInvalid = errors.New("export format must be md, pdf or json")
+	// ErrSearchDateRangeInvalid rejects a conversation search whose from is
+	// not before its to.
+	ErrSearchDateRangeInvalid = errors.New("from must be before to")
+	// Share link errors. ErrShareNotFound also covers revoked and expired
+	// links.
+	ErrShareNotFound      = errors.New("share not found")
+	ErrShareExpiryInvalid = errors.New("expiresAt must be in the future and within a year")
+	// Passage scope validation errors.
+	ErrScopePagesInvalid     = errors.New("scope pages must satisfy 1 <= from <= to")
+	ErrScopeSelectionInvalid = errors.New("scope selection text is required")
 )
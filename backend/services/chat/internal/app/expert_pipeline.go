@@ -17,13 +17,17 @@ const (
 	questionTypeFollowUp   = "follow_up"
 )
 
-func (a *App) buildQueryPlan(ctx context.Context, book domain.Book, question string, history []domain.Message) domain.QueryPlan {
-	decision := decideQueryRoute(question, history)
+func (a *App) buildQueryPlan(ctx context.Context, book domain.Book, question string, history []domain.Message, summary string) domain.QueryPlan {
+	routing := a.router.Route(ctx, question, history)
+	route := queryRoute(routing.Route)
 	standalone := strings.TrimSpace(question)
-	if decision.Route == queryRouteRAG {
-		standalone = a.contextualizeRetrievalQuestion(ctx, book, question, history)
+	if route == queryRouteRAG {
+		standalone = a.contextualizeRetrievalQuestion(ctx, book, question, history, summary)
+	}
+	questionType := classifyQuestionType(route, question, standalone, history)
+	if routing.Source == routeSourceModel {
+		questionType = routing.QuestionType
 	}
-	questionType := classifyQuestionType(decision.Route, question, standalone, history)
 	requiredEvidence := a.requiredEvidenceCount(question, standalone)
 	if questionType == questionTypeAnalysis && requiredEvidence < a.minEvidenceCount {
 		requiredEvidence = a.minEvidenceCount
@@ -31,24 +35,26 @@ func (a *App) buildQueryPlan(ctx context.Context, book domain.Book, question str
 	if questionType == questionTypeSummary && requiredEvidence < 2 {
 		requiredEvidence = 2
 	}
-	if decision.Route == queryRouteDocumentOverview {
+	if route == queryRouteDocumentOverview {
 		requiredEvidence = 1
 	}
 	queries := []string{}
-	if decision.Route == queryRouteRAG {
+	if route == queryRouteRAG {
 		queries = a.buildRetrievalQueries(ctx, standalone)
 		queries = appendDocumentFactQueries(queries, book, standalone)
 	}
 	return domain.QueryPlan{
-		Route:                 string(decision.Route),
+		Route:                 string(route),
+		RouteSource:           routing.Source,
+		RouteConfidence:       routing.Confidence,
 		QuestionType:          questionType,
 		OriginalQuestion:      strings.TrimSpace(question),
 		StandaloneQuestion:    standalone,
 		RetrievalQueries:      uniqueRetrievalQueries(queries),
 		RequiredEvidenceCount: requiredEvidence,
 		ReuseChunkIDs:         latestAssistantChunkIDs(history),
-		NeedsRetrieval:        decision.Route == queryRouteRAG,
-		NeedsHistory:          needsConversationAwareRetrieval(question, history) || decision.Route == queryRouteHistoryOnly,
+		NeedsRetrieval:        route == queryRouteRAG,
+		NeedsHistory:          needsConversationAwareRetrieval(question, history) || route == queryRouteHistoryOnly,
 	}
 }
 
@@ -153,6 +159,14 @@ func (a *App) selectEvidence(ctx context.Context, book domain.Book, plan domain.
 		}
 		candidates = append(factHits, candidates...)
 	}
+	// The reader's selection always leads the evidence.
+	selectionHit, ok, err := a.selectionHit(book, plan.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		candidates = append([]retrieval.StageHit{selectionHit}, candidates...)
+	}
 	selected := selectUniqueEvidenceHits(candidates, a.topK)
 	evidence := make([]domain.Evidence, 0, len(selected))
 	for _, hit := range selected {
@@ -282,6 +296,9 @@ func hitToEvidence(hit retrieval.StageHit) domain.Evidence {
 	case "previous_citation":
 		evidenceType = "previous_citation"
 		reason = "reused previous cited evidence"
+	case "selection":
+		evidenceType = "selected_passage"
+		reason = "passage selected by the reader"
 	case "rerank", "fusion":
 		reason = "ranked as relevant evidence"
 	}
@@ -296,11 +313,15 @@ func hitToEvidence(hit retrieval.StageHit) domain.Evidence {
 	}
 }
 
-func buildContextWithEvidence(hits []retrieval.StageHit, evidence []domain.Evidence) (string, []domain.Source) {
+func buildContextWithEvidence(hits []retrieval.StageHit, evidence []domain.Evidence, books []domain.Book) (string, []domain.Source) {
 	evidenceByChunk := map[string]domain.Evidence{}
 	for _, item := range evidence {
 		evidenceByChunk[item.ChunkID] = item
 	}
+	titles := make(map[string]string, len(books))
+	for _, book := range books {
+		titles[book.ID] = firstNonEmpty(book.Title, book.OriginalFilename)
+	}
 	var sb strings.Builder
 	sources := make([]domain.Source, 0, len(hits))
 	for i, hit := range hits {
@@ -309,7 +330,11 @@ func buildContextWithEvidence(hits []retrieval.StageHit, evidence []domain.Evide
 		location := chunkLocation(chunk.Metadata)
 		snippet := truncateRunes(chunk.Content, 240)
 		ev := evidenceByChunk[chunk.ID]
+		bookID := firstNonEmpty(chunk.BookID, hit.BookID)
 		sb.WriteString(label)
+		if len(books) > 1 && titles[bookID] != "" {
+			sb.WriteString(" 《" + titles[bookID] + "》")
+		}
 		if location != "" {
 			sb.WriteString(" (" + location + ")")
 		}
@@ -324,77 +349,66 @@ func buildContextWithEvidence(hits []retrieval.StageHit, evidence []domain.Evide
 			Location:     location,
 			Snippet:      snippet,
 			ChunkID:      chunk.ID,
+			BookID:       bookID,
+			BookTitle:    titles[bookID],
 			SourceRef:    strings.TrimSpace(chunk.Metadata["source_ref"]),
 			Score:        hit.Score,
 			Language:     strings.TrimSpace(chunk.Metadata["language"]),
 			SourceReason: ev.SourceReason,
 			EvidenceType: ev.EvidenceType,
+			Anchor:       domain.ChunkCitationAnchor(chunk),
 		})
 	}
 	return sb.String(), sources
 }
 
-func validateEvidenceSelection(plan domain.QueryPlan, hits []retrieval.StageHit, citations []domain.Source, abstainEnabled bool) domain.ValidationResult {
+func validateEvidenceSelection(prompts promptSet, plan domain.QueryPlan, hits []retrieval.StageHit, citations []domain.Source, abstainEnabled bool) domain.ValidationResult {
 	if !abstainEnabled {
 		return domain.ValidationResult{Passed: true, Reason: "abstain disabled"}
 	}
 	if len(hits) < plan.RequiredEvidenceCount || len(citations) < plan.RequiredEvidenceCount {
 		return domain.ValidationResult{
 			Passed: false,
-			Reason: fmt.Sprintf("当前问题类型 %s 至少需要 %d 条可引用证据，但只找到 %d 条", plan.QuestionType, plan.RequiredEvidenceCount, len(citations)),
+			Reason: fmt.Sprintf(prompts.EvidenceShortfall, plan.QuestionType, plan.RequiredEvidenceCount, len(citations)),
 		}
 	}
-	return domain.ValidationResult{Passed: true, Reason: "已选证据满足回答策略"}
-}
-
-func abstainAnswerForPlan(plan domain.QueryPlan, reason string) string {
-	reason = strings.TrimSpace(reason)
-	if reason == "" {
-		reason = "未找到足够可引用证据"
-	}
-	return defaultAbstainAnswer + "\n\n缺少证据原因：" + reason
-}
-
-func answerRequirementForPlan(plan domain.QueryPlan) string {
-	switch plan.QuestionType {
-	case questionTypeSingleFact:
-		return "要求：回答单点事实；如果证据中能直接定位答案，用一句话回答并引用编号；不要展开无关内容。"
-	case questionTypeSummary:
-		return "要求：概括整份文档；必须覆盖核心对象、用途和关键时间/事实；引用多个相关编号；不要只总结一个局部片段。"
-	case questionTypeAnalysis:
-		return "要求：只基于多条证据做分析；证据不足则明确拒答并说明缺少哪类依据。"
-	default:
-		return "要求：只基于证据回答；引用相关编号；证据不足则明确拒答。"
-	}
+	return domain.ValidationResult{Passed: true, Reason: "selected evidence satisfies answer policy"}
 }
 
-func buildStructuredAnswerPrompt(book domain.Book, plan domain.QueryPlan, historyText string, contextText string, requirement string) string {
+func buildStructuredAnswerPrompt(prompts promptSet, book domain.Book, plan domain.QueryPlan, historyText string, contextText string, requirement string) string {
+	labels := prompts.Labels
 	var sb strings.Builder
-	sb.WriteString("文档标题：")
+	sb.WriteString(labels.Title)
 	sb.WriteString(firstNonEmpty(book.Title, book.OriginalFilename))
-	if strings.TrimSpace(book.DocumentType) != "" {
-		sb.WriteString("\n文档类型：")
-		sb.WriteString(readableDocumentType(book.DocumentType))
+	if docType := prompts.documentType(book.DocumentType); docType != "" {
+		sb.WriteString("\n" + labels.DocumentType)
+		sb.WriteString(docType)
 	}
 	if strings.TrimSpace(book.DocumentSummary) != "" {
-		sb.WriteString("\n文档摘要：")
+		sb.WriteString("\n" + labels.Summary)
 		sb.WriteString(book.DocumentSummary)
 	}
-	sb.WriteString("\n\n原始问题：")
+	writePlanPromptSection(&sb, labels, plan, historyText)
+	sb.WriteString("\n\n" + labels.Evidence + "\n")
+	sb.WriteString(contextText)
+	sb.WriteString("\n")
+	sb.WriteString(requirement)
+	return sb.String()
+}
+
+// writePlanPromptSection writes the question, its standalone rewrite, type
+// and recent conversation shared by the single-book and shelf prompts.
+func writePlanPromptSection(sb *strings.Builder, labels promptLabels, plan domain.QueryPlan, historyText string) {
+	sb.WriteString("\n\n" + labels.Question)
 	sb.WriteString(plan.OriginalQuestion)
-	sb.WriteString("\n独立检索问题：")
+	sb.WriteString("\n" + labels.StandaloneQuestion)
 	sb.WriteString(plan.StandaloneQuestion)
-	sb.WriteString("\n问题类型：")
+	sb.WriteString("\n" + labels.QuestionType)
 	sb.WriteString(plan.QuestionType)
 	if historyText != "" {
-		sb.WriteString("\n\n最近对话：\n")
+		sb.WriteString("\n\n" + labels.History + "\n")
 		sb.WriteString(historyText)
 	}
-	sb.WriteString("\n\n选中证据：\n")
-	sb.WriteString(contextText)
-	sb.WriteString("\n")
-	sb.WriteString(requirement)
-	return sb.String()
 }
 
 func enrichRetrievalDebug(debug *domain.RetrievalDebug, trace domain.AnswerTrace) {
@@ -413,6 +427,10 @@ func enrichRetrievalDebug(debug *domain.RetrievalDebug, trace domain.AnswerTrace
 	if len(plan.RetrievalQueries) > 0 {
 		debug.Queries = plan.RetrievalQueries
 	}
+	debug.Steps = trace.RetrievalSteps
+	for _, step := range trace.RetrievalSteps {
+		debug.Queries = uniqueRetrievalQueries(append(append([]string(nil), debug.Queries...), step.Queries...))
+	}
 }
 
 func sourcesToEvidence(sources []domain.Source, evidenceType string) []domain.Evidence {
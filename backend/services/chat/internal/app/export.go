@@ -0,0 +1,320 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"onebookai/internal/textpdf"
+	"onebookai/pkg/domain"
+)
+
+// ExportFormat is the file format of a conversation export.
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "md"
+	ExportPDF      ExportFormat = "pdf"
+	ExportJSON     ExportFormat = "json"
+)
+
+// ParseExportFormat validates a format query value; empty means Markdown.
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case "", "markdown":
+		return ExportMarkdown, nil
+	case ExportMarkdown, ExportPDF, ExportJSON:
+		return format, nil
+	default:
+		return "", ErrExportFormatInvalid
+	}
+}
+
+// ContentType is the HTTP content type of the format.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportPDF:
+		return "application/pdf"
+	case ExportJSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// ConversationExport is a conversation's active branch with its citations
+// resolved to book titles, ready to be rendered.
+type ConversationExport struct {
+	Conversation domain.Conversation
+	Books        []ExportedBook
+	Messages     []domain.Message
+	ExportedAt   time.Time
+}
+
+// ExportedBook names a book the conversation asked about or cited.
+type ExportedBook struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type exportedCitation struct {
+	Number    int    `json:"number"`
+	BookID    string `json:"bookId,omitempty"`
+	BookTitle string `json:"bookTitle,omitempty"`
+	Location  string `json:"location,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
+	ChunkID   string `json:"chunkId,omitempty"`
+}
+
+type exportedMessage struct {
+	ID        string             `json:"id"`
+	ParentID  string             `json:"parentId,omitempty"`
+	Role      string             `json:"role"`
+	Content   string             `json:"content"`
+	Abstained bool               `json:"abstained,omitempty"`
+	Citations []exportedCitation `json:"citations,omitempty"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// ExportConversation loads every message on the conversation's active branch
+// for export, with the same ownership checks as ListConversationMessages.
+func (a *App) ExportConversation(user domain.User, conversationID string) (ConversationExport, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return ConversationExport{}, err
+	}
+	return a.conversationExport(conversation)
+}
+
+// conversationExport loads a conversation's active branch and resolves the
+// titles of the books it asked about or cited.
+func (a *App) conversationExport(conversation domain.Conversation) (ConversationExport, error) {
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return ConversationExport{}, err
+	}
+	return a.exportMessages(conversation, tree.branch(conversation.ActiveLeafID))
+}
+
+// exportMessages resolves book titles for messages, one path of the
+// conversation's tree.
+func (a *App) exportMessages(conversation domain.Conversation, messages []domain.Message) (ConversationExport, error) {
+	bookIDs := append([]string{conversation.BookID}, conversation.BookIDs...)
+	for _, msg := range messages {
+		for _, src := range msg.Sources {
+			bookIDs = append(bookIDs, src.BookID)
+		}
+	}
+	titles := map[string]string{}
+	var books []ExportedBook
+	for _, id := range uniqueStrings(bookIDs) {
+		book, ok, err := a.store.GetBookIncludingDeleted(id)
+		if err != nil {
+			return ConversationExport{}, fmt.Errorf("load book: %w", err)
+		}
+		title := id
+		if ok && strings.TrimSpace(book.Title) != "" {
+			title = book.Title
+		}
+		titles[id] = title
+		books = append(books, ExportedBook{ID: id, Title: title})
+	}
+	for i := range messages {
+		sources := make([]domain.Source, len(messages[i].Sources))
+		copy(sources, messages[i].Sources)
+		for j := range sources {
+			if sources[j].BookTitle == "" {
+				sources[j].BookTitle = titles[sources[j].BookID]
+			}
+		}
+		messages[i].Sources = sources
+	}
+	return ConversationExport{
+		Conversation: conversation,
+		Books:        books,
+		Messages:     messages,
+		ExportedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// Write renders the export in the given format, streaming message by
+// message.
+func (e ConversationExport) Write(w io.Writer, format ExportFormat) error {
+	switch format {
+	case ExportPDF:
+		return e.writePDF(w)
+	case ExportJSON:
+		return e.writeJSON(w)
+	case ExportMarkdown:
+		return e.writeMarkdown(w)
+	default:
+		return ErrExportFormatInvalid
+	}
+}
+
+// Filename is the attachment file name for the format.
+func (e ConversationExport) Filename(format ExportFormat) string {
+	return "conversation-" + e.Conversation.ID + "." + string(format)
+}
+
+func (e ConversationExport) title() string {
+	if title := strings.TrimSpace(e.Conversation.Title); title != "" {
+		return title
+	}
+	return "Conversation " + e.Conversation.ID
+}
+
+func (e ConversationExport) bookTitles() string {
+	titles := make([]string, len(e.Books))
+	for i, book := range e.Books {
+		titles[i] = "《" + book.Title + "》"
+	}
+	return strings.Join(titles, "、")
+}
+
+func (e ConversationExport) writeMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", e.title())
+	fmt.Fprintf(bw, "- Conversation: `%s`\n", e.Conversation.ID)
+	if len(e.Books) > 0 {
+		fmt.Fprintf(bw, "- Books: %s\n", e.bookTitles())
+	}
+	fmt.Fprintf(bw, "- Created: %s\n", e.Conversation.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(bw, "- Exported: %s\n", e.ExportedAt.Format(time.RFC3339))
+	for _, msg := range e.Messages {
+		if msg.Role == "user" {
+			fmt.Fprintf(bw, "\n---\n\n## Question\n\n%s\n", strings.TrimSpace(msg.Content))
+			continue
+		}
+		fmt.Fprintf(bw, "\n## Answer\n\n%s\n", strings.TrimSpace(msg.Content))
+		if msg.Abstained {
+			bw.WriteString("\n_The book did not contain enough evidence to answer._\n")
+		}
+		citations := exportCitations(msg.Sources)
+		if len(citations) == 0 {
+			continue
+		}
+		bw.WriteString("\n### Citations\n\n")
+		for _, c := range citations {
+			fmt.Fprintf(bw, "%d. %s\n", c.Number, citationHeading(c))
+			if c.Snippet != "" {
+				fmt.Fprintf(bw, "   > %s\n", strings.Join(strings.Fields(c.Snippet), " "))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func (e ConversationExport) writeJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	head, err := json.Marshal(struct {
+		Conversation domain.Conversation `json:"conversation"`
+		Books        []ExportedBook      `json:"books"`
+		ExportedAt   time.Time           `json:"exportedAt"`
+	}{e.Conversation, e.Books, e.ExportedAt})
+	if err != nil {
+		return err
+	}
+	// Reopen the header object so messages can be appended one at a time.
+	bw.Write(head[:len(head)-1])
+	bw.WriteString(`,"messages":[`)
+	for i, msg := range e.Messages {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		item, err := json.Marshal(exportedMessage{
+			ID:        msg.ID,
+			ParentID:  msg.ParentID,
+			Role:      msg.Role,
+			Content:   msg.Content,
+			Abstained: msg.Abstained,
+			Citations: exportCitations(msg.Sources),
+			CreatedAt: msg.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		bw.Write(item)
+	}
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
+
+func (e ConversationExport) writePDF(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	doc := textpdf.NewWriter(bw, e.title())
+	doc.Text(textpdf.Title, e.title())
+	if len(e.Books) > 0 {
+		doc.Text(textpdf.Muted, "Books: "+e.bookTitles())
+	}
+	doc.Text(textpdf.Muted, "Created: "+e.Conversation.CreatedAt.UTC().Format(time.RFC3339)+"    Exported: "+e.ExportedAt.Format(time.RFC3339))
+	for _, msg := range e.Messages {
+		if msg.Role == "user" {
+			doc.Rule()
+			doc.Text(textpdf.Heading, "Question")
+			doc.Text(textpdf.Body, strings.TrimSpace(msg.Content))
+			doc.Space(6)
+			continue
+		}
+		doc.Text(textpdf.Heading, "Answer")
+		doc.Text(textpdf.Body, strings.TrimSpace(msg.Content))
+		if msg.Abstained {
+			doc.Text(textpdf.Muted, "The book did not contain enough evidence to answer.")
+		}
+		citations := exportCitations(msg.Sources)
+		if len(citations) > 0 {
+			doc.Space(4)
+			doc.Text(textpdf.Heading, "Citations")
+		}
+		for _, c := range citations {
+			doc.Text(textpdf.Body, fmt.Sprintf("[%d] %s", c.Number, citationHeading(c)))
+			if c.Snippet != "" {
+				doc.TextIndent(textpdf.Muted, 18, strings.Join(strings.Fields(c.Snippet), " "))
+			}
+		}
+		doc.Space(6)
+	}
+	if err := doc.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// exportCitations numbers sources by their "[n]" labels so the numbers match
+// the markers in the answer text.
+func exportCitations(sources []domain.Source) []exportedCitation {
+	out := make([]exportedCitation, 0, len(sources))
+	for i, src := range sources {
+		number, err := strconv.Atoi(strings.Trim(strings.TrimSpace(src.Label), "[]"))
+		if err != nil || number <= 0 {
+			number = i + 1
+		}
+		out = append(out, exportedCitation{
+			Number:    number,
+			BookID:    src.BookID,
+			BookTitle: src.BookTitle,
+			Location:  src.Location,
+			Snippet:   strings.TrimSpace(src.Snippet),
+			ChunkID:   src.ChunkID,
+		})
+	}
+	return out
+}
+
+func citationHeading(c exportedCitation) string {
+	parts := make([]string, 0, 2)
+	if c.BookTitle != "" {
+		parts = append(parts, "《"+c.BookTitle+"》")
+	}
+	if c.Location != "" {
+		parts = append(parts, c.Location)
+	}
+	if len(parts) == 0 {
+		return "Source"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+)
+
+func sampleExport() ConversationExport {
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	return ConversationExport{
+		Conversation: domain.Conversation{ID: "c1", Title: "Go 并发", CreatedAt: created},
+		Books:        []ExportedBook{{ID: "b1", Title: "Go 程序设计"}},
+		Messages: []domain.Message{
+			{ID: "m1", Role: "user", Content: "什么是 goroutine？", CreatedAt: created},
+			{ID: "m2", ParentID: "m1", Role: "assistant", Content: "goroutine 是轻量级线程 [2]。", CreatedAt: created, Sources: []domain.Source{
+				{Label: "[2]", BookID: "b1", BookTitle: "Go 程序设计", Location: "page 12", Snippet: "Goroutines are\nlightweight threads", ChunkID: "k1"},
+			}},
+		},
+		ExportedAt: created.Add(time.Hour),
+	}
+}
+
+func TestExportMarkdownNumbersCitationsByLabel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleExport().Write(&buf, ExportMarkdown); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"# Go 并发", "- Books: 《Go 程序设计》", "## Question\n\n什么是 goroutine？", "2. 《Go 程序设计》, page 12", "> Goroutines are lightweight threads"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportJSONIsValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleExport().Write(&buf, ExportJSON); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var doc struct {
+		Conversation domain.Conversation `json:"conversation"`
+		Messages     []exportedMessage   `json:"messages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v\n%s", err, buf.String())
+	}
+	if doc.Conversation.ID != "c1" || len(doc.Messages) != 2 || doc.Messages[1].Citations[0].Number != 2 {
+		t.Fatalf("unexpected export: %+v", doc)
+	}
+}
+
+func TestExportPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleExport().Write(&buf, ExportPDF); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("not a PDF")
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	if format, err := ParseExportFormat(""); err != nil || format != ExportMarkdown {
+		t.Fatalf("default format = %q, %v", format, err)
+	}
+	if _, err := ParseExportFormat("docx"); !errors.Is(err, ErrExportFormatInvalid) {
+		t.Fatalf("err = %v, want ErrExportFormatInvalid", err)
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/domain"
+)
+
+const (
+	feedbackReasonMaxRunes = 1000
+	feedbackMaxChunks      = 20
+)
+
+// AnswerFeedbackInput is a user's judgment of an assistant message.
+type AnswerFeedbackInput struct {
+	Rating            domain.AnswerFeedbackRating
+	Reason            string
+	CorrectedChunkIDs []string
+}
+
+// SubmitAnswerFeedback records thumbs-up/down feedback on an assistant
+// message together with the question, answer, trace and selected chunks it
+// was given on. Submitting again replaces the user's earlier feedback and
+// sends it back to the review queue, keeping the dataset it was converted into
+// so a later conversion re-judges that dataset rather than copying it.
+func (a *App) SubmitAnswerFeedback(user domain.User, conversationID string, messageID string, input AnswerFeedbackInput) (domain.AnswerFeedback, error) {
+	rating := domain.AnswerFeedbackRating(strings.ToLower(strings.TrimSpace(string(input.Rating))))
+	if rating != domain.AnswerFeedbackUp && rating != domain.AnswerFeedbackDown {
+		return domain.AnswerFeedback{}, ErrFeedbackRatingInvalid
+	}
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	msg, ok := tree.message(strings.TrimSpace(messageID))
+	if !ok {
+		return domain.AnswerFeedback{}, ErrMessageNotFound
+	}
+	if msg.Role != "assistant" {
+		return domain.AnswerFeedback{}, ErrMessageNotAnswer
+	}
+	question, _ := tree.message(tree.parents[msg.ID])
+
+	corrected := uniqueStrings(input.CorrectedChunkIDs)
+	if len(corrected) > feedbackMaxChunks {
+		return domain.AnswerFeedback{}, ErrFeedbackTooManyChunks
+	}
+	bookID, err := a.feedbackBookID(conversation, msg, corrected)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+
+	now := time.Now().UTC()
+	feedback := domain.AnswerFeedback{
+		ID:                util.NewID(),
+		MessageID:         msg.ID,
+		ConversationID:    conversation.ID,
+		UserID:            user.ID,
+		BookID:            bookID,
+		Rating:            rating,
+		Reason:            truncateRunes(input.Reason, feedbackReasonMaxRunes),
+		CorrectedChunkIDs: corrected,
+		Question:          question.Content,
+		Answer:            msg.Content,
+		AnswerTrace:       msg.Metadata.AnswerTrace,
+		SelectedChunkIDs:  msg.Metadata.SelectedChunkIDs,
+		Status:            domain.AnswerFeedbackStatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	existing, ok, err := a.store.GetAnswerFeedbackByMessage(msg.ID, user.ID)
+	if err != nil {
+		return domain.AnswerFeedback{}, fmt.Errorf("load feedback: %w", err)
+	}
+	if ok {
+		feedback.ID = existing.ID
+		feedback.CreatedAt = existing.CreatedAt
+		feedback.DatasetID = existing.DatasetID
+	}
+	if err := a.store.SaveAnswerFeedback(feedback); err != nil {
+		return domain.AnswerFeedback{}, fmt.Errorf("save feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// feedbackBookID checks that corrected chunks belong to the conversation's
+// books and returns the book the feedback is about: the corrected chunks'
+// book when they agree on one, otherwise the answer's book.
+func (a *App) feedbackBookID(conversation domain.Conversation, msg domain.Message, corrected []string) (string, error) {
+	if len(corrected) == 0 {
+		return msg.BookID, nil
+	}
+	chunks, err := a.store.GetChunksByIDs(corrected)
+	if err != nil {
+		return "", fmt.Errorf("load corrected chunks: %w", err)
+	}
+	allowed := conversation.BookIDs
+	if len(allowed) == 0 {
+		allowed = []string{conversation.BookID}
+	}
+	found := make(map[string]string, len(chunks))
+	for _, chunk := range chunks {
+		if slices.Contains(allowed, chunk.BookID) {
+			found[chunk.ID] = chunk.BookID
+		}
+	}
+	books := map[string]struct{}{}
+	for _, id := range corrected {
+		chunkBookID, ok := found[id]
+		if !ok {
+			return "", ErrFeedbackChunkInvalid
+		}
+		books[chunkBookID] = struct{}{}
+	}
+	if len(books) > 1 {
+		return msg.BookID, nil
+	}
+	return found[corrected[0]], nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/store"
+)
+
+func TestSubmitAnswerFeedbackRejectsUnknownRating(t *testing.T) {
+	a := &App{}
+	for _, rating := range []string{"", "meh", "5"} {
+		_, err := a.SubmitAnswerFeedback(domain.User{ID: "u1"}, "c1", "m1", AnswerFeedbackInput{Rating: domain.AnswerFeedbackRating(rating)})
+		if !errors.Is(err, ErrFeedbackRatingInvalid) {
+			t.Fatalf("rating %q: err = %v, want ErrFeedbackRatingInvalid", rating, err)
+		}
+	}
+}
+
+type feedbackStore struct {
+	store.Store
+	conversation domain.Conversation
+	messages     []domain.Message
+	feedback     *domain.AnswerFeedback
+}
+
+func (s *feedbackStore) GetConversation(id string) (domain.Conversation, bool, error) {
+	return s.conversation, id == s.conversation.ID, nil
+}
+
+func (s *feedbackStore) ListConversationMessages(string, int) ([]domain.Message, error) {
+	return s.messages, nil
+}
+
+func (s *feedbackStore) GetAnswerFeedbackByMessage(string, string) (domain.AnswerFeedback, bool, error) {
+	if s.feedback == nil {
+		return domain.AnswerFeedback{}, false, nil
+	}
+	return *s.feedback, true, nil
+}
+
+func (s *feedbackStore) SaveAnswerFeedback(feedback domain.AnswerFeedback) error {
+	s.feedback = &feedback
+	return nil
+}
+
+func TestResubmittedFeedbackKeepsConvertedDataset(t *testing.T) {
+	user := domain.User{ID: "u1"}
+	st := &feedbackStore{
+		conversation: domain.Conversation{ID: "c1", UserID: user.ID, BookID: "b1"},
+		messages: []domain.Message{
+			{ID: "q1", Role: "user", Content: "问题"},
+			{ID: "a1", ParentID: "q1", Role: "assistant", BookID: "b1", Content: "回答"},
+		},
+		feedback: &domain.AnswerFeedback{ID: "f1", Status: domain.AnswerFeedbackStatusConverted, DatasetID: "d1"},
+	}
+	a := &App{store: st}
+
+	feedback, err := a.SubmitAnswerFeedback(user, "c1", "a1", AnswerFeedbackInput{Rating: domain.AnswerFeedbackDown})
+	if err != nil {
+		t.Fatalf("SubmitAnswerFeedback: %v", err)
+	}
+	if feedback.ID != "f1" || feedback.Status != domain.AnswerFeedbackStatusPending {
+		t.Fatalf("feedback = %s/%s, want f1 back in review", feedback.ID, feedback.Status)
+	}
+	if st.feedback.DatasetID != "d1" {
+		t.Fatalf("DatasetID = %q after resubmit, want d1 kept", st.feedback.DatasetID)
+	}
+}
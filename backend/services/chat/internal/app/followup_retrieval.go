@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+// Follow-up actions proposed by a FollowUpPlanner.
+const (
+	followUpActionFollowUp  = "follow_up"
+	followUpActionDecompose = "decompose"
+	followUpActionStop      = "stop"
+)
+
+// Reasons the follow-up retrieval loop stops.
+const (
+	followUpStopSatisfied      = "satisfied"
+	followUpStopStepBudget     = "step_budget"
+	followUpStopTimeBudget     = "time_budget"
+	followUpStopNoNewQueries   = "no_new_queries"
+	followUpStopPlanner        = "planner_stop"
+	followUpStopRetrievalError = "retrieval_error"
+)
+
+const (
+	// defaultFollowUpBudget bounds the whole follow-up loop when no budget
+	// is configured.
+	defaultFollowUpBudget = 8 * time.Second
+	// maxFollowUpQueries bounds the sub-queries retrieved in one step.
+	maxFollowUpQueries = 3
+	// followUpEvidenceRunes bounds each evidence snippet shown to the planner.
+	followUpEvidenceRunes = 160
+)
+
+// FollowUp is the next retrieval step a planner proposes after inspecting
+// the evidence found so far.
+type FollowUp struct {
+	Action    string   `json:"action"`
+	Queries   []string `json:"queries"`
+	Reasoning string   `json:"reasoning"`
+}
+
+// FollowUpPlanner proposes sub-queries for evidence the question still lacks.
+// found holds snippets of the selected evidence, tried the queries already run.
+type FollowUpPlanner interface {
+	Plan(ctx context.Context, question string, found, tried []string, missing int) (FollowUp, error)
+}
+
+type modelFollowUpPlanner struct {
+	generator ai.TextGenerator
+}
+
+func newModelFollowUpPlanner(generator ai.TextGenerator) FollowUpPlanner {
+	return &modelFollowUpPlanner{generator: generator}
+}
+
+func (p *modelFollowUpPlanner) Plan(ctx context.Context, question string, found, tried []string, missing int) (FollowUp, error) {
+	if p.generator == nil {
+		return FollowUp{}, errors.New("follow-up planner has no generator")
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Question: %s\n\nEvidence found so far:\n", question)
+	if len(found) == 0 {
+		sb.WriteString("(none)\n")
+	}
+	for i, snippet := range found {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, snippet)
+	}
+	sb.WriteString("\nQueries already searched:\n")
+	for _, query := range tried {
+		fmt.Fprintf(&sb, "- %s\n", query)
+	}
+	fmt.Fprintf(&sb, "\nThe answer needs %d more pieces of evidence. ", missing)
+	sb.WriteString(`Return a JSON object {"action": "follow_up"|"decompose"|"stop", "queries": [...], "reasoning": "..."}. ` +
+		`Use "decompose" to split a multi-hop question into sub-questions, "follow_up" to search for what the evidence is missing, ` +
+		`and "stop" when the document cannot contain the answer. Write at most 3 short queries in the question's language that differ from those already searched.`)
+	out, err := p.generator.GenerateText(ai.WithUsageStage(ctx, usageStageFollowUpRetrieval), "You plan document searches for a question answering system. Output valid JSON only.", sb.String())
+	if err != nil {
+		return FollowUp{}, err
+	}
+	var next FollowUp
+	if err := json.Unmarshal([]byte(trimJSONFence(out)), &next); err != nil {
+		return FollowUp{}, err
+	}
+	next.Action = strings.ToLower(strings.TrimSpace(next.Action))
+	switch next.Action {
+	case followUpActionFollowUp, followUpActionDecompose, followUpActionStop:
+	default:
+		return FollowUp{}, fmt.Errorf("unknown follow-up action %q", next.Action)
+	}
+	return next, nil
+}
+
+// trimJSONFence strips the Markdown code fence models sometimes wrap JSON in.
+func trimJSONFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	return strings.TrimSpace(text)
+}
+
+var subQuestionSeparator = regexp.MustCompile(`[，,；;？?]|以及|并且|而且|和|与|及|\band\b`)
+
+// decomposeQuestion splits a compound question on conjunctions. It stands in
+// for the planner when the model is unavailable.
+func decomposeQuestion(question string) []string {
+	parts := subQuestionSeparator.Split(question, -1)
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if len([]rune(strings.TrimSpace(part))) < 2 {
+			continue
+		}
+		out = append(out, part)
+	}
+	if len(out) < 2 {
+		return nil
+	}
+	return normalizeRetrievalQueries(out)
+}
+
+// evidenceSatisfiesPlan mirrors the evidence count check of
+// validateEvidenceSelection.
+func evidenceSatisfiesPlan(plan domain.QueryPlan, hits []retrieval.StageHit) bool {
+	return len(hits) >= plan.RequiredEvidenceCount
+}
+
+// retrieveFollowUpEvidence runs when the first retrieval selected fewer
+// evidence hits than the plan requires. Each step shows the planner what was
+// found, retrieves its sub-queries within the question's scope and reselects
+// evidence from everything retrieved so far. The loop stops once the evidence
+// suffices, the planner has nothing new to search, or the step or time budget
+// runs out; retrieval debug output accumulates across steps.
+func (a *App) retrieveFollowUpEvidence(ctx context.Context, book domain.Book, plan domain.QueryPlan, retrieved, selected []retrieval.StageHit, evidence []domain.Evidence, history []domain.Message, debug *domain.RetrievalDebug) ([]retrieval.StageHit, []domain.Evidence, []domain.RetrievalStep, error) {
+	if a.followUpSteps <= 0 || !a.abstainEnabled || evidenceSatisfiesPlan(plan, selected) {
+		return selected, evidence, nil, nil
+	}
+	question := firstNonEmpty(strings.TrimSpace(plan.StandaloneQuestion), strings.TrimSpace(plan.OriginalQuestion))
+	tried := map[string]struct{}{}
+	for _, query := range plan.RetrievalQueries {
+		tried[query] = struct{}{}
+	}
+	if debug != nil {
+		for _, query := range debug.Queries {
+			tried[query] = struct{}{}
+		}
+	}
+	budget := a.followUpBudget
+	if budget <= 0 {
+		budget = defaultFollowUpBudget
+	}
+	// ctx carries the answer's generation report; planner calls must not be
+	// attributed to it.
+	loopCtx, cancel := context.WithTimeout(ai.WithoutGenerationReport(ctx), budget)
+	defer cancel()
+	started := time.Now()
+	pool := append([]retrieval.StageHit(nil), retrieved...)
+	var steps []domain.RetrievalStep
+	for stepNumber := 1; ; stepNumber++ {
+		step := domain.RetrievalStep{Step: stepNumber, Evidence: len(selected)}
+		next := a.planFollowUp(loopCtx, question, selected, tried, plan.RequiredEvidenceCount-len(selected))
+		step.Action = next.Action
+		step.Reasoning = next.Reasoning
+		for _, query := range normalizeRetrievalQueries(next.Queries) {
+			if _, ok := tried[query]; ok || len(step.Queries) >= maxFollowUpQueries || next.Action == followUpActionStop {
+				continue
+			}
+			tried[query] = struct{}{}
+			step.Queries = append(step.Queries, query)
+		}
+		if next.Action == followUpActionStop {
+			step.StopReason = followUpStopPlanner
+		} else if len(step.Queries) == 0 {
+			step.StopReason = followUpStopNoNewQueries
+		} else if hits, stepDebug, err := a.retrieveEvidenceWithQueries(loopCtx, book, question, step.Queries, scopeFilter(plan.Scope)); err != nil {
+			step.StopReason = followUpStopRetrievalError
+		} else {
+			pool = append(pool, hits...)
+			reselected, reselectedEvidence, err := a.selectEvidence(loopCtx, book, plan, pool, history)
+			if err != nil {
+				return nil, nil, steps, err
+			}
+			step.NewEvidence = max(len(reselected)-len(selected), 0)
+			step.Evidence = len(reselected)
+			selected, evidence = reselected, reselectedEvidence
+			mergeFollowUpDebug(debug, stepDebug)
+			if evidenceSatisfiesPlan(plan, selected) {
+				step.StopReason = followUpStopSatisfied
+			} else if stepNumber >= a.followUpSteps {
+				step.StopReason = followUpStopStepBudget
+			}
+		}
+		if step.StopReason != followUpStopSatisfied && loopCtx.Err() != nil {
+			step.StopReason = followUpStopTimeBudget
+		}
+		step.ElapsedMs = time.Since(started).Milliseconds()
+		reportProgress(ctx, ProgressRetrievalStep, step)
+		steps = append(steps, step)
+		if step.StopReason != "" {
+			break
+		}
+	}
+	return selected, evidence, steps, nil
+}
+
+// planFollowUp asks the planner for the next step, falling back to splitting
+// the question on conjunctions when the planner fails.
+func (a *App) planFollowUp(ctx context.Context, question string, selected []retrieval.StageHit, tried map[string]struct{}, missing int) FollowUp {
+	if a.followUpPlanner != nil {
+		found := make([]string, 0, len(selected))
+		for _, hit := range selected {
+			found = append(found, truncateRunes(firstNonEmpty(hit.Chunk.Content, hit.Content), followUpEvidenceRunes))
+		}
+		triedQueries := make([]string, 0, len(tried))
+		for query := range tried {
+			triedQueries = append(triedQueries, query)
+		}
+		sort.Strings(triedQueries)
+		next, err := a.followUpPlanner.Plan(ctx, question, found, triedQueries, missing)
+		if err == nil {
+			return next
+		}
+	}
+	return FollowUp{
+		Action:    followUpActionDecompose,
+		Queries:   decomposeQuestion(question),
+		Reasoning: "split the question on its conjunctions",
+	}
+}
+
+// mergeFollowUpDebug folds one step's retrieval debug into the question's.
+func mergeFollowUpDebug(debug, step *domain.RetrievalDebug) {
+	if debug == nil || step == nil {
+		return
+	}
+	*debug = *mergeRetrievalDebug([]*domain.RetrievalDebug{debug, step})
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+type stubFollowUpPlanner struct {
+	next  FollowUp
+	calls int
+	tried []string
+}
+
+func (s *stubFollowUpPlanner) Plan(_ context.Context, _ string, _, tried []string, _ int) (FollowUp, error) {
+	s.calls++
+	s.tried = tried
+	return s.next, nil
+}
+
+func TestModelFollowUpPlannerParsesFencedJSON(t *testing.T) {
+	planner := newModelFollowUpPlanner(stubGenerator{response: "```json\n{\"action\":\"Decompose\",\"queries\":[\"作者生平\",\"成书年代\"],\"reasoning\":\"two hops\"}\n```"})
+
+	next, err := planner.Plan(context.Background(), "作者是谁，书写于何时", nil, nil, 2)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if next.Action != followUpActionDecompose || !reflect.DeepEqual(next.Queries, []string{"作者生平", "成书年代"}) {
+		t.Fatalf("unexpected follow-up %+v", next)
+	}
+}
+
+func TestModelFollowUpPlannerRejectsUnknownAction(t *testing.T) {
+	planner := newModelFollowUpPlanner(stubGenerator{response: `{"action":"guess","queries":["x"]}`})
+
+	if _, err := planner.Plan(context.Background(), "问题", nil, nil, 1); err == nil {
+		t.Fatal("expected an error for an unknown action")
+	}
+}
+
+func TestDecomposeQuestionSplitsConjunctions(t *testing.T) {
+	got := decomposeQuestion("作者的出生地和他的代表作")
+	if len(got) != 2 {
+		t.Fatalf("decomposeQuestion() = %v, want two sub-queries", got)
+	}
+	if got := decomposeQuestion("作者是谁"); got != nil {
+		t.Fatalf("decomposeQuestion() = %v, want nil for a simple question", got)
+	}
+}
+
+func TestFollowUpRetrievalSkipsSatisfiedEvidence(t *testing.T) {
+	planner := &stubFollowUpPlanner{}
+	a := &App{abstainEnabled: true, followUpSteps: 2, followUpPlanner: planner}
+	plan := domain.QueryPlan{StandaloneQuestion: "作者是谁", RequiredEvidenceCount: 1}
+	selected := []retrieval.StageHit{{ChunkID: "c1"}}
+
+	_, _, steps, err := a.retrieveFollowUpEvidence(context.Background(), domain.Book{ID: "b1"}, plan, selected, selected, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("retrieveFollowUpEvidence() error = %v", err)
+	}
+	if len(steps) != 0 || planner.calls != 0 {
+		t.Fatalf("expected no follow-up, got steps %+v and %d planner calls", steps, planner.calls)
+	}
+}
+
+func TestFollowUpRetrievalStopsWithoutNewQueries(t *testing.T) {
+	planner := &stubFollowUpPlanner{next: FollowUp{Action: followUpActionFollowUp, Queries: []string{"作者 生平"}}}
+	a := &App{abstainEnabled: true, followUpSteps: 2, followUpPlanner: planner}
+	plan := domain.QueryPlan{StandaloneQuestion: "作者生平", RetrievalQueries: []string{"作者 生平"}, RequiredEvidenceCount: 2}
+	selected := []retrieval.StageHit{{ChunkID: "c1", Chunk: domain.Chunk{ID: "c1", Content: "作者生于 1900 年。"}}}
+
+	got, _, steps, err := a.retrieveFollowUpEvidence(context.Background(), domain.Book{ID: "b1"}, plan, selected, selected, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("retrieveFollowUpEvidence() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("evidence changed without retrieval: %+v", got)
+	}
+	if len(steps) != 1 || steps[0].StopReason != followUpStopNoNewQueries || steps[0].Evidence != 1 {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	if !reflect.DeepEqual(planner.tried, []string{"作者 生平"}) {
+		t.Fatalf("planner saw tried queries %v", planner.tried)
+	}
+}
+
+func TestFollowUpRetrievalRecordsPlannerStop(t *testing.T) {
+	planner := &stubFollowUpPlanner{next: FollowUp{Action: followUpActionStop, Queries: []string{"无关"}, Reasoning: "not in the book"}}
+	a := &App{abstainEnabled: true, followUpSteps: 3, followUpPlanner: planner}
+	var events []string
+	ctx := WithProgress(context.Background(), func(event string, _ any) error {
+		events = append(events, event)
+		return nil
+	})
+
+	_, _, steps, err := a.retrieveFollowUpEvidence(ctx, domain.Book{ID: "b1"}, domain.QueryPlan{StandaloneQuestion: "明天天气", RequiredEvidenceCount: 1}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("retrieveFollowUpEvidence() error = %v", err)
+	}
+	if len(steps) != 1 || steps[0].StopReason != followUpStopPlanner || len(steps[0].Queries) != 0 || steps[0].Reasoning != "not in the book" {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	if !reflect.DeepEqual(events, []string{ProgressRetrievalStep}) {
+		t.Fatalf("progress events = %v", events)
+	}
+}
+
+type reportingFollowUpPlanner struct{ next FollowUp }
+
+func (p reportingFollowUpPlanner) Plan(ctx context.Context, _ string, _, _ []string, _ int) (FollowUp, error) {
+	ai.RecordGenerationProvider(ctx, "planner")
+	return p.next, nil
+}
+
+func TestFollowUpPlannerIsNotAttributedToAnswerTrace(t *testing.T) {
+	a := &App{abstainEnabled: true, followUpSteps: 2, followUpPlanner: reportingFollowUpPlanner{next: FollowUp{Action: followUpActionStop}}}
+	ctx, generation := ai.WithGenerationReport(context.Background())
+
+	if _, _, _, err := a.retrieveFollowUpEvidence(ctx, domain.Book{ID: "b1"}, domain.QueryPlan{StandaloneQuestion: "q", RequiredEvidenceCount: 1}, nil, nil, nil, nil, nil); err != nil {
+		t.Fatalf("retrieveFollowUpEvidence() error = %v", err)
+	}
+	ai.RecordGenerationProvider(ctx, "answer")
+	if got := generation.Provider(); got != "answer" {
+		t.Fatalf("trace provider = %q, want only the answer call", got)
+	}
+}
+
+func TestEnrichRetrievalDebugRecordsFollowUpSteps(t *testing.T) {
+	debug := &domain.RetrievalDebug{}
+	trace := domain.AnswerTrace{
+		QueryPlan:      domain.QueryPlan{RetrievalQueries: []string{"q1"}},
+		RetrievalSteps: []domain.RetrievalStep{{Step: 1, Action: followUpActionFollowUp, Queries: []string{"q2", "q1"}}},
+	}
+
+	enrichRetrievalDebug(debug, trace)
+	if len(debug.Steps) != 1 || !reflect.DeepEqual(debug.Queries, []string{"q1", "q2"}) {
+		t.Fatalf("unexpected debug steps %+v queries %v", debug.Steps, debug.Queries)
+	}
+}
@@ -12,12 +12,20 @@ import (
 
 const idempotencyScopeAskQuestion = "chat.ask"
 
-func (a *App) beginChatIdempotency(userID, bookID, conversationID, question, key string) (domain.IdempotencyRecord, domain.Answer, bool, error) {
+func (a *App) beginChatIdempotency(userID, bookID, conversationID, question, scope, branch, key string) (domain.IdempotencyRecord, domain.Answer, bool, error) {
 	key = strings.TrimSpace(key)
 	if key == "" {
 		return domain.IdempotencyRecord{}, domain.Answer{}, false, nil
 	}
-	requestHash := util.HashStrings(strings.TrimSpace(bookID), strings.TrimSpace(conversationID), strings.TrimSpace(question))
+	parts := []string{strings.TrimSpace(bookID), strings.TrimSpace(conversationID), strings.TrimSpace(question)}
+	// Unscoped requests keep the hash they had before passage scopes existed.
+	if scope != "" {
+		parts = append(parts, scope)
+	}
+	if branch != "" {
+		parts = append(parts, branch)
+	}
+	requestHash := util.HashStrings(parts...)
 	record, ok, err := a.store.GetIdempotencyRecord(idempotencyScopeAskQuestion, strings.TrimSpace(userID), key)
 	if err != nil {
 		return domain.IdempotencyRecord{}, domain.Answer{}, false, err
@@ -10,7 +10,7 @@ import (
 	"onebookai/pkg/domain"
 )
 
-func (a *App) answerDocumentOverview(ctx context.Context, book domain.Book, question string, onChunk func(string) error) (string, []domain.Source, bool, error) {
+func (a *App) answerDocumentOverview(ctx context.Context, prompts promptSet, book domain.Book, question string, onChunk func(string) error) (string, []domain.Source, bool, error) {
 	chunks, err := a.store.ListChunksByBook(book.ID)
 	if err != nil {
 		return "", nil, false, fmt.Errorf("load document overview chunks: %w", err)
@@ -18,20 +18,19 @@ func (a *App) answerDocumentOverview(ctx context.Context, book domain.Book, ques
 	overviewChunks := selectOverviewChunks(chunks, 4)
 	citations := buildOverviewSources(book, overviewChunks)
 	if len(citations) == 0 && strings.TrimSpace(book.DocumentSummary) == "" && strings.TrimSpace(book.OriginalFilename) == "" && strings.TrimSpace(book.Title) == "" {
-		return defaultAbstainAnswer, nil, true, nil
+		return prompts.AbstainAnswer, nil, true, nil
 	}
 	if onChunk != nil {
-		answer := fallbackDocumentOverviewAnswer(book, overviewChunks)
+		answer := fallbackDocumentOverviewAnswer(prompts, book, overviewChunks)
 		if err := onChunk(answer); err != nil {
 			return "", nil, false, err
 		}
 		return answer, citations, false, nil
 	}
 
-	contextText := buildDocumentOverviewContext(book, overviewChunks)
-	systemPrompt := "你是一个文档概览助手。只根据给定的文件信息和首页/开头内容判断文档是什么，并用简洁中文回答。"
-	userPrompt := fmt.Sprintf("用户问题：%s\n\n文档信息：\n%s\n\n要求：回答这份文档是什么、核心用途是什么；如果能判断文档类型，先说类型；引用证据编号。", question, contextText)
-	answer, genErr := a.generateAnswerText(ctx, systemPrompt, userPrompt, onChunk)
+	contextText := buildDocumentOverviewContext(prompts, book, overviewChunks)
+	userPrompt := fmt.Sprintf(prompts.OverviewUser, question, contextText)
+	answer, genErr := a.generateAnswerText(ctx, prompts.OverviewSystem, userPrompt, onChunk)
 	answer = strings.TrimSpace(answer)
 	if genErr != nil {
 		if onChunk != nil {
@@ -39,32 +38,33 @@ func (a *App) answerDocumentOverview(ctx context.Context, book domain.Book, ques
 		}
 		answer = ""
 	}
-	if answer == "" || strings.Contains(answer, "证据不足") || strings.Contains(strings.ToLower(answer), "insufficient") {
-		answer = fallbackDocumentOverviewAnswer(book, overviewChunks)
+	if answer == "" || signalsInsufficientEvidence(answer) {
+		answer = fallbackDocumentOverviewAnswer(prompts, book, overviewChunks)
 	}
 	return answer, citations, false, nil
 }
 
-func buildDocumentOverviewContext(book domain.Book, chunks []domain.Chunk) string {
+func buildDocumentOverviewContext(prompts promptSet, book domain.Book, chunks []domain.Chunk) string {
+	labels := prompts.Labels
 	var sb strings.Builder
-	sb.WriteString("标题：")
+	sb.WriteString(labels.Title)
 	sb.WriteString(firstNonEmpty(book.Title, book.OriginalFilename))
-	sb.WriteString("\n原始文件名：")
+	sb.WriteString("\n" + labels.Filename)
 	sb.WriteString(book.OriginalFilename)
-	if strings.TrimSpace(book.DocumentType) != "" {
-		sb.WriteString("\n文档类型：")
-		sb.WriteString(readableDocumentType(book.DocumentType))
+	if docType := prompts.documentType(book.DocumentType); docType != "" {
+		sb.WriteString("\n" + labels.DocumentType)
+		sb.WriteString(docType)
 	}
 	if len(book.Keywords) > 0 {
-		sb.WriteString("\n关键词：")
-		sb.WriteString(strings.Join(book.Keywords, "、"))
+		sb.WriteString("\n" + labels.Keywords)
+		sb.WriteString(strings.Join(book.Keywords, labels.KeywordSeparator))
 	}
 	if strings.TrimSpace(book.DocumentSummary) != "" {
-		sb.WriteString("\n文档摘要：")
+		sb.WriteString("\n" + labels.Summary)
 		sb.WriteString(book.DocumentSummary)
 	}
 	if strings.TrimSpace(book.FirstPageText) != "" {
-		sb.WriteString("\n首页文本：")
+		sb.WriteString("\n" + labels.FirstPage)
 		sb.WriteString(limitOverviewRunes(book.FirstPageText, 900))
 	}
 	for i, chunk := range chunks {
@@ -101,6 +101,7 @@ func buildOverviewSources(book domain.Book, chunks []domain.Chunk) []domain.Sour
 			ChunkID:   chunk.ID,
 			SourceRef: strings.TrimSpace(chunk.Metadata["source_ref"]),
 			Language:  strings.TrimSpace(chunk.Metadata["language"]),
+			Anchor:    domain.ChunkCitationAnchor(chunk),
 		})
 	}
 	return sources
@@ -148,15 +149,16 @@ func selectOverviewChunks(chunks []domain.Chunk, limit int) []domain.Chunk {
 	return out
 }
 
-func fallbackDocumentOverviewAnswer(book domain.Book, chunks []domain.Chunk) string {
-	docType := readableDocumentType(book.DocumentType)
-	if docType == "" {
-		docType = inferOverviewTypeFromText(book.OriginalFilename + "\n" + book.DocumentSummary + "\n" + firstOverviewChunkText(chunks))
+func fallbackDocumentOverviewAnswer(prompts promptSet, book domain.Book, chunks []domain.Chunk) string {
+	kind := strings.TrimSpace(book.DocumentType)
+	if prompts.documentType(kind) == "" {
+		kind = inferOverviewKindFromText(book.OriginalFilename + "\n" + book.DocumentSummary + "\n" + firstOverviewChunkText(chunks))
 	}
-	subject := firstNonEmpty(book.Title, book.OriginalFilename, "这份文档")
-	if docType == "" || docType == "文档" {
-		return fmt.Sprintf("%s 是一份文档，主要内容可从文件名和首页内容判断。", subject)
+	subject := firstNonEmpty(book.Title, book.OriginalFilename, prompts.UntitledDocument)
+	if kind == "document" {
+		return fmt.Sprintf(prompts.OverviewFallbackPlain, subject)
 	}
+	docType := prompts.documentType(kind)
 	summary := strings.TrimSpace(book.DocumentSummary)
 	if summary == "" {
 		summary = firstOverviewChunkText(chunks)
@@ -164,52 +166,31 @@ func fallbackDocumentOverviewAnswer(book domain.Book, chunks []domain.Chunk) str
 	summary = limitOverviewRunes(strings.Join(strings.Fields(summary), " "), 160)
 	citation := ""
 	if len(chunks) > 0 || strings.TrimSpace(book.DocumentSummary) != "" {
-		citation = "（依据[1]）"
+		citation = prompts.OverviewCitation
 	}
 	if summary == "" {
-		return fmt.Sprintf("%s 是一份%s。%s", subject, docType, citation)
+		return fmt.Sprintf(prompts.OverviewFallback, subject, docType) + citation
 	}
-	return fmt.Sprintf("%s 是一份%s，主要内容是：%s%s", subject, docType, summary, citation)
+	return fmt.Sprintf(prompts.OverviewFallbackSummary, subject, docType, summary) + citation
 }
 
-func readableDocumentType(kind string) string {
-	switch strings.TrimSpace(kind) {
-	case "internship_certificate":
-		return "实习证明"
-	case "certificate":
-		return "证明材料"
-	case "resume":
-		return "简历"
-	case "contract":
-		return "合同/协议"
-	case "invoice":
-		return "发票"
-	case "report":
-		return "报告"
-	case "book":
-		return "书籍/长文档"
-	case "document":
-		return "文档"
-	default:
-		return ""
-	}
-}
-
-func inferOverviewTypeFromText(text string) string {
+// inferOverviewKindFromText guesses a document type code from the filename
+// and opening text when ingestion did not classify the document.
+func inferOverviewKindFromText(text string) string {
 	text = strings.ToLower(text)
 	switch {
 	case strings.Contains(text, "实习证明"):
-		return "实习证明"
+		return "internship_certificate"
 	case strings.Contains(text, "证明") || strings.Contains(text, "兹证明"):
-		return "证明材料"
+		return "certificate"
 	case strings.Contains(text, "简历") || strings.Contains(text, "resume"):
-		return "简历"
+		return "resume"
 	case strings.Contains(text, "合同") || strings.Contains(text, "协议"):
-		return "合同/协议"
+		return "contract"
 	case strings.Contains(text, "报告") || strings.Contains(text, "report"):
-		return "报告"
+		return "report"
 	default:
-		return "文档"
+		return "document"
 	}
 }
 
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+
+	"onebookai/pkg/domain"
+)
+
+// Progress event names streamed ahead of the answer's chunk events.
+const (
+	ProgressPlan      = "plan"
+	ProgressRetrieval = "retrieval"
+	ProgressCitations = "citations"
+	ProgressAbstain   = "abstain"
+	// ProgressRetrievalStep reports each follow-up retrieval round.
+	ProgressRetrievalStep = "retrieval_step"
+)
+
+// ProgressFunc receives a staged progress event and its payload, one of the
+// domain *Event types.
+type ProgressFunc func(event string, payload any) error
+
+type progressKey struct{}
+
+// WithProgress attaches a progress sink to ctx. Answering reports each stage
+// to it as soon as the stage completes, so clients can show the plan,
+// retrieval counts and citations before the first answer token.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress sends an event to the sink in ctx, if any. Progress is
+// advisory: a failed write surfaces through the chunk writer or the
+// cancelled request context instead.
+func reportProgress(ctx context.Context, event string, payload any) {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	if fn == nil {
+		return
+	}
+	_ = fn(event, payload)
+}
+
+func planEvent(plan domain.QueryPlan) domain.PlanEvent {
+	return domain.PlanEvent{
+		Route:              plan.Route,
+		QuestionType:       plan.QuestionType,
+		StandaloneQuestion: plan.StandaloneQuestion,
+		RetrievalQueries:   plan.RetrievalQueries,
+	}
+}
+
+func retrievalEvent(debug *domain.RetrievalDebug, selected int) domain.RetrievalEvent {
+	event := domain.RetrievalEvent{Selected: selected}
+	if debug != nil {
+		event.Counts = debug.Counts
+	}
+	return event
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+func TestReportProgressDeliversToSink(t *testing.T) {
+	// Without a sink reporting is a no-op.
+	reportProgress(context.Background(), ProgressPlan, domain.PlanEvent{})
+
+	var events []string
+	ctx := WithProgress(context.Background(), func(event string, payload any) error {
+		events = append(events, event)
+		if event == ProgressRetrieval {
+			got := payload.(domain.RetrievalEvent)
+			if got.Counts.Dense != 7 || got.Counts.Fused != 9 || got.Selected != 3 {
+				t.Fatalf("unexpected retrieval payload: %+v", got)
+			}
+		}
+		return nil
+	})
+	reportProgress(ctx, ProgressPlan, planEvent(domain.QueryPlan{Route: "retrieve"}))
+	merged := mergeRetrievalDebug([]*domain.RetrievalDebug{
+		{Counts: domain.RetrievalCounts{Dense: 4, Fused: 5}},
+		nil,
+		{Counts: domain.RetrievalCounts{Dense: 3, Fused: 4}},
+	})
+	reportProgress(ctx, ProgressRetrieval, retrievalEvent(merged, 3))
+	if len(events) != 2 || events[0] != ProgressPlan || events[1] != ProgressRetrieval {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+// Answer languages a request may ask for. Auto answers in the language of
+// the question.
+const (
+	AnswerLanguageAuto = domain.AnswerLanguageAuto
+	AnswerLanguageZH   = domain.AnswerLanguageZH
+	AnswerLanguageEN   = domain.AnswerLanguageEN
+)
+
+// promptSet is one versioned set of answer prompts and user-facing abstain
+// messages. Bump Version whenever a prompt changes so eval runs recorded in
+// the answer trace stay comparable.
+type promptSet struct {
+	Language string
+	Version  string
+
+	StrictSystem          string
+	BestEffortSystem      string
+	BestEffortRequirement string
+	ShelfSystemSuffix     string
+	Requirements          map[string]string
+	DefaultRequirement    string
+
+	HistorySystem                string
+	HistoryBestEffortSystem      string
+	HistoryRequirement           string
+	HistoryBestEffortRequirement string
+	// HistoryUser formats the book title, history, question and requirement.
+	HistoryUser string
+
+	OverviewSystem string
+	// OverviewUser formats the question and the document context.
+	OverviewUser string
+
+	// AbstainSentinel opens every declining answer, the model's included;
+	// answers are only treated as abstentions when they start with it.
+	AbstainSentinel   string
+	AbstainAnswer     string
+	OutOfScopeAnswer  string
+	ReasonLabel       string
+	DefaultReason     string
+	OutOfScopeReason  string
+	EmptyAnswerReason string
+	UngroundedReason  string
+	// EvidenceShortfall formats the question type, required and found counts.
+	EvidenceShortfall string
+
+	// SummarySystem instructs the rolling conversation summary; SummaryFresh
+	// and SummaryMerge ask for a first or an updated summary, and
+	// SummaryLength formats that request with the rune limit.
+	SummarySystem   string
+	SummaryPrevious string
+	SummaryNew      string
+	SummaryFresh    string
+	SummaryMerge    string
+	SummaryLength   string
+
+	Labels        promptLabels
+	DocumentTypes map[string]string
+	// OverviewFallback formats the subject and document type; the Summary
+	// variant also takes the summary. Citation is appended to either.
+	OverviewFallback        string
+	OverviewFallbackSummary string
+	OverviewFallbackPlain   string
+	OverviewCitation        string
+	UntitledDocument        string
+}
+
+type promptLabels struct {
+	Title              string
+	Filename           string
+	DocumentType       string
+	Keywords           string
+	KeywordSeparator   string
+	Summary            string
+	FirstPage          string
+	Question           string
+	StandaloneQuestion string
+	QuestionType       string
+	History            string
+	Evidence           string
+	ShelfBooks         string
+	// ShelfBook formats one book title in the shelf list.
+	ShelfBook     string
+	ShelfSummary  string
+	ShelfEvidence string
+	// UserRole, AssistantRole and OtherRole name the speakers of history
+	// lines; EarlierSummary heads the rolling summary ahead of them.
+	UserRole       string
+	AssistantRole  string
+	OtherRole      string
+	EarlierSummary string
+}
+
+var zhPrompts = promptSet{
+	Language: AnswerLanguageZH,
+	Version:  "zh-v2",
+
+	StrictSystem:          "你是一个严格基于证据回答的文档专家。不要使用证据外知识。每个结论都必须可由提供证据支持；引用相关编号。",
+	BestEffortSystem:      "你是一个优先基于证据回答的读书助手。可以在证据不足时给出谨慎的最佳努力回答，但必须明确不确定性，且不要虚构引用或把证据外信息说成确定事实。",
+	BestEffortRequirement: "要求：优先基于证据回答；引用相关编号；证据不足时可以给出谨慎的最佳努力回答，并明确说明不确定性，但不要编造引用。",
+	ShelfSystemSuffix:     "证据来自多本书；每个结论都要注明出自哪本书（使用证据中的书名）。",
+	Requirements: map[string]string{
+		questionTypeSingleFact: "要求：回答单点事实；如果证据中能直接定位答案，用一句话回答并引用编号；不要展开无关内容。",
+		questionTypeSummary:    "要求：概括整份文档；必须覆盖核心对象、用途和关键时间/事实；引用多个相关编号；不要只总结一个局部片段。",
+		questionTypeAnalysis:   "要求：只基于多条证据做分析；证据不足则以“证据不足”开头明确拒答，并说明缺少哪类依据。",
+	},
+	DefaultRequirement: "要求：只基于证据回答；引用相关编号；证据不足则以“证据不足”开头明确拒答。",
+
+	HistorySystem:                "你是一个延续当前会话上下文的读书助手。只使用给定对话历史，不要虚构新的事实。",
+	HistoryBestEffortSystem:      "你是一个延续当前会话上下文的读书助手。只使用给定对话历史，不要虚构新的事实；在信息不足时可以给出谨慎回答，但要明确不确定性。",
+	HistoryRequirement:           "要求：只基于当前对话历史继续回答，不要引入对话外知识；如果历史不足以支撑回答，就以“证据不足”开头说明。",
+	HistoryBestEffortRequirement: "要求：只基于当前对话历史继续回答，不要引入对话外知识；如果历史不足，也可以给出谨慎的最佳努力回答，并明确说明不确定性。",
+	HistoryUser:                  "书名：%s\n对话历史：\n%s\n\n当前问题：%s\n\n%s",
+
+	OverviewSystem: "你是一个文档概览助手。只根据给定的文件信息和首页/开头内容判断文档是什么，并用简洁中文回答。",
+	OverviewUser:   "用户问题：%s\n\n文档信息：\n%s\n\n要求：回答这份文档是什么、核心用途是什么；如果能判断文档类型，先说类型；引用证据编号。",
+
+	AbstainSentinel:   "证据不足",
+	AbstainAnswer:     "证据不足，当前无法基于已上传内容给出可靠回答。",
+	OutOfScopeAnswer:  "当前问题超出已上传内容和当前会话范围，无法基于书内证据给出可靠回答。",
+	ReasonLabel:       "缺少证据原因：",
+	DefaultReason:     "未找到足够可引用证据",
+	OutOfScopeReason:  "这个问题超出当前上传文档和会话上下文。",
+	EmptyAnswerReason: "模型没有生成可用回答",
+	UngroundedReason:  "回答没有充分绑定到已选证据",
+	EvidenceShortfall: "当前问题类型 %s 至少需要 %d 条可引用证据，但只找到 %d 条",
+
+	SummarySystem:   "你负责维护多轮对话的滚动摘要。只根据给定内容更新摘要，保留用户关心的问题、已确认的事实、结论和未解决的疑问，不要编造。",
+	SummaryPrevious: "已有摘要：",
+	SummaryNew:      "新增对话：",
+	SummaryFresh:    "请概括新增对话",
+	SummaryMerge:    "请把新增对话合并进已有摘要，输出更新后的完整摘要",
+	SummaryLength:   "%s，不超过%d字。只输出摘要正文。",
+
+	Labels: promptLabels{
+		Title:              "文档标题：",
+		Filename:           "原始文件名：",
+		DocumentType:       "文档类型：",
+		Keywords:           "关键词：",
+		KeywordSeparator:   "、",
+		Summary:            "文档摘要：",
+		FirstPage:          "首页文本：",
+		Question:           "原始问题：",
+		StandaloneQuestion: "独立检索问题：",
+		QuestionType:       "问题类型：",
+		History:            "最近对话：",
+		Evidence:           "选中证据：",
+		ShelfBooks:         "书架文档：",
+		ShelfBook:          "\n- 《%s》",
+		ShelfSummary:       "：",
+		ShelfEvidence:      "选中证据（编号后为书名）：",
+		UserRole:           "用户",
+		AssistantRole:      "助手",
+		OtherRole:          "消息",
+		EarlierSummary:     "早前对话摘要：",
+	},
+	DocumentTypes: map[string]string{
+		"internship_certificate": "实习证明",
+		"certificate":            "证明材料",
+		"resume":                 "简历",
+		"contract":               "合同/协议",
+		"invoice":                "发票",
+		"report":                 "报告",
+		"book":                   "书籍/长文档",
+		"document":               "文档",
+	},
+	OverviewFallback:        "%s 是一份%s。",
+	OverviewFallbackSummary: "%s 是一份%s，主要内容是：%s",
+	OverviewFallbackPlain:   "%s 是一份文档，主要内容可从文件名和首页内容判断。",
+	OverviewCitation:        "（依据[1]）",
+	UntitledDocument:        "这份文档",
+}
+
+var enPrompts = promptSet{
+	Language: AnswerLanguageEN,
+	Version:  "en-v3",
+
+	StrictSystem:          "You are a document expert who answers strictly from the provided evidence. Do not use outside knowledge. Every claim must be supported by the evidence; cite the relevant numbers. Answer in the language of the user's question.",
+	BestEffortSystem:      "You are a reading assistant who answers from the evidence first. When the evidence is thin you may give a careful best-effort answer, but state the uncertainty, never invent citations and never present outside information as established fact. Answer in the language of the user's question.",
+	BestEffortRequirement: "Requirements: answer from the evidence first and cite the relevant numbers; if the evidence is insufficient, give a careful best-effort answer and say what is uncertain, without inventing citations.",
+	ShelfSystemSuffix:     " The evidence comes from several books; name the book (as given in the evidence) behind every claim.",
+	Requirements: map[string]string{
+		questionTypeSingleFact: "Requirements: answer the single fact asked; if the evidence states it directly, answer in one sentence with its citation number and leave out unrelated detail.",
+		questionTypeSummary:    "Requirements: summarize the whole document, covering its subject, purpose and key dates or facts; cite several relevant numbers rather than summarizing one passage.",
+		questionTypeAnalysis:   "Requirements: analyze only from several pieces of evidence; if the evidence is insufficient, start the reply with \"Insufficient evidence\" and say what kind of support is missing.",
+	},
+	DefaultRequirement: "Requirements: answer only from the evidence and cite the relevant numbers; if the evidence is insufficient, decline with a reply that starts with \"Insufficient evidence\".",
+
+	HistorySystem:                "You are a reading assistant continuing the current conversation. Use only the given conversation history and do not invent new facts. Answer in the language of the user's question.",
+	HistoryBestEffortSystem:      "You are a reading assistant continuing the current conversation. Use only the given conversation history and do not invent new facts; when it is not enough you may answer carefully, stating the uncertainty. Answer in the language of the user's question.",
+	HistoryRequirement:           "Requirements: continue only from the conversation history without outside knowledge; if the history is not enough to answer, start the reply with \"Insufficient evidence\".",
+	HistoryBestEffortRequirement: "Requirements: continue only from the conversation history without outside knowledge; if the history is not enough, give a careful best-effort answer and say what is uncertain.",
+	HistoryUser:                  "Book: %s\nConversation history:\n%s\n\nQuestion: %s\n\n%s",
+
+	OverviewSystem: "You are a document overview assistant. Decide what the document is only from the given file information and its opening pages, and answer concisely in the language of the user's question.",
+	OverviewUser:   "Question: %s\n\nDocument information:\n%s\n\nRequirements: say what this document is and what it is for; if its type is clear, state the type first; cite the evidence numbers.",
+
+	AbstainSentinel:   "Insufficient evidence",
+	AbstainAnswer:     "Insufficient evidence: the uploaded content does not support a reliable answer to this question.",
+	OutOfScopeAnswer:  "This question is outside the uploaded content and the current conversation, so it cannot be answered reliably from the book.",
+	ReasonLabel:       "Missing evidence: ",
+	DefaultReason:     "not enough citable evidence was found",
+	OutOfScopeReason:  "the question is outside the uploaded document and the conversation.",
+	EmptyAnswerReason: "the model produced no usable answer",
+	UngroundedReason:  "the answer is not sufficiently grounded in the selected evidence",
+	EvidenceShortfall: "question type %s needs at least %d citable passages, but only %d were found",
+
+	SummarySystem:   "You maintain a rolling summary of a multi-turn conversation. Update it only from the given content, keeping the user's questions, confirmed facts, conclusions and open questions, and invent nothing. Write the summary in the language of the conversation.",
+	SummaryPrevious: "Existing summary:",
+	SummaryNew:      "New conversation:",
+	SummaryFresh:    "Summarize the new conversation",
+	SummaryMerge:    "Merge the new conversation into the existing summary and output the full updated summary",
+	SummaryLength:   "%s in at most %d characters. Output only the summary text.",
+
+	Labels: promptLabels{
+		Title:              "Document title: ",
+		Filename:           "Original filename: ",
+		DocumentType:       "Document type: ",
+		Keywords:           "Keywords: ",
+		KeywordSeparator:   ", ",
+		Summary:            "Document summary: ",
+		FirstPage:          "First page text: ",
+		Question:           "Original question: ",
+		StandaloneQuestion: "Standalone retrieval question: ",
+		QuestionType:       "Question type: ",
+		History:            "Recent conversation:",
+		Evidence:           "Selected evidence:",
+		ShelfBooks:         "Shelf documents:",
+		ShelfBook:          "\n- \"%s\"",
+		ShelfSummary:       ": ",
+		ShelfEvidence:      "Selected evidence (book title after each number):",
+		UserRole:           "User",
+		AssistantRole:      "Assistant",
+		OtherRole:          "Message",
+		EarlierSummary:     "Summary of the earlier conversation:",
+	},
+	DocumentTypes: map[string]string{
+		"internship_certificate": "internship certificate",
+		"certificate":            "certificate",
+		"resume":                 "resume",
+		"contract":               "contract or agreement",
+		"invoice":                "invoice",
+		"report":                 "report",
+		"book":                   "book or long document",
+		"document":               "document",
+	},
+	OverviewFallback:        "%s is a %s.",
+	OverviewFallbackSummary: "%s is a %s. It mainly covers: %s",
+	OverviewFallbackPlain:   "%s is a document; its content can be judged from the filename and first page.",
+	OverviewCitation:        " (see [1])",
+	UntitledDocument:        "This document",
+}
+
+// defaultAbstainAnswer is the Chinese abstain message, used where no
+// question is at hand to pick a language from.
+var defaultAbstainAnswer = zhPrompts.AbstainAnswer
+
+// NormalizeAnswerLanguage validates a requested answer language. Empty means
+// auto.
+func NormalizeAnswerLanguage(language string) (string, error) {
+	return domain.NormalizeAnswerLanguage(language)
+}
+
+type answerLanguageKey struct{}
+
+// WithAnswerLanguage attaches the user's preferred answer language to ctx,
+// overriding the language detected from the question. Auto or an empty
+// language leaves detection on.
+func WithAnswerLanguage(ctx context.Context, language string) context.Context {
+	language, err := NormalizeAnswerLanguage(language)
+	if err != nil || language == AnswerLanguageAuto {
+		return ctx
+	}
+	return context.WithValue(ctx, answerLanguageKey{}, language)
+}
+
+// promptsFor picks the prompt set for a question: the preference in ctx if
+// set, otherwise Chinese for Chinese questions and English, which tells the
+// model to mirror the question's language, for everything else.
+func promptsFor(ctx context.Context, question string) promptSet {
+	language, _ := ctx.Value(answerLanguageKey{}).(string)
+	if language == "" {
+		language = AnswerLanguageEN
+		if retrieval.DetectLanguage(question) == "zh" {
+			language = AnswerLanguageZH
+		}
+	}
+	if language == AnswerLanguageZH {
+		return zhPrompts
+	}
+	return enPrompts
+}
+
+func (p promptSet) requirement(questionType string) string {
+	if requirement, ok := p.Requirements[questionType]; ok {
+		return requirement
+	}
+	return p.DefaultRequirement
+}
+
+func (p promptSet) abstainWithReason(reason string) string {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = p.DefaultReason
+	}
+	return p.AbstainAnswer + "\n\n" + p.ReasonLabel + reason
+}
+
+func (p promptSet) documentType(kind string) string {
+	return p.DocumentTypes[strings.TrimSpace(kind)]
+}
+
+// signalsInsufficientEvidence reports whether a model answer declines for
+// lack of evidence: it opens with the abstain sentinel of either prompt
+// language. A sentence that merely uses the word does not count.
+func signalsInsufficientEvidence(answer string) bool {
+	answer = strings.TrimLeft(strings.TrimSpace(answer), "*_#> ")
+	for _, set := range []promptSet{zhPrompts, enPrompts} {
+		sentinel := set.AbstainSentinel
+		if len(answer) >= len(sentinel) && strings.EqualFold(answer[:len(sentinel)], sentinel) {
+			return true
+		}
+	}
+	return false
+}
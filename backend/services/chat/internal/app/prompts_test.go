@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode"
+
+	"onebookai/pkg/domain"
+)
+
+func TestPromptsForPicksLanguageFromQuestionUnlessPreferred(t *testing.T) {
+	tests := []struct {
+		name       string
+		preference string
+		question   string
+		want       string
+	}{
+		{name: "chinese question", question: "作者为什么反对计划经济？", want: "zh-v2"},
+		{name: "english question", question: "Why does the author reject central planning?", want: "en-v3"},
+		{name: "other language", question: "Pourquoi l'auteur rejette-t-il la planification ?", want: "en-v3"},
+		{name: "preference wins", preference: "zh", question: "Who signed the certificate?", want: "zh-v2"},
+		{name: "auto preference", preference: "auto", question: "这份文件是谁签发的？", want: "zh-v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithAnswerLanguage(context.Background(), tt.preference)
+			if got := promptsFor(ctx, tt.question).Version; got != tt.want {
+				t.Fatalf("promptsFor() version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAnswerLanguage(t *testing.T) {
+	for input, want := range map[string]string{"": "auto", "AUTO": "auto", " en ": "en", "zh": "zh"} {
+		got, err := NormalizeAnswerLanguage(input)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeAnswerLanguage(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeAnswerLanguage("fr"); err == nil {
+		t.Fatal("expected an error for an unsupported language")
+	}
+}
+
+func TestPromptSetsCoverTheSameKeys(t *testing.T) {
+	for _, set := range []promptSet{zhPrompts, enPrompts} {
+		if len(set.Requirements) != len(zhPrompts.Requirements) || len(set.DocumentTypes) != len(zhPrompts.DocumentTypes) {
+			t.Fatalf("%s: requirements or document types out of sync with zh", set.Version)
+		}
+		if !signalsInsufficientEvidence(set.AbstainAnswer) {
+			t.Fatalf("%s: abstain answer must read as insufficient evidence", set.Version)
+		}
+	}
+}
+
+func TestEnglishAbstainAndPromptsAreLocalized(t *testing.T) {
+	answer := enPrompts.abstainWithReason("")
+	if !strings.HasPrefix(answer, "Insufficient evidence") || !strings.Contains(answer, enPrompts.DefaultReason) {
+		t.Fatalf("unexpected abstain answer %q", answer)
+	}
+
+	book := domain.Book{Title: "The Road to Serfdom", DocumentType: "book"}
+	plan := domain.QueryPlan{OriginalQuestion: "Why?", StandaloneQuestion: "Why does Hayek reject planning?", QuestionType: questionTypeAnalysis}
+	prompt := buildStructuredAnswerPrompt(enPrompts, book, plan, "", "[1] passage", enPrompts.requirement(plan.QuestionType))
+	for _, want := range []string{"Document title: The Road to Serfdom", "Document type: book or long document", "Selected evidence:", "Requirements:"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.ContainsAny(prompt, "：《") {
+		t.Fatalf("english prompt contains Chinese labels:\n%s", prompt)
+	}
+
+	overview := fallbackDocumentOverviewAnswer(enPrompts, domain.Book{OriginalFilename: "annual-report.pdf"}, nil)
+	if overview != "annual-report.pdf is a report." {
+		t.Fatalf("fallbackDocumentOverviewAnswer() = %q", overview)
+	}
+}
+
+func TestEnglishPromptsContainNoChineseText(t *testing.T) {
+	history := []domain.Message{{Role: "user", Content: "Who is the author?"}, {Role: "assistant", Content: "Hayek [1]."}, {Role: "system", Content: "note"}}
+	book := domain.Book{Title: "The Road to Serfdom", DocumentType: "book"}
+	plan := domain.QueryPlan{OriginalQuestion: "Why?", StandaloneQuestion: "Why does Hayek reject planning?", QuestionType: questionTypeAnalysis}
+	memory := buildConversationMemory(enPrompts.Labels, "The user asked about the author.", history)
+	prompts := map[string]string{
+		"memory":  memory,
+		"answer":  buildStructuredAnswerPrompt(enPrompts, book, plan, memory, "[1] passage", enPrompts.requirement(plan.QuestionType)),
+		"summary": enPrompts.SummarySystem + "\n" + buildSummaryPrompt(enPrompts, "Earlier summary.", history),
+	}
+	for name, prompt := range prompts {
+		for _, r := range prompt {
+			if unicode.Is(unicode.Han, r) || unicode.In(r, unicode.Hiragana, unicode.Katakana) || (r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef) {
+				t.Fatalf("english %s prompt contains CJK text %q:\n%s", name, r, prompt)
+			}
+		}
+	}
+	if !strings.Contains(memory, "User: Who is the author?") || !strings.Contains(memory, "Assistant: Hayek [1].") {
+		t.Fatalf("memory = %q, want English role labels", memory)
+	}
+}
+
+func TestAnswerFromHistoryAbstainsInQuestionLanguage(t *testing.T) {
+	a := &App{generator: stubGenerator{response: "Insufficient evidence: the history does not say."}, abstainEnabled: true}
+
+	answer, _, abstained := a.answerFromHistory(context.Background(), domain.Book{Title: "book"}, "Can you go on?", "user: earlier question", nil)
+	if !abstained || answer != enPrompts.AbstainAnswer {
+		t.Fatalf("answerFromHistory() = %q, %v; want the English abstain answer", answer, abstained)
+	}
+}
+
+func TestSignalsInsufficientEvidenceOnlyMatchesSentinel(t *testing.T) {
+	for _, answer := range []string{
+		enPrompts.AbstainAnswer,
+		zhPrompts.AbstainAnswer,
+		"**Insufficient evidence**: nothing in the book covers this.",
+		"insufficient evidence to say who signed it.",
+		"证据不足，无法判断签发人。",
+	} {
+		if !signalsInsufficientEvidence(answer) {
+			t.Fatalf("signalsInsufficientEvidence(%q) = false, want an abstention", answer)
+		}
+	}
+	for _, answer := range []string{
+		"The funding was insufficient, so the project stalled [1].",
+		"Hayek argues planners have insufficient knowledge [2].",
+		"法院认为原告证据不足，驳回了起诉 [1]。",
+	} {
+		if signalsInsufficientEvidence(answer) {
+			t.Fatalf("signalsInsufficientEvidence(%q) = true, want an ordinary answer", answer)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+)
+
+// Route sources recorded on the query plan.
+const (
+	routeSourceModel     = "model"
+	routeSourceHeuristic = "heuristic"
+)
+
+const (
+	// defaultRouterMinConfidence is the model confidence below which the
+	// heuristic route is used instead.
+	defaultRouterMinConfidence = 0.7
+	// routeCacheLimit bounds the cached model decisions; the cache is
+	// cleared when it fills up.
+	routeCacheLimit = 1024
+)
+
+// RouteClassification is a model's routing verdict for one question.
+type RouteClassification struct {
+	Route        string  `json:"route"`
+	QuestionType string  `json:"questionType"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// RouteClassifier routes a question given whether the conversation already
+// has an answer to follow up on.
+type RouteClassifier interface {
+	Classify(ctx context.Context, question string, hasPriorAnswer bool) (RouteClassification, error)
+}
+
+type modelRouteClassifier struct {
+	generator ai.TextGenerator
+}
+
+func newModelRouteClassifier(generator ai.TextGenerator) RouteClassifier {
+	return &modelRouteClassifier{generator: generator}
+}
+
+func (c *modelRouteClassifier) Classify(ctx context.Context, question string, hasPriorAnswer bool) (RouteClassification, error) {
+	if c.generator == nil {
+		return RouteClassification{}, errors.New("route classifier has no generator")
+	}
+	prompt := fmt.Sprintf(`Question: %s
+The conversation already has an assistant answer: %t
+
+Route the question for a system that answers from one uploaded document:
+- "rag": needs passages from the document
+- "document_overview": asks what the document itself is or is about as a whole
+- "history_only": rephrases, continues, translates or explains the previous answer and needs no new passages
+- "out_of_scope_reject": asks for real-time or outside information the document cannot contain (weather, prices, news)
+Question types: single_fact, summary, analysis, overview, follow_up, out_of_scope, rag (anything else).
+Return a JSON object {"route": "...", "questionType": "...", "confidence": 0..1}.`, question, hasPriorAnswer)
+	out, err := c.generator.GenerateText(ai.WithUsageStage(ctx, usageStageQueryRoute), "You route questions for a document question answering system. Output valid JSON only.", prompt)
+	if err != nil {
+		return RouteClassification{}, err
+	}
+	var verdict RouteClassification
+	if err := json.Unmarshal([]byte(trimJSONFence(out)), &verdict); err != nil {
+		return RouteClassification{}, err
+	}
+	verdict.Route = strings.ToLower(strings.TrimSpace(verdict.Route))
+	verdict.QuestionType = strings.ToLower(strings.TrimSpace(verdict.QuestionType))
+	if !isKnownQueryRoute(queryRoute(verdict.Route)) {
+		return RouteClassification{}, fmt.Errorf("unknown route %q", verdict.Route)
+	}
+	return verdict, nil
+}
+
+// RouteDecision is the route and question type chosen for a question, and
+// whether the model or the keyword heuristics decided it.
+type RouteDecision struct {
+	Route        string
+	QuestionType string
+	Confidence   float64
+	Source       string
+	Reason       string
+}
+
+// QueryRouter routes questions with a model when one is configured and falls
+// back to the keyword heuristics on error or low confidence. Model verdicts
+// are cached per normalized question.
+type QueryRouter struct {
+	classifier    RouteClassifier
+	minConfidence float64
+
+	mu    sync.Mutex
+	cache map[string]RouteClassification
+}
+
+// NewQueryRouter builds a router that asks generator to classify questions,
+// keeping the heuristics below minConfidence. Pass the generator answers are
+// generated with so routing shares its failover chain and breakers. A nil
+// generator routes with the heuristics alone.
+func NewQueryRouter(generator ai.TextGenerator, minConfidence float64) *QueryRouter {
+	var classifier RouteClassifier
+	if generator != nil {
+		classifier = newModelRouteClassifier(generator)
+	}
+	return newQueryRouter(classifier, minConfidence)
+}
+
+func newQueryRouter(classifier RouteClassifier, minConfidence float64) *QueryRouter {
+	if minConfidence <= 0 {
+		minConfidence = defaultRouterMinConfidence
+	}
+	return &QueryRouter{classifier: classifier, minConfidence: minConfidence, cache: map[string]RouteClassification{}}
+}
+
+// Route decides how to answer question. A nil router uses the heuristics.
+func (r *QueryRouter) Route(ctx context.Context, question string, history []domain.Message) RouteDecision {
+	fallback := heuristicRouteDecision(question, history)
+	if r == nil || r.classifier == nil || normalizeRouterText(question) == "" {
+		return fallback
+	}
+	verdict, err := r.classify(ctx, question, hasRecentAssistantReply(history))
+	if err != nil {
+		fallback.Reason = "model_error"
+		return fallback
+	}
+	if verdict.Confidence < r.minConfidence {
+		fallback.Reason = "model_low_confidence"
+		return fallback
+	}
+	route := queryRoute(verdict.Route)
+	return RouteDecision{
+		Route:        verdict.Route,
+		QuestionType: modelQuestionType(route, verdict.QuestionType),
+		Confidence:   verdict.Confidence,
+		Source:       routeSourceModel,
+		Reason:       "model",
+	}
+}
+
+func (r *QueryRouter) classify(ctx context.Context, question string, hasPriorAnswer bool) (RouteClassification, error) {
+	key := fmt.Sprintf("%t\x00%s", hasPriorAnswer, normalizeRouterText(question))
+	r.mu.Lock()
+	verdict, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return verdict, nil
+	}
+	verdict, err := r.classifier.Classify(ctx, question, hasPriorAnswer)
+	if err != nil {
+		return RouteClassification{}, err
+	}
+	r.mu.Lock()
+	if len(r.cache) >= routeCacheLimit {
+		clear(r.cache)
+	}
+	r.cache[key] = verdict
+	r.mu.Unlock()
+	return verdict, nil
+}
+
+func heuristicRouteDecision(question string, history []domain.Message) RouteDecision {
+	decision := decideQueryRoute(question, history)
+	return RouteDecision{
+		Route:        string(decision.Route),
+		QuestionType: classifyQuestionType(decision.Route, question, question, history),
+		Source:       routeSourceHeuristic,
+		Reason:       decision.Reason,
+	}
+}
+
+// modelQuestionType keeps the model's question type consistent with its
+// route: non-retrieval routes imply their type, and unknown types become rag.
+func modelQuestionType(route queryRoute, questionType string) string {
+	switch route {
+	case queryRouteDocumentOverview:
+		return questionTypeOverview
+	case queryRouteHistoryOnly:
+		return questionTypeFollowUp
+	case queryRouteOutOfScopeReject:
+		return "out_of_scope"
+	}
+	switch questionType {
+	case questionTypeSingleFact, questionTypeSummary, questionTypeAnalysis, questionTypeFollowUp:
+		return questionType
+	default:
+		return "rag"
+	}
+}
+
+func isKnownQueryRoute(route queryRoute) bool {
+	switch route {
+	case queryRouteRAG, queryRouteDocumentOverview, queryRouteHistoryOnly, queryRouteOutOfScopeReject:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+type stubRouteClassifier struct {
+	verdict RouteClassification
+	err     error
+	calls   int
+}
+
+func (s *stubRouteClassifier) Classify(_ context.Context, _ string, _ bool) (RouteClassification, error) {
+	s.calls++
+	return s.verdict, s.err
+}
+
+func TestQueryRouterUsesConfidentModelVerdictAndCachesIt(t *testing.T) {
+	classifier := &stubRouteClassifier{verdict: RouteClassification{Route: "out_of_scope_reject", QuestionType: "analysis", Confidence: 0.92}}
+	router := newQueryRouter(classifier, 0.7)
+
+	for _, question := range []string{"What's the Bitcoin price today?", "  what's the bitcoin   price today?"} {
+		decision := router.Route(context.Background(), question, nil)
+		if decision.Route != string(queryRouteOutOfScopeReject) || decision.Source != routeSourceModel || decision.QuestionType != "out_of_scope" {
+			t.Fatalf("unexpected decision %+v", decision)
+		}
+	}
+	if classifier.calls != 1 {
+		t.Fatalf("classifier calls = %d, want 1 for the same normalized question", classifier.calls)
+	}
+
+	history := []domain.Message{{Role: "assistant", Content: "Bitcoin is mentioned in chapter 2."}}
+	router.Route(context.Background(), "What's the Bitcoin price today?", history)
+	if classifier.calls != 2 {
+		t.Fatalf("classifier calls = %d, want a separate decision once there is a prior answer", classifier.calls)
+	}
+}
+
+func TestQueryRouterFallsBackToHeuristics(t *testing.T) {
+	tests := []struct {
+		name       string
+		classifier *stubRouteClassifier
+		reason     string
+	}{
+		{name: "error", classifier: &stubRouteClassifier{err: errors.New("model down")}, reason: "model_error"},
+		{name: "low confidence", classifier: &stubRouteClassifier{verdict: RouteClassification{Route: "history_only", Confidence: 0.4}}, reason: "model_low_confidence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := newQueryRouter(tt.classifier, 0.7).Route(context.Background(), "这是什么文件", nil)
+			if decision.Route != string(queryRouteDocumentOverview) || decision.Source != routeSourceHeuristic || decision.Reason != tt.reason {
+				t.Fatalf("unexpected decision %+v", decision)
+			}
+		})
+	}
+}
+
+func TestNilQueryRouterUsesHeuristics(t *testing.T) {
+	var router *QueryRouter
+	decision := router.Route(context.Background(), "今天天气怎么样", nil)
+	if decision.Route != string(queryRouteOutOfScopeReject) || decision.Source != routeSourceHeuristic {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+}
+
+func TestModelRouteClassifierParsesVerdict(t *testing.T) {
+	classifier := newModelRouteClassifier(stubGenerator{response: "```json\n{\"route\":\"RAG\",\"questionType\":\"single_fact\",\"confidence\":0.9}\n```"})
+
+	verdict, err := classifier.Classify(context.Background(), "Who signed the certificate?", false)
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if verdict.Route != "rag" || verdict.QuestionType != "single_fact" || verdict.Confidence != 0.9 {
+		t.Fatalf("unexpected verdict %+v", verdict)
+	}
+	if _, err := newModelRouteClassifier(stubGenerator{response: `{"route":"web_search","confidence":1}`}).Classify(context.Background(), "q", false); err == nil {
+		t.Fatal("expected an error for an unknown route")
+	}
+}
+
+func TestBuildQueryPlanRecordsModelRoute(t *testing.T) {
+	a := &App{router: newQueryRouter(&stubRouteClassifier{verdict: RouteClassification{Route: "rag", QuestionType: "analysis", Confidence: 0.85}}, 0.7)}
+
+	plan := a.buildQueryPlan(context.Background(), domain.Book{ID: "b1"}, "Why does the author reject central planning?", nil, "")
+	if plan.Route != string(queryRouteRAG) || plan.QuestionType != questionTypeAnalysis || plan.RouteSource != routeSourceModel || plan.RouteConfidence != 0.85 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+}
+
+func TestNewQueryRouterClassifiesWithGivenGenerator(t *testing.T) {
+	generator := stubGenerator{response: `{"route": "out_of_scope_reject", "questionType": "out_of_scope", "confidence": 0.9}`}
+	decision := NewQueryRouter(generator, 0.7).Route(context.Background(), "What's the weather in Paris?", nil)
+	if decision.Source != routeSourceModel || decision.Route != string(queryRouteOutOfScopeReject) {
+		t.Fatalf("decision = %+v, want the generator's verdict", decision)
+	}
+	if decision := NewQueryRouter(nil, 0.7).Route(context.Background(), "What's the weather in Paris?", nil); decision.Source == routeSourceModel {
+		t.Fatalf("router without a generator should use the heuristics, got %+v", decision)
+	}
+}
@@ -5,14 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"onebookai/pkg/ai"
 	"onebookai/pkg/domain"
 	"onebookai/pkg/retrieval"
 )
 
-const defaultAbstainAnswer = "证据不足，当前无法基于已上传内容给出可靠回答。"
-
 type QueryRewriter interface {
 	Rewrite(ctx context.Context, query, language string) ([]string, error)
 }
@@ -58,7 +57,7 @@ func (v *groundingValidator) Validate(question, answer string, citations []domai
 	if answer == "" {
 		return false
 	}
-	if strings.Contains(answer, "证据不足") || strings.Contains(strings.ToLower(answer), "insufficient") {
+	if signalsInsufficientEvidence(answer) {
 		return true
 	}
 	if len(citations) == 0 {
@@ -120,7 +119,7 @@ func (a *App) buildRetrievalQueries(ctx context.Context, question string) []stri
 	}
 	if a.queryRewriteEnabled && a.rewriter != nil {
 		language := retrieval.DetectLanguage(normalized)
-		rewrites, err := a.rewriter.Rewrite(ctx, normalized, language)
+		rewrites, err := a.rewriter.Rewrite(ai.WithUsageStage(ctx, usageStageQueryRewrite), normalized, language)
 		if err == nil {
 			rewrites = normalizeRetrievalQueries(rewrites)
 			if len(rewrites) > 0 {
@@ -135,7 +134,10 @@ func (a *App) buildRetrievalQueries(ctx context.Context, question string) []stri
 	return uniqueRetrievalQueries(queries)
 }
 
-func (a *App) contextualizeRetrievalQuestion(ctx context.Context, book domain.Book, question string, history []domain.Message) string {
+// contextualizeRetrievalQuestion rewrites an elliptical follow-up into a
+// standalone retrieval question using the recent turns and, when present, the
+// rolling summary of earlier ones.
+func (a *App) contextualizeRetrievalQuestion(ctx context.Context, book domain.Book, question string, history []domain.Message, summary string) string {
 	question = strings.TrimSpace(question)
 	if question == "" || !needsConversationAwareRetrieval(question, history) {
 		return question
@@ -145,14 +147,19 @@ func (a *App) contextualizeRetrievalQuestion(ctx context.Context, book domain.Bo
 		return question
 	}
 	if a.generator != nil {
+		summaryText := ""
+		if summary = strings.TrimSpace(summary); summary != "" {
+			summaryText = "早前对话摘要：\n" + limitRewriteContextRunes(summary, 600) + "\n\n"
+		}
 		prompt := fmt.Sprintf(
-			"书名：%s\n文件名：%s\n对话历史：\n%s\n\n当前问题：%s\n\n请把当前问题改写成一个可直接检索文档内容的独立中文问题。只输出改写后的问题，不要解释。",
+			"书名：%s\n文件名：%s\n%s对话历史：\n%s\n\n当前问题：%s\n\n请把当前问题改写成一个可直接检索文档内容的独立中文问题。只输出改写后的问题，不要解释。",
 			firstNonEmpty(book.Title, book.OriginalFilename),
 			book.OriginalFilename,
+			summaryText,
 			historyText,
 			question,
 		)
-		out, err := a.generator.GenerateText(ctx, "你负责把多轮对话中的省略追问改写成独立检索问题。必须保留用户原始意图，不回答问题。", prompt)
+		out, err := a.generator.GenerateText(ai.WithUsageStage(ctx, usageStageQueryContextualize), "你负责把多轮对话中的省略追问改写成独立检索问题。必须保留用户原始意图，不回答问题。", prompt)
 		if err == nil {
 			if rewritten := cleanStandaloneRetrievalQuestion(out); rewritten != "" {
 				return rewritten
@@ -297,7 +304,8 @@ func recentHistoryForRetrievalRewrite(history []domain.Message, maxMessages int,
 	for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
 		selected[i], selected[j] = selected[j], selected[i]
 	}
-	text := buildHistory(selected)
+	// The rewrite prompt is in Chinese whatever the answer language.
+	text := buildHistory(zhPrompts.Labels, selected)
 	if len([]rune(text)) <= maxRunes {
 		return text
 	}
@@ -349,7 +357,7 @@ func limitRewriteContextRunes(text string, limit int) string {
 
 func (a *App) retrieveEvidence(ctx context.Context, book domain.Book, question string) ([]retrieval.StageHit, *domain.RetrievalDebug, error) {
 	queries := a.buildRetrievalQueries(ctx, question)
-	return a.retrieveEvidenceWithQueries(ctx, book, question, queries)
+	return a.retrieveEvidenceWithQueries(ctx, book, question, queries, retrieval.ChunkFilter{})
 }
 
 func (a *App) retrieveEvidenceForPlan(ctx context.Context, book domain.Book, plan domain.QueryPlan) ([]retrieval.StageHit, *domain.RetrievalDebug, error) {
@@ -361,17 +369,56 @@ func (a *App) retrieveEvidenceForPlan(ctx context.Context, book domain.Book, pla
 	if len(queries) == 0 {
 		queries = a.buildRetrievalQueries(ctx, question)
 	}
-	return a.retrieveEvidenceWithQueries(ctx, book, question, queries)
+	if query := selectionQuery(plan.Scope); query != "" {
+		queries = normalizeRetrievalQueries(append(queries, query))
+	}
+	return a.retrieveEvidenceWithQueries(ctx, book, question, queries, scopeFilter(plan.Scope))
 }
 
-func (a *App) retrieveEvidenceWithQueries(ctx context.Context, book domain.Book, question string, queries []string) ([]retrieval.StageHit, *domain.RetrievalDebug, error) {
+type queryEmbeddingsKey struct{}
+
+// queryEmbeddings memoizes query embeddings while one question is answered.
+type queryEmbeddings struct {
+	mu      sync.Mutex
+	vectors map[string][]float32
+}
+
+// withQueryEmbeddings makes embedQuery embed each query text at most once
+// for ctx, so the answer cache probe and dense retrieval share a vector.
+func withQueryEmbeddings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryEmbeddingsKey{}, &queryEmbeddings{vectors: map[string][]float32{}})
+}
+
+func (a *App) embedQuery(ctx context.Context, query string) ([]float32, error) {
+	memo, _ := ctx.Value(queryEmbeddingsKey{}).(*queryEmbeddings)
+	if memo != nil {
+		memo.mu.Lock()
+		vector, ok := memo.vectors[query]
+		memo.mu.Unlock()
+		if ok {
+			return vector, nil
+		}
+	}
+	vector, err := a.embedder.EmbedText(ctx, query, "RETRIEVAL_QUERY")
+	if err != nil {
+		return nil, err
+	}
+	if memo != nil {
+		memo.mu.Lock()
+		memo.vectors[query] = vector
+		memo.mu.Unlock()
+	}
+	return vector, nil
+}
+
+func (a *App) retrieveEvidenceWithQueries(ctx context.Context, book domain.Book, question string, queries []string, filter retrieval.ChunkFilter) ([]retrieval.StageHit, *domain.RetrievalDebug, error) {
 	pipeline := retrieval.Pipeline{
 		Dense: func(ctx context.Context, query, _ string, topK int) ([]retrieval.StageHit, error) {
-			vector, err := a.embedder.EmbedText(ctx, query, "RETRIEVAL_QUERY")
+			vector, err := a.embedQuery(ctx, query)
 			if err != nil {
 				return nil, err
 			}
-			points, err := a.search.QueryDense(ctx, book.ID, vector, topK)
+			points, err := a.search.QueryDense(ctx, book.ID, filter, vector, topK)
 			if err != nil {
 				return nil, err
 			}
@@ -379,35 +426,38 @@ func (a *App) retrieveEvidenceWithQueries(ctx context.Context, book domain.Book,
 		},
 		Lexical: func(ctx context.Context, query, language string, topK int) ([]retrieval.StageHit, error) {
 			terms := strings.Join(retrieval.Tokenize(query, language), " ")
-			points, err := a.lexical.QueryBM25(ctx, book.ID, terms, topK)
+			points, err := a.lexical.QueryBM25(ctx, book.ID, filter, terms, topK)
+			if err != nil && a.lexicalFallback != nil {
+				points, err = a.lexicalFallback.QueryBM25(ctx, book.ID, filter, terms, topK)
+			}
 			if err != nil {
 				return nil, err
 			}
 			return pointsToStageHits(points, "lexical"), nil
 		},
-		ChunkLoader: func(ctx context.Context, ids []string) (map[string]domain.Chunk, error) {
-			chunks, err := a.store.GetChunksByIDs(ids)
+		Sparse: func(ctx context.Context, query, language string, topK int) ([]retrieval.StageHit, error) {
+			points, err := a.search.QuerySparse(ctx, book.ID, filter, retrieval.BuildSparseVector(query, language), topK)
 			if err != nil {
 				return nil, err
 			}
-			index := make(map[string]domain.Chunk, len(chunks))
-			for _, chunk := range chunks {
-				index[chunk.ID] = chunk
-			}
-			return index, nil
+			return pointsToStageHits(points, "sparse"), nil
 		},
-		Reranker: a.reranker,
+		ChunkLoader: a.loadChunks,
+		Reranker:    a.reranker,
 	}
 	result, err := pipeline.Run(ctx, retrieval.PipelineOptions{
 		Query:         question,
 		Queries:       queries,
 		BookID:        book.ID,
+		Filter:        filter,
 		RetrievalMode: a.retrievalMode,
 		TopK:          a.topK,
 		DenseTopK:     a.denseRecallTopK,
 		LexicalTopK:   a.lexicalRecallTopK,
+		SparseTopK:    a.denseRecallTopK,
 		DenseWeight:   a.denseWeight,
 		LexicalWeight: a.lexicalWeight,
+		SparseWeight:  a.sparseWeight,
 		FusionTopK:    a.fusionTopK,
 		RerankTopN:    a.rerankTopN,
 		ContextBudget: a.contextBudget,
@@ -420,12 +470,32 @@ func (a *App) retrieveEvidenceWithQueries(ctx context.Context, book domain.Book,
 		Queries:  result.Queries,
 		Dense:    stageHitsToDebug(result.Dense, "dense", a.rerankTopN),
 		Lexical:  stageHitsToDebug(result.Lexical, "lexical", a.rerankTopN),
+		Sparse:   stageHitsToDebug(result.Sparse, "sparse", a.rerankTopN),
 		Fused:    stageHitsToDebug(result.Fused, "fusion", a.rerankTopN),
 		Reranked: stageHitsToDebug(result.Reranked, "rerank", a.topK),
+		Counts: domain.RetrievalCounts{
+			Dense:    len(result.Dense),
+			Lexical:  len(result.Lexical),
+			Sparse:   len(result.Sparse),
+			Fused:    len(result.Fused),
+			Reranked: len(result.Reranked),
+		},
 	}
 	return result.Final, debugInfo, nil
 }
 
+func (a *App) loadChunks(_ context.Context, ids []string) (map[string]domain.Chunk, error) {
+	chunks, err := a.store.GetChunksByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]domain.Chunk, len(chunks))
+	for _, chunk := range chunks {
+		index[chunk.ID] = chunk
+	}
+	return index, nil
+}
+
 func pointsToStageHits(points []retrieval.Point, stage string) []retrieval.StageHit {
 	out := make([]retrieval.StageHit, 0, len(points))
 	for _, point := range points {
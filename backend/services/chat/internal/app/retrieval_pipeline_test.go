@@ -89,7 +89,7 @@ func TestContextualizeRetrievalQuestionUsesRecentHistoryFallback(t *testing.T) {
 		{Role: "assistant", Content: "赖新鹏实习证明是一份实习证明，内容包括学生 赖新鹏 在工程研发部门实习。"},
 	}
 
-	got := a.contextualizeRetrievalQuestion(context.Background(), book, "学生是谁", history)
+	got := a.contextualizeRetrievalQuestion(context.Background(), book, "学生是谁", history, "")
 	for _, want := range []string{"学生是谁", "赖新鹏", "实习证明"} {
 		if !strings.Contains(got, want) {
 			t.Fatalf("contextualized question = %q, want it to contain %q", got, want)
@@ -104,7 +104,7 @@ func TestContextualizeRetrievalQuestionUsesModelRewrite(t *testing.T) {
 		{Role: "assistant", Content: "这是一份实习证明。"},
 	}
 
-	got := a.contextualizeRetrievalQuestion(context.Background(), book, "学生是谁", history)
+	got := a.contextualizeRetrievalQuestion(context.Background(), book, "学生是谁", history, "")
 	want := "这份实习证明中的学生姓名是谁？"
 	if got != want {
 		t.Fatalf("contextualized question = %q, want %q", got, want)
@@ -143,7 +143,7 @@ func TestBuildQueryPlanRewritesFollowUpSingleFact(t *testing.T) {
 		{Role: "assistant", Content: "这是一份实习证明，证明学生 赖新鹏 的实习情况。", Sources: []domain.Source{{ChunkID: "chunk-1", Label: "[1]"}}},
 	}
 
-	plan := a.buildQueryPlan(context.Background(), book, "学生是谁", history)
+	plan := a.buildQueryPlan(context.Background(), book, "学生是谁", history, "")
 	if plan.QuestionType != questionTypeSingleFact {
 		t.Fatalf("questionType = %q, want %q", plan.QuestionType, questionTypeSingleFact)
 	}
@@ -174,13 +174,13 @@ func TestBuildQueryPlanAddsDateAndDepartmentFactQueries(t *testing.T) {
 		},
 	}
 
-	timePlan := a.buildQueryPlan(context.Background(), book, "实习时间是什么", nil)
+	timePlan := a.buildQueryPlan(context.Background(), book, "实习时间是什么", nil, "")
 	if !containsString(timePlan.RetrievalQueries, retrieval.NormalizeText("实习开始时间 2024-08-01")) ||
 		!containsString(timePlan.RetrievalQueries, retrieval.NormalizeText("实习结束时间 2024-12-30")) {
 		t.Fatalf("time retrievalQueries = %#v, want internship date fact queries", timePlan.RetrievalQueries)
 	}
 
-	departmentPlan := a.buildQueryPlan(context.Background(), book, "在哪个部门", nil)
+	departmentPlan := a.buildQueryPlan(context.Background(), book, "在哪个部门", nil, "")
 	if !containsString(departmentPlan.RetrievalQueries, retrieval.NormalizeText("实习部门 工程研发")) {
 		t.Fatalf("department retrievalQueries = %#v, want department fact query", departmentPlan.RetrievalQueries)
 	}
@@ -189,7 +189,7 @@ func TestBuildQueryPlanAddsDateAndDepartmentFactQueries(t *testing.T) {
 func TestBuildQueryPlanRequiresMultipleEvidenceForSummary(t *testing.T) {
 	a := &App{minEvidenceCount: 1}
 
-	plan := a.buildQueryPlan(context.Background(), domain.Book{Title: "证明"}, "总结这份证明", nil)
+	plan := a.buildQueryPlan(context.Background(), domain.Book{Title: "证明"}, "总结这份证明", nil, "")
 	if plan.QuestionType != questionTypeSummary {
 		t.Fatalf("questionType = %q, want %q", plan.QuestionType, questionTypeSummary)
 	}
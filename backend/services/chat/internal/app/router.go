@@ -6,8 +6,6 @@ import (
 	"onebookai/pkg/domain"
 )
 
-const outOfScopeAbstainAnswer = "当前问题超出已上传内容和当前会话范围，无法基于书内证据给出可靠回答。"
-
 type queryRoute string
 
 const (
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+const (
+	// maxSelectionRunes bounds a selected passage; longer selections are
+	// truncated before they become evidence.
+	maxSelectionRunes = 4000
+	// selectionQueryRunes bounds the selection text added as a retrieval query.
+	selectionQueryRunes = 200
+)
+
+// NormalizePassageScope validates a passage scope and returns it trimmed, or
+// nil when it narrows nothing. A page range without To covers one page.
+func NormalizePassageScope(scope domain.PassageScope) (*domain.PassageScope, error) {
+	out := domain.PassageScope{Section: strings.TrimSpace(scope.Section)}
+	if scope.Pages != nil {
+		pages := *scope.Pages
+		if pages.To == 0 {
+			pages.To = pages.From
+		}
+		if pages.From < 1 || pages.To < pages.From {
+			return nil, ErrScopePagesInvalid
+		}
+		out.Pages = &pages
+	}
+	if scope.Selection != nil {
+		text := strings.TrimSpace(scope.Selection.Text)
+		if text == "" {
+			return nil, ErrScopeSelectionInvalid
+		}
+		out.Selection = &domain.PassageSelection{
+			Text:    truncateRunes(text, maxSelectionRunes),
+			ChunkID: strings.TrimSpace(scope.Selection.ChunkID),
+			Page:    max(scope.Selection.Page, 0),
+			Section: strings.TrimSpace(scope.Selection.Section),
+		}
+	}
+	if out.Pages == nil && out.Section == "" && out.Selection == nil {
+		return nil, nil
+	}
+	return &out, nil
+}
+
+// applyPassageScope records the scope on the plan. Scoped questions are
+// about the book by construction, so they always take the retrieval route.
+func applyPassageScope(plan domain.QueryPlan, scope *domain.PassageScope) domain.QueryPlan {
+	if scope == nil {
+		return plan
+	}
+	plan.Scope = scope
+	if queryRoute(plan.Route) != queryRouteRAG {
+		plan.Route = string(queryRouteRAG)
+		plan.NeedsRetrieval = true
+	}
+	return plan
+}
+
+// scopeFilter limits retrieval to the scope's pages and section. A selection
+// does not narrow retrieval; the rest of the book may explain it.
+func scopeFilter(scope *domain.PassageScope) retrieval.ChunkFilter {
+	if scope == nil {
+		return retrieval.ChunkFilter{}
+	}
+	filter := retrieval.ChunkFilter{SectionPath: scope.Section}
+	if scope.Pages != nil {
+		filter.PageFrom = scope.Pages.From
+		filter.PageTo = scope.Pages.To
+	}
+	return filter
+}
+
+// selectionQuery is the start of the selected passage, searched alongside
+// the question so retrieval finds text related to the selection.
+func selectionQuery(scope *domain.PassageScope) string {
+	if scope == nil || scope.Selection == nil {
+		return ""
+	}
+	return truncateRunes(scope.Selection.Text, selectionQueryRunes)
+}
+
+// scopeFingerprint distinguishes scoped requests in idempotency hashes.
+func scopeFingerprint(scope *domain.PassageScope) string {
+	if scope == nil {
+		return ""
+	}
+	data, _ := json.Marshal(scope)
+	return string(data)
+}
+
+// selectionHit turns the selected passage into pinned evidence. The
+// selection's own text is the evidence; a chunk ID from the same book lends
+// its metadata so the citation points at the right place in the reader.
+func (a *App) selectionHit(book domain.Book, scope *domain.PassageScope) (retrieval.StageHit, bool, error) {
+	if scope == nil || scope.Selection == nil {
+		return retrieval.StageHit{}, false, nil
+	}
+	selection := scope.Selection
+	chunk := domain.Chunk{
+		ID:       strings.TrimSpace(book.ID) + ":selection",
+		BookID:   book.ID,
+		Metadata: map[string]string{},
+	}
+	if selection.ChunkID != "" {
+		chunks, err := a.store.GetChunksByIDs([]string{selection.ChunkID})
+		if err != nil {
+			return retrieval.StageHit{}, false, err
+		}
+		if len(chunks) == 1 && chunks[0].BookID == book.ID {
+			chunk = chunks[0]
+			metadata := make(map[string]string, len(chunk.Metadata))
+			for key, value := range chunk.Metadata {
+				metadata[key] = value
+			}
+			// The anchor covers the whole chunk, not the selection.
+			delete(metadata, "char_start")
+			delete(metadata, "char_end")
+			chunk.Metadata = metadata
+		}
+	}
+	if selection.Page > 0 {
+		chunk.Metadata["page"] = strconv.Itoa(selection.Page)
+		chunk.Metadata["source_ref"] = "page:" + strconv.Itoa(selection.Page)
+	} else if selection.Section != "" {
+		chunk.Metadata["section_path"] = selection.Section
+		chunk.Metadata["source_ref"] = "section:" + selection.Section
+	} else if chunk.Metadata["source_ref"] == "" {
+		chunk.Metadata["source_ref"] = "selection"
+	}
+	chunk.Content = selection.Text
+	return retrieval.StageHit{
+		ChunkID:  chunk.ID,
+		BookID:   book.ID,
+		Score:    1.3,
+		Stage:    "selection",
+		Content:  chunk.Content,
+		Metadata: chunk.Metadata,
+		Chunk:    chunk,
+	}, true, nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+func TestNormalizePassageScope(t *testing.T) {
+	scope, err := NormalizePassageScope(domain.PassageScope{Pages: &domain.PageRange{From: 12}, Section: " ch05.xhtml "})
+	if err != nil {
+		t.Fatalf("NormalizePassageScope: %v", err)
+	}
+	if scope.Pages.To != 12 || scope.Section != "ch05.xhtml" {
+		t.Fatalf("unexpected scope: %+v", scope)
+	}
+	if got := scopeFilter(scope); got != (retrieval.ChunkFilter{PageFrom: 12, PageTo: 12, SectionPath: "ch05.xhtml"}) {
+		t.Fatalf("unexpected filter: %+v", got)
+	}
+	if scope, err := NormalizePassageScope(domain.PassageScope{}); err != nil || scope != nil {
+		t.Fatalf("empty scope = %+v, %v; want nil", scope, err)
+	}
+	if _, err := NormalizePassageScope(domain.PassageScope{Pages: &domain.PageRange{From: 5, To: 3}}); !errors.Is(err, ErrScopePagesInvalid) {
+		t.Fatalf("reversed pages error = %v", err)
+	}
+	if _, err := NormalizePassageScope(domain.PassageScope{Selection: &domain.PassageSelection{Text: "  "}}); !errors.Is(err, ErrScopeSelectionInvalid) {
+		t.Fatalf("blank selection error = %v", err)
+	}
+}
+
+func TestApplyPassageScopeForcesRetrieval(t *testing.T) {
+	scope := &domain.PassageScope{Selection: &domain.PassageSelection{Text: "这一段在讲什么", Page: 7}}
+	plan := applyPassageScope(domain.QueryPlan{Route: string(queryRouteHistoryOnly)}, scope)
+	if plan.Route != string(queryRouteRAG) || !plan.NeedsRetrieval || plan.Scope != scope {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	// A selection anchors evidence but does not narrow retrieval.
+	if !scopeFilter(scope).IsZero() {
+		t.Fatalf("selection should not filter retrieval")
+	}
+}
+
+func TestSelectionHitCitesSelectedPage(t *testing.T) {
+	a := &App{}
+	scope := &domain.PassageScope{Selection: &domain.PassageSelection{Text: "被选中的段落", Page: 7}}
+	hit, ok, err := a.selectionHit(domain.Book{ID: "b1"}, scope)
+	if err != nil || !ok {
+		t.Fatalf("selectionHit = %v, %v", ok, err)
+	}
+	if hit.Chunk.Content != "被选中的段落" || chunkLocation(hit.Chunk.Metadata) != "page 7" {
+		t.Fatalf("unexpected selection hit: %+v", hit)
+	}
+	if evidence := hitToEvidence(hit); evidence.EvidenceType != "selected_passage" {
+		t.Fatalf("evidence type = %q", evidence.EvidenceType)
+	}
+}
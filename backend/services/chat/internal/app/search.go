@@ -0,0 +1,326 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+const (
+	// MaxSearchBooks caps how many books one passage search fans out to.
+	MaxSearchBooks = 50
+	// maxSearchResults caps how many ranked passages a search can page through.
+	maxSearchResults     = 100
+	defaultSearchPerPage = 10
+	searchBookFanOut     = 8
+	searchSnippetRunes   = 160
+)
+
+// PassageSearchOptions controls one library passage search.
+type PassageSearchOptions struct {
+	Query    string
+	Page     int
+	PageSize int
+	Rerank   bool
+}
+
+// SearchPassages ranks passages across books with the retrieval pipeline
+// (dense + lexical, optionally reranked) without generating an answer. Books
+// the user cannot access or that are not ready are skipped.
+func (a *App) SearchPassages(ctx context.Context, user domain.User, books []domain.Book, opts PassageSearchOptions) (domain.PassageSearchResult, error) {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return domain.PassageSearchResult{}, fmt.Errorf("query required")
+	}
+	page := opts.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSearchPerPage
+	}
+	if pageSize > maxSearchResults {
+		pageSize = maxSearchResults
+	}
+	result := domain.PassageSearchResult{
+		Query:    query,
+		Items:    []domain.PassageHit{},
+		Page:     page,
+		PageSize: pageSize,
+		Facets:   domain.PassageSearchFacets{Books: []domain.SearchFacet{}, Categories: []domain.SearchFacet{}},
+	}
+	allowed := make([]domain.Book, 0, len(books))
+	for _, book := range books {
+		if book.Status != domain.StatusReady {
+			continue
+		}
+		if book.OwnerID != user.ID && user.Role != domain.RoleAdmin {
+			continue
+		}
+		allowed = append(allowed, book)
+	}
+	if len(allowed) > MaxSearchBooks {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("searched %d of %d books; narrow the search with bookIds or tags", MaxSearchBooks, len(allowed)))
+		allowed = allowed[:MaxSearchBooks]
+	}
+	if len(allowed) == 0 {
+		return result, nil
+	}
+	bookByID := make(map[string]domain.Book, len(allowed))
+	for _, book := range allowed {
+		bookByID[book.ID] = book
+	}
+
+	// Every page ranks the same window so the total and facets, which count
+	// the whole window, do not change while paging.
+	limit := maxSearchResults
+	var (
+		highlightsMu sync.Mutex
+		highlights   = map[string][]string{}
+	)
+	pipeline := retrieval.Pipeline{
+		Dense: func(ctx context.Context, query, _ string, topK int) ([]retrieval.StageHit, error) {
+			vector, err := a.embedder.EmbedText(ctx, query, "RETRIEVAL_QUERY")
+			if err != nil {
+				return nil, err
+			}
+			points, err := searchAcrossBooks(ctx, allowed, topK, func(ctx context.Context, bookID string) ([]retrieval.Point, error) {
+				return a.search.QueryDense(ctx, bookID, retrieval.ChunkFilter{}, vector, topK)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return pointsToStageHits(points, "dense"), nil
+		},
+		Lexical: func(ctx context.Context, query, language string, topK int) ([]retrieval.StageHit, error) {
+			terms := strings.Join(retrieval.Tokenize(query, language), " ")
+			points, err := searchAcrossBooks(ctx, allowed, topK, func(ctx context.Context, bookID string) ([]retrieval.Point, error) {
+				return a.queryLexicalHighlighted(ctx, bookID, terms, topK, func(chunkID string, fragments []string) {
+					highlightsMu.Lock()
+					defer highlightsMu.Unlock()
+					if _, ok := highlights[chunkID]; !ok {
+						highlights[chunkID] = fragments
+					}
+				})
+			})
+			if err != nil {
+				return nil, err
+			}
+			return pointsToStageHits(points, "lexical"), nil
+		},
+		ChunkLoader: a.loadChunks,
+		Reranker:    a.reranker,
+	}
+	mode := "hybrid"
+	if opts.Rerank && a.reranker != nil {
+		mode = "hybrid_best"
+	}
+	ranked, err := pipeline.Run(ctx, retrieval.PipelineOptions{
+		Query:         query,
+		Queries:       []string{retrieval.NormalizeText(query)},
+		RetrievalMode: mode,
+		TopK:          limit,
+		DenseTopK:     max(a.denseRecallTopK, limit),
+		LexicalTopK:   max(a.lexicalRecallTopK, limit),
+		DenseWeight:   a.denseWeight,
+		LexicalWeight: a.lexicalWeight,
+		FusionTopK:    max(a.fusionTopK, limit),
+		RerankTopN:    limit,
+		// Search returns passages rather than prompt context, so the context
+		// budget must not drop results.
+		ContextBudget: 1 << 30,
+	})
+	if err != nil {
+		return domain.PassageSearchResult{}, err
+	}
+	result.Warnings = append(result.Warnings, ranked.Warnings...)
+
+	hits := make([]domain.PassageHit, 0, len(ranked.Final))
+	bookCounts := map[string]int{}
+	categoryCounts := map[string]int{}
+	for _, hit := range ranked.Final {
+		chunk := hit.Chunk
+		bookID := firstNonEmpty(chunk.BookID, hit.BookID)
+		book, ok := bookByID[bookID]
+		if !ok {
+			continue
+		}
+		content := firstNonEmpty(chunk.Content, hit.Content)
+		fragments := highlights[firstNonEmpty(chunk.ID, hit.ChunkID)]
+		if len(fragments) == 0 {
+			if snippet := highlightSnippet(content, query, ranked.Language); snippet != "" {
+				fragments = []string{snippet}
+			}
+		}
+		snippet := html.EscapeString(truncateRunes(content, searchSnippetRunes))
+		if len(fragments) > 0 {
+			snippet = fragments[0]
+		}
+		category := strings.TrimSpace(book.PrimaryCategory)
+		hits = append(hits, domain.PassageHit{
+			ChunkID:    firstNonEmpty(chunk.ID, hit.ChunkID),
+			BookID:     bookID,
+			BookTitle:  firstNonEmpty(book.Title, book.OriginalFilename),
+			Category:   category,
+			Location:   chunkLocation(chunk.Metadata),
+			Page:       strings.TrimSpace(chunk.Metadata["page"]),
+			Section:    firstNonEmpty(chunk.Metadata["section_path"], chunk.Metadata["section_title"], chunk.Metadata["section"]),
+			Snippet:    snippet,
+			Highlights: fragments,
+			Score:      hit.Score,
+		})
+		bookCounts[bookID]++
+		if category != "" {
+			categoryCounts[category]++
+		}
+	}
+	result.Total = len(hits)
+	start := (page - 1) * pageSize
+	if start < len(hits) {
+		end := start + pageSize
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Items = hits[start:end]
+	}
+	for bookID, count := range bookCounts {
+		book := bookByID[bookID]
+		result.Facets.Books = append(result.Facets.Books, domain.SearchFacet{Value: bookID, Label: firstNonEmpty(book.Title, book.OriginalFilename), Count: count})
+	}
+	for category, count := range categoryCounts {
+		result.Facets.Categories = append(result.Facets.Categories, domain.SearchFacet{Value: category, Count: count})
+	}
+	sortFacets(result.Facets.Books)
+	sortFacets(result.Facets.Categories)
+	return result, nil
+}
+
+// queryLexicalHighlighted uses the lexical store's highlighter when it has one,
+// and otherwise behaves like the chat lexical channel.
+func (a *App) queryLexicalHighlighted(ctx context.Context, bookID, terms string, limit int, onHighlight func(chunkID string, fragments []string)) ([]retrieval.Point, error) {
+	if highlighter, ok := a.lexical.(retrieval.LexicalHighlighter); ok {
+		hits, err := highlighter.QueryBM25Highlighted(ctx, bookID, terms, limit)
+		if err == nil {
+			points := make([]retrieval.Point, 0, len(hits))
+			for _, hit := range hits {
+				if len(hit.Highlights) > 0 {
+					onHighlight(hit.ID, hit.Highlights)
+				}
+				points = append(points, hit.Point)
+			}
+			return points, nil
+		}
+		if a.lexicalFallback == nil {
+			return nil, err
+		}
+		return a.lexicalFallback.QueryBM25(ctx, bookID, retrieval.ChunkFilter{}, terms, limit)
+	}
+	points, err := a.lexical.QueryBM25(ctx, bookID, retrieval.ChunkFilter{}, terms, limit)
+	if err != nil && a.lexicalFallback != nil {
+		points, err = a.lexicalFallback.QueryBM25(ctx, bookID, retrieval.ChunkFilter{}, terms, limit)
+	}
+	return points, err
+}
+
+// searchAcrossBooks runs a per-book query for every book with bounded
+// concurrency and keeps the topK best points overall.
+func searchAcrossBooks(ctx context.Context, books []domain.Book, topK int, query func(ctx context.Context, bookID string) ([]retrieval.Point, error)) ([]retrieval.Point, error) {
+	results := make([][]retrieval.Point, len(books))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(searchBookFanOut)
+	for i, book := range books {
+		group.Go(func() error {
+			points, err := query(groupCtx, book.ID)
+			if err != nil {
+				return err
+			}
+			results[i] = points
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	merged := make([]retrieval.Point, 0, topK)
+	for _, points := range results {
+		merged = append(merged, points...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if topK > 0 && len(merged) > topK {
+		merged = merged[:topK]
+	}
+	return merged, nil
+}
+
+// highlightSnippet cuts a window around the first query term in content and
+// wraps every term occurrence in <mark>, HTML-escaping the rest. It serves hits
+// the lexical highlighter did not cover, such as dense-only matches.
+func highlightSnippet(content, query, language string) string {
+	return highlightTerms(content, retrieval.Tokenize(query, language))
+}
+
+func highlightTerms(content string, terms []string) string {
+	content = strings.TrimSpace(content)
+	if content == "" || len(terms) == 0 {
+		return ""
+	}
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	pattern, err := regexp.Compile("(?i)" + strings.Join(quoted, "|"))
+	if err != nil {
+		return ""
+	}
+	first := pattern.FindStringIndex(content)
+	if first == nil {
+		return ""
+	}
+	runes := []rune(content)
+	start := len([]rune(content[:first[0]])) - searchSnippetRunes/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + searchSnippetRunes
+	if end > len(runes) {
+		end = len(runes)
+	}
+	window := string(runes[start:end])
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(window, -1) {
+		sb.WriteString(html.EscapeString(window[last:loc[0]]))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(window[loc[0]:loc[1]]))
+		sb.WriteString("</mark>")
+		last = loc[1]
+	}
+	sb.WriteString(html.EscapeString(window[last:]))
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+	return strings.ReplaceAll(sb.String(), "</mark><mark>", "")
+}
+
+func sortFacets(items []domain.SearchFacet) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Value < items[j].Value
+	})
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+	"onebookai/pkg/store"
+)
+
+func TestHighlightSnippetMarksTermsAndEscapesHTML(t *testing.T) {
+	content := strings.Repeat("前言部分。", 40) + "Go 的 <b>goroutine</b> 调度器使用 GMP 模型。"
+	got := highlightSnippet(content, "goroutine 调度", "")
+	if !strings.Contains(got, "<mark>goroutine</mark>") {
+		t.Fatalf("expected latin term highlighted, got %q", got)
+	}
+	if !strings.Contains(got, "&lt;b&gt;") {
+		t.Fatalf("expected surrounding HTML escaped, got %q", got)
+	}
+	if !strings.HasPrefix(got, "…") {
+		t.Fatalf("expected leading ellipsis for a window past the start, got %q", got)
+	}
+	if highlightSnippet("nothing relevant here", "goroutine", "") != "" {
+		t.Fatal("expected empty snippet when no term matches")
+	}
+}
+
+func TestSearchAcrossBooksKeepsBestPointsOverall(t *testing.T) {
+	books := []domain.Book{{ID: "b1"}, {ID: "b2"}, {ID: "b3"}}
+	scores := map[string][]float64{"b1": {0.2, 0.1}, "b2": {0.9}, "b3": {0.5, 0.4}}
+	got, err := searchAcrossBooks(context.Background(), books, 3, func(_ context.Context, bookID string) ([]retrieval.Point, error) {
+		points := make([]retrieval.Point, 0, len(scores[bookID]))
+		for _, score := range scores[bookID] {
+			points = append(points, retrieval.Point{ID: bookID, Score: score})
+		}
+		return points, nil
+	})
+	if err != nil {
+		t.Fatalf("searchAcrossBooks: %v", err)
+	}
+	if len(got) != 3 || got[0].ID != "b2" || got[1].ID != "b3" || got[2].Score != 0.4 {
+		t.Fatalf("unexpected merge: %+v", got)
+	}
+}
+
+func TestSearchPassagesRequiresQuery(t *testing.T) {
+	a := &App{}
+	if _, err := a.SearchPassages(context.Background(), domain.User{ID: "u1"}, nil, PassageSearchOptions{Query: "  "}); err == nil {
+		t.Fatal("expected error for blank query")
+	}
+	result, err := a.SearchPassages(context.Background(), domain.User{ID: "u1"}, []domain.Book{{ID: "b1", OwnerID: "u2", Status: domain.StatusReady}}, PassageSearchOptions{Query: "go"})
+	if err != nil {
+		t.Fatalf("SearchPassages: %v", err)
+	}
+	if result.Total != 0 || len(result.Items) != 0 {
+		t.Fatalf("expected books of other users to be skipped, got %+v", result)
+	}
+}
+
+type pagingVectorStore struct {
+	retrieval.VectorStore
+	perBook int
+}
+
+func (s pagingVectorStore) QueryDense(_ context.Context, bookID string, _ retrieval.ChunkFilter, _ []float32, limit int) ([]retrieval.Point, error) {
+	points := make([]retrieval.Point, 0, s.perBook)
+	for i := 0; i < s.perBook && i < limit; i++ {
+		points = append(points, retrieval.Point{
+			ID:      fmt.Sprintf("%s-c%02d", bookID, i),
+			Score:   1 - float64(i)/100,
+			Payload: map[string]any{"book_id": bookID, "content_text": "passage about go"},
+		})
+	}
+	return points, nil
+}
+
+type emptyLexicalStore struct{ retrieval.LexicalStore }
+
+func (emptyLexicalStore) QueryBM25(context.Context, string, retrieval.ChunkFilter, string, int) ([]retrieval.Point, error) {
+	return nil, nil
+}
+
+type chunkLookupStore struct{ store.Store }
+
+func (chunkLookupStore) GetChunksByIDs(ids []string) ([]domain.Chunk, error) {
+	chunks := make([]domain.Chunk, 0, len(ids))
+	for _, id := range ids {
+		bookID, _, _ := strings.Cut(id, "-")
+		chunks = append(chunks, domain.Chunk{ID: id, BookID: bookID, Content: "passage about go"})
+	}
+	return chunks, nil
+}
+
+func TestSearchPassagesPagesKeepTotalAndFacets(t *testing.T) {
+	a := &App{
+		store:    chunkLookupStore{},
+		embedder: &stubEmbedder{vector: []float32{1, 0}},
+		search:   pagingVectorStore{perBook: 15},
+		lexical:  emptyLexicalStore{},
+	}
+	user := domain.User{ID: "u1"}
+	books := []domain.Book{
+		{ID: "b1", OwnerID: "u1", Status: domain.StatusReady, Title: "Economics", PrimaryCategory: "economics"},
+		{ID: "b2", OwnerID: "u1", Status: domain.StatusReady, Title: "History", PrimaryCategory: "history"},
+	}
+
+	first, err := a.SearchPassages(context.Background(), user, books, PassageSearchOptions{Query: "go", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("SearchPassages page 1: %v", err)
+	}
+	second, err := a.SearchPassages(context.Background(), user, books, PassageSearchOptions{Query: "go", Page: 2, PageSize: 10})
+	if err != nil {
+		t.Fatalf("SearchPassages page 2: %v", err)
+	}
+	if first.Total != 30 || second.Total != first.Total {
+		t.Fatalf("totals = %d, %d; want 30 on both pages", first.Total, second.Total)
+	}
+	if !reflect.DeepEqual(first.Facets, second.Facets) {
+		t.Fatalf("facets changed between pages:\n%+v\n%+v", first.Facets, second.Facets)
+	}
+	if len(first.Facets.Books) != 2 || first.Facets.Books[0].Count != 15 || first.Facets.Books[1].Count != 15 {
+		t.Fatalf("book facets = %+v, want 15 passages per book", first.Facets.Books)
+	}
+	if len(first.Items) != 10 || len(second.Items) != 10 || first.Items[0].ChunkID == second.Items[0].ChunkID {
+		t.Fatalf("pages should hold different passages: %d, %d items", len(first.Items), len(second.Items))
+	}
+}
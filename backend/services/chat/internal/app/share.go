@@ -0,0 +1,208 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/domain"
+)
+
+const (
+	shareTokenBytes  = 24
+	maxShareLifetime = 365 * 24 * time.Hour
+)
+
+// ConversationShareInput configures a new share link. A nil ExpiresAt never
+// expires.
+type ConversationShareInput struct {
+	ExpiresAt       *time.Time
+	IncludeSnippets bool
+}
+
+// CreateConversationShare creates a read-only link to the conversation. The
+// returned share carries the link token, which is not stored and cannot be
+// retrieved again.
+func (a *App) CreateConversationShare(user domain.User, conversationID string, input ConversationShareInput, requestID string) (domain.ConversationShare, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	now := time.Now().UTC()
+	if input.ExpiresAt != nil && (!input.ExpiresAt.After(now) || input.ExpiresAt.Sub(now) > maxShareLifetime) {
+		return domain.ConversationShare{}, ErrShareExpiryInvalid
+	}
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return domain.ConversationShare{}, fmt.Errorf("generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	share := domain.ConversationShare{
+		ID:              util.NewID(),
+		ConversationID:  conversation.ID,
+		UserID:          user.ID,
+		LeafMessageID:   tree.leaf(conversation.ActiveLeafID),
+		IncludeSnippets: input.IncludeSnippets,
+		ExpiresAt:       input.ExpiresAt,
+		CreatedAt:       now,
+	}
+	after := map[string]any{"conversationId": conversation.ID, "leafMessageId": share.LeafMessageID, "includeSnippets": share.IncludeSnippets}
+	if share.ExpiresAt != nil {
+		after["expiresAt"] = share.ExpiresAt.UTC()
+	}
+	audit := shareAuditLog(user, share, "conversation.share.create", requestID, after, now)
+	if err := a.store.CreateConversationShare(share, shareTokenHash(token), audit); err != nil {
+		return domain.ConversationShare{}, fmt.Errorf("save share: %w", err)
+	}
+	share.Token = token
+	return share, nil
+}
+
+// ListConversationShares lists a conversation's share links, including
+// revoked and expired ones.
+func (a *App) ListConversationShares(user domain.User, conversationID string) ([]domain.ConversationShare, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := a.store.ListConversationShares(conversation.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	return items, nil
+}
+
+// RevokeConversationShare disables a share link. Links are checked on every
+// view, so revocation takes effect immediately.
+func (a *App) RevokeConversationShare(user domain.User, conversationID, shareID, requestID string) (domain.ConversationShare, error) {
+	conversation, err := a.ownedConversation(user, conversationID)
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	share, ok, err := a.store.GetConversationShare(shareID)
+	if err != nil {
+		return domain.ConversationShare{}, fmt.Errorf("load share: %w", err)
+	}
+	if !ok || share.ConversationID != conversation.ID {
+		return domain.ConversationShare{}, ErrShareNotFound
+	}
+	if share.RevokedAt != nil {
+		return share, nil
+	}
+	now := time.Now().UTC()
+	after := map[string]any{"conversationId": conversation.ID, "revokedAt": now}
+	audit := shareAuditLog(user, share, "conversation.share.revoke", requestID, after, now)
+	if err := a.store.RevokeConversationShare(share.ID, now, audit); err != nil {
+		return domain.ConversationShare{}, fmt.Errorf("revoke share: %w", err)
+	}
+	share.RevokedAt = &now
+	return share, nil
+}
+
+// GetSharedConversation renders the conversation behind a share token as it
+// stood when the link was created. Unknown, revoked and expired tokens are
+// indistinguishable.
+func (a *App) GetSharedConversation(token string) (domain.SharedConversation, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return domain.SharedConversation{}, ErrShareNotFound
+	}
+	share, ok, err := a.store.GetConversationShareByTokenHash(shareTokenHash(token))
+	if err != nil {
+		return domain.SharedConversation{}, fmt.Errorf("load share: %w", err)
+	}
+	if !ok || share.RevokedAt != nil || (share.ExpiresAt != nil && !time.Now().Before(*share.ExpiresAt)) {
+		return domain.SharedConversation{}, ErrShareNotFound
+	}
+	conversation, ok, err := a.store.GetConversation(share.ConversationID)
+	if err != nil {
+		return domain.SharedConversation{}, fmt.Errorf("load conversation: %w", err)
+	}
+	if !ok {
+		return domain.SharedConversation{}, ErrShareNotFound
+	}
+	tree, err := a.loadMessageTree(conversation.ID)
+	if err != nil {
+		return domain.SharedConversation{}, err
+	}
+	export, err := a.exportMessages(conversation, sharedMessages(tree, conversation, share))
+	if err != nil {
+		return domain.SharedConversation{}, err
+	}
+	return sharedConversation(export, share), nil
+}
+
+// sharedMessages returns the path from the root to the shared leaf, ignoring
+// anything added below it since. Links created before the leaf was recorded
+// show the active branch up to the link's creation time.
+func sharedMessages(tree *messageTree, conversation domain.Conversation, share domain.ConversationShare) []domain.Message {
+	if share.LeafMessageID != "" {
+		return tree.path(share.LeafMessageID)
+	}
+	var messages []domain.Message
+	for _, msg := range tree.branch(conversation.ActiveLeafID) {
+		if msg.CreatedAt.After(share.CreatedAt) {
+			break
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func sharedConversation(export ConversationExport, share domain.ConversationShare) domain.SharedConversation {
+	view := domain.SharedConversation{
+		Title:           export.title(),
+		BookTitles:      make([]string, 0, len(export.Books)),
+		IncludeSnippets: share.IncludeSnippets,
+		Messages:        make([]domain.SharedMessage, 0, len(export.Messages)),
+		ExpiresAt:       share.ExpiresAt,
+		SharedAt:        share.CreatedAt,
+	}
+	for _, book := range export.Books {
+		view.BookTitles = append(view.BookTitles, book.Title)
+	}
+	for _, msg := range export.Messages {
+		item := domain.SharedMessage{
+			Role:      msg.Role,
+			Content:   msg.Content,
+			Abstained: msg.Abstained,
+			CreatedAt: msg.CreatedAt,
+		}
+		for _, c := range exportCitations(msg.Sources) {
+			citation := domain.SharedCitation{Number: c.Number, BookTitle: c.BookTitle, Location: c.Location}
+			if share.IncludeSnippets {
+				citation.Snippet = c.Snippet
+			}
+			item.Citations = append(item.Citations, citation)
+		}
+		view.Messages = append(view.Messages, item)
+	}
+	return view
+}
+
+func shareAuditLog(user domain.User, share domain.ConversationShare, action, requestID string, after map[string]any, at time.Time) domain.AdminAuditLog {
+	return domain.AdminAuditLog{
+		ID:         util.NewID(),
+		ActorID:    user.ID,
+		Action:     action,
+		TargetType: "conversation_share",
+		TargetID:   share.ID,
+		After:      after,
+		RequestID:  strings.TrimSpace(requestID),
+		CreatedAt:  at,
+	}
+}
+
+func shareTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/store"
+)
+
+func TestSharedConversationHidesSnippetsAndIDs(t *testing.T) {
+	export := sampleExport()
+	view := sharedConversation(export, domain.ConversationShare{ID: "s1"})
+	citation := view.Messages[1].Citations[0]
+	if citation.Number != 2 || citation.BookTitle != "Go 程序设计" || citation.Location != "page 12" {
+		t.Fatalf("unexpected citation: %+v", citation)
+	}
+	if citation.Snippet != "" {
+		t.Fatalf("snippet leaked without includeSnippets: %q", citation.Snippet)
+	}
+	withSnippets := sharedConversation(export, domain.ConversationShare{ID: "s1", IncludeSnippets: true})
+	if withSnippets.Messages[1].Citations[0].Snippet == "" {
+		t.Fatalf("expected snippet with includeSnippets")
+	}
+}
+
+func TestGetSharedConversationRejectsEmptyToken(t *testing.T) {
+	if _, err := (&App{}).GetSharedConversation("  "); !errors.Is(err, ErrShareNotFound) {
+		t.Fatalf("err = %v, want ErrShareNotFound", err)
+	}
+}
+
+// shareStore serves one conversation and keeps the share created for it.
+type shareStore struct {
+	store.Store
+	conversation domain.Conversation
+	messages     []domain.Message
+	share        domain.ConversationShare
+}
+
+func (s *shareStore) GetConversation(id string) (domain.Conversation, bool, error) {
+	return s.conversation, id == s.conversation.ID, nil
+}
+
+func (s *shareStore) ListConversationMessages(string, int) ([]domain.Message, error) {
+	return s.messages, nil
+}
+
+func (s *shareStore) GetBookIncludingDeleted(string) (domain.Book, bool, error) {
+	return domain.Book{}, false, nil
+}
+
+func (s *shareStore) CreateConversationShare(share domain.ConversationShare, _ string, _ domain.AdminAuditLog) error {
+	s.share = share
+	return nil
+}
+
+func (s *shareStore) GetConversationShareByTokenHash(string) (domain.ConversationShare, bool, error) {
+	return s.share, true, nil
+}
+
+func TestSharedConversationShowsBranchAsShared(t *testing.T) {
+	user := domain.User{ID: "u1"}
+	st := &shareStore{
+		conversation: domain.Conversation{ID: "c1", UserID: user.ID, ActiveLeafID: "a2"},
+		messages:     branchedMessages(),
+	}
+	a := &App{store: st}
+	share, err := a.CreateConversationShare(user, "c1", ConversationShareInput{}, "")
+	if err != nil {
+		t.Fatalf("CreateConversationShare: %v", err)
+	}
+	if share.LeafMessageID != "a2" {
+		t.Fatalf("LeafMessageID = %q, want a2", share.LeafMessageID)
+	}
+
+	// The owner keeps asking on the shared branch and then switches branches.
+	st.messages = append(st.messages,
+		domain.Message{ID: "q3", ParentID: "a2", Role: "user", Content: "还有呢", CreatedAt: share.CreatedAt.Add(time.Minute)},
+		domain.Message{ID: "a4", ParentID: "q3", Role: "assistant", Content: "没有了。", CreatedAt: share.CreatedAt.Add(2 * time.Minute)},
+	)
+	st.conversation.ActiveLeafID = "a3"
+
+	view, err := a.GetSharedConversation(share.Token)
+	if err != nil {
+		t.Fatalf("GetSharedConversation: %v", err)
+	}
+	var got []string
+	for _, msg := range view.Messages {
+		got = append(got, msg.Content)
+	}
+	if want := []string{"第一章讲了什么", "讲了背景。", "作者是谁", "张三。"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("shared messages = %v, want %v", got, want)
+	}
+}
+
+func TestSharedMessagesWithoutLeafStopAtShareTime(t *testing.T) {
+	messages := branchedMessages()
+	tree := newMessageTree(messages)
+	conversation := domain.Conversation{ID: "c1", ActiveLeafID: "a3"}
+	share := domain.ConversationShare{CreatedAt: messages[5].CreatedAt}
+	if got, want := messageIDs(sharedMessages(tree, conversation, share)), []string{"q1", "a1", "q2b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sharedMessages = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+// MaxShelfBooks caps how many books one shelf question may retrieve across.
+const MaxShelfBooks = 10
+
+// AskShelfQuestion answers one question across several books. Every book must
+// belong to the user and be ready; the first book is the conversation's
+// primary book.
+func (a *App) AskShelfQuestion(
+	ctx context.Context,
+	user domain.User,
+	books []domain.Book,
+	question string,
+	conversationID string,
+	idempotencyKey string,
+	includeDebug bool,
+	onChunk func(string) error,
+) (domain.Answer, bool, error) {
+	if len(books) > MaxShelfBooks {
+		return domain.Answer{}, false, fmt.Errorf("too many books in scope (max %d)", MaxShelfBooks)
+	}
+	return a.askQuestion(ctx, user, books, question, nil, conversationID, idempotencyKey, includeDebug, onChunk, branchPoint{})
+}
+
+// buildShelfQueryPlan plans against the shelf as a whole. Overview questions
+// summarize a single document, so across a shelf they are answered by
+// retrieving from every book instead.
+func (a *App) buildShelfQueryPlan(ctx context.Context, books []domain.Book, question string, history []domain.Message, summary string) domain.QueryPlan {
+	titles := make([]string, 0, len(books))
+	for _, book := range books {
+		titles = append(titles, firstNonEmpty(book.Title, book.OriginalFilename))
+	}
+	shelfBook := domain.Book{ID: books[0].ID, Title: strings.Join(titles, "、")}
+	plan := a.buildQueryPlan(ctx, shelfBook, question, history, summary)
+	if queryRoute(plan.Route) != queryRouteDocumentOverview {
+		return plan
+	}
+	plan.Route = string(queryRouteRAG)
+	plan.QuestionType = questionTypeSummary
+	plan.RequiredEvidenceCount = 2
+	plan.NeedsRetrieval = true
+	plan.RetrievalQueries = uniqueRetrievalQueries(a.buildRetrievalQueries(ctx, plan.StandaloneQuestion))
+	return plan
+}
+
+// retrieveShelfEvidence runs the retrieval pipeline for every book and merges
+// the results with a per-book quota so one large book cannot crowd out the rest.
+func (a *App) retrieveShelfEvidence(ctx context.Context, books []domain.Book, plan domain.QueryPlan) ([]retrieval.StageHit, *domain.RetrievalDebug, error) {
+	perBook := make([][]retrieval.StageHit, len(books))
+	debugs := make([]*domain.RetrievalDebug, len(books))
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, book := range books {
+		group.Go(func() error {
+			hits, debugInfo, err := a.retrieveEvidenceForPlan(groupCtx, book, plan)
+			if err != nil {
+				return fmt.Errorf("retrieve from book %s: %w", book.ID, err)
+			}
+			perBook[i] = selectUniqueEvidenceHits(hits, a.topK)
+			debugs[i] = debugInfo
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return mergeShelfHits(perBook, a.topK), mergeRetrievalDebug(debugs), nil
+}
+
+// mergeShelfHits takes up to ceil(topK/len(books)) hits from each book, rank
+// by rank, then fills any slots left by books with too few hits from the
+// remaining candidates by score.
+func mergeShelfHits(perBook [][]retrieval.StageHit, topK int) []retrieval.StageHit {
+	if topK <= 0 {
+		topK = 5
+	}
+	if len(perBook) == 0 {
+		return nil
+	}
+	quota := (topK + len(perBook) - 1) / len(perBook)
+	byScore := func(hits []retrieval.StageHit) {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	}
+	out := make([]retrieval.StageHit, 0, topK)
+	for rank := 0; rank < quota; rank++ {
+		round := make([]retrieval.StageHit, 0, len(perBook))
+		for _, hits := range perBook {
+			if rank < len(hits) {
+				round = append(round, hits[rank])
+			}
+		}
+		byScore(round)
+		out = append(out, round...)
+	}
+	var leftovers []retrieval.StageHit
+	for _, hits := range perBook {
+		if len(hits) > quota {
+			leftovers = append(leftovers, hits[quota:]...)
+		}
+	}
+	byScore(leftovers)
+	out = append(out, leftovers...)
+	if len(out) > topK {
+		out = out[:topK]
+	}
+	return out
+}
+
+func mergeRetrievalDebug(items []*domain.RetrievalDebug) *domain.RetrievalDebug {
+	var merged *domain.RetrievalDebug
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if merged == nil {
+			copied := *item
+			merged = &copied
+			continue
+		}
+		merged.Dense = append(merged.Dense, item.Dense...)
+		merged.Lexical = append(merged.Lexical, item.Lexical...)
+		merged.Sparse = append(merged.Sparse, item.Sparse...)
+		merged.Fused = append(merged.Fused, item.Fused...)
+		merged.Reranked = append(merged.Reranked, item.Reranked...)
+		merged.Counts.Dense += item.Counts.Dense
+		merged.Counts.Lexical += item.Counts.Lexical
+		merged.Counts.Sparse += item.Counts.Sparse
+		merged.Counts.Fused += item.Counts.Fused
+		merged.Counts.Reranked += item.Counts.Reranked
+	}
+	return merged
+}
+
+func buildShelfAnswerPrompt(prompts promptSet, books []domain.Book, plan domain.QueryPlan, historyText string, contextText string, requirement string) string {
+	labels := prompts.Labels
+	var sb strings.Builder
+	sb.WriteString(labels.ShelfBooks)
+	for _, book := range books {
+		sb.WriteString(fmt.Sprintf(labels.ShelfBook, firstNonEmpty(book.Title, book.OriginalFilename)))
+		if summary := strings.TrimSpace(book.DocumentSummary); summary != "" {
+			sb.WriteString(labels.ShelfSummary)
+			sb.WriteString(truncateRunes(summary, 200))
+		}
+	}
+	writePlanPromptSection(&sb, labels, plan, historyText)
+	sb.WriteString("\n\n" + labels.ShelfEvidence + "\n")
+	sb.WriteString(contextText)
+	sb.WriteString("\n")
+	sb.WriteString(requirement)
+	return sb.String()
+}
+
+func bookIDsOf(books []domain.Book) []string {
+	ids := make([]string, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.ID)
+	}
+	return ids
+}
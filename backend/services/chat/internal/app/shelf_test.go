@@ -0,0 +1,59 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+func shelfHits(bookID string, scores ...float64) []retrieval.StageHit {
+	hits := make([]retrieval.StageHit, 0, len(scores))
+	for i, score := range scores {
+		id := bookID + "-" + string(rune('a'+i))
+		hits = append(hits, retrieval.StageHit{ChunkID: id, BookID: bookID, Score: score, Chunk: domain.Chunk{ID: id, BookID: bookID}})
+	}
+	return hits
+}
+
+func TestMergeShelfHitsAppliesPerBookQuota(t *testing.T) {
+	perBook := [][]retrieval.StageHit{
+		shelfHits("big", 0.9, 0.8, 0.7, 0.6),
+		shelfHits("small", 0.3, 0.2),
+	}
+	got := mergeShelfHits(perBook, 4)
+	counts := map[string]int{}
+	for _, hit := range got {
+		counts[hit.BookID]++
+	}
+	if len(got) != 4 || counts["big"] != 2 || counts["small"] != 2 {
+		t.Fatalf("expected 2 hits per book, got %v", counts)
+	}
+	if got[0].ChunkID != "big-a" || got[1].ChunkID != "small-a" {
+		t.Fatalf("expected rank-by-rank interleaving, got %s, %s", got[0].ChunkID, got[1].ChunkID)
+	}
+}
+
+func TestMergeShelfHitsBackfillsUnusedQuota(t *testing.T) {
+	perBook := [][]retrieval.StageHit{
+		shelfHits("big", 0.9, 0.8, 0.7, 0.6),
+		shelfHits("tiny", 0.5),
+	}
+	got := mergeShelfHits(perBook, 4)
+	if len(got) != 4 || got[3].ChunkID != "big-c" {
+		t.Fatalf("expected leftover slot filled from big book, got %+v", got)
+	}
+}
+
+func TestBuildContextWithEvidenceLabelsShelfBooks(t *testing.T) {
+	books := []domain.Book{{ID: "b1", Title: "Go 并发"}, {ID: "b2", Title: "分布式系统"}}
+	hits := append(shelfHits("b1", 0.9), shelfHits("b2", 0.8)...)
+	contextText, sources := buildContextWithEvidence(hits, nil, books)
+	if len(sources) != 2 || sources[1].BookTitle != "分布式系统" || sources[1].BookID != "b2" {
+		t.Fatalf("expected citations to carry book titles, got %+v", sources)
+	}
+	if want := "[2] 《分布式系统》"; !strings.Contains(contextText, want) {
+		t.Fatalf("expected context to name the book, got %q", contextText)
+	}
+}
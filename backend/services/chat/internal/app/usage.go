@@ -0,0 +1,54 @@
+package app
+
+import (
+	"strings"
+
+	"onebookai/pkg/ai"
+	"onebookai/pkg/domain"
+)
+
+// Usage stages label which part of the chat pipeline made a model call.
+const (
+	usageStageQueryRewrite       = "query_rewrite"
+	usageStageQueryContextualize = "query_contextualize"
+	usageStageQueryRoute         = "query_route"
+	usageStageAnswer             = "answer"
+	usageStageCitationVerify     = "citation_verify"
+	usageStageSummary            = "conversation_summary"
+	usageStageFollowUpRetrieval  = "followup_retrieval"
+)
+
+// ModelPrice is the USD price per million tokens for one generation model.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// summarizeUsage turns the calls recorded for one question into message
+// metadata, pricing each call by model name. Unpriced models cost 0.
+func summarizeUsage(calls []ai.Usage, pricing map[string]ModelPrice) *domain.LLMUsage {
+	if len(calls) == 0 {
+		return nil
+	}
+	usage := &domain.LLMUsage{Calls: make([]domain.LLMCallUsage, 0, len(calls))}
+	for _, call := range calls {
+		item := domain.LLMCallUsage{
+			Stage:            call.Stage,
+			Provider:         call.Provider,
+			Model:            call.Model,
+			PromptTokens:     call.PromptTokens,
+			CompletionTokens: call.CompletionTokens,
+			LatencyMs:        call.Latency.Milliseconds(),
+		}
+		if price, ok := pricing[strings.ToLower(strings.TrimSpace(call.Model))]; ok {
+			item.CostUSD = (float64(call.PromptTokens)*price.InputPerMillion + float64(call.CompletionTokens)*price.OutputPerMillion) / 1e6
+		}
+		usage.Calls = append(usage.Calls, item)
+		usage.PromptTokens += item.PromptTokens
+		usage.CompletionTokens += item.CompletionTokens
+		usage.CostUSD += item.CostUSD
+		usage.LatencyMs += item.LatencyMs
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"onebookai/pkg/ai"
+)
+
+func TestSummarizeUsagePricesCallsByModel(t *testing.T) {
+	calls := []ai.Usage{
+		{Stage: usageStageQueryRewrite, Provider: "gemini", Model: "gemini-2.5-flash", PromptTokens: 1000, CompletionTokens: 100, Latency: 300 * time.Millisecond},
+		{Stage: usageStageAnswer, Provider: "ollama", Model: "qwen3", PromptTokens: 4000, CompletionTokens: 600, Latency: 2 * time.Second},
+	}
+	usage := summarizeUsage(calls, map[string]ModelPrice{"gemini-2.5-flash": {InputPerMillion: 0.3, OutputPerMillion: 2.5}})
+	if usage == nil || len(usage.Calls) != 2 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if usage.PromptTokens != 5000 || usage.CompletionTokens != 700 || usage.TotalTokens != 5700 || usage.LatencyMs != 2300 {
+		t.Fatalf("unexpected totals %+v", usage)
+	}
+	if want := 0.00055; math.Abs(usage.CostUSD-want) > 1e-12 || usage.Calls[1].CostUSD != 0 {
+		t.Fatalf("unexpected cost %v (calls %+v)", usage.CostUSD, usage.Calls)
+	}
+	if summarizeUsage(nil, nil) != nil {
+		t.Fatal("expected nil usage when no model was called")
+	}
+}
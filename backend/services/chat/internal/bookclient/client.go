@@ -3,6 +3,7 @@ package bookclient
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -47,20 +48,7 @@ func (c *Client) GetBook(token, id string) (domain.Book, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error string `json:"error"`
-			Code  string `json:"code"`
-		}
-		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		msg := errResp.Error
-		if msg == "" {
-			msg = resp.Status
-		}
-		return domain.Book{}, &APIError{
-			Status:  resp.StatusCode,
-			Message: msg,
-			Code:    strings.TrimSpace(errResp.Code),
-		}
+		return domain.Book{}, decodeAPIError(resp)
 	}
 	var book domain.Book
 	if err := json.NewDecoder(resp.Body).Decode(&book); err != nil {
@@ -69,6 +57,52 @@ func (c *Client) GetBook(token, id string) (domain.Book, error) {
 	return book, nil
 }
 
+// ListBooks lists books visible to the caller, filtered by the given book
+// service query parameters (for example tag, primaryCategory, status).
+func (c *Client) ListBooks(token string, query url.Values) ([]domain.Book, error) {
+	endpoint := c.baseURL + "/books"
+	if encoded := query.Encode(); encoded != "" {
+		endpoint += "?" + encoded
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	addAuthHeader(req, token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, decodeAPIError(resp)
+	}
+	var payload struct {
+		Items []domain.Book `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	return payload.Items, nil
+}
+
+func decodeAPIError(resp *http.Response) error {
+	var errResp struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	msg := errResp.Error
+	if msg == "" {
+		msg = resp.Status
+	}
+	return &APIError{
+		Status:  resp.StatusCode,
+		Message: msg,
+		Code:    strings.TrimSpace(errResp.Code),
+	}
+}
+
 func addAuthHeader(req *http.Request, token string) {
 	if strings.TrimSpace(token) == "" {
 		return
@@ -9,51 +9,85 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"onebookai/pkg/retrieval"
 )
 
 // FileConfig represents configuration loaded from YAML.
 type FileConfig struct {
-	Port                     string  `yaml:"port"`
-	DatabaseURL              string  `yaml:"databaseURL"`
-	LogLevel                 string  `yaml:"logLevel"`
-	LogsDir                  string  `yaml:"logsDir"`
-	AuthServiceURL           string  `yaml:"authServiceURL"`
-	AuthJWKSURL              string  `yaml:"authJwksURL"`
-	JWTIssuer                string  `yaml:"jwtIssuer"`
-	JWTAudience              string  `yaml:"jwtAudience"`
-	JWTLeeway                string  `yaml:"jwtLeeway"`
-	BookServiceURL           string  `yaml:"bookServiceURL"`
-	GenerationProvider       string  `yaml:"generationProvider"`
-	GenerationBaseURL        string  `yaml:"generationBaseURL"`
-	GenerationAPIKey         string  `yaml:"generationAPIKey"`
-	GenerationModel          string  `yaml:"generationModel"`
-	GenerationEnableThinking *bool   `yaml:"generationEnableThinking"`
-	EmbeddingProvider        string  `yaml:"embeddingProvider"`
-	EmbeddingBaseURL         string  `yaml:"embeddingBaseURL"`
-	EmbeddingModel           string  `yaml:"embeddingModel"`
-	EmbeddingDim             int     `yaml:"embeddingDim"`
-	TopK                     int     `yaml:"topK"`
-	DenseRecallTopK          int     `yaml:"denseRecallTopK"`
-	LexicalRecallTopK        int     `yaml:"lexicalRecallTopK"`
-	DenseWeight              float64 `yaml:"denseWeight"`
-	LexicalWeight            float64 `yaml:"lexicalWeight"`
-	FusionTopK               int     `yaml:"fusionTopK"`
-	HistoryLimit             int     `yaml:"historyLimit"`
-	QdrantURL                string  `yaml:"qdrantURL"`
-	QdrantAPIKey             string  `yaml:"qdrantAPIKey"`
-	QdrantCollection         string  `yaml:"qdrantCollection"`
-	OpenSearchURL            string  `yaml:"openSearchURL"`
-	OpenSearchIndex          string  `yaml:"openSearchIndex"`
-	OpenSearchUsername       string  `yaml:"openSearchUsername"`
-	OpenSearchPassword       string  `yaml:"openSearchPassword"`
-	RerankTopN               int     `yaml:"rerankTopN"`
-	RetrievalMode            string  `yaml:"retrievalMode"`
-	RerankerURL              string  `yaml:"rerankerURL"`
-	ContextBudget            int     `yaml:"contextBudget"`
-	MinEvidenceCount         int     `yaml:"minEvidenceCount"`
-	QueryRewriteEnabled      bool    `yaml:"queryRewriteEnabled"`
-	MultiQueryEnabled        bool    `yaml:"multiQueryEnabled"`
-	AbstainEnabled           bool    `yaml:"abstainEnabled"`
+	Port                         string                `yaml:"port"`
+	DatabaseURL                  string                `yaml:"databaseURL"`
+	LogLevel                     string                `yaml:"logLevel"`
+	LogsDir                      string                `yaml:"logsDir"`
+	AuthServiceURL               string                `yaml:"authServiceURL"`
+	AuthJWKSURL                  string                `yaml:"authJwksURL"`
+	JWTIssuer                    string                `yaml:"jwtIssuer"`
+	JWTAudience                  string                `yaml:"jwtAudience"`
+	JWTLeeway                    string                `yaml:"jwtLeeway"`
+	BookServiceURL               string                `yaml:"bookServiceURL"`
+	GenerationProvider           string                `yaml:"generationProvider"`
+	GenerationBaseURL            string                `yaml:"generationBaseURL"`
+	GenerationAPIKey             string                `yaml:"generationAPIKey"`
+	GenerationModel              string                `yaml:"generationModel"`
+	GenerationEnableThinking     *bool                 `yaml:"generationEnableThinking"`
+	GenerationFallbacks          []GenerationFallback  `yaml:"generationFallbacks"`
+	GenerationBreakerFailures    int                   `yaml:"generationBreakerFailures"`
+	GenerationBreakerOpenSeconds int                   `yaml:"generationBreakerOpenSeconds"`
+	GenerationSlowCallMs         int                   `yaml:"generationSlowCallMs"`
+	GenerationPricing            map[string]ModelPrice `yaml:"generationPricing"`
+	EmbeddingProvider            string                `yaml:"embeddingProvider"`
+	EmbeddingBaseURL             string                `yaml:"embeddingBaseURL"`
+	EmbeddingModel               string                `yaml:"embeddingModel"`
+	EmbeddingDim                 int                   `yaml:"embeddingDim"`
+	TopK                         int                   `yaml:"topK"`
+	DenseRecallTopK              int                   `yaml:"denseRecallTopK"`
+	LexicalRecallTopK            int                   `yaml:"lexicalRecallTopK"`
+	DenseWeight                  float64               `yaml:"denseWeight"`
+	LexicalWeight                float64               `yaml:"lexicalWeight"`
+	SparseWeight                 float64               `yaml:"sparseWeight"`
+	FusionTopK                   int                   `yaml:"fusionTopK"`
+	HistoryLimit                 int                   `yaml:"historyLimit"`
+	VectorStore                  string                `yaml:"vectorStore"`
+	QdrantURL                    string                `yaml:"qdrantURL"`
+	QdrantAPIKey                 string                `yaml:"qdrantAPIKey"`
+	QdrantCollection             string                `yaml:"qdrantCollection"`
+	LexicalStore                 string                `yaml:"lexicalStore"`
+	LexicalFallback              bool                  `yaml:"lexicalFallback"`
+	OpenSearchURL                string                `yaml:"openSearchURL"`
+	OpenSearchIndex              string                `yaml:"openSearchIndex"`
+	OpenSearchUsername           string                `yaml:"openSearchUsername"`
+	OpenSearchPassword           string                `yaml:"openSearchPassword"`
+	RerankTopN                   int                   `yaml:"rerankTopN"`
+	RetrievalMode                string                `yaml:"retrievalMode"`
+	RerankerURL                  string                `yaml:"rerankerURL"`
+	ContextBudget                int                   `yaml:"contextBudget"`
+	MinEvidenceCount             int                   `yaml:"minEvidenceCount"`
+	QueryRewriteEnabled          bool                  `yaml:"queryRewriteEnabled"`
+	MultiQueryEnabled            bool                  `yaml:"multiQueryEnabled"`
+	AbstainEnabled               bool                  `yaml:"abstainEnabled"`
+	CitationEntailmentEnabled    bool                  `yaml:"citationEntailmentEnabled"`
+	SummaryEnabled               bool                  `yaml:"summaryEnabled"`
+	FollowUpRetrievalSteps       int                   `yaml:"followUpRetrievalSteps"`
+	FollowUpRetrievalBudgetMs    int                   `yaml:"followUpRetrievalBudgetMs"`
+	ModelRouterEnabled           bool                  `yaml:"modelRouterEnabled"`
+	ModelRouterMinConfidence     float64               `yaml:"modelRouterMinConfidence"`
+	AnswerCacheEnabled           bool                  `yaml:"answerCacheEnabled"`
+	AnswerCacheMinSimilarity     float64               `yaml:"answerCacheMinSimilarity"`
+	AnswerCacheMaxEntries        int                   `yaml:"answerCacheMaxEntries"`
+}
+
+// GenerationFallback is one provider tried after the primary generation
+// provider fails or its circuit breaker is open.
+type GenerationFallback struct {
+	Provider string `yaml:"provider"`
+	BaseURL  string `yaml:"baseURL"`
+	APIKey   string `yaml:"apiKey"`
+	Model    string `yaml:"model"`
+}
+
+// ModelPrice is the USD price per million input/output tokens of a model.
+type ModelPrice struct {
+	InputPerMillion  float64 `yaml:"inputPerMillion"`
+	OutputPerMillion float64 `yaml:"outputPerMillion"`
 }
 
 // Load reads config from path (defaults to config.yaml).
@@ -62,6 +96,13 @@ func Load(path string) (FileConfig, error) {
 		QueryRewriteEnabled: true,
 		MultiQueryEnabled:   true,
 		AbstainEnabled:      true,
+		SummaryEnabled:      true,
+		// Two follow-up rounds within eight seconds before abstaining.
+		FollowUpRetrievalSteps:    2,
+		FollowUpRetrievalBudgetMs: 8000,
+		ModelRouterMinConfidence:  0.7,
+		AnswerCacheMinSimilarity:  0.95,
+		AnswerCacheMaxEntries:     200,
 	}
 	if path == "" {
 		path = ConfigPath
@@ -115,6 +156,35 @@ func Load(path string) (FileConfig, error) {
 			cfg.GenerationEnableThinking = &enabled
 		}
 	}
+	if v := os.Getenv("GENERATION_FALLBACKS"); v != "" {
+		fallbacks, err := ParseGenerationFallbacks(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.GenerationFallbacks = fallbacks
+	}
+	if v := os.Getenv("GENERATION_BREAKER_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.GenerationBreakerFailures = n
+		}
+	}
+	if v := os.Getenv("GENERATION_BREAKER_OPEN_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.GenerationBreakerOpenSeconds = n
+		}
+	}
+	if v := os.Getenv("GENERATION_SLOW_CALL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.GenerationSlowCallMs = n
+		}
+	}
+	if v := os.Getenv("GENERATION_PRICING"); v != "" {
+		pricing, err := ParseGenerationPricing(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.GenerationPricing = pricing
+	}
 	if v := os.Getenv("ONEBOOK_EMBEDDING_DIM"); v != "" {
 		if dim, err := strconv.Atoi(v); err == nil {
 			cfg.EmbeddingDim = dim
@@ -153,11 +223,19 @@ func Load(path string) (FileConfig, error) {
 			cfg.LexicalWeight = n
 		}
 	}
+	if v := os.Getenv("CHAT_SPARSE_WEIGHT"); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.SparseWeight = n
+		}
+	}
 	if v := os.Getenv("CHAT_FUSION_TOPK"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.FusionTopK = n
 		}
 	}
+	if v := os.Getenv("VECTOR_STORE"); v != "" {
+		cfg.VectorStore = v
+	}
 	if v := os.Getenv("QDRANT_URL"); v != "" {
 		cfg.QdrantURL = v
 	}
@@ -167,6 +245,14 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("QDRANT_COLLECTION"); v != "" {
 		cfg.QdrantCollection = v
 	}
+	if v := os.Getenv("LEXICAL_STORE"); v != "" {
+		cfg.LexicalStore = v
+	}
+	if v := os.Getenv("LEXICAL_POSTGRES_FALLBACK"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.LexicalFallback = enabled
+		}
+	}
 	if v := os.Getenv("OPENSEARCH_URL"); v != "" {
 		cfg.OpenSearchURL = v
 	}
@@ -215,6 +301,51 @@ func Load(path string) (FileConfig, error) {
 			cfg.AbstainEnabled = enabled
 		}
 	}
+	if v := os.Getenv("CHAT_CITATION_ENTAILMENT_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.CitationEntailmentEnabled = enabled
+		}
+	}
+	if v := os.Getenv("CHAT_SUMMARY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.SummaryEnabled = enabled
+		}
+	}
+	if v := os.Getenv("CHAT_FOLLOWUP_RETRIEVAL_STEPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.FollowUpRetrievalSteps = n
+		}
+	}
+	if v := os.Getenv("CHAT_FOLLOWUP_RETRIEVAL_BUDGET_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.FollowUpRetrievalBudgetMs = n
+		}
+	}
+	if v := os.Getenv("CHAT_MODEL_ROUTER_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.ModelRouterEnabled = enabled
+		}
+	}
+	if v := os.Getenv("CHAT_MODEL_ROUTER_MIN_CONFIDENCE"); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.ModelRouterMinConfidence = n
+		}
+	}
+	if v := os.Getenv("CHAT_ANSWER_CACHE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.AnswerCacheEnabled = enabled
+		}
+	}
+	if v := os.Getenv("CHAT_ANSWER_CACHE_MIN_SIMILARITY"); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.AnswerCacheMinSimilarity = n
+		}
+	}
+	if v := os.Getenv("CHAT_ANSWER_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.AnswerCacheMaxEntries = n
+		}
+	}
 	if err := validateConfig(cfg); err != nil {
 		return cfg, err
 	}
@@ -258,6 +389,39 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.GenerationModel == "" {
 		return errors.New("config: generationModel is required (set GENERATION_MODEL)")
 	}
+	for i, fallback := range cfg.GenerationFallbacks {
+		switch strings.ToLower(strings.TrimSpace(fallback.Provider)) {
+		case "gemini":
+			if strings.TrimSpace(fallback.APIKey) == "" && cfg.GenerationAPIKey == "" {
+				return fmt.Errorf("config: generationFallbacks[%d] apiKey is required for gemini", i)
+			}
+		case "ollama":
+		case "openai-compat":
+			if strings.TrimSpace(fallback.BaseURL) == "" {
+				return fmt.Errorf("config: generationFallbacks[%d] baseURL is required for openai-compat", i)
+			}
+		default:
+			return fmt.Errorf("config: generationFallbacks[%d] provider must be gemini, ollama, or openai-compat (got %q)", i, fallback.Provider)
+		}
+		if strings.TrimSpace(fallback.Model) == "" {
+			return fmt.Errorf("config: generationFallbacks[%d] model is required", i)
+		}
+	}
+	if cfg.GenerationBreakerFailures < 0 || cfg.GenerationBreakerOpenSeconds < 0 || cfg.GenerationSlowCallMs < 0 {
+		return errors.New("config: generation breaker settings must not be negative")
+	}
+	if cfg.FollowUpRetrievalSteps < 0 || cfg.FollowUpRetrievalBudgetMs < 0 {
+		return errors.New("config: follow-up retrieval settings must not be negative")
+	}
+	if cfg.ModelRouterMinConfidence < 0 || cfg.ModelRouterMinConfidence > 1 {
+		return errors.New("config: modelRouterMinConfidence must be between 0 and 1")
+	}
+	if cfg.AnswerCacheMinSimilarity < 0 || cfg.AnswerCacheMinSimilarity > 1 {
+		return errors.New("config: answerCacheMinSimilarity must be between 0 and 1")
+	}
+	if cfg.AnswerCacheMaxEntries < 0 {
+		return errors.New("config: answerCacheMaxEntries must not be negative")
+	}
 	provider := strings.ToLower(strings.TrimSpace(cfg.EmbeddingProvider))
 	if provider == "" {
 		provider = "ollama"
@@ -268,17 +432,29 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.EmbeddingDim <= 0 {
 		return errors.New("config: embeddingDim is required (set ONEBOOK_EMBEDDING_DIM)")
 	}
-	if strings.TrimSpace(cfg.QdrantURL) == "" {
-		return errors.New("config: qdrantURL is required (set QDRANT_URL)")
-	}
-	if strings.TrimSpace(cfg.QdrantCollection) == "" {
-		return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
-	}
-	if strings.TrimSpace(cfg.OpenSearchURL) == "" {
-		return errors.New("config: openSearchURL is required (set OPENSEARCH_URL)")
+	switch retrieval.NormalizeVectorStoreBackend(cfg.VectorStore) {
+	case retrieval.VectorStoreQdrant:
+		if strings.TrimSpace(cfg.QdrantURL) == "" {
+			return errors.New("config: qdrantURL is required (set QDRANT_URL)")
+		}
+		if strings.TrimSpace(cfg.QdrantCollection) == "" {
+			return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
+		}
+	case retrieval.VectorStorePgvector:
+	default:
+		return fmt.Errorf("config: unsupported vectorStore %q (set VECTOR_STORE to qdrant or pgvector)", cfg.VectorStore)
 	}
-	if strings.TrimSpace(cfg.OpenSearchIndex) == "" {
-		return errors.New("config: openSearchIndex is required (set OPENSEARCH_INDEX)")
+	switch retrieval.NormalizeLexicalStoreBackend(cfg.LexicalStore) {
+	case retrieval.LexicalStoreOpenSearch:
+		if strings.TrimSpace(cfg.OpenSearchURL) == "" {
+			return errors.New("config: openSearchURL is required (set OPENSEARCH_URL)")
+		}
+		if strings.TrimSpace(cfg.OpenSearchIndex) == "" {
+			return errors.New("config: openSearchIndex is required (set OPENSEARCH_INDEX)")
+		}
+	case retrieval.LexicalStorePostgres:
+	default:
+		return fmt.Errorf("config: unsupported lexicalStore %q (set LEXICAL_STORE to opensearch or postgres)", cfg.LexicalStore)
 	}
 	if cfg.DenseWeight < 0 {
 		return errors.New("config: denseWeight must be >= 0")
@@ -286,6 +462,9 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.LexicalWeight < 0 {
 		return errors.New("config: lexicalWeight must be >= 0")
 	}
+	if cfg.SparseWeight < 0 {
+		return errors.New("config: sparseWeight must be >= 0")
+	}
 	switch provider {
 	case "ollama":
 	default:
@@ -305,3 +484,59 @@ func ParseJWTLeeway(leewayStr string) (time.Duration, error) {
 	}
 	return dur, nil
 }
+
+// ParseGenerationFallbacks parses "provider|model|baseURL|apiKey" entries
+// separated by commas; baseURL and apiKey are optional. "|" keeps model names
+// such as "qwen2.5:7b" and URLs unambiguous.
+func ParseGenerationFallbacks(raw string) ([]GenerationFallback, error) {
+	var out []GenerationFallback
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid GENERATION_FALLBACKS entry %q (want provider|model|baseURL|apiKey)", entry)
+		}
+		for len(parts) < 4 {
+			parts = append(parts, "")
+		}
+		out = append(out, GenerationFallback{
+			Provider: strings.TrimSpace(parts[0]),
+			Model:    strings.TrimSpace(parts[1]),
+			BaseURL:  strings.TrimSpace(parts[2]),
+			APIKey:   strings.TrimSpace(parts[3]),
+		})
+	}
+	return out, nil
+}
+
+// ParseGenerationPricing parses "model=input:output" entries separated by
+// commas, with prices in USD per million tokens, e.g.
+// "gemini-2.5-flash=0.30:2.50". Model names are matched case-insensitively.
+func ParseGenerationPricing(raw string) (map[string]ModelPrice, error) {
+	out := map[string]ModelPrice{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid GENERATION_PRICING entry %q (want model=input:output)", entry)
+		}
+		model := strings.ToLower(strings.TrimSpace(entry[:idx]))
+		prices := strings.Split(entry[idx+1:], ":")
+		if len(prices) != 2 {
+			return nil, fmt.Errorf("invalid GENERATION_PRICING entry %q (want model=input:output)", entry)
+		}
+		input, inErr := strconv.ParseFloat(strings.TrimSpace(prices[0]), 64)
+		output, outErr := strconv.ParseFloat(strings.TrimSpace(prices[1]), 64)
+		if inErr != nil || outErr != nil || input < 0 || output < 0 {
+			return nil, fmt.Errorf("invalid GENERATION_PRICING entry %q", entry)
+		}
+		out[model] = ModelPrice{InputPerMillion: input, OutputPerMillion: output}
+	}
+	return out, nil
+}
@@ -39,6 +39,15 @@ openSearchIndex: "onebook_lexical_chunks"
 	if !cfg.AbstainEnabled {
 		t.Fatal("AbstainEnabled = false, want true")
 	}
+	if !cfg.SummaryEnabled {
+		t.Fatal("SummaryEnabled = false, want true")
+	}
+	if cfg.FollowUpRetrievalSteps != 2 || cfg.FollowUpRetrievalBudgetMs != 8000 {
+		t.Fatalf("follow-up retrieval = %d steps / %dms, want 2 / 8000ms", cfg.FollowUpRetrievalSteps, cfg.FollowUpRetrievalBudgetMs)
+	}
+	if cfg.AnswerCacheEnabled || cfg.AnswerCacheMinSimilarity != 0.95 || cfg.AnswerCacheMaxEntries != 200 {
+		t.Fatalf("answer cache = %v / %.2f / %d, want false / 0.95 / 200", cfg.AnswerCacheEnabled, cfg.AnswerCacheMinSimilarity, cfg.AnswerCacheMaxEntries)
+	}
 }
 
 func TestLoadReadsFeatureFlagsFromEnv(t *testing.T) {
@@ -91,3 +100,22 @@ func writeTempConfig(t *testing.T, data string) string {
 	}
 	return path
 }
+
+func TestParseGenerationFallbacks(t *testing.T) {
+	got, err := ParseGenerationFallbacks("ollama|qwen2.5:7b|http://ollama:11434, openai-compat|deepseek-chat|https://api.deepseek.com/v1|sk-test")
+	if err != nil {
+		t.Fatalf("ParseGenerationFallbacks() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	if got[0].Model != "qwen2.5:7b" || got[0].BaseURL != "http://ollama:11434" || got[0].APIKey != "" {
+		t.Fatalf("first fallback = %+v", got[0])
+	}
+	if got[1].Provider != "openai-compat" || got[1].APIKey != "sk-test" {
+		t.Fatalf("second fallback = %+v", got[1])
+	}
+	if _, err := ParseGenerationFallbacks("gemini"); err == nil {
+		t.Fatal("expected error for entry without model")
+	}
+}
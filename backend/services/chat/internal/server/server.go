@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"onebookai/internal/usertoken"
 	"onebookai/internal/util"
@@ -56,12 +59,21 @@ func (s *Server) Router() http.Handler {
 func (s *Server) routes() {
 	s.mux.HandleFunc("/healthz", s.handleHealth)
 	s.mux.Handle("/chats", s.withUser(s.handleChats))
+	s.mux.Handle("/search", s.withUser(s.handleSearch))
 	s.mux.Handle("/conversations", s.withUser(s.handleConversations))
+	s.mux.Handle("/conversations/search", s.withUser(s.handleConversationSearch))
+	s.mux.HandleFunc("/shared/", s.handleSharedConversation)
 	s.mux.Handle("/conversations/", s.withUser(s.handleConversationByID))
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	resp := map[string]any{"status": "ok"}
+	if s.app != nil {
+		if stats, ok := s.app.AnswerCacheStats(); ok {
+			resp["answerCache"] = stats
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 type userHandler func(http.ResponseWriter, *http.Request, string, domain.User)
@@ -106,13 +118,61 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request, token strin
 		writeError(w, http.StatusBadRequest, "question is required")
 		return
 	}
+	r, ok := withAnswerOptions(w, r, user, req.Language, req.NoCache)
+	if !ok {
+		return
+	}
 	idempotencyKey := util.IdempotencyKeyFromRequest(r)
+	var passage *domain.PassageScope
+	if req.Scope != nil {
+		scope, err := app.NormalizePassageScope(req.Scope.PassageScope)
+		if err != nil {
+			writeErrorWithCode(w, http.StatusBadRequest, err.Error(), "CHAT_SCOPE_INVALID")
+			return
+		}
+		passage = scope
+	}
 
-	// For existing conversations, resolve bookId from the stored conversation
-	// so the frontend doesn't need to track which book a conversation belongs to.
-	resolvedBookID := strings.TrimSpace(req.BookID)
+	if s.books == nil {
+		writeError(w, http.StatusInternalServerError, "book client not configured")
+		return
+	}
+	books, ok := s.resolveChatBooks(w, token, user, req)
+	if !ok {
+		return
+	}
+	if len(books) > 1 {
+		if passage != nil {
+			writeErrorWithCode(w, http.StatusBadRequest, "pages, section and selection scopes apply to a single book", "CHAT_SCOPE_INVALID")
+			return
+		}
+		s.answerShelfQuestion(w, r, user, books, req, idempotencyKey)
+		return
+	}
+	book := books[0]
+	if wantsSSE(r) {
+		s.streamChatAnswer(w, r, user, book, req, passage, idempotencyKey)
+		return
+	}
+	ans, replayed, err := s.app.AskQuestion(r.Context(), user, book, req.Question, passage, req.ConversationID, idempotencyKey, req.Debug && user.Role == domain.RoleAdmin)
+	if err != nil {
+		writeAskError(w, err)
+		return
+	}
+	if replayed {
+		w.Header().Set("Idempotency-Replayed", "true")
+	}
+	writeJSON(w, http.StatusOK, ans)
+}
+
+// resolveChatBooks returns the books a chat request covers. Existing
+// conversations keep the books they were created with, so the frontend doesn't
+// need to track which books a conversation belongs to. New conversations use
+// scope when given (explicit ids, or the user's ready books with a tag or
+// category) and fall back to bookId.
+func (s *Server) resolveChatBooks(w http.ResponseWriter, token string, user domain.User, req chatRequest) ([]domain.Book, bool) {
 	if strings.TrimSpace(req.ConversationID) != "" {
-		convBookID, err := s.app.GetConversationBookID(user, req.ConversationID)
+		ids, err := s.app.GetConversationBookIDs(user, req.ConversationID)
 		if err != nil {
 			if errors.Is(err, app.ErrConversationNotFound) {
 				writeError(w, http.StatusNotFound, err.Error())
@@ -121,43 +181,88 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request, token strin
 			} else {
 				writeError(w, http.StatusInternalServerError, err.Error())
 			}
-			return
+			return nil, false
 		}
-		if convBookID != "" {
-			resolvedBookID = convBookID
+		if len(ids) > 0 {
+			return s.getChatBooks(w, token, ids, len(ids) > 1)
 		}
 	}
-
-	if resolvedBookID == "" {
-		writeError(w, http.StatusBadRequest, "bookId is required")
-		return
+	scope := req.Scope
+	if scopeEmpty(scope) {
+		if strings.TrimSpace(req.BookID) == "" {
+			writeError(w, http.StatusBadRequest, "bookId is required")
+			return nil, false
+		}
+		return s.getChatBooks(w, token, []string{strings.TrimSpace(req.BookID)}, false)
 	}
-	if s.books == nil {
-		writeError(w, http.StatusInternalServerError, "book client not configured")
-		return
+	if len(scope.BookIDs) > 0 {
+		ids := uniqueNonEmpty(scope.BookIDs)
+		if len(ids) > app.MaxShelfBooks {
+			writeErrorWithCode(w, http.StatusBadRequest, fmt.Sprintf("scope may include at most %d books", app.MaxShelfBooks), "CHAT_SCOPE_TOO_LARGE")
+			return nil, false
+		}
+		return s.getChatBooks(w, token, ids, false)
 	}
-	book, err := s.books.GetBook(token, resolvedBookID)
+	query := url.Values{}
+	query.Set("ownerId", user.ID)
+	query.Set("status", string(domain.StatusReady))
+	query.Set("sortBy", "updatedAt")
+	if tag := strings.TrimSpace(scope.Tag); tag != "" {
+		query.Set("tag", tag)
+	}
+	if category := strings.TrimSpace(scope.Category); category != "" {
+		query.Set("primaryCategory", category)
+	}
+	books, err := s.books.ListBooks(token, query)
 	if err != nil {
 		writeBookError(w, err)
-		return
+		return nil, false
+	}
+	if len(books) == 0 {
+		writeErrorWithCode(w, http.StatusBadRequest, "scope matched no ready books", "CHAT_SCOPE_EMPTY")
+		return nil, false
+	}
+	if len(books) > app.MaxShelfBooks {
+		writeErrorWithCode(w, http.StatusBadRequest, fmt.Sprintf("scope matched %d books; at most %d are allowed", len(books), app.MaxShelfBooks), "CHAT_SCOPE_TOO_LARGE")
+		return nil, false
+	}
+	return books, true
+}
+
+// getChatBooks fetches each book with the caller's token. Books deleted since a
+// shelf conversation started are skipped when skipMissing is set.
+func (s *Server) getChatBooks(w http.ResponseWriter, token string, ids []string, skipMissing bool) ([]domain.Book, bool) {
+	books := make([]domain.Book, 0, len(ids))
+	for _, id := range ids {
+		book, err := s.books.GetBook(token, id)
+		if err != nil {
+			var apiErr *bookclient.APIError
+			if skipMissing && errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				continue
+			}
+			writeBookError(w, err)
+			return nil, false
+		}
+		books = append(books, book)
+	}
+	if len(books) == 0 {
+		writeError(w, http.StatusNotFound, "book not found")
+		return nil, false
 	}
+	return books, true
+}
+
+func (s *Server) answerShelfQuestion(w http.ResponseWriter, r *http.Request, user domain.User, books []domain.Book, req chatRequest, idempotencyKey string) {
+	includeDebug := req.Debug && user.Role == domain.RoleAdmin
 	if wantsSSE(r) {
-		s.streamChatAnswer(w, r, user, book, req, idempotencyKey)
+		streamAnswer(w, r, func(ctx context.Context, onChunk func(string) error) (domain.Answer, bool, error) {
+			return s.app.AskShelfQuestion(ctx, user, books, req.Question, req.ConversationID, idempotencyKey, includeDebug, onChunk)
+		})
 		return
 	}
-	ans, replayed, err := s.app.AskQuestion(user, book, req.Question, req.ConversationID, idempotencyKey, req.Debug && user.Role == domain.RoleAdmin)
+	ans, replayed, err := s.app.AskShelfQuestion(r.Context(), user, books, req.Question, req.ConversationID, idempotencyKey, includeDebug, nil)
 	if err != nil {
-		status := http.StatusBadRequest
-		if errors.Is(err, app.ErrBookNotReady) {
-			status = http.StatusConflict
-		} else if errors.Is(err, app.ErrConversationNotFound) {
-			status = http.StatusNotFound
-		} else if errors.Is(err, app.ErrConversationForbidden) {
-			status = http.StatusForbidden
-		} else if strings.Contains(err.Error(), "forbidden") {
-			status = http.StatusForbidden
-		}
-		writeError(w, status, err.Error())
+		writeAskError(w, err)
 		return
 	}
 	if replayed {
@@ -166,27 +271,41 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request, token strin
 	writeJSON(w, http.StatusOK, ans)
 }
 
-func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, user domain.User, book domain.Book, req chatRequest, idempotencyKey string) {
+func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, user domain.User, book domain.Book, req chatRequest, passage *domain.PassageScope, idempotencyKey string) {
+	streamAnswer(w, r, func(ctx context.Context, onChunk func(string) error) (domain.Answer, bool, error) {
+		return s.app.AskQuestionStream(
+			ctx,
+			user,
+			book,
+			req.Question,
+			passage,
+			req.ConversationID,
+			idempotencyKey,
+			req.Debug && user.Role == domain.RoleAdmin,
+			onChunk,
+		)
+	})
+}
+
+// streamAnswer runs ask as an SSE stream: staged progress events (plan,
+// retrieval, citations, abstain) and periodic heartbeats, then the answer's
+// chunk events and a closing final or error event.
+func streamAnswer(w http.ResponseWriter, r *http.Request, ask func(ctx context.Context, onChunk func(string) error) (domain.Answer, bool, error)) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, http.StatusInternalServerError, "streaming unsupported")
 		return
 	}
 	prepareSSE(w)
-	ans, replayed, err := s.app.AskQuestionStream(
-		r.Context(),
-		user,
-		book,
-		req.Question,
-		req.ConversationID,
-		idempotencyKey,
-		req.Debug && user.Role == domain.RoleAdmin,
-		func(chunk string) error {
-			return writeSSEEvent(w, flusher, "chunk", map[string]string{"delta": chunk})
-		},
-	)
+	stream := &sseStream{w: w, flusher: flusher}
+	stopHeartbeat := stream.heartbeat(sseHeartbeatInterval)
+	ctx := app.WithProgress(r.Context(), stream.send)
+	ans, replayed, err := ask(ctx, func(chunk string) error {
+		return stream.send("chunk", map[string]string{"delta": chunk})
+	})
+	stopHeartbeat()
 	if err != nil {
-		_ = writeSSEEvent(w, flusher, "error", errorResponse{
+		_ = stream.send("error", errorResponse{
 			Error:     err.Error(),
 			Code:      "CHAT_STREAM_FAILED",
 			RequestID: util.RequestIDFromRequest(r),
@@ -196,7 +315,167 @@ func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, user d
 	if replayed {
 		w.Header().Set("Idempotency-Replayed", "true")
 	}
-	_ = writeSSEEvent(w, flusher, "final", ans)
+	_ = stream.send("final", ans)
+}
+
+// handleSearch ranks passages across the caller's ready books without
+// generating an answer. bookIds and tags are comma-separated filters.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, token string, user domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	values := r.URL.Query()
+	query := strings.TrimSpace(values.Get("q"))
+	if query == "" {
+		writeErrorWithCode(w, http.StatusBadRequest, "q is required", "SEARCH_QUERY_REQUIRED")
+		return
+	}
+	if s.books == nil {
+		writeError(w, http.StatusInternalServerError, "book client not configured")
+		return
+	}
+	books, err := s.searchableBooks(token, uniqueNonEmpty(strings.Split(values.Get("tags"), ",")))
+	if err != nil {
+		writeBookError(w, err)
+		return
+	}
+	if ids := uniqueNonEmpty(strings.Split(values.Get("bookIds"), ",")); len(ids) > 0 {
+		wanted := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			wanted[id] = struct{}{}
+		}
+		filtered := books[:0]
+		for _, book := range books {
+			if _, ok := wanted[book.ID]; ok {
+				filtered = append(filtered, book)
+			}
+		}
+		books = filtered
+	}
+	rerank := true
+	if raw := strings.TrimSpace(values.Get("rerank")); raw != "" {
+		rerank, _ = strconv.ParseBool(raw)
+	}
+	result, err := s.app.SearchPassages(r.Context(), user, books, app.PassageSearchOptions{
+		Query:    query,
+		Page:     readIntParam(values, "page", 1, 1<<20),
+		PageSize: readIntParam(values, "pageSize", 10, 50),
+		Rerank:   rerank,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleSharedConversation serves the public read-only view behind a share
+// token. It needs no user token; the share token is the credential.
+func (s *Server) handleSharedConversation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/shared/"), "/")
+	view, err := s.app.GetSharedConversation(token)
+	if err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, view)
+}
+
+// handleConversationSearch searches the caller's questions and answers.
+// bookIds is comma-separated; from and to are RFC 3339 times or dates, with a
+// date-only to covering that whole day.
+func (s *Server) handleConversationSearch(w http.ResponseWriter, r *http.Request, _ string, user domain.User) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	values := r.URL.Query()
+	query := strings.TrimSpace(values.Get("q"))
+	if query == "" {
+		writeErrorWithCode(w, http.StatusBadRequest, "q is required", "SEARCH_QUERY_REQUIRED")
+		return
+	}
+	from, _, err := parseSearchTime(values.Get("from"))
+	if err != nil {
+		writeErrorWithCode(w, http.StatusBadRequest, "invalid from", "SEARCH_DATE_INVALID")
+		return
+	}
+	to, dateOnly, err := parseSearchTime(values.Get("to"))
+	if err != nil {
+		writeErrorWithCode(w, http.StatusBadRequest, "invalid to", "SEARCH_DATE_INVALID")
+		return
+	}
+	if to != nil && dateOnly {
+		end := to.AddDate(0, 0, 1)
+		to = &end
+	}
+	result, err := s.app.SearchConversations(user, app.ConversationSearchOptions{
+		Query:    query,
+		BookIDs:  uniqueNonEmpty(strings.Split(values.Get("bookIds"), ",")),
+		From:     from,
+		To:       to,
+		Page:     readIntParam(values, "page", 1, 1<<20),
+		PageSize: readIntParam(values, "pageSize", 20, 50),
+	})
+	if errors.Is(err, app.ErrSearchDateRangeInvalid) {
+		writeErrorWithCode(w, http.StatusBadRequest, err.Error(), "SEARCH_DATE_INVALID")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func parseSearchTime(value string) (*time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, false, err
+	}
+	return &t, true, nil
+}
+
+// searchableBooks lists the caller's ready books, optionally restricted to any
+// of the given tags.
+func (s *Server) searchableBooks(token string, tags []string) ([]domain.Book, error) {
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+	seen := map[string]struct{}{}
+	var books []domain.Book
+	for _, tag := range tags {
+		query := url.Values{}
+		query.Set("status", string(domain.StatusReady))
+		if tag != "" {
+			query.Set("tag", tag)
+		}
+		items, err := s.books.ListBooks(token, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, book := range items {
+			if _, ok := seen[book.ID]; ok {
+				continue
+			}
+			seen[book.ID] = struct{}{}
+			books = append(books, book)
+		}
+	}
+	return books, nil
 }
 
 func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request, _ string, user domain.User) {
@@ -216,7 +495,7 @@ func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request, _ s
 	})
 }
 
-func (s *Server) handleConversationByID(w http.ResponseWriter, r *http.Request, _ string, user domain.User) {
+func (s *Server) handleConversationByID(w http.ResponseWriter, r *http.Request, token string, user domain.User) {
 	path := strings.TrimPrefix(r.URL.Path, "/conversations/")
 	path = strings.Trim(path, "/")
 	if path == "" {
@@ -250,36 +529,241 @@ func (s *Server) handleConversationByID(w http.ResponseWriter, r *http.Request,
 		}
 		return
 	}
-	if len(parts) != 2 || parts[1] != "messages" {
+	conversationID := parts[0]
+	switch {
+	case len(parts) == 2 && parts[1] == "messages":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		limit := readLimitParam(r.URL.Query(), 200, 500)
+		items, err := s.app.ListConversationMessages(user, conversationID, limit)
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+		})
+	case len(parts) == 4 && parts[1] == "messages" && (parts[3] == "regenerate" || parts[3] == "edit"):
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		s.handleBranchMessage(w, r, token, user, conversationID, parts[2], parts[3])
+	case len(parts) == 4 && parts[1] == "messages" && parts[3] == "feedback":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		var req answerFeedbackRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		feedback, err := s.app.SubmitAnswerFeedback(user, conversationID, parts[2], app.AnswerFeedbackInput{
+			Rating:            domain.AnswerFeedbackRating(req.Rating),
+			Reason:            req.Reason,
+			CorrectedChunkIDs: req.CorrectedChunkIDs,
+		})
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, feedback)
+	case len(parts) == 2 && parts[1] == "export":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		format, err := app.ParseExportFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		export, err := s.app.ExportConversation(user, conversationID)
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename(format)))
+		w.WriteHeader(http.StatusOK)
+		// Headers are already sent; a failed write means the client went away.
+		_ = export.Write(w, format)
+	case len(parts) == 2 && parts[1] == "shares":
+		switch r.Method {
+		case http.MethodGet:
+			items, err := s.app.ListConversationShares(user, conversationID)
+			if err != nil {
+				writeConversationError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"items": items,
+				"count": len(items),
+			})
+		case http.MethodPost:
+			var req createShareRequest
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+			share, err := s.app.CreateConversationShare(user, conversationID, app.ConversationShareInput{
+				ExpiresAt:       req.ExpiresAt,
+				IncludeSnippets: req.IncludeSnippets,
+			}, util.RequestIDFromRequest(r))
+			if err != nil {
+				writeConversationError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, share)
+		default:
+			methodNotAllowed(w)
+		}
+	case len(parts) == 3 && parts[1] == "shares":
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		share, err := s.app.RevokeConversationShare(user, conversationID, parts[2], util.RequestIDFromRequest(r))
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, share)
+	case len(parts) == 2 && parts[1] == "branches":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		items, err := s.app.ListConversationBranches(user, conversationID)
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+		})
+	case len(parts) == 2 && parts[1] == "active-branch":
+		if r.Method != http.MethodPut {
+			methodNotAllowed(w)
+			return
+		}
+		var req switchBranchRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if strings.TrimSpace(req.MessageID) == "" {
+			writeError(w, http.StatusBadRequest, "messageId is required")
+			return
+		}
+		conversation, err := s.app.SwitchConversationBranch(user, conversationID, req.MessageID)
+		if err != nil {
+			writeConversationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, conversation)
+	default:
 		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleBranchMessage regenerates an answer or answers an edited question,
+// creating a sibling branch in the conversation.
+func (s *Server) handleBranchMessage(w http.ResponseWriter, r *http.Request, token string, user domain.User, conversationID, messageID, action string) {
+	var req branchMessageRequest
+	if action == "edit" {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if strings.TrimSpace(req.Question) == "" {
+			writeError(w, http.StatusBadRequest, "question is required")
+			return
+		}
+	} else if r.ContentLength > 0 {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+	r, ok := withAnswerOptions(w, r, user, req.Language, req.NoCache)
+	if !ok {
 		return
 	}
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
+	if s.books == nil {
+		writeError(w, http.StatusInternalServerError, "book client not configured")
 		return
 	}
-	conversationID := parts[0]
-	limit := readLimitParam(r.URL.Query(), 200, 500)
-	items, err := s.app.ListConversationMessages(user, conversationID, limit)
+	books, ok := s.resolveChatBooks(w, token, user, chatRequest{ConversationID: conversationID})
+	if !ok {
+		return
+	}
+	includeDebug := req.Debug && user.Role == domain.RoleAdmin
+	idempotencyKey := util.IdempotencyKeyFromRequest(r)
+	var (
+		ans      domain.Answer
+		replayed bool
+		err      error
+	)
+	if action == "edit" {
+		ans, replayed, err = s.app.EditQuestion(r.Context(), user, books, conversationID, messageID, req.Question, idempotencyKey, includeDebug)
+	} else {
+		ans, replayed, err = s.app.RegenerateAnswer(r.Context(), user, books, conversationID, messageID, idempotencyKey, includeDebug)
+	}
 	if err != nil {
-		status := http.StatusBadRequest
-		if errors.Is(err, app.ErrConversationNotFound) {
-			status = http.StatusNotFound
-		} else if errors.Is(err, app.ErrConversationForbidden) {
-			status = http.StatusForbidden
-		}
-		writeError(w, status, err.Error())
+		writeAskError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items": items,
-		"count": len(items),
-	})
+	if replayed {
+		w.Header().Set("Idempotency-Replayed", "true")
+	}
+	writeJSON(w, http.StatusOK, ans)
+}
+
+// withAnswerOptions attaches the requested answer language and answer cache
+// bypass to the request context, rejecting unsupported languages.
+// withAnswerOptions applies the request's answer language, falling back to
+// the user's stored preference when the request leaves it empty.
+func withAnswerOptions(w http.ResponseWriter, r *http.Request, user domain.User, language string, noCache bool) (*http.Request, bool) {
+	if strings.TrimSpace(language) == "" {
+		language = user.AnswerLanguage
+	}
+	language, err := app.NormalizeAnswerLanguage(language)
+	if err != nil {
+		writeErrorWithCode(w, http.StatusBadRequest, err.Error(), "CHAT_LANGUAGE_INVALID")
+		return nil, false
+	}
+	ctx := app.WithAnswerLanguage(r.Context(), language)
+	if noCache {
+		ctx = app.WithoutAnswerCache(ctx)
+	}
+	return r.WithContext(ctx), true
+}
+
+func writeAskError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, app.ErrBookNotReady) {
+		status = http.StatusConflict
+	} else if errors.Is(err, app.ErrConversationNotFound) {
+		status = http.StatusNotFound
+	} else if errors.Is(err, app.ErrConversationForbidden) {
+		status = http.StatusForbidden
+	} else if errors.Is(err, app.ErrMessageNotFound) {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "forbidden") {
+		status = http.StatusForbidden
+	}
+	writeError(w, status, err.Error())
 }
 
 func writeConversationError(w http.ResponseWriter, err error) {
 	status := http.StatusBadRequest
-	if errors.Is(err, app.ErrConversationNotFound) {
+	if errors.Is(err, app.ErrConversationNotFound) || errors.Is(err, app.ErrMessageNotFound) || errors.Is(err, app.ErrShareNotFound) {
 		status = http.StatusNotFound
 	} else if errors.Is(err, app.ErrConversationForbidden) {
 		status = http.StatusForbidden
@@ -288,7 +772,11 @@ func writeConversationError(w http.ResponseWriter, err error) {
 }
 
 func readLimitParam(values url.Values, fallback int, max int) int {
-	raw := strings.TrimSpace(values.Get("limit"))
+	return readIntParam(values, "limit", fallback, max)
+}
+
+func readIntParam(values url.Values, name string, fallback int, max int) int {
+	raw := strings.TrimSpace(values.Get(name))
 	if raw == "" {
 		return fallback
 	}
@@ -307,16 +795,65 @@ func methodNotAllowed(w http.ResponseWriter) {
 }
 
 type chatRequest struct {
-	ConversationID string `json:"conversationId,omitempty"`
-	BookID         string `json:"bookId"`
-	Question       string `json:"question"`
-	Debug          bool   `json:"debug,omitempty"`
+	ConversationID string            `json:"conversationId,omitempty"`
+	BookID         string            `json:"bookId"`
+	Scope          *domain.ChatScope `json:"scope,omitempty"`
+	Question       string            `json:"question"`
+	// Language overrides the answer language detected from the question:
+	// auto (default), zh or en.
+	Language string `json:"language,omitempty"`
+	// NoCache answers afresh instead of reusing a cached answer.
+	NoCache bool `json:"noCache,omitempty"`
+	Debug   bool `json:"debug,omitempty"`
+}
+
+func scopeEmpty(scope *domain.ChatScope) bool {
+	return scope == nil || (len(uniqueNonEmpty(scope.BookIDs)) == 0 && strings.TrimSpace(scope.Tag) == "" && strings.TrimSpace(scope.Category) == "")
+}
+
+func uniqueNonEmpty(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
 }
 
 type renameConversationRequest struct {
 	Title string `json:"title"`
 }
 
+type branchMessageRequest struct {
+	Question string `json:"question"`
+	Language string `json:"language,omitempty"`
+	NoCache  bool   `json:"noCache,omitempty"`
+	Debug    bool   `json:"debug,omitempty"`
+}
+
+type switchBranchRequest struct {
+	MessageID string `json:"messageId"`
+}
+
+type createShareRequest struct {
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	IncludeSnippets bool       `json:"includeSnippets"`
+}
+
+type answerFeedbackRequest struct {
+	Rating            string   `json:"rating"`
+	Reason            string   `json:"reason,omitempty"`
+	CorrectedChunkIDs []string `json:"correctedChunkIds,omitempty"`
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -346,6 +883,51 @@ func writeSSEEvent(w io.Writer, flusher http.Flusher, event string, payload any)
 	return nil
 }
 
+// sseHeartbeatInterval is how often an idle answer stream sends a heartbeat
+// event, well under common proxy read timeouts.
+const sseHeartbeatInterval = 10 * time.Second
+
+// sseStream serializes events from the answer pipeline and the heartbeat
+// goroutine onto one response.
+type sseStream struct {
+	mu      sync.Mutex
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (s *sseStream) send(event string, payload any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeSSEEvent(s.w, s.flusher, event, payload)
+}
+
+// heartbeat sends a heartbeat event every interval until the returned stop
+// function is called; stop waits for the goroutine to exit.
+func (s *sseStream) heartbeat(interval time.Duration) (stop func()) {
+	started := time.Now()
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.send("heartbeat", domain.HeartbeatEvent{ElapsedMs: time.Since(started).Milliseconds()}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
 type errorResponse struct {
 	Error     string `json:"error"`
 	Code      string `json:"code"`
@@ -396,6 +978,20 @@ func errorCodeForChat(status int, msg string) string {
 		return "CHAT_CONVERSATION_NOT_FOUND"
 	case message == "conversation forbidden":
 		return "CHAT_CONVERSATION_FORBIDDEN"
+	case message == "message not found":
+		return "CHAT_MESSAGE_NOT_FOUND"
+	case message == "message is not an answer", message == "message is not a question":
+		return "CHAT_MESSAGE_INVALID_TARGET"
+	case message == "messageid is required":
+		return "CHAT_MESSAGE_ID_REQUIRED"
+	case message == "rating must be up or down", message == "corrected chunk not found", message == "too many corrected chunks":
+		return "CHAT_FEEDBACK_INVALID"
+	case message == "share not found":
+		return "CHAT_SHARE_NOT_FOUND"
+	case message == "expiresat must be in the future and within a year":
+		return "CHAT_SHARE_INVALID"
+	case message == "export format must be md, pdf or json":
+		return "CHAT_EXPORT_FORMAT_INVALID"
 	case message == "book not ready":
 		return "CHAT_BOOK_NOT_READY"
 	case message == "forbidden":
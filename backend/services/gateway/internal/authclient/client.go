@@ -181,7 +181,16 @@ func (c *Client) Me(requestID, token string) (domain.User, error) {
 	return user, nil
 }
 
-func (c *Client) UpdateMe(requestID, token string, email *string, displayName *string) (domain.User, error) {
+// MeUsage returns the caller's quota limits and current consumption.
+func (c *Client) MeUsage(requestID, token string) (domain.UserUsage, error) {
+	var usage domain.UserUsage
+	if err := c.doJSON(http.MethodGet, "/auth/me/usage", requestID, token, nil, &usage); err != nil {
+		return domain.UserUsage{}, err
+	}
+	return usage, nil
+}
+
+func (c *Client) UpdateMe(requestID, token string, email *string, displayName *string, answerLanguage *string) (domain.User, error) {
 	payload := map[string]string{}
 	if email != nil {
 		payload["email"] = *email
@@ -189,6 +198,9 @@ func (c *Client) UpdateMe(requestID, token string, email *string, displayName *s
 	if displayName != nil {
 		payload["displayName"] = *displayName
 	}
+	if answerLanguage != nil {
+		payload["answerLanguage"] = *answerLanguage
+	}
 	var user domain.User
 	if err := c.doJSON(http.MethodPatch, "/auth/me", requestID, token, payload, &user); err != nil {
 		return domain.User{}, err
@@ -452,6 +464,57 @@ func (c *Client) AdminOverview(requestID, token string) (domain.AdminOverview, e
 	return overview, nil
 }
 
+// AdminLLMUsage returns LLM token usage totals; query carries from, to,
+// userId and bookId filters.
+func (c *Client) AdminLLMUsage(requestID, token string, query url.Values) (domain.LLMUsageReport, error) {
+	path := "/auth/admin/llm-usage"
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	var report domain.LLMUsageReport
+	if err := c.doJSON(http.MethodGet, path, requestID, token, nil, &report); err != nil {
+		return domain.LLMUsageReport{}, err
+	}
+	return report, nil
+}
+
+// AdminUserQuotaRequest overrides a user's plan or individual limits; nil
+// limits inherit the plan.
+type AdminUserQuotaRequest struct {
+	Plan            string `json:"plan,omitempty"`
+	DailyQuestions  *int   `json:"dailyQuestions,omitempty"`
+	MonthlyTokens   *int64 `json:"monthlyTokens,omitempty"`
+	MaxBooks        *int   `json:"maxBooks,omitempty"`
+	MaxStorageBytes *int64 `json:"maxStorageBytes,omitempty"`
+}
+
+func (c *Client) AdminGetUserQuota(requestID, token, userID string) (domain.UserUsage, error) {
+	var usage domain.UserUsage
+	path := fmt.Sprintf("/auth/admin/users/%s/quota", userID)
+	if err := c.doJSON(http.MethodGet, path, requestID, token, nil, &usage); err != nil {
+		return domain.UserUsage{}, err
+	}
+	return usage, nil
+}
+
+func (c *Client) AdminSetUserQuota(requestID, token, userID string, req AdminUserQuotaRequest) (domain.UserUsage, error) {
+	var usage domain.UserUsage
+	path := fmt.Sprintf("/auth/admin/users/%s/quota", userID)
+	if err := c.doJSON(http.MethodPut, path, requestID, token, req, &usage); err != nil {
+		return domain.UserUsage{}, err
+	}
+	return usage, nil
+}
+
+func (c *Client) AdminClearUserQuota(requestID, token, userID string) (domain.UserUsage, error) {
+	var usage domain.UserUsage
+	path := fmt.Sprintf("/auth/admin/users/%s/quota", userID)
+	if err := c.doJSON(http.MethodDelete, path, requestID, token, nil, &usage); err != nil {
+		return domain.UserUsage{}, err
+	}
+	return usage, nil
+}
+
 type AdminEvalOverview = domain.AdminEvalOverview
 
 type PagedEvalDatasetsResponse struct {
@@ -504,6 +567,36 @@ type AdminCreateEvalRunRequest struct {
 	Params        map[string]any `json:"params,omitempty"`
 }
 
+type PagedAnswerFeedbackResponse struct {
+	Items      []domain.AnswerFeedback `json:"items"`
+	Count      int                     `json:"count"`
+	Page       int                     `json:"page"`
+	PageSize   int                     `json:"pageSize"`
+	Total      int                     `json:"total"`
+	TotalPages int                     `json:"totalPages"`
+}
+
+type AdminListAnswerFeedbackOptions struct {
+	Status   string
+	Rating   string
+	BookID   string
+	Page     int
+	PageSize int
+}
+
+type AdminAnswerFeedbackReviewRequest struct {
+	Status            string    `json:"status,omitempty"`
+	CorrectedChunkIDs *[]string `json:"correctedChunkIds,omitempty"`
+	ReviewNote        *string   `json:"reviewNote,omitempty"`
+}
+
+type AdminAnswerFeedbackConvertRequest struct {
+	FeedbackIDs []string `json:"feedbackIds"`
+	DatasetID   string   `json:"datasetId,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
 func (c *Client) AdminEvalOverview(requestID, token string) (AdminEvalOverview, error) {
 	var overview AdminEvalOverview
 	if err := c.doJSON(http.MethodGet, "/auth/admin/evals/overview", requestID, token, nil, &overview); err != nil {
@@ -660,6 +753,58 @@ func (c *Client) AdminGetEvalPerQuery(requestID, token, id string) (map[string]a
 	return out, nil
 }
 
+func (c *Client) AdminListAnswerFeedback(requestID, token string, opts AdminListAnswerFeedbackOptions) (PagedAnswerFeedbackResponse, error) {
+	query := url.Values{}
+	if v := strings.TrimSpace(opts.Status); v != "" {
+		query.Set("status", v)
+	}
+	if v := strings.TrimSpace(opts.Rating); v != "" {
+		query.Set("rating", v)
+	}
+	if v := strings.TrimSpace(opts.BookID); v != "" {
+		query.Set("bookId", v)
+	}
+	if opts.Page > 0 {
+		query.Set("page", fmt.Sprintf("%d", opts.Page))
+	}
+	if opts.PageSize > 0 {
+		query.Set("pageSize", fmt.Sprintf("%d", opts.PageSize))
+	}
+	path := "/auth/admin/evals/feedback"
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	var resp PagedAnswerFeedbackResponse
+	if err := c.doJSON(http.MethodGet, path, requestID, token, nil, &resp); err != nil {
+		return PagedAnswerFeedbackResponse{}, err
+	}
+	return resp, nil
+}
+
+func (c *Client) AdminGetAnswerFeedback(requestID, token, id string) (domain.AnswerFeedback, error) {
+	var out domain.AnswerFeedback
+	if err := c.doJSON(http.MethodGet, "/auth/admin/evals/feedback/"+id, requestID, token, nil, &out); err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) AdminReviewAnswerFeedback(requestID, token, id string, req AdminAnswerFeedbackReviewRequest) (domain.AnswerFeedback, error) {
+	var out domain.AnswerFeedback
+	if err := c.doJSON(http.MethodPatch, "/auth/admin/evals/feedback/"+id, requestID, token, req, &out); err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) AdminConvertAnswerFeedback(requestID, token string, req AdminAnswerFeedbackConvertRequest) (domain.EvalDataset, error) {
+	var out domain.EvalDataset
+	if err := c.doJSON(http.MethodPost, "/auth/admin/evals/feedback/convert", requestID, token, req, &out); err != nil {
+		return domain.EvalDataset{}, err
+	}
+	return out, nil
+}
+
 func (c *Client) AdminDownloadEvalArtifact(requestID, token, runID, name string) ([]byte, string, error) {
 	path := "/auth/admin/evals/runs/" + runID + "/artifacts/" + name
 	return c.doBytes(http.MethodGet, path, requestID, token)
@@ -215,6 +215,36 @@ func (c *Client) GetBookIndexStatus(requestID, token, id string) (domain.BookInd
 	return summary, nil
 }
 
+// ResolveCitation maps a cited chunk of a book to reader coordinates.
+func (c *Client) ResolveCitation(requestID, token, id, chunkID string) (domain.ReaderLocation, error) {
+	path := fmt.Sprintf("%s/books/%s/citations/%s", c.baseURL, url.PathEscape(id), url.PathEscape(chunkID))
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return domain.ReaderLocation{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	var location domain.ReaderLocation
+	if _, err := c.do(req, &location); err != nil {
+		return domain.ReaderLocation{}, err
+	}
+	return location, nil
+}
+
+func (c *Client) GetIndexConsistencyReport(requestID, token string) (domain.IndexConsistencyReport, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/admin/index-consistency", nil)
+	if err != nil {
+		return domain.IndexConsistencyReport{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	var report domain.IndexConsistencyReport
+	if _, err := c.do(req, &report); err != nil {
+		return domain.IndexConsistencyReport{}, err
+	}
+	return report, nil
+}
+
 func (c *Client) RepairBookIndex(requestID, token, idempotencyKey, id string) (domain.Book, bool, error) {
 	path := fmt.Sprintf("%s/books/%s/repair-index", c.baseURL, id)
 	req, err := http.NewRequest(http.MethodPost, path, bytes.NewReader([]byte("{}")))
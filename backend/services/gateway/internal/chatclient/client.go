@@ -41,8 +41,8 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
-func (c *Client) AskQuestion(requestID, token, idempotencyKey, conversationID, bookID, question string, debug bool) (domain.Answer, bool, error) {
-	payload := chatRequest{ConversationID: strings.TrimSpace(conversationID), BookID: bookID, Question: question, Debug: debug}
+func (c *Client) AskQuestion(requestID, token, idempotencyKey, conversationID, bookID string, scope *domain.ChatScope, question, language string, noCache, debug bool) (domain.Answer, bool, error) {
+	payload := chatRequest{ConversationID: strings.TrimSpace(conversationID), BookID: bookID, Scope: scope, Question: question, Language: language, NoCache: noCache, Debug: debug}
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return domain.Answer{}, false, err
@@ -94,10 +94,13 @@ func (c *Client) StreamQuestion(
 	idempotencyKey string,
 	conversationID string,
 	bookID string,
+	scope *domain.ChatScope,
 	question string,
+	language string,
+	noCache bool,
 	debug bool,
 ) (*StreamResponse, error) {
-	payload := chatRequest{ConversationID: strings.TrimSpace(conversationID), BookID: bookID, Question: question, Debug: debug}
+	payload := chatRequest{ConversationID: strings.TrimSpace(conversationID), BookID: bookID, Scope: scope, Question: question, Language: language, NoCache: noCache, Debug: debug}
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
@@ -161,6 +164,46 @@ func (c *Client) ListConversations(requestID, token string, limit int) ([]domain
 	return resp.Items, nil
 }
 
+// SearchPassages forwards a library passage search (q, bookIds, tags, page,
+// pageSize, rerank) to the chat service.
+func (c *Client) SearchPassages(requestID, token string, query url.Values) (domain.PassageSearchResult, error) {
+	endpoint := c.baseURL + "/search"
+	if encoded := query.Encode(); encoded != "" {
+		endpoint += "?" + encoded
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.PassageSearchResult{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+
+	var resp domain.PassageSearchResult
+	if err := c.do(req, &resp); err != nil {
+		return domain.PassageSearchResult{}, err
+	}
+	return resp, nil
+}
+
+func (c *Client) SearchConversations(requestID, token string, query url.Values) (domain.ConversationSearchResult, error) {
+	endpoint := c.baseURL + "/conversations/search"
+	if encoded := query.Encode(); encoded != "" {
+		endpoint += "?" + encoded
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.ConversationSearchResult{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+
+	var resp domain.ConversationSearchResult
+	if err := c.do(req, &resp); err != nil {
+		return domain.ConversationSearchResult{}, err
+	}
+	return resp, nil
+}
+
 func (c *Client) ListConversationMessages(requestID, token, conversationID string, limit int) ([]domain.Message, error) {
 	query := url.Values{}
 	if limit > 0 {
@@ -219,10 +262,237 @@ func (c *Client) DeleteConversation(requestID, token, conversationID string) err
 	return c.do(req, nil)
 }
 
+// RegenerateAnswer answers the question behind an assistant message again,
+// adding a sibling branch.
+func (c *Client) RegenerateAnswer(requestID, token, idempotencyKey, conversationID, messageID, language string, debug bool) (domain.Answer, bool, error) {
+	return c.branchMessage(requestID, token, idempotencyKey, conversationID, messageID, "regenerate", branchMessageRequest{Language: language, Debug: debug})
+}
+
+// EditQuestion answers an edited copy of a user message, adding a sibling
+// branch.
+func (c *Client) EditQuestion(requestID, token, idempotencyKey, conversationID, messageID, question, language string, noCache, debug bool) (domain.Answer, bool, error) {
+	return c.branchMessage(requestID, token, idempotencyKey, conversationID, messageID, "edit", branchMessageRequest{Question: question, Language: language, NoCache: noCache, Debug: debug})
+}
+
+func (c *Client) branchMessage(requestID, token, idempotencyKey, conversationID, messageID, action string, payload branchMessageRequest) (domain.Answer, bool, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	endpoint := fmt.Sprintf("%s/conversations/%s/messages/%s/%s", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)), url.PathEscape(strings.TrimSpace(messageID)), action)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	req.Header.Set("Content-Type", "application/json")
+	if strings.TrimSpace(idempotencyKey) != "" {
+		req.Header.Set("Idempotency-Key", strings.TrimSpace(idempotencyKey))
+	}
+
+	var ans domain.Answer
+	header, err := c.doWithHeader(req, &ans)
+	if err != nil {
+		return domain.Answer{}, false, err
+	}
+	replayed := strings.EqualFold(strings.TrimSpace(header.Get("Idempotency-Replayed")), "true")
+	return ans, replayed, nil
+}
+
+func (c *Client) ListConversationBranches(requestID, token, conversationID string) ([]domain.ConversationBranch, error) {
+	endpoint := fmt.Sprintf("%s/conversations/%s/branches", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+
+	var resp struct {
+		Items []domain.ConversationBranch `json:"items"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+func (c *Client) SwitchConversationBranch(requestID, token, conversationID, messageID string) (domain.Conversation, error) {
+	data, err := json.Marshal(switchBranchRequest{MessageID: strings.TrimSpace(messageID)})
+	if err != nil {
+		return domain.Conversation{}, err
+	}
+	endpoint := fmt.Sprintf("%s/conversations/%s/active-branch", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)))
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return domain.Conversation{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	req.Header.Set("Content-Type", "application/json")
+
+	var conversation domain.Conversation
+	if err := c.do(req, &conversation); err != nil {
+		return domain.Conversation{}, err
+	}
+	return conversation, nil
+}
+
+// SubmitAnswerFeedback records the caller's feedback on an assistant message.
+func (c *Client) SubmitAnswerFeedback(requestID, token, conversationID, messageID string, feedback AnswerFeedbackRequest) (domain.AnswerFeedback, error) {
+	data, err := json.Marshal(feedback)
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	endpoint := fmt.Sprintf("%s/conversations/%s/messages/%s/feedback", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)), url.PathEscape(strings.TrimSpace(messageID)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	req.Header.Set("Content-Type", "application/json")
+
+	var out domain.AnswerFeedback
+	if err := c.do(req, &out); err != nil {
+		return domain.AnswerFeedback{}, err
+	}
+	return out, nil
+}
+
+// ExportResponse is a conversation export streamed from the chat service.
+type ExportResponse struct {
+	Body               io.ReadCloser
+	ContentType        string
+	ContentDisposition string
+}
+
+// ExportConversation streams a conversation export in the given format. The
+// caller must close the body.
+func (c *Client) ExportConversation(ctx context.Context, requestID, token, conversationID, format string) (*ExportResponse, error) {
+	endpoint := fmt.Sprintf("%s/conversations/%s/export", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)))
+	if format = strings.TrimSpace(format); format != "" {
+		endpoint += "?format=" + url.QueryEscape(format)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	resp, err := c.streamHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var errResp struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		msg := strings.TrimSpace(errResp.Error)
+		if msg == "" {
+			msg = resp.Status
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: msg, Code: strings.TrimSpace(errResp.Code)}
+	}
+	return &ExportResponse{
+		Body:               resp.Body,
+		ContentType:        resp.Header.Get("Content-Type"),
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+	}, nil
+}
+
+// CreateShareRequest configures a new conversation share link.
+type CreateShareRequest struct {
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	IncludeSnippets bool       `json:"includeSnippets"`
+}
+
+func (c *Client) CreateConversationShare(requestID, token, conversationID string, payload CreateShareRequest) (domain.ConversationShare, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	endpoint := fmt.Sprintf("%s/conversations/%s/shares", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp domain.ConversationShare
+	if err := c.do(req, &resp); err != nil {
+		return domain.ConversationShare{}, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListConversationShares(requestID, token, conversationID string) ([]domain.ConversationShare, error) {
+	endpoint := fmt.Sprintf("%s/conversations/%s/shares", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+
+	var resp struct {
+		Items []domain.ConversationShare `json:"items"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+func (c *Client) RevokeConversationShare(requestID, token, conversationID, shareID string) (domain.ConversationShare, error) {
+	endpoint := fmt.Sprintf("%s/conversations/%s/shares/%s", c.baseURL, url.PathEscape(strings.TrimSpace(conversationID)), url.PathEscape(strings.TrimSpace(shareID)))
+	req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return domain.ConversationShare{}, err
+	}
+	addAuthHeader(req, token)
+	addRequestIDHeader(req, requestID)
+
+	var resp domain.ConversationShare
+	if err := c.do(req, &resp); err != nil {
+		return domain.ConversationShare{}, err
+	}
+	return resp, nil
+}
+
+// GetSharedConversation fetches the public view behind a share token; no
+// user token is sent.
+func (c *Client) GetSharedConversation(requestID, shareToken string) (domain.SharedConversation, error) {
+	endpoint := fmt.Sprintf("%s/shared/%s", c.baseURL, url.PathEscape(strings.TrimSpace(shareToken)))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.SharedConversation{}, err
+	}
+	addRequestIDHeader(req, requestID)
+
+	var resp domain.SharedConversation
+	if err := c.do(req, &resp); err != nil {
+		return domain.SharedConversation{}, err
+	}
+	return resp, nil
+}
+
 func (c *Client) do(req *http.Request, out any) error {
+	_, err := c.doWithHeader(req, out)
+	return err
+}
+
+// doWithHeader is do, also returning the response headers.
+func (c *Client) doWithHeader(req *http.Request, out any) (http.Header, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
@@ -235,15 +505,15 @@ func (c *Client) do(req *http.Request, out any) error {
 		if msg == "" {
 			msg = resp.Status
 		}
-		return &APIError{Status: resp.StatusCode, Message: msg, Code: strings.TrimSpace(errResp.Code)}
+		return nil, &APIError{Status: resp.StatusCode, Message: msg, Code: strings.TrimSpace(errResp.Code)}
 	}
 	if out == nil {
-		return nil
+		return resp.Header, nil
 	}
 	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return resp.Header, nil
 }
 
 func addAuthHeader(req *http.Request, token string) {
@@ -261,12 +531,33 @@ func addRequestIDHeader(req *http.Request, requestID string) {
 }
 
 type chatRequest struct {
-	ConversationID string `json:"conversationId,omitempty"`
-	BookID         string `json:"bookId"`
-	Question       string `json:"question"`
-	Debug          bool   `json:"debug,omitempty"`
+	ConversationID string            `json:"conversationId,omitempty"`
+	BookID         string            `json:"bookId"`
+	Scope          *domain.ChatScope `json:"scope,omitempty"`
+	Question       string            `json:"question"`
+	Language       string            `json:"language,omitempty"`
+	NoCache        bool              `json:"noCache,omitempty"`
+	Debug          bool              `json:"debug,omitempty"`
 }
 
 type renameConversationRequest struct {
 	Title string `json:"title"`
 }
+
+type branchMessageRequest struct {
+	Question string `json:"question,omitempty"`
+	Language string `json:"language,omitempty"`
+	NoCache  bool   `json:"noCache,omitempty"`
+	Debug    bool   `json:"debug,omitempty"`
+}
+
+type switchBranchRequest struct {
+	MessageID string `json:"messageId"`
+}
+
+// AnswerFeedbackRequest is a thumbs-up/down judgment of an answer.
+type AnswerFeedbackRequest struct {
+	Rating            string   `json:"rating"`
+	Reason            string   `json:"reason,omitempty"`
+	CorrectedChunkIDs []string `json:"correctedChunkIds,omitempty"`
+}
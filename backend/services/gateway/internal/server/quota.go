@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"onebookai/internal/quota"
+	"onebookai/internal/util"
+	"onebookai/pkg/domain"
+	"onebookai/services/gateway/internal/authclient"
+)
+
+// chatQuota is a question reservation taken before forwarding to chat. It is
+// either settled with the answer's token usage or released.
+type chatQuota struct {
+	userID   string
+	reserved bool
+}
+
+// reserveChatQuota checks the caller's plan and reserves one question. It
+// writes a 429 QUOTA_EXCEEDED (or 503 when counters are unreachable) and
+// returns false when the question must not be asked. Admins and users on the
+// unlimited plan are not counted.
+func (s *Server) reserveChatQuota(w http.ResponseWriter, r *http.Request, ctx authContext) (chatQuota, bool) {
+	if ctx.User.Role == domain.RoleAdmin {
+		return chatQuota{}, true
+	}
+	usage, err := s.auth.MeUsage(util.RequestIDFromRequest(r), ctx.AccessToken)
+	if err != nil {
+		writeAuthError(w, r, err)
+		return chatQuota{}, false
+	}
+	if usage.Limits.Plan == quota.UnlimitedPlan {
+		return chatQuota{}, true
+	}
+	if usage.Limits.MonthlyTokens > 0 && usage.TokensThisMonth >= usage.Limits.MonthlyTokens {
+		writeQuotaExceeded(w, r, "monthly token quota exceeded", usage.TokensResetAt)
+		return chatQuota{}, false
+	}
+	ok, err := s.quota.ReserveQuestion(r.Context(), ctx.User.ID, usage.Limits.DailyQuestions)
+	if err != nil {
+		util.LoggerFromContext(r.Context()).Error("quota_reserve_failed", "user_id", ctx.User.ID, "err", err)
+		writeErrorWithCode(w, r, http.StatusServiceUnavailable, "quota service unavailable", "QUOTA_UNAVAILABLE")
+		return chatQuota{}, false
+	}
+	if !ok {
+		writeQuotaExceeded(w, r, "daily question quota exceeded", usage.QuestionsResetAt)
+		return chatQuota{}, false
+	}
+	return chatQuota{userID: ctx.User.ID, reserved: true}, true
+}
+
+// settleChatQuota records the tokens of an answered question, or gives the
+// reservation back when no new answer was produced (errors and replays).
+func (s *Server) settleChatQuota(r *http.Request, q chatQuota, answered bool, usage *domain.LLMUsage) {
+	if !q.reserved {
+		return
+	}
+	ctx := context.WithoutCancel(r.Context())
+	var err error
+	if answered {
+		if usage != nil {
+			err = s.quota.AddTokens(ctx, q.userID, int64(usage.TotalTokens))
+		}
+	} else {
+		err = s.quota.ReleaseQuestion(ctx, q.userID)
+	}
+	if err != nil {
+		util.LoggerFromContext(r.Context()).Warn("quota_settle_failed", "user_id", q.userID, "answered", answered, "err", err)
+	}
+}
+
+func (s *Server) handleMeUsage(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	usage, err := s.auth.MeUsage(util.RequestIDFromRequest(r), ctx.AccessToken)
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
+
+func (s *Server) handleAdminUserQuota(w http.ResponseWriter, r *http.Request, ctx authContext, id string) {
+	requestID := util.RequestIDFromRequest(r)
+	if r.Method == http.MethodGet {
+		usage, err := s.auth.AdminGetUserQuota(requestID, ctx.AccessToken, id)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, usage)
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		methodNotAllowed(w, r)
+		return
+	}
+	var req authclient.AdminUserQuotaRequest
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid request payload", "ADMIN_INVALID_REQUEST")
+			return
+		}
+	}
+	before, err := s.auth.AdminGetUserQuota(requestID, ctx.AccessToken, id)
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	var updated domain.UserUsage
+	if r.Method == http.MethodPut {
+		updated, err = s.auth.AdminSetUserQuota(requestID, ctx.AccessToken, id, req)
+	} else {
+		updated, err = s.auth.AdminClearUserQuota(requestID, ctx.AccessToken, id)
+	}
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	if err := s.writeAdminAuditLog(r, ctx, authclient.AdminAuditLogCreateRequest{
+		Action:     "admin.user.quota.update",
+		TargetType: "user",
+		TargetID:   id,
+		Before:     toMap(before.Override),
+		After:      toMap(updated.Override),
+		RequestID:  requestID,
+		IP:         util.ClientIP(r, s.trustedProxies),
+		UserAgent:  strings.TrimSpace(r.UserAgent()),
+	}); err != nil {
+		writeErrorWithCode(w, r, http.StatusInternalServerError, "failed to write admin audit log", "ADMIN_AUDIT_WRITE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func writeQuotaExceeded(w http.ResponseWriter, r *http.Request, msg string, resetAt time.Time) {
+	if wait := time.Until(resetAt); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+	writeErrorWithCode(w, r, http.StatusTooManyRequests, msg, "QUOTA_EXCEEDED")
+}
+
+// copySSEWithFinal relays a chat event stream line by line and returns the
+// answer carried by its "final" event, if one arrived.
+func copySSEWithFinal(dst io.Writer, src io.Reader) (*domain.Answer, error) {
+	reader := bufio.NewReader(src)
+	var (
+		event string
+		final *domain.Answer
+	)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, err := dst.Write(line); err != nil {
+				return final, err
+			}
+			trimmed := bytes.TrimRight(line, "\r\n")
+			switch {
+			case bytes.HasPrefix(trimmed, []byte("event:")):
+				event = strings.TrimSpace(string(trimmed[len("event:"):]))
+			case bytes.HasPrefix(trimmed, []byte("data:")) && event == "final":
+				var answer domain.Answer
+				if err := json.Unmarshal(bytes.TrimSpace(trimmed[len("data:"):]), &answer); err == nil {
+					final = &answer
+				}
+			case len(trimmed) == 0:
+				event = ""
+			}
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return final, nil
+			}
+			return final, readErr
+		}
+	}
+}
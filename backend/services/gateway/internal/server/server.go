@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"onebookai/internal/quota"
 	"onebookai/internal/ratelimit"
 	"onebookai/internal/usertoken"
 	"onebookai/internal/util"
@@ -95,6 +97,7 @@ type Server struct {
 	trustedProxies    *util.TrustedProxies
 	oauth             oauthConfig
 	oauthStates       *oauthStateStore
+	quota             *quota.Counter
 }
 
 // New constructs the server with routes configured.
@@ -140,6 +143,10 @@ func New(cfg Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	quotaCounter, err := quota.NewRedisCounter(cfg.RedisAddr, cfg.RedisPassword, "")
+	if err != nil {
+		return nil, fmt.Errorf("init quota counter: %w", err)
+	}
 	trustedProxies, err := util.NewTrustedProxies(cfg.TrustedProxyCIDRs)
 	if err != nil {
 		return nil, fmt.Errorf("parse trustedProxyCIDRs: %w", err)
@@ -191,6 +198,7 @@ func New(cfg Config) (*Server, error) {
 		trustedProxies:    trustedProxies,
 		oauth:             newOAuthConfig(cfg.OAuthAppBaseURL, oauthProviders),
 		oauthStates:       newOAuthStateStore(cfg.RedisAddr, cfg.RedisPassword, cfg.OAuthStateRedisPrefix),
+		quota:             quotaCounter,
 	}
 	s.routes()
 	return s, nil
@@ -227,27 +235,36 @@ func (s *Server) routes() {
 	s.mux.Handle("/api/users/me", s.authenticated(s.handleMe))
 	s.mux.Handle("/api/users/me/avatar", s.authenticated(s.handleMeAvatar))
 	s.mux.Handle("/api/users/me/password", s.authenticated(s.handleChangePassword))
+	s.mux.Handle("/api/users/me/usage", s.authenticated(s.handleMeUsage))
 	s.mux.Handle("/api/users/", s.authenticated(s.handleUserAvatar))
 
 	// books & chats (auth required)
 	s.mux.Handle("/api/books", s.authenticated(s.handleBooks))
 	s.mux.Handle("/api/books/", s.authenticated(s.handleBookByID))
 	s.mux.Handle("/api/chats", s.authenticated(s.handleChats))
+	s.mux.Handle("/api/search", s.authenticated(s.handleSearch))
 	s.mux.Handle("/api/conversations", s.authenticated(s.handleConversations))
 	s.mux.Handle("/api/conversations/", s.authenticated(s.handleConversationByID))
+	s.mux.Handle("/api/conversations/search", s.authenticated(s.handleConversationSearch))
+	s.mux.HandleFunc("/api/shared/", s.handleSharedConversation)
 
 	// admin
 	s.mux.Handle("/api/admin/users", s.adminOnly(s.handleAdminUsers))
 	s.mux.Handle("/api/admin/users/", s.adminOnly(s.handleAdminUserByID))
 	s.mux.Handle("/api/admin/books", s.adminOnly(s.handleAdminBooks))
 	s.mux.Handle("/api/admin/books/", s.adminOnly(s.handleAdminBookByID))
+	s.mux.Handle("/api/admin/index-consistency", s.adminOnly(s.handleAdminIndexConsistency))
 	s.mux.Handle("/api/admin/audit-logs", s.adminOnly(s.handleAdminAuditLogs))
 	s.mux.Handle("/api/admin/overview", s.adminOnly(s.handleAdminOverview))
+	s.mux.Handle("/api/admin/llm-usage", s.adminOnly(s.handleAdminLLMUsage))
 	s.mux.Handle("/api/admin/evals/overview", s.adminOnly(s.handleAdminEvalOverview))
 	s.mux.Handle("/api/admin/evals/datasets", s.adminOnly(s.handleAdminEvalDatasets))
 	s.mux.Handle("/api/admin/evals/datasets/", s.adminOnly(s.handleAdminEvalDatasetByID))
 	s.mux.Handle("/api/admin/evals/runs", s.adminOnly(s.handleAdminEvalRuns))
 	s.mux.Handle("/api/admin/evals/runs/", s.adminOnly(s.handleAdminEvalRunByID))
+	s.mux.Handle("/api/admin/evals/feedback", s.adminOnly(s.handleAdminAnswerFeedback))
+	s.mux.Handle("/api/admin/evals/feedback/convert", s.adminOnly(s.handleAdminConvertAnswerFeedback))
+	s.mux.Handle("/api/admin/evals/feedback/", s.adminOnly(s.handleAdminAnswerFeedbackByID))
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -703,11 +720,11 @@ func (s *Server) handleMe(w http.ResponseWriter, r *http.Request, ctx authContex
 			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid request payload", "AUTH_INVALID_REQUEST")
 			return
 		}
-		if req.Email == nil && req.DisplayName == nil {
-			writeErrorWithCode(w, r, http.StatusBadRequest, "email or displayName is required", "AUTH_PROFILE_FIELDS_REQUIRED")
+		if req.Email == nil && req.DisplayName == nil && req.AnswerLanguage == nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "email, displayName or answerLanguage is required", "AUTH_PROFILE_FIELDS_REQUIRED")
 			return
 		}
-		updated, err := s.auth.UpdateMe(util.RequestIDFromRequest(r), ctx.AccessToken, req.Email, req.DisplayName)
+		updated, err := s.auth.UpdateMe(util.RequestIDFromRequest(r), ctx.AccessToken, req.Email, req.DisplayName, req.AnswerLanguage)
 		if err != nil {
 			writeAuthError(w, r, err)
 			return
@@ -803,7 +820,8 @@ func (s *Server) handleBooks(w http.ResponseWriter, r *http.Request, ctx authCon
 	}
 }
 
-// /api/books/{id} or /api/books/{id}/download or /api/books/{id}/content
+// /api/books/{id}, /api/books/{id}/download, /api/books/{id}/content or
+// /api/books/{id}/citations/{chunkId}
 func (s *Server) handleBookByID(w http.ResponseWriter, r *http.Request, ctx authContext) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/books/")
 	parts := strings.SplitN(path, "/", 2)
@@ -822,6 +840,10 @@ func (s *Server) handleBookByID(w http.ResponseWriter, r *http.Request, ctx auth
 		s.handleBookContent(w, r, ctx.AccessToken, id)
 		return
 	}
+	if len(parts) == 2 && strings.HasPrefix(parts[1], "citations/") {
+		s.handleResolveCitation(w, r, ctx.AccessToken, id, strings.TrimPrefix(parts[1], "citations/"))
+		return
+	}
 	if len(parts) == 2 {
 		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "BOOK_NOT_FOUND")
 		return
@@ -876,6 +898,25 @@ func (s *Server) handleDownloadBook(w http.ResponseWriter, r *http.Request, toke
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// handleResolveCitation returns the reader coordinates of a cited chunk so
+// the reader can open the page or spine item and highlight the passage.
+func (s *Server) handleResolveCitation(w http.ResponseWriter, r *http.Request, token, id, chunkID string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	if strings.TrimSpace(chunkID) == "" || strings.Contains(chunkID, "/") {
+		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "BOOK_NOT_FOUND")
+		return
+	}
+	location, err := s.books.ResolveCitation(util.RequestIDFromRequest(r), token, id, chunkID)
+	if err != nil {
+		writeBookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, location)
+}
+
 // handleBookContent proxies the original book file through the gateway so the
 // reader can use a stable same-origin URL and browser range requests.
 func (s *Server) handleBookContent(w http.ResponseWriter, r *http.Request, token, id string) {
@@ -990,12 +1031,16 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request, ctx authCon
 		writeErrorWithCode(w, r, http.StatusBadRequest, "invalid request payload", "CHAT_INVALID_REQUEST")
 		return
 	}
-	if req.BookID == "" {
+	if req.BookID == "" && req.Scope == nil {
 		writeErrorWithCode(w, r, http.StatusBadRequest, "book ID is required", "CHAT_BOOK_ID_REQUIRED")
 		return
 	}
+	reservation, ok := s.reserveChatQuota(w, r, ctx)
+	if !ok {
+		return
+	}
 	if wantsSSE(r) {
-		s.streamChatAnswer(w, r, ctx, req)
+		s.streamChatAnswer(w, r, ctx, req, reservation)
 		return
 	}
 	ans, replayed, err := s.chat.AskQuestion(
@@ -1004,20 +1049,25 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request, ctx authCon
 		util.IdempotencyKeyFromRequest(r),
 		req.ConversationID,
 		req.BookID,
+		req.Scope,
 		req.Question,
+		req.Language,
+		req.NoCache,
 		req.Debug && ctx.User.Role == domain.RoleAdmin,
 	)
 	if err != nil {
+		s.settleChatQuota(r, reservation, false, nil)
 		writeChatError(w, r, err)
 		return
 	}
+	s.settleChatQuota(r, reservation, !replayed, ans.Usage)
 	if replayed {
 		w.Header().Set("Idempotency-Replayed", "true")
 	}
 	writeJSON(w, http.StatusOK, ans)
 }
 
-func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, ctx authContext, req chatRequest) {
+func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, ctx authContext, req chatRequest, reservation chatQuota) {
 	stream, err := s.chat.StreamQuestion(
 		r.Context(),
 		util.RequestIDFromRequest(r),
@@ -1025,10 +1075,14 @@ func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, ctx au
 		util.IdempotencyKeyFromRequest(r),
 		req.ConversationID,
 		req.BookID,
+		req.Scope,
 		req.Question,
+		req.Language,
+		req.NoCache,
 		req.Debug && ctx.User.Role == domain.RoleAdmin,
 	)
 	if err != nil {
+		s.settleChatQuota(r, reservation, false, nil)
 		writeChatError(w, r, err)
 		return
 	}
@@ -1036,6 +1090,7 @@ func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, ctx au
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
+		s.settleChatQuota(r, reservation, false, nil)
 		writeErrorWithCode(w, r, http.StatusInternalServerError, "streaming unsupported", "SYSTEM_STREAM_UNSUPPORTED")
 		return
 	}
@@ -1048,7 +1103,82 @@ func (s *Server) streamChatAnswer(w http.ResponseWriter, r *http.Request, ctx au
 	}
 	w.WriteHeader(http.StatusOK)
 	flusher.Flush()
-	_, _ = io.Copy(flushWriter{ResponseWriter: w, Flusher: flusher}, stream.Body)
+	final, _ := copySSEWithFinal(flushWriter{ResponseWriter: w, Flusher: flusher}, stream.Body)
+	if final == nil || stream.Replayed {
+		s.settleChatQuota(r, reservation, false, nil)
+		return
+	}
+	s.settleChatQuota(r, reservation, true, final.Usage)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	source := r.URL.Query()
+	if strings.TrimSpace(source.Get("q")) == "" {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "q is required", "SEARCH_QUERY_REQUIRED")
+		return
+	}
+	query := url.Values{}
+	for _, key := range []string{"q", "bookIds", "tags", "page", "pageSize", "rerank"} {
+		if value := strings.TrimSpace(source.Get(key)); value != "" {
+			query.Set(key, value)
+		}
+	}
+	result, err := s.chat.SearchPassages(util.RequestIDFromRequest(r), ctx.AccessToken, query)
+	if err != nil {
+		writeChatError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleSharedConversation serves a share link's read-only view without a
+// session; the share token is the credential.
+func (s *Server) handleSharedConversation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/shared/"), "/")
+	if token == "" || strings.Contains(token, "/") {
+		writeErrorWithCode(w, r, http.StatusNotFound, "share not found", "CHAT_SHARE_NOT_FOUND")
+		return
+	}
+	view, err := s.chat.GetSharedConversation(util.RequestIDFromRequest(r), token)
+	if err != nil {
+		writeChatError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (s *Server) handleConversationSearch(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	source := r.URL.Query()
+	if strings.TrimSpace(source.Get("q")) == "" {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "q is required", "SEARCH_QUERY_REQUIRED")
+		return
+	}
+	query := url.Values{}
+	for _, key := range []string{"q", "bookIds", "from", "to", "page", "pageSize"} {
+		if value := strings.TrimSpace(source.Get(key)); value != "" {
+			query.Set(key, value)
+		}
+	}
+	result, err := s.chat.SearchConversations(util.RequestIDFromRequest(r), ctx.AccessToken, query)
+	if err != nil {
+		writeChatError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
 }
 
 func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request, ctx authContext) {
@@ -1106,29 +1236,185 @@ func (s *Server) handleConversationByID(w http.ResponseWriter, r *http.Request,
 		}
 		return
 	}
-	if len(parts) != 2 || parts[1] != "messages" {
-		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "CHAT_CONVERSATION_NOT_FOUND")
+	conversationID := strings.TrimSpace(parts[0])
+	if conversationID == "" {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "conversation ID is required", "CHAT_CONVERSATION_ID_REQUIRED")
 		return
 	}
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w, r)
+	switch {
+	case len(parts) == 2 && parts[1] == "shares":
+		switch r.Method {
+		case http.MethodGet:
+			items, err := s.chat.ListConversationShares(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID)
+			if err != nil {
+				writeChatError(w, r, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"items": items,
+				"count": len(items),
+			})
+		case http.MethodPost:
+			var req chatclient.CreateShareRequest
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "CHAT_INVALID_REQUEST")
+				return
+			}
+			share, err := s.chat.CreateConversationShare(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, req)
+			if err != nil {
+				writeChatError(w, r, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, share)
+		default:
+			methodNotAllowed(w, r)
+		}
+	case len(parts) == 3 && parts[1] == "shares":
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w, r)
+			return
+		}
+		share, err := s.chat.RevokeConversationShare(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, parts[2])
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, share)
+	case len(parts) == 2 && parts[1] == "export":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r)
+			return
+		}
+		export, err := s.chat.ExportConversation(r.Context(), util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, r.URL.Query().Get("format"))
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		defer export.Body.Close()
+		w.Header().Set("Content-Type", export.ContentType)
+		if export.ContentDisposition != "" {
+			w.Header().Set("Content-Disposition", export.ContentDisposition)
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.Copy(w, export.Body)
+	case len(parts) == 2 && parts[1] == "messages":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r)
+			return
+		}
+		limit := parsePositiveIntWithMax(r.URL.Query().Get("limit"), 200, 500)
+		items, err := s.chat.ListConversationMessages(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, limit)
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+		})
+	case len(parts) == 4 && parts[1] == "messages" && (parts[3] == "regenerate" || parts[3] == "edit"):
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r)
+			return
+		}
+		s.handleBranchMessage(w, r, ctx, conversationID, strings.TrimSpace(parts[2]), parts[3])
+	case len(parts) == 4 && parts[1] == "messages" && parts[3] == "feedback":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r)
+			return
+		}
+		var req chatclient.AnswerFeedbackRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "CHAT_INVALID_REQUEST")
+			return
+		}
+		feedback, err := s.chat.SubmitAnswerFeedback(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, strings.TrimSpace(parts[2]), req)
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, feedback)
+	case len(parts) == 2 && parts[1] == "branches":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r)
+			return
+		}
+		items, err := s.chat.ListConversationBranches(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID)
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+		})
+	case len(parts) == 2 && parts[1] == "active-branch":
+		if r.Method != http.MethodPut {
+			methodNotAllowed(w, r)
+			return
+		}
+		var req switchBranchRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "CHAT_INVALID_REQUEST")
+			return
+		}
+		if strings.TrimSpace(req.MessageID) == "" {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "messageId is required", "CHAT_MESSAGE_ID_REQUIRED")
+			return
+		}
+		conversation, err := s.chat.SwitchConversationBranch(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, req.MessageID)
+		if err != nil {
+			writeChatError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, conversation)
+	default:
+		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "CHAT_CONVERSATION_NOT_FOUND")
+	}
+}
+
+// handleBranchMessage regenerates an answer or answers an edited question.
+// Both generate a new answer, so they count against the chat quota like a
+// new question.
+func (s *Server) handleBranchMessage(w http.ResponseWriter, r *http.Request, ctx authContext, conversationID, messageID, action string) {
+	var req branchMessageRequest
+	if action == "edit" || r.ContentLength > 0 {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "CHAT_INVALID_REQUEST")
+			return
+		}
+	}
+	if action == "edit" && strings.TrimSpace(req.Question) == "" {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "question is required", "CHAT_QUESTION_REQUIRED")
 		return
 	}
-	conversationID := strings.TrimSpace(parts[0])
-	if conversationID == "" {
-		writeErrorWithCode(w, r, http.StatusBadRequest, "conversation ID is required", "CHAT_CONVERSATION_ID_REQUIRED")
+	reservation, ok := s.reserveChatQuota(w, r, ctx)
+	if !ok {
 		return
 	}
-	limit := parsePositiveIntWithMax(r.URL.Query().Get("limit"), 200, 500)
-	items, err := s.chat.ListConversationMessages(util.RequestIDFromRequest(r), ctx.AccessToken, conversationID, limit)
+	debug := req.Debug && ctx.User.Role == domain.RoleAdmin
+	idempotencyKey := util.IdempotencyKeyFromRequest(r)
+	var (
+		ans      domain.Answer
+		replayed bool
+		err      error
+	)
+	if action == "edit" {
+		ans, replayed, err = s.chat.EditQuestion(util.RequestIDFromRequest(r), ctx.AccessToken, idempotencyKey, conversationID, messageID, req.Question, req.Language, req.NoCache, debug)
+	} else {
+		ans, replayed, err = s.chat.RegenerateAnswer(util.RequestIDFromRequest(r), ctx.AccessToken, idempotencyKey, conversationID, messageID, req.Language, debug)
+	}
 	if err != nil {
+		s.settleChatQuota(r, reservation, false, nil)
 		writeChatError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items": items,
-		"count": len(items),
-	})
+	s.settleChatQuota(r, reservation, !replayed, ans.Usage)
+	if replayed {
+		w.Header().Set("Idempotency-Replayed", "true")
+	}
+	writeJSON(w, http.StatusOK, ans)
 }
 
 // admin handlers
@@ -1198,6 +1484,10 @@ func (s *Server) handleAdminUserByID(w http.ResponseWriter, r *http.Request, ctx
 		writeJSON(w, http.StatusOK, item)
 		return
 	}
+	if action == "quota" {
+		s.handleAdminUserQuota(w, r, ctx, id)
+		return
+	}
 	if r.Method == http.MethodDelete && action == "" {
 		before, err := s.auth.AdminGetUser(util.RequestIDFromRequest(r), ctx.AccessToken, id)
 		if err != nil {
@@ -1531,6 +1821,19 @@ func (s *Server) handleAdminBookByID(w http.ResponseWriter, r *http.Request, ctx
 	methodNotAllowed(w, r)
 }
 
+func (s *Server) handleAdminIndexConsistency(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	report, err := s.books.GetIndexConsistencyReport(util.RequestIDFromRequest(r), ctx.AccessToken)
+	if err != nil {
+		writeBookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
 func (s *Server) handleAdminAuditLogs(w http.ResponseWriter, r *http.Request, ctx authContext) {
 	if r.Method != http.MethodGet {
 		methodNotAllowed(w, r)
@@ -1571,6 +1874,25 @@ func (s *Server) handleAdminOverview(w http.ResponseWriter, r *http.Request, ctx
 	writeJSON(w, http.StatusOK, overview)
 }
 
+func (s *Server) handleAdminLLMUsage(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	query := url.Values{}
+	for _, key := range []string{"from", "to", "userId", "bookId"} {
+		if v := strings.TrimSpace(r.URL.Query().Get(key)); v != "" {
+			query.Set(key, v)
+		}
+	}
+	report, err := s.auth.AdminLLMUsage(util.RequestIDFromRequest(r), ctx.AccessToken, query)
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
 func (s *Server) writeAdminAuditLog(r *http.Request, ctx authContext, req authclient.AdminAuditLogCreateRequest) error {
 	_, err := s.auth.AdminCreateAuditLog(util.RequestIDFromRequest(r), ctx.AccessToken, req)
 	return err
@@ -1667,16 +1989,30 @@ func isAllowedValue(value string, allowed ...string) bool {
 }
 
 type chatRequest struct {
-	ConversationID string `json:"conversationId,omitempty"`
-	BookID         string `json:"bookId"`
-	Question       string `json:"question"`
-	Debug          bool   `json:"debug,omitempty"`
+	ConversationID string            `json:"conversationId,omitempty"`
+	BookID         string            `json:"bookId"`
+	Scope          *domain.ChatScope `json:"scope,omitempty"`
+	Question       string            `json:"question"`
+	Language       string            `json:"language,omitempty"`
+	NoCache        bool              `json:"noCache,omitempty"`
+	Debug          bool              `json:"debug,omitempty"`
 }
 
 type renameConversationRequest struct {
 	Title string `json:"title"`
 }
 
+type branchMessageRequest struct {
+	Question string `json:"question"`
+	Language string `json:"language,omitempty"`
+	NoCache  bool   `json:"noCache,omitempty"`
+	Debug    bool   `json:"debug,omitempty"`
+}
+
+type switchBranchRequest struct {
+	MessageID string `json:"messageId"`
+}
+
 type authRequest struct {
 	Email      string `json:"email"`
 	Identifier string `json:"identifier"`
@@ -1741,8 +2077,9 @@ type signupCompleteRequest struct {
 }
 
 type updateMeRequest struct {
-	Email       *string `json:"email"`
-	DisplayName *string `json:"displayName"`
+	Email          *string `json:"email"`
+	DisplayName    *string `json:"displayName"`
+	AnswerLanguage *string `json:"answerLanguage"`
 }
 
 type changePasswordRequest struct {
@@ -153,6 +153,58 @@ func TestAdminRouteUsesAuthoritativeRole(t *testing.T) {
 	}
 }
 
+func TestUpdateMeForwardsAnswerLanguage(t *testing.T) {
+	verifier, signer, err := newJWKSVerifier(t)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	token := mustSignUserToken(t, signer, "user-1")
+
+	var patched map[string]string
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/me" {
+			http.NotFound(w, r)
+			return
+		}
+		user := domain.User{ID: "user-1", Email: "u@example.com", Role: domain.RoleUser, Status: domain.StatusActive}
+		if r.Method == http.MethodPatch {
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			user.AnswerLanguage = patched["answerLanguage"]
+		}
+		_ = json.NewEncoder(w).Encode(user)
+	}))
+	defer authSrv.Close()
+	redis := miniredis.RunT(t)
+
+	gw, err := New(Config{
+		Auth:          authclient.NewClient(authSrv.URL),
+		TokenVerifier: verifier,
+		RedisAddr:     redis.Addr(),
+	})
+	if err != nil {
+		t.Fatalf("new gateway server: %v", err)
+	}
+	gwSrv := httptest.NewServer(gw.Router())
+	defer gwSrv.Close()
+
+	req, _ := http.NewRequest(http.MethodPatch, gwSrv.URL+"/api/users/me", strings.NewReader(`{"answerLanguage":"en"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: defaultAccessCookieName, Value: token})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("update me: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update me expected 200, got %d", resp.StatusCode)
+	}
+	var user domain.User
+	_ = json.NewDecoder(resp.Body).Decode(&user)
+	if patched["answerLanguage"] != "en" || user.AnswerLanguage != "en" {
+		t.Fatalf("expected answerLanguage to reach auth and come back, sent %v got %+v", patched, user)
+	}
+}
+
 func newJWKSVerifier(t *testing.T) (*usertoken.Verifier, *rsa.PrivateKey, error) {
 	t.Helper()
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"onebookai/pkg/domain"
+)
+
+func TestRegenerateAnswerProxiesAndCountsQuota(t *testing.T) {
+	var chatPath, chatMethod string
+	gwSrv, redis := newChatTestGateway(t, 5, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chatPath, chatMethod = r.URL.Path, r.Method
+		_ = json.NewEncoder(w).Encode(domain.Answer{Answer: "again", MessageID: "m-2", Usage: &domain.LLMUsage{TotalTokens: 25}})
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/conversations/c-1/messages/m-1/regenerate", nil)
+	resp := sendAsUser(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("regenerate expected 200, got %d", resp.StatusCode)
+	}
+	var ans domain.Answer
+	_ = json.NewDecoder(resp.Body).Decode(&ans)
+	if ans.MessageID != "m-2" {
+		t.Fatalf("unexpected answer: %+v", ans)
+	}
+	if chatMethod != http.MethodPost || chatPath != "/conversations/c-1/messages/m-1/regenerate" {
+		t.Fatalf("unexpected chat call %s %s", chatMethod, chatPath)
+	}
+	if got := recordedQuestions(redis); got != "1" {
+		t.Fatalf("expected the regenerate to count one question, got %q", got)
+	}
+	if got := recordedTokens(redis); got != "25" {
+		t.Fatalf("expected 25 tokens recorded, got %q", got)
+	}
+}
+
+func TestFailedRegenerateReleasesReservedQuestion(t *testing.T) {
+	gwSrv, redis := newChatTestGateway(t, 5, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "generation failed"})
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/conversations/c-1/messages/m-1/regenerate", nil)
+	resp := sendAsUser(t, req)
+	resp.Body.Close()
+	if resp.StatusCode < http.StatusBadRequest {
+		t.Fatalf("failed regenerate expected an error status, got %d", resp.StatusCode)
+	}
+	if got := recordedQuestions(redis); got != "0" {
+		t.Fatalf("expected the reserved question to be released, got %q", got)
+	}
+	if got := recordedTokens(redis); got != "" {
+		t.Fatalf("failed regenerate must not record tokens, got %q", got)
+	}
+}
+
+func TestReplayedRegenerateForwardsKeyWithoutCountingQuota(t *testing.T) {
+	var forwardedKey string
+	gwSrv, redis := newChatTestGateway(t, 5, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedKey = r.Header.Get("Idempotency-Key")
+		w.Header().Set("Idempotency-Replayed", "true")
+		_ = json.NewEncoder(w).Encode(domain.Answer{Answer: "again", MessageID: "m-2", Usage: &domain.LLMUsage{TotalTokens: 25}})
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/conversations/c-1/messages/m-1/regenerate", nil)
+	req.Header.Set("Idempotency-Key", "regen-1")
+	resp := sendAsUser(t, req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotency-Replayed") != "true" {
+		t.Fatalf("replayed regenerate expected 200 marked replayed, got %d %q", resp.StatusCode, resp.Header.Get("Idempotency-Replayed"))
+	}
+	if forwardedKey != "regen-1" {
+		t.Fatalf("expected the idempotency key to reach chat, got %q", forwardedKey)
+	}
+	if got := recordedQuestions(redis); got != "0" {
+		t.Fatalf("replayed regenerate must not count a question, got %q", got)
+	}
+	if got := recordedTokens(redis); got != "" {
+		t.Fatalf("replayed regenerate must not record tokens, got %q", got)
+	}
+}
@@ -278,3 +278,95 @@ func (s *Server) handleAdminEvalRunByID(w http.ResponseWriter, r *http.Request,
 		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "ADMIN_EVAL_RUN_NOT_FOUND")
 	}
 }
+
+func (s *Server) handleAdminAnswerFeedback(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	page, pageSize, err := parseAdminPageParams(r)
+	if err != nil {
+		writeErrorWithCode(w, r, http.StatusBadRequest, err.Error(), "ADMIN_PAGINATION_INVALID")
+		return
+	}
+	resp, err := s.auth.AdminListAnswerFeedback(util.RequestIDFromRequest(r), ctx.AccessToken, authclient.AdminListAnswerFeedbackOptions{
+		Status:   strings.TrimSpace(r.URL.Query().Get("status")),
+		Rating:   strings.TrimSpace(r.URL.Query().Get("rating")),
+		BookID:   strings.TrimSpace(r.URL.Query().Get("bookId")),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAdminAnswerFeedbackByID(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/evals/feedback/")
+	id := strings.Trim(path, "/")
+	if id == "" || strings.Contains(id, "/") {
+		writeErrorWithCode(w, r, http.StatusNotFound, "not found", "ADMIN_EVAL_FEEDBACK_NOT_FOUND")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		item, err := s.auth.AdminGetAnswerFeedback(util.RequestIDFromRequest(r), ctx.AccessToken, id)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodPatch:
+		var req authclient.AdminAnswerFeedbackReviewRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "ADMIN_EVAL_FEEDBACK_INVALID")
+			return
+		}
+		item, err := s.auth.AdminReviewAnswerFeedback(util.RequestIDFromRequest(r), ctx.AccessToken, id, req)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		_ = s.writeAdminAuditLog(r, ctx, authclient.AdminAuditLogCreateRequest{
+			Action:     "admin.eval.feedback.review",
+			TargetType: "answer_feedback",
+			TargetID:   item.ID,
+			After:      map[string]any{"status": item.Status, "correctedChunkIds": item.CorrectedChunkIDs},
+			RequestID:  util.RequestIDFromRequest(r),
+		})
+		writeJSON(w, http.StatusOK, item)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminConvertAnswerFeedback(w http.ResponseWriter, r *http.Request, ctx authContext) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+	var req authclient.AdminAnswerFeedbackConvertRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "invalid JSON body", "ADMIN_EVAL_FEEDBACK_INVALID")
+		return
+	}
+	if len(req.FeedbackIDs) == 0 {
+		writeErrorWithCode(w, r, http.StatusBadRequest, "feedbackIds is required", "ADMIN_EVAL_FEEDBACK_INVALID")
+		return
+	}
+	item, err := s.auth.AdminConvertAnswerFeedback(util.RequestIDFromRequest(r), ctx.AccessToken, req)
+	if err != nil {
+		writeAuthError(w, r, err)
+		return
+	}
+	_ = s.writeAdminAuditLog(r, ctx, authclient.AdminAuditLogCreateRequest{
+		Action:     "admin.eval.feedback.convert",
+		TargetType: "eval_dataset",
+		TargetID:   item.ID,
+		After:      map[string]any{"name": item.Name, "version": item.Version, "feedbackIds": req.FeedbackIDs},
+		RequestID:  util.RequestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusCreated, item)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"onebookai/pkg/domain"
+	"onebookai/services/gateway/internal/authclient"
+)
+
+func TestConvertAnswerFeedbackProxiesAndAudits(t *testing.T) {
+	verifier, signer, err := newJWKSVerifier(t)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	token := mustSignUserToken(t, signer, "admin-1")
+
+	var convertReq authclient.AdminAnswerFeedbackConvertRequest
+	var audit authclient.AdminAuditLogCreateRequest
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/me":
+			_ = json.NewEncoder(w).Encode(domain.User{ID: "admin-1", Role: domain.RoleAdmin, Status: domain.StatusActive})
+		case "/auth/admin/evals/feedback/convert":
+			_ = json.NewDecoder(r.Body).Decode(&convertReq)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(domain.EvalDataset{ID: "ds-2", Name: "golden", Version: 2})
+		case "/auth/admin/audit-logs":
+			_ = json.NewDecoder(r.Body).Decode(&audit)
+			_ = json.NewEncoder(w).Encode(domain.AdminAuditLog{ID: "log-1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer authSrv.Close()
+	redis := miniredis.RunT(t)
+
+	gw, err := New(Config{
+		Auth:          authclient.NewClient(authSrv.URL),
+		TokenVerifier: verifier,
+		RedisAddr:     redis.Addr(),
+	})
+	if err != nil {
+		t.Fatalf("new gateway server: %v", err)
+	}
+	gwSrv := httptest.NewServer(gw.Router())
+	defer gwSrv.Close()
+
+	body, _ := json.Marshal(map[string]any{"feedbackIds": []string{"fb-1", "fb-2"}, "datasetId": "ds-1"})
+	req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/admin/evals/feedback/convert", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: defaultAccessCookieName, Value: token})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("convert feedback: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	var dataset domain.EvalDataset
+	if err := json.NewDecoder(resp.Body).Decode(&dataset); err != nil {
+		t.Fatalf("decode dataset: %v", err)
+	}
+	if dataset.ID != "ds-2" || dataset.Version != 2 {
+		t.Fatalf("unexpected dataset: %+v", dataset)
+	}
+	if convertReq.DatasetID != "ds-1" || !reflect.DeepEqual(convertReq.FeedbackIDs, []string{"fb-1", "fb-2"}) {
+		t.Fatalf("unexpected convert request: %+v", convertReq)
+	}
+	if audit.Action != "admin.eval.feedback.convert" || audit.TargetID != "ds-2" {
+		t.Fatalf("unexpected audit log: %+v", audit)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"onebookai/internal/quota"
+	"onebookai/pkg/domain"
+	"onebookai/services/gateway/internal/authclient"
+	"onebookai/services/gateway/internal/chatclient"
+)
+
+// newChatTestGateway starts a gateway in front of a fake auth service, which
+// reports an active user-1 allowed dailyQuestions questions a day, and the
+// given chat service. Quota counters live in the returned miniredis.
+func newChatTestGateway(t *testing.T, dailyQuestions int, chat http.Handler) (*httptest.Server, *miniredis.Miniredis) {
+	t.Helper()
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/me":
+			_ = json.NewEncoder(w).Encode(domain.User{ID: "user-1", Role: domain.RoleUser, Status: domain.StatusActive})
+		case "/auth/me/usage":
+			now := time.Now().UTC()
+			_ = json.NewEncoder(w).Encode(domain.UserUsage{
+				Limits:           domain.QuotaLimits{Plan: "free", DailyQuestions: dailyQuestions, MonthlyTokens: 1000},
+				QuestionsResetAt: quota.QuestionsResetAt(now),
+				TokensResetAt:    quota.TokensResetAt(now),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(authSrv.Close)
+	chatSrv := httptest.NewServer(chat)
+	t.Cleanup(chatSrv.Close)
+	redis := miniredis.RunT(t)
+
+	gw, err := New(Config{
+		Auth:      authclient.NewClient(authSrv.URL),
+		Chat:      chatclient.NewClient(chatSrv.URL),
+		RedisAddr: redis.Addr(),
+	})
+	if err != nil {
+		t.Fatalf("new gateway server: %v", err)
+	}
+	gwSrv := httptest.NewServer(gw.Router())
+	t.Cleanup(gwSrv.Close)
+	return gwSrv, redis
+}
+
+// sendAsUser sends req to the test gateway with user-1's access cookie.
+func sendAsUser(t *testing.T, req *http.Request) *http.Response {
+	t.Helper()
+	req.AddCookie(&http.Cookie{Name: defaultAccessCookieName, Value: "token"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp
+}
+
+// recordedTokens returns the tokens counted for user-1 this month.
+func recordedTokens(redis *miniredis.Miniredis) string {
+	got, _ := redis.Get("onebook:quota:tokens:user-1:" + time.Now().UTC().Format("200601"))
+	return got
+}
+
+// recordedQuestions returns the questions counted for user-1 today.
+func recordedQuestions(redis *miniredis.Miniredis) string {
+	got, _ := redis.Get("onebook:quota:questions:user-1:" + time.Now().UTC().Format("20060102"))
+	return got
+}
+
+func TestChatQuotaEnforcement(t *testing.T) {
+	var chatCalls int
+	gwSrv, redis := newChatTestGateway(t, 1, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chatCalls++
+		_ = json.NewEncoder(w).Encode(domain.Answer{Answer: "ok", Usage: &domain.LLMUsage{TotalTokens: 40}})
+	}))
+
+	ask := func() *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/chats", strings.NewReader(`{"bookId":"b-1","question":"q"}`))
+		return sendAsUser(t, req)
+	}
+	resp := ask()
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("first question expected 200, got %d", resp.StatusCode)
+	}
+	resp = ask()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second question expected 429, got %d", resp.StatusCode)
+	}
+	var body errorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if body.Code != "QUOTA_EXCEEDED" || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected QUOTA_EXCEEDED with Retry-After, got %+v %q", body, resp.Header.Get("Retry-After"))
+	}
+	if chatCalls != 1 {
+		t.Fatalf("over-quota question must not reach chat, got %d calls", chatCalls)
+	}
+	if got := recordedTokens(redis); got != "40" {
+		t.Fatalf("expected 40 tokens recorded, got %q", got)
+	}
+}
+
+func TestCopySSEWithFinal(t *testing.T) {
+	stream := "event: chunk\ndata: {\"delta\":\"hi\"}\n\nevent: final\ndata: {\"answer\":\"hi\",\"usage\":{\"totalTokens\":12}}\n\n"
+	var out bytes.Buffer
+	final, err := copySSEWithFinal(&out, strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if out.String() != stream {
+		t.Fatalf("stream was not relayed verbatim: %q", out.String())
+	}
+	if final == nil || final.Usage == nil || final.Usage.TotalTokens != 12 {
+		t.Fatalf("unexpected final answer: %+v", final)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"onebookai/pkg/domain"
+	"onebookai/services/gateway/internal/authclient"
+	"onebookai/services/gateway/internal/chatclient"
+)
+
+func TestSharedConversationIsPublic(t *testing.T) {
+	var gotAuth string
+	chatSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/shared/good-token":
+			_ = json.NewEncoder(w).Encode(domain.SharedConversation{Title: "Go 并发", Messages: []domain.SharedMessage{{Role: "user", Content: "q"}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "share not found", "code": "CHAT_SHARE_NOT_FOUND"})
+		}
+	}))
+	defer chatSrv.Close()
+	authSrv := httptest.NewServer(http.NotFoundHandler())
+	defer authSrv.Close()
+	redis := miniredis.RunT(t)
+
+	gw, err := New(Config{
+		Auth:      authclient.NewClient(authSrv.URL),
+		Chat:      chatclient.NewClient(chatSrv.URL),
+		RedisAddr: redis.Addr(),
+	})
+	if err != nil {
+		t.Fatalf("new gateway server: %v", err)
+	}
+	gwSrv := httptest.NewServer(gw.Router())
+	defer gwSrv.Close()
+
+	resp, err := http.Get(gwSrv.URL + "/api/shared/good-token")
+	if err != nil {
+		t.Fatalf("get shared conversation: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Fatalf("shared view must not be cached, got %q", resp.Header.Get("Cache-Control"))
+	}
+	var view domain.SharedConversation
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("decode view: %v", err)
+	}
+	if view.Title != "Go 并发" || len(view.Messages) != 1 || gotAuth != "" {
+		t.Fatalf("unexpected view %+v (authorization %q)", view, gotAuth)
+	}
+
+	revoked, err := http.Get(gwSrv.URL + "/api/shared/revoked-token")
+	if err != nil {
+		t.Fatalf("get revoked share: %v", err)
+	}
+	defer revoked.Body.Close()
+	if revoked.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for revoked share, got %d", revoked.StatusCode)
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStreamChatAnswerRelaysStagedEvents(t *testing.T) {
+	stream := "event: plan\ndata: {\"route\":\"retrieve\",\"questionType\":\"fact\",\"standaloneQuestion\":\"q\"}\n\n" +
+		"event: retrieval\ndata: {\"counts\":{\"dense\":8,\"lexical\":6,\"sparse\":0,\"fused\":10,\"reranked\":5},\"selected\":3}\n\n" +
+		"event: citations\ndata: {\"citations\":[{\"label\":\"[1]\",\"location\":\"p. 3\",\"snippet\":\"s\"}]}\n\n" +
+		"event: heartbeat\ndata: {\"elapsedMs\":10000}\n\n" +
+		"event: chunk\ndata: {\"delta\":\"hi\"}\n\n" +
+		"event: final\ndata: {\"answer\":\"hi\",\"usage\":{\"totalTokens\":30}}\n\n"
+	gwSrv, redis := newChatTestGateway(t, 5, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(stream))
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, gwSrv.URL+"/api/chats", strings.NewReader(`{"bookId":"b-1","question":"q"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	resp := sendAsUser(t, req)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != stream {
+		t.Fatalf("staged events were not relayed in order:\n%s", body)
+	}
+	if got := recordedTokens(redis); got != "30" {
+		t.Fatalf("expected 30 tokens recorded from the final event, got %q", got)
+	}
+}
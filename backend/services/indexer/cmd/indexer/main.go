@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"onebookai/internal/servicetoken"
@@ -49,13 +52,23 @@ func main() {
 		EmbeddingDim:              cfg.EmbeddingDim,
 		EmbeddingBatchSize:        cfg.EmbeddingBatchSize,
 		EmbeddingConcurrency:      cfg.EmbeddingConcurrency,
+		EmbeddingMaxBatchSize:     cfg.EmbeddingMaxBatchSize,
+		EmbeddingTargetLatencyMs:  cfg.EmbeddingTargetLatencyMs,
+		EmbeddingRetryBudget:      cfg.EmbeddingRetryBudget,
+		EmbeddingRetryBaseDelayMs: cfg.EmbeddingRetryBaseDelayMs,
+		VectorStore:               cfg.VectorStore,
 		QdrantURL:                 cfg.QdrantURL,
 		QdrantAPIKey:              cfg.QdrantAPIKey,
 		QdrantCollection:          cfg.QdrantCollection,
+		LexicalStore:              cfg.LexicalStore,
+		LexicalFallback:           cfg.LexicalFallback,
 		OpenSearchURL:             cfg.OpenSearchURL,
 		OpenSearchIndex:           cfg.OpenSearchIndex,
 		OpenSearchUsername:        cfg.OpenSearchUsername,
 		OpenSearchPassword:        cfg.OpenSearchPassword,
+		ReconcileEnabled:          cfg.ReconcileEnabled,
+		ReconcileIntervalSeconds:  cfg.ReconcileIntervalSeconds,
+		ReconcileAutoRepair:       cfg.ReconcileAutoRepair,
 	})
 	if err != nil {
 		util.Fatal("failed to init app", "err", err)
@@ -80,8 +93,18 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
 	slog.Info("indexer server listening", "addr", addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		logger.Error("server error", "err", err)
 	}
+	appCore.Close()
 }
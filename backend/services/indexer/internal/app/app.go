@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,8 +15,6 @@ import (
 	"onebookai/pkg/queue"
 	"onebookai/pkg/retrieval"
 	"onebookai/pkg/store"
-
-	"golang.org/x/sync/errgroup"
 )
 
 // Status represents the lifecycle of an index job.
@@ -63,26 +62,42 @@ type Config struct {
 	EmbeddingDim              int
 	EmbeddingBatchSize        int
 	EmbeddingConcurrency      int
+	EmbeddingMaxBatchSize     int
+	EmbeddingTargetLatencyMs  int
+	EmbeddingRetryBudget      int
+	EmbeddingRetryBaseDelayMs int
+	VectorStore               string
 	QdrantURL                 string
 	QdrantAPIKey              string
 	QdrantCollection          string
+	LexicalStore              string
+	LexicalFallback           bool
 	OpenSearchURL             string
 	OpenSearchIndex           string
 	OpenSearchUsername        string
 	OpenSearchPassword        string
+	ReconcileEnabled          bool
+	ReconcileIntervalSeconds  int
+	ReconcileAutoRepair       bool
 }
 
 // App processes indexing jobs.
 type App struct {
-	store            store.Store
-	bookClient       *bookClient
-	embedder         ai.Embedder
-	embedDim         int
-	queue            queue.JobQueue
-	embedBatchSize   int
-	embedConcurrency int
-	search           *retrieval.Client
-	lexical          *retrieval.OpenSearchClient
+	store               store.Store
+	bookClient          *bookClient
+	embedder            ai.Embedder
+	embedDim            int
+	queue               queue.JobQueue
+	embedBatchSize      int
+	embedConcurrency    int
+	embedMaxBatchSize   int
+	embedTargetLatency  time.Duration
+	embedRetry          embedRetryPolicy
+	search              retrieval.VectorStore
+	lexical             retrieval.LexicalStore
+	reconcileAutoRepair bool
+	stopReconciler      context.CancelFunc
+	reconcilerDone      <-chan struct{}
 }
 
 // New constructs the indexer service with persistence.
@@ -147,29 +162,72 @@ func New(cfg Config) (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	searchClient, err := retrieval.NewQdrantClient(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection, dim)
+	searchClient, err := retrieval.NewVectorStore(retrieval.VectorStoreConfig{
+		Backend:          cfg.VectorStore,
+		QdrantURL:        cfg.QdrantURL,
+		QdrantAPIKey:     cfg.QdrantAPIKey,
+		QdrantCollection: cfg.QdrantCollection,
+		DatabaseURL:      cfg.DatabaseURL,
+		DenseSize:        dim,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("init qdrant client: %w", err)
-	}
-	lexicalClient, err := retrieval.NewOpenSearchClient(cfg.OpenSearchURL, cfg.OpenSearchIndex, cfg.OpenSearchUsername, cfg.OpenSearchPassword)
+		return nil, fmt.Errorf("init vector store: %w", err)
+	}
+	lexicalClient, err := retrieval.NewLexicalStore(retrieval.LexicalStoreConfig{
+		Backend:            cfg.LexicalStore,
+		OpenSearchURL:      cfg.OpenSearchURL,
+		OpenSearchIndex:    cfg.OpenSearchIndex,
+		OpenSearchUsername: cfg.OpenSearchUsername,
+		OpenSearchPassword: cfg.OpenSearchPassword,
+		DatabaseURL:        cfg.DatabaseURL,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("init opensearch client: %w", err)
+		return nil, fmt.Errorf("init lexical store: %w", err)
+	}
+	if cfg.LexicalFallback && retrieval.NormalizeLexicalStoreBackend(cfg.LexicalStore) != retrieval.LexicalStorePostgres {
+		standby, err := retrieval.NewPostgresLexicalStore(cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("init lexical fallback: %w", err)
+		}
+		lexicalClient = &retrieval.MirroredLexicalStore{Primary: lexicalClient, Standby: standby}
 	}
 	app := &App{
-		store:            dataStore,
-		bookClient:       newBookClient(cfg.BookServiceURL, signer),
-		embedder:         embedder,
-		embedDim:         dim,
-		queue:            q,
-		embedBatchSize:   cfg.EmbeddingBatchSize,
-		embedConcurrency: cfg.EmbeddingConcurrency,
-		search:           searchClient,
-		lexical:          lexicalClient,
+		store:              dataStore,
+		bookClient:         newBookClient(cfg.BookServiceURL, signer),
+		embedder:           embedder,
+		embedDim:           dim,
+		queue:              q,
+		embedBatchSize:     cfg.EmbeddingBatchSize,
+		embedConcurrency:   cfg.EmbeddingConcurrency,
+		embedMaxBatchSize:  cfg.EmbeddingMaxBatchSize,
+		embedTargetLatency: time.Duration(cfg.EmbeddingTargetLatencyMs) * time.Millisecond,
+		embedRetry: embedRetryPolicy{
+			Budget:    defaultInt(cfg.EmbeddingRetryBudget, defaultEmbedRetryBudget),
+			BaseDelay: time.Duration(cfg.EmbeddingRetryBaseDelayMs) * time.Millisecond,
+		},
+		search:              searchClient,
+		lexical:             lexicalClient,
+		reconcileAutoRepair: cfg.ReconcileAutoRepair,
 	}
 	app.startWorkers(cfg.QueueConcurrency)
+	if cfg.ReconcileEnabled {
+		ctx, cancel := context.WithCancel(context.Background())
+		app.stopReconciler = cancel
+		app.reconcilerDone = app.startReconciler(ctx, time.Duration(cfg.ReconcileIntervalSeconds)*time.Second)
+	}
 	return app, nil
 }
 
+// Close stops the background reconciler and waits for an in-flight pass to
+// return.
+func (a *App) Close() {
+	if a.stopReconciler == nil {
+		return
+	}
+	a.stopReconciler()
+	<-a.reconcilerDone
+}
+
 // Enqueue registers a new index job and begins processing.
 func (a *App) Enqueue(bookID string, generation int64) (Job, error) {
 	if strings.TrimSpace(bookID) == "" {
@@ -242,7 +300,8 @@ func (a *App) process(ctx context.Context, job queue.JobStatus) error {
 		_ = a.bookClient.UpdateStatus(ctx, job.BookID, generation, domain.StatusFailed, err.Error())
 		return err
 	}
-	if err := a.search.DeleteByBook(ctx, job.BookID); err != nil {
+	pendingChunks, err := a.prepareSemanticIndex(ctx, job.BookID, semanticChunks)
+	if err != nil {
 		_ = a.bookClient.UpdateStatus(ctx, job.BookID, generation, domain.StatusFailed, err.Error())
 		return err
 	}
@@ -250,12 +309,21 @@ func (a *App) process(ctx context.Context, job queue.JobStatus) error {
 		_ = a.bookClient.UpdateStatus(ctx, job.BookID, generation, domain.StatusFailed, err.Error())
 		return err
 	}
-	if err := a.embedAndStore(ctx, semanticChunks); err != nil {
-		_ = a.store.UpdateChunkIndexStatus(chunkIDs(semanticChunks), domain.ChunkIndexBackendQdrant, domain.ChunkIndexSyncStatusFailed, cfgEmbeddingModel(a.embedder), a.embedDim, err.Error())
-		_ = a.bookClient.UpdateStatus(ctx, job.BookID, generation, domain.StatusFailed, err.Error())
-		return err
-	}
-	if err := a.store.UpdateChunkIndexStatus(chunkIDs(semanticChunks), domain.ChunkIndexBackendQdrant, domain.ChunkIndexSyncStatusSynced, cfgEmbeddingModel(a.embedder), a.embedDim, ""); err != nil {
+	embeddingModel := cfgEmbeddingModel(a.embedder)
+	completed := make(map[string]struct{}, len(pendingChunks))
+	if err := a.embedAndStore(ctx, pendingChunks, func(batch []domain.Chunk) error {
+		for _, chunk := range batch {
+			completed[chunk.ID] = struct{}{}
+		}
+		return a.store.UpdateChunkIndexStatus(chunkIDs(batch), domain.ChunkIndexBackendQdrant, domain.ChunkIndexSyncStatusSynced, embeddingModel, a.embedDim, "")
+	}); err != nil {
+		remaining := make([]string, 0, len(pendingChunks))
+		for _, chunk := range pendingChunks {
+			if _, ok := completed[chunk.ID]; !ok {
+				remaining = append(remaining, chunk.ID)
+			}
+		}
+		_ = a.store.UpdateChunkIndexStatus(remaining, domain.ChunkIndexBackendQdrant, domain.ChunkIndexSyncStatusFailed, embeddingModel, a.embedDim, err.Error())
 		_ = a.bookClient.UpdateStatus(ctx, job.BookID, generation, domain.StatusFailed, err.Error())
 		return err
 	}
@@ -305,35 +373,65 @@ func (a *App) startWorkers(concurrency int) {
 	a.queue.Start(ctx, concurrency, a.process)
 }
 
-func (a *App) embedAndStore(ctx context.Context, chunks []domain.Chunk) error {
-	if len(chunks) == 0 {
-		return nil
+// embedAndStore embeds chunks with adaptive batching and calls onDone for
+// every batch whose points were written.
+func (a *App) embedAndStore(ctx context.Context, chunks []domain.Chunk, onDone func([]domain.Chunk) error) error {
+	ctrl := newBatchController(a.embedBatchSize, a.embedMaxBatchSize, a.embedConcurrency, a.embedTargetLatency)
+	return runAdaptiveBatches(ctx, chunks, ctrl, a.embedRetry, a.checkEmbedderHealth, a.processBatch, onDone)
+}
+
+// checkEmbedderHealth probes the embedding provider before a retry so an
+// unreachable Ollama fails fast instead of burning a full request timeout.
+func (a *App) checkEmbedderHealth(ctx context.Context) error {
+	type pinger interface {
+		Ping(ctx context.Context) error
 	}
-	batchSize := a.embedBatchSize
-	if batchSize <= 0 {
-		batchSize = 1
+	if p, ok := a.embedder.(pinger); ok {
+		return p.Ping(ctx)
 	}
-	concurrency := a.embedConcurrency
-	if concurrency <= 0 {
-		concurrency = 1
+	return nil
+}
+
+// prepareSemanticIndex returns the semantic chunks that still need vectors.
+// Chunks checkpointed as synced by an earlier attempt are skipped as long as
+// their points still exist; without any checkpoint the book's vectors are
+// rebuilt from scratch.
+func (a *App) prepareSemanticIndex(ctx context.Context, bookID string, chunks []domain.Chunk) ([]domain.Chunk, error) {
+	statuses, err := a.store.ListChunkIndexStatusesByBook(bookID)
+	if err != nil {
+		return nil, err
 	}
-	batches := make([][]domain.Chunk, 0, (len(chunks)/batchSize)+1)
-	for i := 0; i < len(chunks); i += batchSize {
-		end := i + batchSize
-		if end > len(chunks) {
-			end = len(chunks)
+	checkpointed := make(map[string]struct{}, len(statuses))
+	for _, status := range statuses {
+		if status.QdrantStatus == domain.ChunkIndexSyncStatusSynced && status.EmbeddingDim == a.embedDim {
+			checkpointed[status.ChunkID] = struct{}{}
 		}
-		batches = append(batches, chunks[i:end])
-	}
-	g, gctx := errgroup.WithContext(ctx)
-	g.SetLimit(concurrency)
-	for _, batch := range batches {
-		b := batch
-		g.Go(func() error {
-			return a.processBatch(gctx, b)
-		})
 	}
-	return g.Wait()
+	if len(checkpointed) == 0 {
+		return chunks, a.search.DeleteByBook(ctx, bookID)
+	}
+	points, err := a.search.ListBookChunks(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffIndexedChunks(chunks, points)
+	if err := a.search.DeleteChunks(ctx, bookID, diff.Orphaned); err != nil {
+		return nil, err
+	}
+	present := make(map[string]struct{}, len(diff.Synced))
+	for _, id := range diff.Synced {
+		present[id] = struct{}{}
+	}
+	pending := make([]domain.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		_, done := checkpointed[chunk.ID]
+		_, exists := present[chunk.ID]
+		if done && exists {
+			continue
+		}
+		pending = append(pending, chunk)
+	}
+	return pending, nil
 }
 
 func (a *App) processBatch(ctx context.Context, batch []domain.Chunk) error {
@@ -379,15 +477,18 @@ func (a *App) processBatch(ctx context.Context, batch []domain.Chunk) error {
 			Dense:  embedding,
 			Sparse: retrieval.BuildSparseVector(batch[i].Content, language),
 			Payload: map[string]any{
-				"chunk_id":      batch[i].ID,
-				"book_id":       batch[i].BookID,
-				"chunk_family":  strings.TrimSpace(batch[i].Metadata["chunk_family"]),
-				"section_id":    strings.TrimSpace(batch[i].Metadata["section_id"]),
-				"block_type":    firstNonEmpty(batch[i].Metadata["block_type"], batch[i].Metadata["source_type"]),
-				"language":      language,
-				"is_first_page": strings.TrimSpace(batch[i].Metadata["is_first_page"]),
-				"entities":      strings.TrimSpace(batch[i].Metadata["entities"]),
-				"facts":         strings.TrimSpace(batch[i].Metadata["facts"]),
+				"chunk_id":       batch[i].ID,
+				"book_id":        batch[i].BookID,
+				"chunk_family":   strings.TrimSpace(batch[i].Metadata["chunk_family"]),
+				"section_id":     strings.TrimSpace(batch[i].Metadata["section_id"]),
+				"section_path":   firstNonEmpty(batch[i].Metadata["section_path"], batch[i].Metadata["section"]),
+				"page":           chunkPage(batch[i]),
+				"block_type":     firstNonEmpty(batch[i].Metadata["block_type"], batch[i].Metadata["source_type"]),
+				"language":       language,
+				"is_first_page":  strings.TrimSpace(batch[i].Metadata["is_first_page"]),
+				"entities":       strings.TrimSpace(batch[i].Metadata["entities"]),
+				"facts":          strings.TrimSpace(batch[i].Metadata["facts"]),
+				"content_sha256": strings.TrimSpace(batch[i].Metadata["content_sha256"]),
 			},
 		})
 	}
@@ -398,6 +499,10 @@ func (a *App) indexLexical(ctx context.Context, chunks []domain.Chunk) error {
 	if len(chunks) == 0 {
 		return nil
 	}
+	return a.lexical.IndexDocuments(ctx, lexicalDocuments(chunks))
+}
+
+func lexicalDocuments(chunks []domain.Chunk) []retrieval.LexicalDocument {
 	docs := make([]retrieval.LexicalDocument, 0, len(chunks))
 	for _, chunk := range chunks {
 		language := strings.TrimSpace(chunk.Metadata["language"])
@@ -405,19 +510,22 @@ func (a *App) indexLexical(ctx context.Context, chunks []domain.Chunk) error {
 			language = retrieval.DetectLanguage(chunk.Content)
 		}
 		payload := map[string]any{
-			"chunk_id":      chunk.ID,
-			"book_id":       chunk.BookID,
-			"chunk_family":  strings.TrimSpace(chunk.Metadata["chunk_family"]),
-			"section_id":    strings.TrimSpace(chunk.Metadata["section_id"]),
-			"title":         strings.TrimSpace(chunk.Metadata["title"]),
-			"section_title": firstNonEmpty(chunk.Metadata["section_title"], chunk.Metadata["section"], chunk.Metadata["section_path"]),
-			"keywords":      strings.TrimSpace(chunk.Metadata["keywords"]),
-			"tags":          strings.TrimSpace(chunk.Metadata["tags"]),
-			"block_type":    firstNonEmpty(chunk.Metadata["block_type"], chunk.Metadata["source_type"]),
-			"language":      language,
-			"is_first_page": strings.TrimSpace(chunk.Metadata["is_first_page"]),
-			"entities":      strings.TrimSpace(chunk.Metadata["entities"]),
-			"facts":         strings.TrimSpace(chunk.Metadata["facts"]),
+			"chunk_id":       chunk.ID,
+			"book_id":        chunk.BookID,
+			"chunk_family":   strings.TrimSpace(chunk.Metadata["chunk_family"]),
+			"section_id":     strings.TrimSpace(chunk.Metadata["section_id"]),
+			"title":          strings.TrimSpace(chunk.Metadata["title"]),
+			"section_title":  firstNonEmpty(chunk.Metadata["section_title"], chunk.Metadata["section"], chunk.Metadata["section_path"]),
+			"section_path":   firstNonEmpty(chunk.Metadata["section_path"], chunk.Metadata["section"]),
+			"page":           chunkPage(chunk),
+			"keywords":       strings.TrimSpace(chunk.Metadata["keywords"]),
+			"tags":           strings.TrimSpace(chunk.Metadata["tags"]),
+			"block_type":     firstNonEmpty(chunk.Metadata["block_type"], chunk.Metadata["source_type"]),
+			"language":       language,
+			"is_first_page":  strings.TrimSpace(chunk.Metadata["is_first_page"]),
+			"entities":       strings.TrimSpace(chunk.Metadata["entities"]),
+			"facts":          strings.TrimSpace(chunk.Metadata["facts"]),
+			"content_sha256": strings.TrimSpace(chunk.Metadata["content_sha256"]),
 		}
 		docs = append(docs, retrieval.LexicalDocument{
 			ID:      chunk.ID,
@@ -426,7 +534,17 @@ func (a *App) indexLexical(ctx context.Context, chunks []domain.Chunk) error {
 			Payload: payload,
 		})
 	}
-	return a.lexical.IndexDocuments(ctx, docs)
+	return docs
+}
+
+// chunkPage is the chunk's PDF page as a number, or 0, so vector and lexical
+// stores can range-filter scoped questions.
+func chunkPage(chunk domain.Chunk) int {
+	page, err := strconv.Atoi(strings.TrimSpace(chunk.Metadata["page"]))
+	if err != nil || page < 0 {
+		return 0
+	}
+	return page
 }
 
 func chunkIDs(chunks []domain.Chunk) []string {
@@ -471,6 +589,13 @@ func jobFromStatus(status queue.JobStatus) Job {
 	}
 }
 
+func defaultInt(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
 func defaultQueueName(name string) string {
 	if strings.TrimSpace(name) != "" {
 		return strings.TrimSpace(name)
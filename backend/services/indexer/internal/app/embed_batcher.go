@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"onebookai/pkg/domain"
+)
+
+const (
+	defaultEmbedTargetLatency  = 8 * time.Second
+	defaultEmbedRetryBudget    = 6
+	defaultEmbedRetryBaseDelay = 500 * time.Millisecond
+	maxEmbedRetryDelay         = 30 * time.Second
+	embedConcurrencyRampStreak = 3
+)
+
+// batchController adapts embedding batch size and concurrency to observed
+// latency and errors: additive increase while the provider is fast,
+// multiplicative decrease when it is slow or failing. It is only touched by
+// the dispatch loop, so it needs no locking.
+type batchController struct {
+	batchSize      int
+	maxBatchSize   int
+	concurrency    int
+	maxConcurrency int
+	targetLatency  time.Duration
+	streak         int
+}
+
+func newBatchController(batchSize, maxBatchSize, concurrency int, targetLatency time.Duration) *batchController {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if maxBatchSize < batchSize {
+		maxBatchSize = batchSize
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if targetLatency <= 0 {
+		targetLatency = defaultEmbedTargetLatency
+	}
+	return &batchController{
+		batchSize:      batchSize,
+		maxBatchSize:   maxBatchSize,
+		concurrency:    concurrency,
+		maxConcurrency: concurrency,
+		targetLatency:  targetLatency,
+	}
+}
+
+func (c *batchController) observeSuccess(latency time.Duration) {
+	switch {
+	case latency > c.targetLatency:
+		c.batchSize = maxInt(1, c.batchSize/2)
+		c.streak = 0
+	case latency < c.targetLatency/2:
+		c.batchSize = minInt(c.maxBatchSize, c.batchSize+1)
+		c.streak++
+		if c.streak >= embedConcurrencyRampStreak && c.concurrency < c.maxConcurrency {
+			c.concurrency++
+			c.streak = 0
+		}
+	default:
+		c.streak = 0
+	}
+}
+
+func (c *batchController) observeFailure() {
+	c.batchSize = maxInt(1, c.batchSize/2)
+	c.concurrency = maxInt(1, c.concurrency-1)
+	c.streak = 0
+}
+
+// embedRetryPolicy bounds how often failed batches are retried within one job.
+type embedRetryPolicy struct {
+	Budget    int
+	BaseDelay time.Duration
+}
+
+func (p embedRetryPolicy) delay(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultEmbedRetryBaseDelay
+	}
+	delay := base << uint(minInt(attempt-1, 10))
+	if delay > maxEmbedRetryDelay {
+		delay = maxEmbedRetryDelay
+	}
+	// Jitter keeps concurrent retries from hitting the provider in lockstep.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+type embedBatch struct {
+	chunks  []domain.Chunk
+	attempt int
+}
+
+type embedBatchResult struct {
+	batch   embedBatch
+	latency time.Duration
+	err     error
+}
+
+// runAdaptiveBatches embeds chunks in adaptively sized batches. Failed batches
+// are split in half and retried with exponential backoff until the retry
+// budget is exhausted; onDone is called for every batch that succeeded so
+// progress can be checkpointed.
+func runAdaptiveBatches(
+	ctx context.Context,
+	chunks []domain.Chunk,
+	ctrl *batchController,
+	policy embedRetryPolicy,
+	beforeRetry func(context.Context) error,
+	process func(context.Context, []domain.Chunk) error,
+	onDone func([]domain.Chunk) error,
+) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	results := make(chan embedBatchResult)
+	retries := make([]embedBatch, 0, 4)
+	retriesLeft := policy.Budget
+	next := 0
+	inflight := 0
+	var firstErr error
+	for {
+		for firstErr == nil && ctx.Err() == nil && inflight < ctrl.concurrency {
+			var batch embedBatch
+			switch {
+			case len(retries) > 0:
+				batch = retries[0]
+				retries = retries[1:]
+			case next < len(chunks):
+				end := minInt(next+ctrl.batchSize, len(chunks))
+				batch = embedBatch{chunks: chunks[next:end]}
+				next = end
+			}
+			if len(batch.chunks) == 0 {
+				break
+			}
+			inflight++
+			go func(b embedBatch) {
+				if b.attempt > 0 {
+					if err := sleepContext(ctx, policy.delay(b.attempt)); err != nil {
+						results <- embedBatchResult{batch: b, err: err}
+						return
+					}
+					if beforeRetry != nil {
+						if err := beforeRetry(ctx); err != nil {
+							results <- embedBatchResult{batch: b, err: err}
+							return
+						}
+					}
+				}
+				start := time.Now()
+				err := process(ctx, b.chunks)
+				results <- embedBatchResult{batch: b, latency: time.Since(start), err: err}
+			}(batch)
+		}
+		if inflight == 0 {
+			break
+		}
+		res := <-results
+		inflight--
+		if res.err == nil {
+			ctrl.observeSuccess(res.latency)
+			if onDone != nil {
+				if err := onDone(res.batch.chunks); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			continue
+		}
+		ctrl.observeFailure()
+		if firstErr != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			firstErr = ctx.Err()
+			continue
+		}
+		if retriesLeft <= 0 {
+			firstErr = fmt.Errorf("embedding retry budget exhausted: %w", res.err)
+			continue
+		}
+		retriesLeft--
+		retries = append(retries, splitEmbedBatch(res.batch)...)
+	}
+	if firstErr == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+func splitEmbedBatch(batch embedBatch) []embedBatch {
+	attempt := batch.attempt + 1
+	if len(batch.chunks) <= 1 {
+		return []embedBatch{{chunks: batch.chunks, attempt: attempt}}
+	}
+	mid := len(batch.chunks) / 2
+	return []embedBatch{
+		{chunks: batch.chunks[:mid], attempt: attempt},
+		{chunks: batch.chunks[mid:], attempt: attempt},
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func minInt(left, right int) int {
+	if left < right {
+		return left
+	}
+	return right
+}
+
+func maxInt(left, right int) int {
+	if left > right {
+		return left
+	}
+	return right
+}
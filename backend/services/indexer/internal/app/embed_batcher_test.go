@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+)
+
+func testChunks(n int) []domain.Chunk {
+	chunks := make([]domain.Chunk, 0, n)
+	for i := 0; i < n; i++ {
+		chunks = append(chunks, domain.Chunk{ID: fmt.Sprintf("c%02d", i)})
+	}
+	return chunks
+}
+
+func TestBatchControllerAdaptsToLatency(t *testing.T) {
+	ctrl := newBatchController(4, 8, 2, time.Second)
+	ctrl.observeFailure()
+	if ctrl.batchSize != 2 || ctrl.concurrency != 1 {
+		t.Fatalf("expected batch=2 concurrency=1 after failure, got batch=%d concurrency=%d", ctrl.batchSize, ctrl.concurrency)
+	}
+	for i := 0; i < embedConcurrencyRampStreak; i++ {
+		ctrl.observeSuccess(10 * time.Millisecond)
+	}
+	if ctrl.batchSize != 5 || ctrl.concurrency != 2 {
+		t.Fatalf("expected batch=5 concurrency=2 after fast batches, got batch=%d concurrency=%d", ctrl.batchSize, ctrl.concurrency)
+	}
+	for i := 0; i < 10; i++ {
+		ctrl.observeSuccess(10 * time.Millisecond)
+	}
+	if ctrl.batchSize != 8 || ctrl.concurrency != 2 {
+		t.Fatalf("expected limits batch=8 concurrency=2, got batch=%d concurrency=%d", ctrl.batchSize, ctrl.concurrency)
+	}
+	ctrl.observeSuccess(2 * time.Second)
+	if ctrl.batchSize != 4 {
+		t.Fatalf("expected slow batch to halve size, got %d", ctrl.batchSize)
+	}
+}
+
+func TestRunAdaptiveBatchesSplitsAndRetriesFailedBatch(t *testing.T) {
+	chunks := testChunks(8)
+	var mu sync.Mutex
+	failed := false
+	var pings int
+	var done []string
+	err := runAdaptiveBatches(context.Background(), chunks, newBatchController(4, 4, 1, time.Second),
+		embedRetryPolicy{Budget: 2, BaseDelay: time.Millisecond},
+		func(context.Context) error {
+			mu.Lock()
+			pings++
+			mu.Unlock()
+			return nil
+		},
+		func(_ context.Context, batch []domain.Chunk) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !failed && len(batch) == 4 && batch[0].ID == "c00" {
+				failed = true
+				return errors.New("ollama timeout")
+			}
+			return nil
+		},
+		func(batch []domain.Chunk) error {
+			for _, chunk := range batch {
+				done = append(done, chunk.ID)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("runAdaptiveBatches: %v", err)
+	}
+	sort.Strings(done)
+	if strings.Join(done, ",") != "c00,c01,c02,c03,c04,c05,c06,c07" {
+		t.Fatalf("expected every chunk checkpointed once, got %v", done)
+	}
+	if pings != 2 {
+		t.Fatalf("expected health check before each split retry, got %d", pings)
+	}
+}
+
+func TestRunAdaptiveBatchesStopsWhenBudgetExhausted(t *testing.T) {
+	var done []string
+	err := runAdaptiveBatches(context.Background(), testChunks(4), newBatchController(2, 2, 1, time.Second),
+		embedRetryPolicy{Budget: 1, BaseDelay: time.Millisecond},
+		nil,
+		func(_ context.Context, batch []domain.Chunk) error {
+			if batch[0].ID == "c00" {
+				return errors.New("ollama unavailable")
+			}
+			return nil
+		},
+		func(batch []domain.Chunk) error {
+			for _, chunk := range batch {
+				done = append(done, chunk.ID)
+			}
+			return nil
+		},
+	)
+	if err == nil || !strings.Contains(err.Error(), "retry budget exhausted") {
+		t.Fatalf("expected budget exhausted error, got %v", err)
+	}
+	for _, id := range done {
+		if id == "c00" {
+			t.Fatalf("failed chunk must not be checkpointed: %v", done)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"onebookai/internal/util"
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+)
+
+const defaultReconcileInterval = time.Hour
+
+// reconcileLockID is the Postgres advisory lock that keeps scheduled passes
+// to one replica at a time.
+const reconcileLockID int64 = 73217322
+
+// chunkDiff lists how an index backend diverges from the chunks stored in Postgres.
+type chunkDiff struct {
+	Synced   []string
+	Missing  []string
+	Orphaned []string
+	Stale    []string
+}
+
+func (d chunkDiff) consistent() bool {
+	return len(d.Missing) == 0 && len(d.Orphaned) == 0 && len(d.Stale) == 0
+}
+
+// startReconciler runs a reconcile pass every interval until ctx is done.
+// The returned channel is closed once the ticker goroutine has exited.
+func (a *App) startReconciler(ctx context.Context, interval time.Duration) <-chan struct{} {
+	if interval <= 0 {
+		interval = defaultReconcileInterval
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				passCtx, cancel := context.WithTimeout(ctx, interval)
+				a.runScheduledReconcile(passCtx)
+				cancel()
+			}
+		}
+	}()
+	return done
+}
+
+// runScheduledReconcile runs one pass under reconcileLockID and skips it when
+// another replica is already reconciling.
+func (a *App) runScheduledReconcile(ctx context.Context) bool {
+	ran, _ := a.store.TryAdvisoryLock(reconcileLockID, func() error {
+		_, err := a.Reconcile(ctx)
+		return err
+	})
+	return ran
+}
+
+// Reconcile compares chunk IDs per book across Postgres, Qdrant and OpenSearch,
+// records divergences into chunk_index_status, purges vectors of deleted books
+// and persists a library-wide report.
+func (a *App) Reconcile(ctx context.Context) (domain.IndexConsistencyReport, error) {
+	report := domain.IndexConsistencyReport{
+		ID:         util.NewID(),
+		AutoRepair: a.reconcileAutoRepair,
+		StartedAt:  time.Now().UTC(),
+	}
+	books, err := a.store.ListBooks()
+	if err != nil {
+		return report, err
+	}
+	live := make(map[string]struct{}, len(books))
+	for _, book := range books {
+		live[book.ID] = struct{}{}
+		if book.Status != domain.StatusReady {
+			continue
+		}
+		if ctx.Err() != nil {
+			report.Errors = append(report.Errors, ctx.Err().Error())
+			break
+		}
+		item := a.reconcileBook(ctx, book)
+		report.BooksChecked++
+		missing := len(item.QdrantMissing) + len(item.OpenSearchMissing)
+		orphaned := len(item.QdrantOrphaned) + len(item.OpenSearchOrphaned)
+		stale := len(item.QdrantStale) + len(item.OpenSearchStale)
+		report.MissingChunks += missing
+		report.OrphanedChunks += orphaned
+		report.StaleChunks += stale
+		if item.Repaired {
+			report.RepairedBooks++
+		}
+		if missing+orphaned+stale == 0 && item.Error == "" {
+			continue
+		}
+		report.InconsistentBooks++
+		report.Books = append(report.Books, item)
+	}
+	if mirror, ok := a.lexical.(*retrieval.MirroredLexicalStore); ok {
+		report.Errors = append(report.Errors, a.repairLexicalStandby(ctx, mirror, books)...)
+	}
+	purged, errs := a.purgeDeletedBooks(ctx, live)
+	report.PurgedBookIDs = purged
+	report.Errors = append(report.Errors, errs...)
+	report.FinishedAt = time.Now().UTC()
+	if err := a.store.SaveIndexConsistencyReport(report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (a *App) reconcileBook(ctx context.Context, book domain.Book) domain.BookIndexConsistency {
+	item := domain.BookIndexConsistency{BookID: book.ID, Title: book.Title}
+	chunks, err := a.store.ListChunksByBook(book.ID)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	semanticChunks, lexicalChunks := splitChunksByTier(chunks)
+	item.ExpectedQdrant = len(semanticChunks)
+	item.ExpectedOpenSearch = len(lexicalChunks)
+
+	vectors, err := a.search.ListBookChunks(ctx, book.ID)
+	if err != nil {
+		item.Error = fmt.Sprintf("list qdrant points: %v", err)
+		return item
+	}
+	docs, err := a.lexical.ListBookChunks(ctx, book.ID)
+	if err != nil {
+		item.Error = fmt.Sprintf("list opensearch docs: %v", err)
+		return item
+	}
+	qdrantDiff := diffIndexedChunks(semanticChunks, vectors)
+	openSearchDiff := diffIndexedChunks(lexicalChunks, docs)
+	item.QdrantMissing = qdrantDiff.Missing
+	item.QdrantOrphaned = qdrantDiff.Orphaned
+	item.QdrantStale = qdrantDiff.Stale
+	item.OpenSearchMissing = openSearchDiff.Missing
+	item.OpenSearchOrphaned = openSearchDiff.Orphaned
+	item.OpenSearchStale = openSearchDiff.Stale
+
+	statuses, err := a.store.ListChunkIndexStatusesByBook(book.ID)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	if err := a.recordChunkDiff(domain.ChunkIndexBackendQdrant, qdrantDiff, statuses); err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	if err := a.recordChunkDiff(domain.ChunkIndexBackendOpenSearch, openSearchDiff, statuses); err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	if !a.reconcileAutoRepair || (qdrantDiff.consistent() && openSearchDiff.consistent()) {
+		return item
+	}
+	if err := a.repairBook(ctx, book, semanticChunks, lexicalChunks, qdrantDiff, openSearchDiff); err != nil {
+		item.Error = fmt.Sprintf("repair: %v", err)
+		return item
+	}
+	item.Repaired = true
+	return item
+}
+
+// recordChunkDiff marks missing and stale chunks as failed and confirms chunks
+// that are present with a matching hash but not yet recorded as synced.
+func (a *App) recordChunkDiff(backend domain.ChunkIndexBackend, diff chunkDiff, statuses []domain.ChunkIndexStatus) error {
+	model := cfgEmbeddingModel(a.embedder)
+	if err := a.store.UpdateChunkIndexStatus(diff.Missing, backend, domain.ChunkIndexSyncStatusFailed, model, a.embedDim, fmt.Sprintf("reconcile: chunk missing from %s", backend)); err != nil {
+		return err
+	}
+	if err := a.store.UpdateChunkIndexStatus(diff.Stale, backend, domain.ChunkIndexSyncStatusFailed, model, a.embedDim, fmt.Sprintf("reconcile: content hash mismatch in %s", backend)); err != nil {
+		return err
+	}
+	current := make(map[string]domain.ChunkIndexSyncStatus, len(statuses))
+	for _, status := range statuses {
+		if backend == domain.ChunkIndexBackendQdrant {
+			current[status.ChunkID] = status.QdrantStatus
+		} else {
+			current[status.ChunkID] = status.OpenSearchStatus
+		}
+	}
+	confirmed := make([]string, 0, len(diff.Synced))
+	for _, id := range diff.Synced {
+		if status, ok := current[id]; ok && status != domain.ChunkIndexSyncStatusSynced {
+			confirmed = append(confirmed, id)
+		}
+	}
+	return a.store.UpdateChunkIndexStatus(confirmed, backend, domain.ChunkIndexSyncStatusSynced, model, a.embedDim, "")
+}
+
+func (a *App) repairBook(ctx context.Context, book domain.Book, semanticChunks, lexicalChunks []domain.Chunk, qdrantDiff, openSearchDiff chunkDiff) error {
+	// A reprocess may have started since the book was listed; leave it to the job.
+	current, ok, err := a.store.GetBook(book.ID)
+	if err != nil {
+		return err
+	}
+	if !ok || current.Status != domain.StatusReady || current.ProcessingGeneration != book.ProcessingGeneration {
+		return fmt.Errorf("book changed during reconcile")
+	}
+	if err := a.search.DeleteChunks(ctx, book.ID, qdrantDiff.Orphaned); err != nil {
+		return err
+	}
+	if err := a.lexical.DeleteDocuments(ctx, openSearchDiff.Orphaned); err != nil {
+		return err
+	}
+	model := cfgEmbeddingModel(a.embedder)
+	semantic := selectChunks(semanticChunks, append(qdrantDiff.Missing, qdrantDiff.Stale...))
+	if err := a.embedAndStore(ctx, semantic, func(batch []domain.Chunk) error {
+		return a.store.UpdateChunkIndexStatus(chunkIDs(batch), domain.ChunkIndexBackendQdrant, domain.ChunkIndexSyncStatusSynced, model, a.embedDim, "")
+	}); err != nil {
+		return err
+	}
+	lexical := selectChunks(lexicalChunks, append(openSearchDiff.Missing, openSearchDiff.Stale...))
+	if err := a.indexLexical(ctx, lexical); err != nil {
+		_ = a.store.UpdateChunkIndexStatus(chunkIDs(lexical), domain.ChunkIndexBackendOpenSearch, domain.ChunkIndexSyncStatusFailed, model, a.embedDim, err.Error())
+		return err
+	}
+	return a.store.UpdateChunkIndexStatus(chunkIDs(lexical), domain.ChunkIndexBackendOpenSearch, domain.ChunkIndexSyncStatusSynced, model, a.embedDim, "")
+}
+
+// repairLexicalStandby brings the standby of a mirrored lexical store back in
+// line with Postgres for the books whose standby writes failed. The primary
+// already accepted those writes, so this runs regardless of auto-repair.
+// Books that are not ready, or whose repair fails, stay marked for the next
+// run; deleted books are purged from both stores by purgeDeletedBooks.
+func (a *App) repairLexicalStandby(ctx context.Context, mirror *retrieval.MirroredLexicalStore, books []domain.Book) []string {
+	repairs := mirror.TakeStandbyRepairs()
+	var errs []string
+	if repairs.Failures > 0 {
+		errs = append(errs, fmt.Sprintf("opensearch standby missed %d writes: %s", repairs.Failures, repairs.LastError))
+	}
+	marked := make(map[string]struct{}, len(repairs.BookIDs))
+	for _, id := range repairs.BookIDs {
+		marked[id] = struct{}{}
+	}
+	for _, book := range books {
+		if _, ok := marked[book.ID]; !ok && !repairs.AllBooks {
+			continue
+		}
+		if book.Status != domain.StatusReady || ctx.Err() != nil {
+			mirror.MarkStandbyRepair(book.ID)
+			continue
+		}
+		if err := a.repairStandbyBook(ctx, mirror.Standby, book); err != nil {
+			mirror.MarkStandbyRepair(book.ID)
+			errs = append(errs, fmt.Sprintf("repair opensearch standby for book %s: %v", book.ID, err))
+		}
+	}
+	return errs
+}
+
+func (a *App) repairStandbyBook(ctx context.Context, standby retrieval.LexicalStore, book domain.Book) error {
+	chunks, err := a.store.ListChunksByBook(book.ID)
+	if err != nil {
+		return err
+	}
+	_, lexicalChunks := splitChunksByTier(chunks)
+	docs, err := standby.ListBookChunks(ctx, book.ID)
+	if err != nil {
+		return err
+	}
+	diff := diffIndexedChunks(lexicalChunks, docs)
+	if diff.consistent() {
+		return nil
+	}
+	if len(diff.Orphaned) > 0 {
+		if err := standby.DeleteDocuments(ctx, diff.Orphaned); err != nil {
+			return err
+		}
+	}
+	missing := selectChunks(lexicalChunks, append(diff.Missing, diff.Stale...))
+	if len(missing) == 0 {
+		return nil
+	}
+	return standby.IndexDocuments(ctx, lexicalDocuments(missing))
+}
+
+// purgeDeletedBooks removes index entries whose book no longer exists or is
+// soft-deleted in Postgres.
+func (a *App) purgeDeletedBooks(ctx context.Context, live map[string]struct{}) ([]string, []string) {
+	var errs []string
+	candidates := map[string]struct{}{}
+	vectorBooks, err := a.search.ListBookIDs(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list qdrant books: %v", err))
+	}
+	lexicalBooks, err := a.lexical.ListBookIDs(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list opensearch books: %v", err))
+	}
+	for _, id := range append(vectorBooks, lexicalBooks...) {
+		if _, ok := live[id]; !ok {
+			candidates[id] = struct{}{}
+		}
+	}
+	purged := make([]string, 0, len(candidates))
+	for id := range candidates {
+		book, ok, err := a.store.GetBookIncludingDeleted(id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("load book %s: %v", id, err))
+			continue
+		}
+		if ok && book.DeletedAt == nil {
+			// Created after the book list was taken.
+			continue
+		}
+		if err := a.search.DeleteByBook(ctx, id); err != nil {
+			errs = append(errs, fmt.Sprintf("purge qdrant book %s: %v", id, err))
+			continue
+		}
+		if err := a.lexical.DeleteByBook(ctx, id); err != nil {
+			errs = append(errs, fmt.Sprintf("purge opensearch book %s: %v", id, err))
+			continue
+		}
+		purged = append(purged, id)
+	}
+	sort.Strings(purged)
+	return purged, errs
+}
+
+func diffIndexedChunks(expected []domain.Chunk, indexed []retrieval.IndexedChunk) chunkDiff {
+	hashes := make(map[string]string, len(expected))
+	for _, chunk := range expected {
+		hashes[chunk.ID] = strings.TrimSpace(chunk.Metadata["content_sha256"])
+	}
+	var diff chunkDiff
+	seen := make(map[string]struct{}, len(indexed))
+	for _, item := range indexed {
+		if item.ChunkID == "" {
+			continue
+		}
+		if _, ok := seen[item.ChunkID]; ok {
+			continue
+		}
+		seen[item.ChunkID] = struct{}{}
+		hash, ok := hashes[item.ChunkID]
+		switch {
+		case !ok:
+			diff.Orphaned = append(diff.Orphaned, item.ChunkID)
+		// Entries indexed before hashes were stored carry no hash; treat them as current.
+		case hash != "" && item.ContentSHA256 != "" && hash != item.ContentSHA256:
+			diff.Stale = append(diff.Stale, item.ChunkID)
+		default:
+			diff.Synced = append(diff.Synced, item.ChunkID)
+		}
+	}
+	for _, chunk := range expected {
+		if _, ok := seen[chunk.ID]; !ok {
+			diff.Missing = append(diff.Missing, chunk.ID)
+		}
+	}
+	sort.Strings(diff.Synced)
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Orphaned)
+	sort.Strings(diff.Stale)
+	return diff
+}
+
+func selectChunks(chunks []domain.Chunk, ids []string) []domain.Chunk {
+	if len(ids) == 0 {
+		return nil
+	}
+	wanted := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[id] = struct{}{}
+	}
+	out := make([]domain.Chunk, 0, len(ids))
+	for _, chunk := range chunks {
+		if _, ok := wanted[chunk.ID]; ok {
+			out = append(out, chunk)
+		}
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"onebookai/pkg/domain"
+	"onebookai/pkg/retrieval"
+	"onebookai/pkg/store"
+)
+
+func TestDiffIndexedChunksClassifiesEntries(t *testing.T) {
+	expected := []domain.Chunk{
+		{ID: "c1", Metadata: map[string]string{"content_sha256": "h1"}},
+		{ID: "c2", Metadata: map[string]string{"content_sha256": "h2"}},
+		{ID: "c3", Metadata: map[string]string{"content_sha256": "h3"}},
+		{ID: "c4", Metadata: map[string]string{"content_sha256": "h4"}},
+	}
+	indexed := []retrieval.IndexedChunk{
+		{ChunkID: "c1", ContentSHA256: "h1"},
+		{ChunkID: "c2", ContentSHA256: "old"},
+		{ChunkID: "c4"},
+		{ChunkID: "c4"},
+		{ChunkID: "gone", ContentSHA256: "x"},
+	}
+
+	diff := diffIndexedChunks(expected, indexed)
+	if want := []string{"c1", "c4"}; !reflect.DeepEqual(diff.Synced, want) {
+		t.Fatalf("synced = %#v, want %#v", diff.Synced, want)
+	}
+	if want := []string{"c3"}; !reflect.DeepEqual(diff.Missing, want) {
+		t.Fatalf("missing = %#v, want %#v", diff.Missing, want)
+	}
+	if want := []string{"c2"}; !reflect.DeepEqual(diff.Stale, want) {
+		t.Fatalf("stale = %#v, want %#v", diff.Stale, want)
+	}
+	if want := []string{"gone"}; !reflect.DeepEqual(diff.Orphaned, want) {
+		t.Fatalf("orphaned = %#v, want %#v", diff.Orphaned, want)
+	}
+	if diff.consistent() {
+		t.Fatal("consistent() = true, want false")
+	}
+}
+
+func TestDiffIndexedChunksConsistentWhenAllPresent(t *testing.T) {
+	expected := []domain.Chunk{{ID: "c1", Metadata: map[string]string{"content_sha256": "h1"}}}
+	diff := diffIndexedChunks(expected, []retrieval.IndexedChunk{{ChunkID: "c1", ContentSHA256: "h1"}})
+	if !diff.consistent() {
+		t.Fatalf("consistent() = false, diff = %#v", diff)
+	}
+}
+
+type chunkStore struct {
+	store.Store
+	chunks map[string][]domain.Chunk
+}
+
+func (s chunkStore) ListChunksByBook(bookID string) ([]domain.Chunk, error) {
+	return s.chunks[bookID], nil
+}
+
+type memoryLexicalStore struct {
+	retrieval.LexicalStore
+	docs map[string]retrieval.IndexedChunk
+	err  error
+}
+
+func (s *memoryLexicalStore) IndexDocuments(_ context.Context, docs []retrieval.LexicalDocument) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, doc := range docs {
+		s.docs[doc.ID] = retrieval.IndexedChunk{ChunkID: doc.ID, BookID: doc.Payload["book_id"].(string)}
+	}
+	return nil
+}
+
+func (s *memoryLexicalStore) DeleteDocuments(_ context.Context, ids []string) error {
+	for _, id := range ids {
+		delete(s.docs, id)
+	}
+	return nil
+}
+
+func (s *memoryLexicalStore) ListBookChunks(_ context.Context, bookID string) ([]retrieval.IndexedChunk, error) {
+	var out []retrieval.IndexedChunk
+	for _, doc := range s.docs {
+		if doc.BookID == bookID {
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+func TestReconcileRepairsLexicalStandbyAfterFailedWrite(t *testing.T) {
+	lexicalChunk := func(id string) domain.Chunk {
+		return domain.Chunk{ID: id, BookID: "b1", Content: "text", Metadata: map[string]string{"retrieval_tier": "lexical"}}
+	}
+	primary := &memoryLexicalStore{docs: map[string]retrieval.IndexedChunk{}}
+	standby := &memoryLexicalStore{docs: map[string]retrieval.IndexedChunk{}, err: errors.New("postgres down")}
+	mirror := &retrieval.MirroredLexicalStore{Primary: primary, Standby: standby}
+	a := &App{
+		store:   chunkStore{chunks: map[string][]domain.Chunk{"b1": {lexicalChunk("c1"), lexicalChunk("c2")}}},
+		lexical: mirror,
+	}
+	if err := a.indexLexical(context.Background(), []domain.Chunk{lexicalChunk("c1"), lexicalChunk("c2")}); err != nil {
+		t.Fatalf("indexLexical() error = %v, want standby failure tolerated", err)
+	}
+	if len(primary.docs) != 2 || len(standby.docs) != 0 {
+		t.Fatalf("primary %d docs, standby %d docs; want 2 and 0", len(primary.docs), len(standby.docs))
+	}
+
+	standby.err = nil
+	books := []domain.Book{{ID: "b1", Status: domain.StatusReady}, {ID: "b2", Status: domain.StatusReady}}
+	errs := a.repairLexicalStandby(context.Background(), mirror, books)
+	if len(errs) != 1 {
+		t.Fatalf("repairLexicalStandby() errors = %v, want the missed writes reported", errs)
+	}
+	if len(standby.docs) != 2 {
+		t.Fatalf("standby has %d docs after repair, want 2", len(standby.docs))
+	}
+	if repairs := mirror.TakeStandbyRepairs(); len(repairs.BookIDs) != 0 {
+		t.Fatalf("repaired books should no longer be marked, got %+v", repairs)
+	}
+}
+
+type lockStore struct {
+	store.Store
+	held      bool
+	listCalls int
+}
+
+func (s *lockStore) TryAdvisoryLock(lockID int64, fn func() error) (bool, error) {
+	if lockID != reconcileLockID || s.held {
+		return false, nil
+	}
+	return true, fn()
+}
+
+func (s *lockStore) ListBooks() ([]domain.Book, error) {
+	s.listCalls++
+	return nil, errors.New("postgres down")
+}
+
+func TestScheduledReconcileSkipsWhenAnotherReplicaHoldsLock(t *testing.T) {
+	st := &lockStore{held: true}
+	a := &App{store: st}
+
+	if a.runScheduledReconcile(context.Background()) {
+		t.Fatal("runScheduledReconcile() = true while lock is held")
+	}
+	if st.listCalls != 0 {
+		t.Fatalf("ListBooks called %d times while lock is held", st.listCalls)
+	}
+
+	st.held = false
+	if !a.runScheduledReconcile(context.Background()) {
+		t.Fatal("runScheduledReconcile() = false with lock free")
+	}
+	if st.listCalls != 1 {
+		t.Fatalf("ListBooks called %d times, want 1", st.listCalls)
+	}
+}
+
+func TestCloseStopsReconciler(t *testing.T) {
+	a := &App{store: &lockStore{held: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopReconciler = cancel
+	a.reconcilerDone = a.startReconciler(ctx, time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		a.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not stop the reconciler")
+	}
+}
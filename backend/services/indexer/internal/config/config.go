@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+	"onebookai/pkg/retrieval"
 )
 
 // FileConfig represents configuration loaded from YAML.
@@ -34,13 +35,23 @@ type FileConfig struct {
 	EmbeddingDim                int    `yaml:"embeddingDim"`
 	EmbeddingBatchSize          int    `yaml:"embeddingBatchSize"`
 	EmbeddingConcurrency        int    `yaml:"embeddingConcurrency"`
+	EmbeddingMaxBatchSize       int    `yaml:"embeddingMaxBatchSize"`
+	EmbeddingTargetLatencyMs    int    `yaml:"embeddingTargetLatencyMs"`
+	EmbeddingRetryBudget        int    `yaml:"embeddingRetryBudget"`
+	EmbeddingRetryBaseDelayMs   int    `yaml:"embeddingRetryBaseDelayMs"`
+	VectorStore                 string `yaml:"vectorStore"`
 	QdrantURL                   string `yaml:"qdrantURL"`
 	QdrantAPIKey                string `yaml:"qdrantAPIKey"`
 	QdrantCollection            string `yaml:"qdrantCollection"`
+	LexicalStore                string `yaml:"lexicalStore"`
+	LexicalFallback             bool   `yaml:"lexicalFallback"`
 	OpenSearchURL               string `yaml:"openSearchURL"`
 	OpenSearchIndex             string `yaml:"openSearchIndex"`
 	OpenSearchUsername          string `yaml:"openSearchUsername"`
 	OpenSearchPassword          string `yaml:"openSearchPassword"`
+	ReconcileEnabled            bool   `yaml:"reconcileEnabled"`
+	ReconcileIntervalSeconds    int    `yaml:"reconcileIntervalSeconds"`
+	ReconcileAutoRepair         bool   `yaml:"reconcileAutoRepair"`
 }
 
 // Load reads config from path (defaults to config.yaml).
@@ -117,6 +128,29 @@ func Load(path string) (FileConfig, error) {
 			cfg.EmbeddingConcurrency = n
 		}
 	}
+	if v := os.Getenv("EMBEDDING_MAX_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.EmbeddingMaxBatchSize = n
+		}
+	}
+	if v := os.Getenv("EMBEDDING_TARGET_LATENCY_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.EmbeddingTargetLatencyMs = n
+		}
+	}
+	if v := os.Getenv("EMBEDDING_RETRY_BUDGET"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.EmbeddingRetryBudget = n
+		}
+	}
+	if v := os.Getenv("EMBEDDING_RETRY_BASE_DELAY_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.EmbeddingRetryBaseDelayMs = n
+		}
+	}
+	if v := os.Getenv("VECTOR_STORE"); v != "" {
+		cfg.VectorStore = v
+	}
 	if v := os.Getenv("QDRANT_URL"); v != "" {
 		cfg.QdrantURL = v
 	}
@@ -126,6 +160,14 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("QDRANT_COLLECTION"); v != "" {
 		cfg.QdrantCollection = v
 	}
+	if v := os.Getenv("LEXICAL_STORE"); v != "" {
+		cfg.LexicalStore = v
+	}
+	if v := os.Getenv("LEXICAL_POSTGRES_FALLBACK"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.LexicalFallback = enabled
+		}
+	}
 	if v := os.Getenv("OPENSEARCH_URL"); v != "" {
 		cfg.OpenSearchURL = v
 	}
@@ -138,6 +180,21 @@ func Load(path string) (FileConfig, error) {
 	if v := os.Getenv("OPENSEARCH_PASSWORD"); v != "" {
 		cfg.OpenSearchPassword = v
 	}
+	if v := os.Getenv("INDEXER_RECONCILE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.ReconcileEnabled = enabled
+		}
+	}
+	if v := os.Getenv("INDEXER_RECONCILE_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ReconcileIntervalSeconds = n
+		}
+	}
+	if v := os.Getenv("INDEXER_RECONCILE_AUTO_REPAIR"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			cfg.ReconcileAutoRepair = enabled
+		}
+	}
 	if v := os.Getenv("OLLAMA_HOST"); v != "" {
 		cfg.EmbeddingBaseURL = v
 		cfg.EmbeddingProvider = "ollama"
@@ -178,17 +235,29 @@ func validateConfig(cfg FileConfig) error {
 	if cfg.EmbeddingDim <= 0 {
 		return errors.New("config: embeddingDim is required (set ONEBOOK_EMBEDDING_DIM)")
 	}
-	if strings.TrimSpace(cfg.QdrantURL) == "" {
-		return errors.New("config: qdrantURL is required (set QDRANT_URL)")
-	}
-	if strings.TrimSpace(cfg.QdrantCollection) == "" {
-		return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
-	}
-	if strings.TrimSpace(cfg.OpenSearchURL) == "" {
-		return errors.New("config: openSearchURL is required (set OPENSEARCH_URL)")
+	switch retrieval.NormalizeVectorStoreBackend(cfg.VectorStore) {
+	case retrieval.VectorStoreQdrant:
+		if strings.TrimSpace(cfg.QdrantURL) == "" {
+			return errors.New("config: qdrantURL is required (set QDRANT_URL)")
+		}
+		if strings.TrimSpace(cfg.QdrantCollection) == "" {
+			return errors.New("config: qdrantCollection is required (set QDRANT_COLLECTION)")
+		}
+	case retrieval.VectorStorePgvector:
+	default:
+		return fmt.Errorf("config: unsupported vectorStore %q (set VECTOR_STORE to qdrant or pgvector)", cfg.VectorStore)
 	}
-	if strings.TrimSpace(cfg.OpenSearchIndex) == "" {
-		return errors.New("config: openSearchIndex is required (set OPENSEARCH_INDEX)")
+	switch retrieval.NormalizeLexicalStoreBackend(cfg.LexicalStore) {
+	case retrieval.LexicalStoreOpenSearch:
+		if strings.TrimSpace(cfg.OpenSearchURL) == "" {
+			return errors.New("config: openSearchURL is required (set OPENSEARCH_URL)")
+		}
+		if strings.TrimSpace(cfg.OpenSearchIndex) == "" {
+			return errors.New("config: openSearchIndex is required (set OPENSEARCH_INDEX)")
+		}
+	case retrieval.LexicalStorePostgres:
+	default:
+		return fmt.Errorf("config: unsupported lexicalStore %q (set LEXICAL_STORE to opensearch or postgres)", cfg.LexicalStore)
 	}
 	switch provider {
 	case "ollama":
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// quoteContextRunes is how much surrounding text the prefix and suffix of a
+// chunk's quote selector keep.
+const quoteContextRunes = 32
+
+// blockLocator finds chunk text inside the block it was cut from. Chunking
+// rejoins sentences with normalized whitespace, so matching ignores
+// whitespace and maps back to rune offsets in the block.
+type blockLocator struct {
+	runes   []rune
+	compact string
+	// offsets[i] is the block rune index of the i-th non-space rune and
+	// byteOffsets[i] its byte offset in compact.
+	offsets     []int
+	byteOffsets []int
+}
+
+// chunkRange is a located chunk: rune offsets [start, end) in the block and
+// the indexes of its first and last non-space runes.
+type chunkRange struct {
+	start, end  int
+	first, last int
+}
+
+func newBlockLocator(text string) *blockLocator {
+	l := &blockLocator{runes: []rune(text)}
+	var sb strings.Builder
+	for i, r := range l.runes {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		l.offsets = append(l.offsets, i)
+		l.byteOffsets = append(l.byteOffsets, sb.Len())
+		sb.WriteRune(r)
+	}
+	l.compact = sb.String()
+	return l
+}
+
+// locate finds chunk in the block, searching from the non-space rune index
+// from onwards and then from the beginning, since overlapping chunks advance
+// through the block in order.
+func (l *blockLocator) locate(chunk string, from int) (chunkRange, bool) {
+	needle := compactText(chunk)
+	if needle == "" || len(l.offsets) == 0 {
+		return chunkRange{}, false
+	}
+	fromByte := len(l.compact)
+	if from >= 0 && from < len(l.byteOffsets) {
+		fromByte = l.byteOffsets[from]
+	}
+	idx := strings.Index(l.compact[fromByte:], needle)
+	if idx >= 0 {
+		idx += fromByte
+	} else if idx = strings.Index(l.compact, needle); idx < 0 {
+		return chunkRange{}, false
+	}
+	first := utf8.RuneCountInString(l.compact[:idx])
+	last := first + utf8.RuneCountInString(needle) - 1
+	return chunkRange{start: l.offsets[first], end: l.offsets[last] + 1, first: first, last: last}, true
+}
+
+// quote returns the raw selector context around [start, end).
+func (l *blockLocator) quote(start, end int) (prefix, suffix string) {
+	from := max(start-quoteContextRunes, 0)
+	to := min(end+quoteContextRunes, len(l.runes))
+	return string(l.runes[from:start]), string(l.runes[end:to])
+}
+
+func compactText(text string) string {
+	return strings.Join(strings.FieldsFunc(text, unicode.IsSpace), "")
+}
+
+// epubSpineItem is a content document's position in the package spine.
+type epubSpineItem struct {
+	Index int
+	IDRef string
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// readEPUBSpine maps content document paths inside the archive to their
+// spine position. EPUBs without a readable package document yield nil.
+func readEPUBSpine(files []*zip.File) map[string]epubSpineItem {
+	byName := make(map[string]*zip.File, len(files))
+	for _, file := range files {
+		byName[file.Name] = file
+	}
+	var container epubContainer
+	if err := decodeZipXML(byName["META-INF/container.xml"], &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := decodeZipXML(byName[opfPath], &pkg); err != nil {
+		return nil
+	}
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+	spine := make(map[string]epubSpineItem, len(pkg.Spine))
+	for i, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		href, _, _ = strings.Cut(href, "#")
+		spine[path.Join(path.Dir(opfPath), href)] = epubSpineItem{Index: i, IDRef: ref.IDRef}
+	}
+	return spine
+}
+
+func decodeZipXML(file *zip.File, out any) error {
+	if file == nil {
+		return fmt.Errorf("missing file")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, 4<<20)).Decode(out)
+}
+
+// cfiPosition is one non-space character of a content document: the CFI
+// steps of its text node and its UTF-16 offset within that node.
+type cfiPosition struct {
+	node   int
+	offset int
+	width  int
+}
+
+// epubCFIIndex maps non-space characters of an EPUB section's extracted text
+// back to DOM text nodes so chunk ranges can be expressed as CFIs.
+type epubCFIIndex struct {
+	spineStep string
+	paths     [][]int
+	positions []cfiPosition
+}
+
+// newEPUBCFIIndex walks doc in the same order as extractText. It returns nil
+// when the walk does not line up with the extracted text, e.g. for markup
+// the sanitizer treats differently.
+func newEPUBCFIIndex(doc *html.Node, spine epubSpineItem, text string) *epubCFIIndex {
+	root := doc
+	for root != nil && !(root.Type == html.ElementNode && root.Data == "html") {
+		root = root.FirstChild
+		for root != nil && root.Type != html.ElementNode {
+			root = root.NextSibling
+		}
+	}
+	if root == nil {
+		return nil
+	}
+	idx := &epubCFIIndex{spineStep: fmt.Sprintf("/6/%d[%s]", (spine.Index+1)*2, spine.IDRef)}
+	var walk func(node *html.Node, steps []int)
+	walk = func(node *html.Node, steps []int) {
+		elements := 0
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.ElementNode:
+				elements++
+				if child.Data == "script" || child.Data == "style" {
+					continue
+				}
+				walk(child, append(append([]int(nil), steps...), elements*2))
+			case html.TextNode:
+				idx.addText(child.Data, append(append([]int(nil), steps...), elements*2+1))
+			}
+		}
+	}
+	walk(root, nil)
+	if len(idx.positions) != utf8.RuneCountInString(compactText(text)) {
+		return nil
+	}
+	return idx
+}
+
+func (idx *epubCFIIndex) addText(data string, steps []int) {
+	node := -1
+	offset := 0
+	for _, r := range strings.ToValidUTF8(data, "") {
+		width := utf16Len(r)
+		if !unicode.IsSpace(r) && !droppedBySanitizer(r) {
+			if node < 0 {
+				idx.paths = append(idx.paths, steps)
+				node = len(idx.paths) - 1
+			}
+			idx.positions = append(idx.positions, cfiPosition{node: node, offset: offset, width: width})
+		}
+		offset += width
+	}
+}
+
+// rangeCFI returns the CFI range covering compact characters [first, last].
+func (idx *epubCFIIndex) rangeCFI(first, last int) string {
+	if idx == nil || first < 0 || last >= len(idx.positions) || first > last {
+		return ""
+	}
+	start, end := idx.positions[first], idx.positions[last]
+	startPath, endPath := idx.paths[start.node], idx.paths[end.node]
+	common := 0
+	for common < len(startPath)-1 && common < len(endPath)-1 && startPath[common] == endPath[common] {
+		common++
+	}
+	return fmt.Sprintf("epubcfi(%s!%s,%s:%d,%s:%d)",
+		idx.spineStep,
+		formatCFISteps(startPath[:common]),
+		formatCFISteps(startPath[common:]), start.offset,
+		formatCFISteps(endPath[common:]), end.offset+end.width,
+	)
+}
+
+func formatCFISteps(steps []int) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		sb.WriteString("/")
+		sb.WriteString(strconv.Itoa(step))
+	}
+	return sb.String()
+}
+
+func droppedBySanitizer(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\ufeff', '\u2060', '\u00ad':
+		return true
+	}
+	return unicode.IsControl(r)
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// setChunkAnchorMetadata records where a chunk sits in its page or section:
+// rune offsets, quote context and, for EPUB, the CFI range.
+func setChunkAnchorMetadata(meta map[string]string, locator *blockLocator, loc chunkRange, cfi *epubCFIIndex) {
+	meta["char_start"] = strconv.Itoa(loc.start)
+	meta["char_end"] = strconv.Itoa(loc.end)
+	prefix, suffix := locator.quote(loc.start, loc.end)
+	if prefix != "" {
+		meta["quote_prefix"] = prefix
+	}
+	if suffix != "" {
+		meta["quote_suffix"] = suffix
+	}
+	if value := cfi.rangeCFI(loc.first, loc.last); value != "" {
+		meta["epub_cfi"] = value
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestBlockLocatorMapsNormalizedChunksToBlockOffsets(t *testing.T) {
+	block := "First line\nstill first.\n\nSecond paragraph here."
+	locator := newBlockLocator(block)
+
+	loc, ok := locator.locate("still first.\n\nSecond paragraph", 0)
+	if !ok {
+		t.Fatal("expected chunk to be located")
+	}
+	if got := string([]rune(block)[loc.start:loc.end]); got != "still first.\n\nSecond paragraph" {
+		t.Fatalf("located %q", got)
+	}
+	prefix, suffix := locator.quote(loc.start, loc.end)
+	if prefix != "First line\n" || suffix != " here." {
+		t.Fatalf("quote context = %q / %q", prefix, suffix)
+	}
+	if _, ok := locator.locate("missing text", 0); ok {
+		t.Fatal("expected unknown text not to be located")
+	}
+}
+
+func TestBuildRetrievalChunksRecordsAnchors(t *testing.T) {
+	a := &App{lexicalChunkSize: 4, semanticChunkSize: 400}
+	block := chunkPayload{
+		Content:  "Alpha beta gamma.\nDelta epsilon zeta. Eta theta iota.",
+		Metadata: map[string]string{"source_type": "pdf", "source_ref": "page:2", "page": "2"},
+	}
+
+	chunks := a.buildRetrievalChunks("book-1", []chunkPayload{block})
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	runes := []rune(block.Content)
+	for _, chunk := range chunks {
+		start, end := chunk.Metadata["char_start"], chunk.Metadata["char_end"]
+		if start == "" || end == "" {
+			t.Fatalf("chunk %q has no offsets: %v", chunk.Content, chunk.Metadata)
+		}
+		from, _ := strconv.Atoi(start)
+		to, _ := strconv.Atoi(end)
+		got := string(runes[from:to])
+		if compactText(got) != compactText(chunk.Content) {
+			t.Fatalf("offsets %s..%s select %q, want %q", start, end, got, chunk.Content)
+		}
+	}
+}
+
+func TestParseEPUBRecordsSpineAndCFI(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf":      `<package><manifest><item id="c1" href="text/ch1.xhtml"/><item id="c2" href="text/ch2.xhtml"/></manifest><spine><itemref idref="c1"/><itemref idref="c2"/></spine></package>`,
+		"OEBPS/text/ch2.xhtml":   `<html><head><title>Two</title></head><body><p>Hello <em>brave</em> world.</p><p>Second para.</p></body></html>`,
+	})
+
+	blocks, err := (&App{}).parseEPUB(path)
+	if err != nil {
+		t.Fatalf("parseEPUB: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].cfi == nil {
+		t.Fatalf("expected one block with a CFI index, got %+v", blocks)
+	}
+	meta := blocks[0].Metadata
+	if meta["spine_index"] != "1" || meta["spine_idref"] != "c2" || meta["section_href"] != "OEBPS/text/ch2.xhtml" {
+		t.Fatalf("unexpected spine metadata: %v", meta)
+	}
+	locator := newBlockLocator(blocks[0].Content)
+	loc, ok := locator.locate("brave world. Second", 0)
+	if !ok {
+		t.Fatalf("chunk not located in %q", blocks[0].Content)
+	}
+	want := "epubcfi(/6/4[c2]!/4,/2/2/1:0,/4/1:6)"
+	if got := blocks[0].cfi.rangeCFI(loc.first, loc.last); got != want {
+		t.Fatalf("rangeCFI() = %q, want %q", got, want)
+	}
+}
+
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write epub: %v", err)
+	}
+	return path
+}
@@ -417,11 +417,13 @@ func (a *App) buildRetrievalChunks(bookID string, blocks []chunkPayload) []domai
 			chunkFamily = sha256Hex(strings.TrimSpace(blockMeta["source_ref"]) + "\n" + blockContent)
 		}
 		blockMeta["chunk_family"] = chunkFamily
+		locator := newBlockLocator(blockContent)
 		for _, spec := range specs {
 			parts := chunkTextByTokens(blockContent, spec.size, spec.overlap)
 			if len(parts) == 0 {
 				continue
 			}
+			searchFrom := 0
 			for idx, part := range parts {
 				meta := cloneMetadata(blockMeta)
 				meta["retrieval_tier"] = spec.name
@@ -429,6 +431,10 @@ func (a *App) buildRetrievalChunks(bookID string, blocks []chunkPayload) []domai
 				meta["tier_chunk_index"] = strconv.Itoa(idx)
 				meta["tier_chunk_count"] = strconv.Itoa(len(parts))
 				meta["chunk"] = strconv.Itoa(idx)
+				if loc, ok := locator.locate(part, searchFrom); ok {
+					searchFrom = loc.first + 1
+					setChunkAnchorMetadata(meta, locator, loc, block.cfi)
+				}
 				out = append(out, domain.Chunk{
 					ID:        util.NewID(),
 					BookID:    bookID,
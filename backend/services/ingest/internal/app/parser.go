@@ -26,6 +26,8 @@ import (
 type chunkPayload struct {
 	Content  string
 	Metadata map[string]string
+	// cfi maps EPUB section text back to the DOM; nil for other formats.
+	cfi *epubCFIIndex
 }
 
 type pageExtraction struct {
@@ -315,6 +317,7 @@ func (a *App) parseEPUB(path string) ([]chunkPayload, error) {
 		return nil, fmt.Errorf("open epub: %w", err)
 	}
 	defer reader.Close()
+	spine := readEPUBSpine(reader.File)
 	var chunks []chunkPayload
 	for _, file := range reader.File {
 		name := strings.ToLower(file.Name)
@@ -339,15 +342,24 @@ func (a *App) parseEPUB(path string) ([]chunkPayload, error) {
 		if text == "" {
 			continue
 		}
+		meta := map[string]string{
+			"source_type":    "epub",
+			"source_ref":     fmt.Sprintf("section:%s", baseName),
+			"section":        baseName,
+			"section_path":   baseName,
+			"section_href":   file.Name,
+			"extract_method": "epub_html_parser",
+		}
+		var cfi *epubCFIIndex
+		if item, ok := spine[file.Name]; ok {
+			meta["spine_index"] = strconv.Itoa(item.Index)
+			meta["spine_idref"] = item.IDRef
+			cfi = newEPUBCFIIndex(doc, item, text)
+		}
 		chunks = append(chunks, chunkPayload{
-			Content: text,
-			Metadata: map[string]string{
-				"source_type":    "epub",
-				"source_ref":     fmt.Sprintf("section:%s", baseName),
-				"section":        baseName,
-				"section_path":   baseName,
-				"extract_method": "epub_html_parser",
-			},
+			Content:  text,
+			Metadata: meta,
+			cfi:      cfi,
 		})
 	}
 	return chunks, nil